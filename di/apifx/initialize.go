@@ -1,7 +1,14 @@
 package apifx
 
 import (
+	"context"
+
+	"github.com/leehai1107/chophimco-server/pkg/cache"
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/mailer"
 	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/oauth"
+	"github.com/leehai1107/chophimco-server/pkg/storage"
 	"github.com/leehai1107/chophimco-server/service/chophimco/delivery/http"
 	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
 	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
@@ -13,6 +20,11 @@ var Module = fx.Provide(
 	provideRouter,
 	provideHandler,
 	provideJWTService,
+	provideRevocationList,
+	provideSuggestIndex,
+	provideStorage,
+	provideMailer,
+	provideOAuthProviders,
 
 	// Repositories
 	provideUserRepo,
@@ -23,6 +35,23 @@ var Module = fx.Provide(
 	provideReviewRepo,
 	providePaymentRepo,
 	provideSellerRepo,
+	provideFlashSaleRepo,
+	provideSuggestRepo,
+	provideCatalogRepo,
+	provideAttributeRepo,
+	provideImportRepo,
+	provideProductRevisionRepo,
+	provideNotificationRepo,
+	provideSellerKYCRepo,
+	provideLedgerRepo,
+	provideAnalyticsRepo,
+	provideStorefrontRepo,
+	provideFollowRepo,
+	provideModerationRepo,
+	provideSessionRepo,
+	provideAccountRepo,
+	provideOAuthRepo,
+	provideTwoFactorRepo,
 
 	// Usecases
 	provideUserUsecase,
@@ -32,16 +61,59 @@ var Module = fx.Provide(
 	provideVoucherUsecase,
 	provideReviewUsecase,
 	provideSellerUsecase,
+	provideFlashSaleUsecase,
+	provideSuggestUsecase,
+	provideCatalogUsecase,
+	provideAttributeUsecase,
+	provideVariantUsecase,
+	provideImportUsecase,
+	provideNotificationUsecase,
+	provideSellerKYCUsecase,
+	provideLedgerUsecase,
+	provideSellerAnalyticsUsecase,
+	provideStorefrontUsecase,
+	provideFollowUsecase,
+	provideModerationUsecase,
+	provideSessionUsecase,
+	provideAccountUsecase,
+	provideOAuthUsecase,
+	provideTwoFactorUsecase,
 )
 
-func provideRouter(handler http.IHandler, jwtService auth.IJWTService) http.Router {
-	return http.NewRouter(handler, jwtService)
+func provideRouter(
+	handler http.IHandler,
+	jwtService auth.IJWTService,
+	revocations auth.RevocationList,
+	storage storage.Backend,
+) http.Router {
+	return http.NewRouter(handler, jwtService, revocations, storage)
+}
+
+// provideRevocationList lets the auth middleware reject tokens of logged out sessions
+func provideRevocationList(sessionUsecase usecase.ISessionUsecase) auth.RevocationList {
+	return sessionUsecase
 }
 
-func provideJWTService() auth.IJWTService {
+func provideJWTService() (auth.IJWTService, error) {
 	return auth.NewJWTService()
 }
 
+func provideSuggestIndex() *cache.SuggestIndex {
+	return cache.NewSuggestIndex(usecase.SuggestIndexMaxAge)
+}
+
+func provideStorage() (storage.Backend, error) {
+	return storage.New(config.StorageConfig())
+}
+
+func provideMailer() (mailer.Mailer, error) {
+	return mailer.New(config.MailConfig())
+}
+
+func provideOAuthProviders() oauth.Providers {
+	return oauth.NewProviders(config.OAuthConfig())
+}
+
 func provideHandler(
 	userUsecase usecase.IUserUsecase,
 	productUsecase usecase.IProductUsecase,
@@ -50,6 +122,23 @@ func provideHandler(
 	voucherUsecase usecase.IVoucherUsecase,
 	reviewUsecase usecase.IReviewUsecase,
 	sellerUsecase usecase.ISellerUsecase,
+	flashSaleUsecase usecase.IFlashSaleUsecase,
+	suggestUsecase usecase.ISuggestUsecase,
+	catalogUsecase usecase.ICatalogUsecase,
+	attributeUsecase usecase.IAttributeUsecase,
+	variantUsecase usecase.IVariantUsecase,
+	importUsecase usecase.IImportUsecase,
+	notificationUsecase usecase.INotificationUsecase,
+	sellerKYCUsecase usecase.ISellerKYCUsecase,
+	ledgerUsecase usecase.ILedgerUsecase,
+	sellerAnalyticsUsecase usecase.ISellerAnalyticsUsecase,
+	storefrontUsecase usecase.IStorefrontUsecase,
+	followUsecase usecase.IFollowUsecase,
+	moderationUsecase usecase.IModerationUsecase,
+	sessionUsecase usecase.ISessionUsecase,
+	accountUsecase usecase.IAccountUsecase,
+	oauthUsecase usecase.IOAuthUsecase,
+	twoFactorUsecase usecase.ITwoFactorUsecase,
 ) http.IHandler {
 	handler := http.NewHandler(
 		userUsecase,
@@ -59,6 +148,23 @@ func provideHandler(
 		voucherUsecase,
 		reviewUsecase,
 		sellerUsecase,
+		flashSaleUsecase,
+		suggestUsecase,
+		catalogUsecase,
+		attributeUsecase,
+		variantUsecase,
+		importUsecase,
+		notificationUsecase,
+		sellerKYCUsecase,
+		ledgerUsecase,
+		sellerAnalyticsUsecase,
+		storefrontUsecase,
+		followUsecase,
+		moderationUsecase,
+		sessionUsecase,
+		accountUsecase,
+		oauthUsecase,
+		twoFactorUsecase,
 	)
 	return handler
 }
@@ -96,13 +202,89 @@ func provideSellerRepo(db *gorm.DB) repository.ISellerRepository {
 	return repository.NewSellerRepo(db)
 }
 
+func provideFlashSaleRepo(db *gorm.DB) repository.IFlashSaleRepo {
+	return repository.NewFlashSaleRepo(db)
+}
+
+func provideSuggestRepo(db *gorm.DB) repository.ISuggestRepo {
+	return repository.NewSuggestRepo(db)
+}
+
+func provideCatalogRepo(db *gorm.DB) repository.ICatalogRepo {
+	return repository.NewCatalogRepo(db)
+}
+
+func provideAttributeRepo(db *gorm.DB) repository.IAttributeRepo {
+	return repository.NewAttributeRepo(db)
+}
+
+func provideImportRepo(db *gorm.DB) repository.IImportRepo {
+	return repository.NewImportRepo(db)
+}
+
+func provideProductRevisionRepo(db *gorm.DB) repository.IProductRevisionRepo {
+	return repository.NewProductRevisionRepo(db)
+}
+
+func provideNotificationRepo(db *gorm.DB) repository.INotificationRepo {
+	return repository.NewNotificationRepo(db)
+}
+
+func provideSellerKYCRepo(db *gorm.DB) repository.ISellerKYCRepo {
+	return repository.NewSellerKYCRepo(db)
+}
+
+func provideLedgerRepo(db *gorm.DB) repository.ILedgerRepo {
+	return repository.NewLedgerRepo(db)
+}
+
+func provideAnalyticsRepo(db *gorm.DB) repository.IAnalyticsRepo {
+	return repository.NewAnalyticsRepo(db)
+}
+
+func provideStorefrontRepo(db *gorm.DB) repository.IStorefrontRepo {
+	return repository.NewStorefrontRepo(db)
+}
+
+func provideFollowRepo(db *gorm.DB) repository.IFollowRepo {
+	return repository.NewFollowRepo(db)
+}
+
+func provideModerationRepo(db *gorm.DB) repository.IModerationRepo {
+	return repository.NewModerationRepo(db)
+}
+
+func provideSessionRepo(db *gorm.DB) repository.ISessionRepo {
+	return repository.NewSessionRepo(db)
+}
+
+func provideAccountRepo(db *gorm.DB) repository.IAccountRepo {
+	return repository.NewAccountRepo(db)
+}
+
+func provideOAuthRepo(db *gorm.DB) repository.IOAuthRepo {
+	return repository.NewOAuthRepo(db)
+}
+
+func provideTwoFactorRepo(db *gorm.DB) repository.ITwoFactorRepo {
+	return repository.NewTwoFactorRepo(db)
+}
+
 // Usecase providers
-func provideUserUsecase(repo repository.IUserRepo, jwtService auth.IJWTService) usecase.IUserUsecase {
-	return usecase.NewUserUsecase(repo, jwtService)
+func provideUserUsecase(
+	repo repository.IUserRepo,
+	twoFactorUsecase usecase.ITwoFactorUsecase,
+	accountUsecase usecase.IAccountUsecase,
+) usecase.IUserUsecase {
+	return usecase.NewUserUsecase(repo, twoFactorUsecase, accountUsecase)
 }
 
-func provideProductUsecase(repo repository.IProductRepo) usecase.IProductUsecase {
-	return usecase.NewProductUsecase(repo)
+func provideProductUsecase(
+	repo repository.IProductRepo,
+	attributeRepo repository.IAttributeRepo,
+	suggestIndex *cache.SuggestIndex,
+) usecase.IProductUsecase {
+	return usecase.NewProductUsecase(repo, attributeRepo, suggestIndex)
 }
 
 func provideCartUsecase(
@@ -118,21 +300,179 @@ func provideOrderUsecase(
 	voucherRepo repository.IVoucherRepo,
 	productRepo repository.IProductRepo,
 	paymentRepo repository.IPaymentRepo,
+	ledgerUsecase usecase.ILedgerUsecase,
+	accountUsecase usecase.IAccountUsecase,
 ) usecase.IOrderUsecase {
-	return usecase.NewOrderUsecase(orderRepo, cartRepo, voucherRepo, productRepo, paymentRepo)
+	return usecase.NewOrderUsecase(orderRepo, cartRepo, voucherRepo, productRepo, paymentRepo, ledgerUsecase, accountUsecase)
 }
 
 func provideVoucherUsecase(repo repository.IVoucherRepo) usecase.IVoucherUsecase {
 	return usecase.NewVoucherUsecase(repo)
 }
 
-func provideReviewUsecase(reviewRepo repository.IReviewRepo) usecase.IReviewUsecase {
-	return usecase.NewReviewUsecase(reviewRepo)
+func provideReviewUsecase(
+	reviewRepo repository.IReviewRepo,
+	storage storage.Backend,
+	notificationUsecase usecase.INotificationUsecase,
+) usecase.IReviewUsecase {
+	return usecase.NewReviewUsecase(reviewRepo, storage, notificationUsecase)
 }
 
 func provideSellerUsecase(
 	sellerRepo repository.ISellerRepository,
 	userRepo repository.IUserRepo,
+	suggestIndex *cache.SuggestIndex,
+	storage storage.Backend,
+	revisionRepo repository.IProductRevisionRepo,
+	notificationUsecase usecase.INotificationUsecase,
+	followUsecase usecase.IFollowUsecase,
 ) usecase.ISellerUsecase {
-	return usecase.NewSellerUsecase(sellerRepo, userRepo)
+	return usecase.NewSellerUsecase(sellerRepo, userRepo, suggestIndex, storage, revisionRepo, notificationUsecase, followUsecase)
+}
+
+func provideFlashSaleUsecase(
+	flashSaleRepo repository.IFlashSaleRepo,
+	productRepo repository.IProductRepo,
+	orderUsecase usecase.IOrderUsecase,
+	accountUsecase usecase.IAccountUsecase,
+) usecase.IFlashSaleUsecase {
+	return usecase.NewFlashSaleUsecase(flashSaleRepo, productRepo, orderUsecase, accountUsecase)
+}
+
+func provideSuggestUsecase(suggestRepo repository.ISuggestRepo, suggestIndex *cache.SuggestIndex) usecase.ISuggestUsecase {
+	return usecase.NewSuggestUsecase(suggestRepo, suggestIndex)
+}
+
+func provideCatalogUsecase(catalogRepo repository.ICatalogRepo, suggestIndex *cache.SuggestIndex) usecase.ICatalogUsecase {
+	return usecase.NewCatalogUsecase(catalogRepo, suggestIndex)
+}
+
+func provideAttributeUsecase(attributeRepo repository.IAttributeRepo, catalogRepo repository.ICatalogRepo) usecase.IAttributeUsecase {
+	return usecase.NewAttributeUsecase(attributeRepo, catalogRepo)
+}
+
+func provideVariantUsecase(
+	productRepo repository.IProductRepo,
+	attributeRepo repository.IAttributeRepo,
+	catalogRepo repository.ICatalogRepo,
+) usecase.IVariantUsecase {
+	return usecase.NewVariantUsecase(productRepo, attributeRepo, catalogRepo)
+}
+
+func provideImportUsecase(
+	lifecycle fx.Lifecycle,
+	importRepo repository.IImportRepo,
+	productRepo repository.IProductRepo,
+	attributeRepo repository.IAttributeRepo,
+	catalogRepo repository.ICatalogRepo,
+	sellerRepo repository.ISellerRepository,
+) usecase.IImportUsecase {
+	importUsecase := usecase.NewImportUsecase(importRepo, productRepo, attributeRepo, catalogRepo, sellerRepo)
+
+	// Background jobs run in this process, any left unfinished died with the previous one
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			importUsecase.FailInterruptedJobs(ctx)
+			return nil
+		},
+	})
+	return importUsecase
+}
+
+func provideNotificationUsecase(notificationRepo repository.INotificationRepo) usecase.INotificationUsecase {
+	return usecase.NewNotificationUsecase(notificationRepo)
+}
+
+func provideSellerKYCUsecase(
+	sellerRepo repository.ISellerRepository,
+	kycRepo repository.ISellerKYCRepo,
+	storage storage.Backend,
+	notificationUsecase usecase.INotificationUsecase,
+) usecase.ISellerKYCUsecase {
+	return usecase.NewSellerKYCUsecase(sellerRepo, kycRepo, storage, notificationUsecase)
+}
+
+func provideLedgerUsecase(
+	lifecycle fx.Lifecycle,
+	ledgerRepo repository.ILedgerRepo,
+	sellerRepo repository.ISellerRepository,
+	catalogRepo repository.ICatalogRepo,
+	notificationUsecase usecase.INotificationUsecase,
+) usecase.ILedgerUsecase {
+	ledgerUsecase := usecase.NewLedgerUsecase(ledgerRepo, sellerRepo, catalogRepo, notificationUsecase)
+
+	// Scheduled payout batches run for the life of the app
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go ledgerUsecase.RunPayoutSchedule(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return ledgerUsecase
+}
+
+func provideSellerAnalyticsUsecase(analyticsRepo repository.IAnalyticsRepo) usecase.ISellerAnalyticsUsecase {
+	return usecase.NewSellerAnalyticsUsecase(analyticsRepo)
+}
+
+func provideStorefrontUsecase(
+	storefrontRepo repository.IStorefrontRepo,
+	sellerRepo repository.ISellerRepository,
+	followRepo repository.IFollowRepo,
+	productUsecase usecase.IProductUsecase,
+) usecase.IStorefrontUsecase {
+	return usecase.NewStorefrontUsecase(storefrontRepo, sellerRepo, followRepo, productUsecase)
+}
+
+func provideFollowUsecase(
+	followRepo repository.IFollowRepo,
+	sellerRepo repository.ISellerRepository,
+	productRepo repository.IProductRepo,
+	notificationUsecase usecase.INotificationUsecase,
+) usecase.IFollowUsecase {
+	return usecase.NewFollowUsecase(followRepo, sellerRepo, productRepo, notificationUsecase)
+}
+
+func provideModerationUsecase(
+	moderationRepo repository.IModerationRepo,
+	notificationUsecase usecase.INotificationUsecase,
+) usecase.IModerationUsecase {
+	return usecase.NewModerationUsecase(moderationRepo, notificationUsecase)
+}
+
+func provideSessionUsecase(sessionRepo repository.ISessionRepo, jwtService auth.IJWTService) usecase.ISessionUsecase {
+	return usecase.NewSessionUsecase(sessionRepo, jwtService)
+}
+
+func provideAccountUsecase(
+	accountRepo repository.IAccountRepo,
+	userRepo repository.IUserRepo,
+	jwtService auth.IJWTService,
+	mailer mailer.Mailer,
+	sessionUsecase usecase.ISessionUsecase,
+) usecase.IAccountUsecase {
+	return usecase.NewAccountUsecase(accountRepo, userRepo, jwtService, mailer, sessionUsecase)
+}
+
+func provideOAuthUsecase(
+	oauthRepo repository.IOAuthRepo,
+	userRepo repository.IUserRepo,
+	providers oauth.Providers,
+	twoFactorUsecase usecase.ITwoFactorUsecase,
+) usecase.IOAuthUsecase {
+	return usecase.NewOAuthUsecase(oauthRepo, userRepo, providers, twoFactorUsecase)
+}
+
+func provideTwoFactorUsecase(
+	twoFactorRepo repository.ITwoFactorRepo,
+	userRepo repository.IUserRepo,
+	jwtService auth.IJWTService,
+	sessionUsecase usecase.ISessionUsecase,
+) usecase.ITwoFactorUsecase {
+	return usecase.NewTwoFactorUsecase(twoFactorRepo, userRepo, jwtService, sessionUsecase)
 }
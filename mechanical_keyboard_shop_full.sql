@@ -31,6 +31,7 @@ CREATE TABLE users (
     full_name VARCHAR(100),
     phone VARCHAR(20),
     is_seller_verified BOOLEAN DEFAULT FALSE,
+    email_verified_at TIMESTAMP, -- NULL until the verification link is followed
     created_at TIMESTAMP DEFAULT NOW()
 );
 
@@ -41,13 +42,18 @@ CREATE TABLE seller_profiles (
     id SERIAL PRIMARY KEY,
     user_id INT NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
     shop_name VARCHAR(200) NOT NULL,
+    slug VARCHAR(120) UNIQUE,
     shop_description TEXT,
     business_address TEXT,
     business_phone VARCHAR(20),
     logo_url TEXT,
-    verification_status VARCHAR(50) DEFAULT 'pending', -- pending, verified, rejected
+    logo_thumbnail_url TEXT,
+    logo_storage_key VARCHAR(255),
+    logo_content_type VARCHAR(50),
+    verification_status VARCHAR(50) DEFAULT 'draft', -- draft, pending, info_requested, verified, rejected
     average_rating DECIMAL(3, 2) DEFAULT 0.0,
     total_sales INT DEFAULT 0,
+    follower_count INT DEFAULT 0,
     created_at TIMESTAMP DEFAULT NOW(),
     verified_at TIMESTAMP
 );
@@ -57,7 +63,10 @@ CREATE TABLE seller_profiles (
 -- =======================
 CREATE TABLE categories (
     id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL
+    parent_id INT REFERENCES categories (id),
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) UNIQUE,
+    display_order INT NOT NULL DEFAULT 0
 );
 
 -- =======================
@@ -93,7 +102,15 @@ CREATE TABLE products (
     rejection_reason TEXT,
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT NOW(),
-    approved_at TIMESTAMP
+    approved_at TIMESTAMP,
+    -- review aggregates, kept up to date as reviews are written
+    average_rating DECIMAL(3, 2) DEFAULT 0.0,
+    review_count INT DEFAULT 0,
+    rating_1_count INT DEFAULT 0,
+    rating_2_count INT DEFAULT 0,
+    rating_3_count INT DEFAULT 0,
+    rating_4_count INT DEFAULT 0,
+    rating_5_count INT DEFAULT 0
 );
 
 -- =======================
@@ -122,7 +139,17 @@ CREATE TABLE product_images (
     is_primary BOOLEAN DEFAULT FALSE,
     display_order INT DEFAULT 0,
     alt_text VARCHAR(255),
-    created_at TIMESTAMP DEFAULT NOW()
+    created_at TIMESTAMP DEFAULT NOW(),
+    -- resized copies of uploaded images, empty for external URLs
+    thumbnail_url TEXT,
+    small_url TEXT,
+    medium_url TEXT,
+    large_url TEXT,
+    storage_key VARCHAR(255),
+    content_type VARCHAR(50),
+    width INT DEFAULT 0,
+    height INT DEFAULT 0,
+    size_bytes BIGINT DEFAULT 0
 );
 
 -- =======================
@@ -199,9 +226,10 @@ CREATE TABLE orders (
     voucher_id INT REFERENCES vouchers (id),
     discount_amount DECIMAL(12, 2) DEFAULT 0,
     total_amount DECIMAL(12, 2) NOT NULL,
-    status VARCHAR(50) NOT NULL, -- pending, paid, shipped, completed, cancelled
+    status VARCHAR(50) NOT NULL, -- pending, paid, shipped, completed, cancelled, refunded
     shipping_address TEXT,
-    created_at TIMESTAMP DEFAULT NOW()
+    created_at TIMESTAMP DEFAULT NOW(),
+    completed_at TIMESTAMP -- when the order was marked completed, starts the seller review window
 );
 
 -- =======================
@@ -212,7 +240,10 @@ CREATE TABLE order_items (
     order_id INT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
     product_variant_id INT NOT NULL REFERENCES product_variants (id),
     price DECIMAL(12, 2) NOT NULL,
-    quantity INT NOT NULL CHECK (quantity > 0)
+    list_price DECIMAL(12, 2) DEFAULT 0, -- regular price, above price when the seller marked it down
+    quantity INT NOT NULL CHECK (quantity > 0),
+    refunded_quantity INT DEFAULT 0,
+    flash_sale_item_id INT -- the flash sale item it was bought from, its units go back when the order is cancelled
 );
 
 -- =======================
@@ -233,12 +264,76 @@ CREATE TABLE reviews (
     id SERIAL PRIMARY KEY,
     user_id INT NOT NULL REFERENCES users (id),
     product_id INT NOT NULL REFERENCES products (id),
+    order_id INT REFERENCES orders (id), -- the completed order the product was bought in
     rating INT CHECK (rating BETWEEN 1 AND 5),
     comment TEXT,
+    is_verified_purchase BOOLEAN DEFAULT FALSE,
+    helpful_count INT DEFAULT 0,
     created_at TIMESTAMP DEFAULT NOW(),
+    edited_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'published', -- published, pending, rejected
+    flag_reason TEXT, -- why the review was held
+    moderated_by INT REFERENCES users (id),
+    moderated_at TIMESTAMP,
+    moderation_note TEXT,
     UNIQUE (user_id, product_id)
 );
 
+CREATE TABLE review_images (
+    id SERIAL PRIMARY KEY,
+    review_id INT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    image_url TEXT NOT NULL,
+    thumbnail_url TEXT,
+    medium_url TEXT,
+    storage_key VARCHAR(255),
+    content_type VARCHAR(255),
+    width INT DEFAULT 0,
+    height INT DEFAULT 0,
+    size_bytes BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Earlier versions of edited reviews
+CREATE TABLE review_edits (
+    id SERIAL PRIMARY KEY,
+    review_id INT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    rating INT NOT NULL,
+    comment TEXT,
+    edited_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE review_replies (
+    id SERIAL PRIMARY KEY,
+    review_id INT NOT NULL UNIQUE REFERENCES reviews (id) ON DELETE CASCADE,
+    seller_id INT NOT NULL REFERENCES users (id),
+    comment TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE review_votes (
+    id SERIAL PRIMARY KEY,
+    review_id INT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (review_id, user_id)
+);
+
+-- Reports on product and seller reviews, one per reporter and review
+CREATE TABLE review_reports (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL, -- product_review, seller_review
+    target_id INT NOT NULL,
+    reporter_id INT NOT NULL REFERENCES users (id),
+    reason VARCHAR(20) NOT NULL, -- spam, abuse, off_topic, fake, other
+    details TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, upheld, dismissed
+    resolved_by INT REFERENCES users (id),
+    created_at TIMESTAMP DEFAULT NOW(),
+    resolved_at TIMESTAMP,
+    UNIQUE (target_type, target_id, reporter_id)
+);
+
 -- =======================
 -- 20. SELLER REVIEWS
 -- =======================
@@ -250,12 +345,415 @@ CREATE TABLE seller_reviews (
     rating INT CHECK (rating BETWEEN 1 AND 5),
     comment TEXT,
     created_at TIMESTAMP DEFAULT NOW(),
+    status VARCHAR(20) NOT NULL DEFAULT 'published', -- published, pending, rejected
+    flag_reason TEXT, -- why the review was held
+    moderated_by INT REFERENCES users (id),
+    moderated_at TIMESTAMP,
+    moderation_note TEXT,
     UNIQUE (buyer_id, seller_id, order_id)
 );
 
 -- =======================
--- 21. INDEXES (PERFORMANCE)
+-- 21. ATTRIBUTE DEFINITIONS
+-- =======================
+CREATE TABLE attribute_definitions (
+    id SERIAL PRIMARY KEY,
+    category_id INT REFERENCES categories (id) ON DELETE CASCADE, -- NULL = global
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL DEFAULT 'text', -- text, number, boolean, enum
+    allowed_values JSONB,
+    unit VARCHAR(20),
+    is_required BOOLEAN DEFAULT FALSE,
+    is_filterable BOOLEAN DEFAULT FALSE,
+    display_order INT NOT NULL DEFAULT 0
+);
+
+-- =======================
+-- 22. PRODUCT VARIANT ATTRIBUTES
+-- =======================
+CREATE TABLE product_variant_attributes (
+    id SERIAL PRIMARY KEY,
+    product_variant_id INT NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    attribute_definition_id INT NOT NULL REFERENCES attribute_definitions (id) ON DELETE CASCADE,
+    value TEXT NOT NULL,
+    UNIQUE (product_variant_id, attribute_definition_id)
+);
+
+-- =======================
+-- 23. IMPORT JOBS
+-- =======================
+CREATE TABLE import_jobs (
+    id SERIAL PRIMARY KEY,
+    seller_id INT NOT NULL REFERENCES users (id),
+    file_name VARCHAR(255),
+    format VARCHAR(10) NOT NULL, -- csv, xlsx
+    dry_run BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed
+    total_rows INT DEFAULT 0,
+    products INT DEFAULT 0,
+    variants INT DEFAULT 0,
+    images INT DEFAULT 0,
+    errors JSONB,
+    message TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+-- =======================
+-- 24. PRODUCT REVISIONS
+-- =======================
+CREATE TABLE product_revisions (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    revision_number INT NOT NULL,
+    submitted_by INT NOT NULL REFERENCES users (id),
+    name VARCHAR(255) NOT NULL,
+    category_id INT REFERENCES categories (id),
+    brand_id INT REFERENCES brands (id),
+    description TEXT,
+    base_price DECIMAL(12, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected, superseded
+    reviewer_id INT REFERENCES users (id),
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    reviewed_at TIMESTAMP,
+    UNIQUE (product_id, revision_number)
+);
+
+-- =======================
+-- 25. NOTIFICATIONS
+-- =======================
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT,
+    reference_type VARCHAR(50),
+    reference_id INT,
+    is_read BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    read_at TIMESTAMP
+);
+
+-- =======================
+-- 26. SELLER KYC
+-- =======================
+CREATE TABLE seller_kycs (
+    id SERIAL PRIMARY KEY,
+    seller_profile_id INT NOT NULL UNIQUE REFERENCES seller_profiles (id) ON DELETE CASCADE,
+    business_type VARCHAR(20), -- individual, company
+    legal_name VARCHAR(255),
+    registration_number VARCHAR(255),
+    registered_address TEXT,
+    tax_id VARCHAR(255),
+    bank_name VARCHAR(255),
+    bank_branch VARCHAR(255),
+    bank_account_name VARCHAR(255),
+    bank_account_number VARCHAR(255),
+    submitted_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 27. SELLER DOCUMENTS
+-- =======================
+CREATE TABLE seller_documents (
+    id SERIAL PRIMARY KEY,
+    seller_profile_id INT NOT NULL REFERENCES seller_profiles (id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL, -- business_registration, tax_certificate, identity_front, identity_back, bank_statement
+    file_name VARCHAR(255),
+    storage_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100),
+    size_bytes BIGINT DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected, superseded
+    reviewer_id INT REFERENCES users (id),
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    reviewed_at TIMESTAMP
+);
+
+-- =======================
+-- 28. SELLER VERIFICATION EVENTS
+-- =======================
+CREATE TABLE seller_verification_events (
+    id SERIAL PRIMARY KEY,
+    seller_profile_id INT NOT NULL REFERENCES seller_profiles (id) ON DELETE CASCADE,
+    document_id INT REFERENCES seller_documents (id) ON DELETE SET NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor_id INT NOT NULL REFERENCES users (id),
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 29. LEDGER ENTRIES
+-- =======================
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    type VARCHAR(20) NOT NULL, -- sale, refund, payout, payout_paid, payout_reversed
+    reference_key VARCHAR(255) NOT NULL UNIQUE,
+    seller_id INT REFERENCES users (id),
+    order_id INT REFERENCES orders (id),
+    refund_id INT,
+    payout_id INT,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 30. LEDGER LINES
+-- =======================
+CREATE TABLE ledger_lines (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES ledger_entries (id) ON DELETE CASCADE,
+    account VARCHAR(50) NOT NULL, -- platform_cash, seller_payable, payouts_in_transit, commission_revenue, promotion_expense
+    seller_id INT REFERENCES users (id),
+    component VARCHAR(30) NOT NULL, -- gross, seller_discount, platform_discount, commission, refund, commission_reversal, payout
+    order_item_id INT REFERENCES order_items (id),
+    debit DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    credit DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 31. COMMISSION RULES
+-- =======================
+CREATE TABLE commission_rules (
+    id SERIAL PRIMARY KEY,
+    seller_id INT UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    category_id INT UNIQUE REFERENCES categories (id) ON DELETE CASCADE,
+    rate DECIMAL(5, 2) NOT NULL, -- percent
+    updated_by INT REFERENCES users (id),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CHECK ((seller_id IS NULL) <> (category_id IS NULL))
+);
+
+-- =======================
+-- 32. ORDER REFUNDS
+-- =======================
+CREATE TABLE order_refunds (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders (id),
+    amount DECIMAL(12, 2) NOT NULL, -- returned to the buyer
+    reason TEXT,
+    created_by INT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 33. ORDER REFUND ITEMS
+-- =======================
+CREATE TABLE order_refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INT NOT NULL REFERENCES order_refunds (id) ON DELETE CASCADE,
+    order_item_id INT NOT NULL REFERENCES order_items (id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(12, 2) NOT NULL
+);
+
 -- =======================
+-- 34. PAYOUT BATCHES
+-- =======================
+CREATE TABLE payout_batches (
+    id SERIAL PRIMARY KEY,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, processing, completed, cancelled
+    total_amount DECIMAL(12, 2) DEFAULT 0,
+    created_by INT REFERENCES users (id), -- NULL for scheduled batches
+    note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+-- =======================
+-- 35. PAYOUTS
+-- =======================
+CREATE TABLE payouts (
+    id SERIAL PRIMARY KEY,
+    batch_id INT NOT NULL REFERENCES payout_batches (id),
+    seller_id INT NOT NULL REFERENCES users (id),
+    amount DECIMAL(12, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, paid, failed, cancelled
+    bank_name VARCHAR(255),
+    bank_account_name VARCHAR(255),
+    bank_account_number VARCHAR(255),
+    transfer_reference VARCHAR(255),
+    failure_reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    settled_at TIMESTAMP
+);
+
+-- =======================
+-- 36. CART ADD EVENTS
+-- =======================
+CREATE TABLE cart_add_events (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id),
+    seller_id INT NOT NULL REFERENCES users (id),
+    product_variant_id INT NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    quantity INT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 37. SHOP COLLECTIONS
+-- =======================
+CREATE TABLE shop_collections (
+    id SERIAL PRIMARY KEY,
+    seller_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    position INT DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE shop_collection_items (
+    id SERIAL PRIMARY KEY,
+    collection_id INT NOT NULL REFERENCES shop_collections (id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position INT DEFAULT 0,
+    UNIQUE (collection_id, product_id)
+);
+
+-- =======================
+-- 38. SHOP FOLLOWS
+-- =======================
+CREATE TABLE shop_follows (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    seller_profile_id INT NOT NULL REFERENCES seller_profiles (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, seller_profile_id)
+);
+
+-- =======================
+-- 39. AUTH SESSIONS
+-- =======================
+-- One row per login, access tokens carry its id and stop working once it is revoked
+CREATE TABLE auth_sessions (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_used_at TIMESTAMP DEFAULT NOW(),
+    revoked_at TIMESTAMP,
+    revoke_reason VARCHAR(20), -- logout, logout_all, token_reuse
+    two_factor BOOLEAN NOT NULL DEFAULT FALSE -- the login passed two-factor authentication
+);
+
+-- Rotating refresh tokens, stored as SHA-256 hashes
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL REFERENCES auth_sessions (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP, -- exchanged for a new token, presenting it again revokes the session
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 40. ACCOUNT ACTION TOKENS
+-- =======================
+-- One-time email verification and password reset tokens. The tokens are signed JWTs whose
+-- jti is the id, rows only record whether they were used.
+CREATE TABLE action_tokens (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL, -- verify_email, reset_password, login_2fa
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0, -- wrong two-factor codes entered against a login challenge
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 41. SOCIAL LOGIN
+-- =======================
+-- Accounts at external login providers (google, facebook) linked to users. Users who signed
+-- up through one have an empty password_hash until they set a password.
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    subject VARCHAR(255) NOT NULL, -- the provider's user ID
+    email VARCHAR(100),
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_login_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+-- Logins between the redirect to the provider and its callback, deleted when used
+CREATE TABLE oauth_states (
+    id VARCHAR(64) PRIMARY KEY, -- the state parameter
+    provider VARCHAR(20) NOT NULL,
+    code_verifier VARCHAR(64) NOT NULL, -- PKCE
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 42. TWO-FACTOR AUTHENTICATION
+-- =======================
+-- TOTP authenticators, pending until enabled_at is set by a first valid code
+CREATE TABLE user_two_factors (
+    user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL, -- base32
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- time step of the last accepted code, refused again
+    failed_attempts INT NOT NULL DEFAULT 0, -- codes tried since the last accepted one
+    locked_until TIMESTAMP, -- no codes are checked and no challenges issued until then
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Single use recovery codes, stored as SHA-256 hashes
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- =======================
+-- 43. INDEXES (PERFORMANCE)
+-- =======================
+CREATE INDEX idx_categories_parent ON categories (parent_id);
+
+CREATE UNIQUE INDEX idx_attribute_definitions_scope_code ON attribute_definitions (COALESCE(category_id, 0), code);
+
+CREATE INDEX idx_variant_attributes_definition ON product_variant_attributes (attribute_definition_id, value);
+
+CREATE INDEX idx_import_jobs_seller ON import_jobs (seller_id);
+
+CREATE INDEX idx_product_revisions_status ON product_revisions (status);
+
+CREATE INDEX idx_notifications_user ON notifications (user_id, is_read);
+
+CREATE INDEX idx_seller_documents_profile ON seller_documents (seller_profile_id);
+
+CREATE INDEX idx_seller_verification_events_profile ON seller_verification_events (seller_profile_id);
+
+CREATE INDEX idx_ledger_entries_order ON ledger_entries (order_id);
+
+CREATE INDEX idx_ledger_entries_seller ON ledger_entries (seller_id);
+
+CREATE INDEX idx_ledger_lines_entry ON ledger_lines (entry_id);
+
+CREATE INDEX idx_ledger_lines_account ON ledger_lines (account, seller_id);
+
+CREATE INDEX idx_order_refunds_order ON order_refunds (order_id);
+
+CREATE INDEX idx_payouts_batch ON payouts (batch_id);
+
+CREATE INDEX idx_payouts_seller ON payouts (seller_id);
+
 CREATE INDEX idx_products_category ON products (category_id);
 
 CREATE INDEX idx_products_brand ON products (brand_id);
@@ -280,6 +778,30 @@ CREATE INDEX idx_orders_user ON orders (user_id);
 
 CREATE INDEX idx_orders_voucher ON orders (voucher_id);
 
+CREATE INDEX idx_orders_created_at ON orders (created_at);
+
+CREATE INDEX idx_order_items_order ON order_items (order_id);
+
+CREATE INDEX idx_order_items_variant ON order_items (product_variant_id);
+
+CREATE INDEX idx_cart_add_events_seller ON cart_add_events (seller_id, created_at);
+
+CREATE INDEX idx_shop_collections_seller ON shop_collections (seller_id);
+
+CREATE INDEX idx_shop_follows_shop ON shop_follows (seller_profile_id);
+
+CREATE INDEX idx_auth_sessions_user ON auth_sessions (user_id);
+
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens (session_id);
+
+CREATE INDEX idx_action_tokens_user ON action_tokens (user_id);
+
+CREATE INDEX idx_user_identities_user ON user_identities (user_id);
+
+CREATE INDEX idx_oauth_states_expires ON oauth_states (expires_at);
+
+CREATE INDEX idx_recovery_codes_user ON recovery_codes (user_id);
+
 CREATE INDEX idx_voucher_code ON vouchers (code);
 
 CREATE INDEX idx_voucher_active ON vouchers (is_active);
@@ -290,7 +812,17 @@ CREATE INDEX idx_product_discount ON product_discounts (product_id);
 
 CREATE INDEX idx_reviews_product ON reviews (product_id);
 
-CREATE INDEX idx_seller_reviews_seller ON seller_reviews (seller_id);
+CREATE INDEX idx_review_images_review ON review_images (review_id);
+
+CREATE INDEX idx_review_edits_review ON review_edits (review_id);
+
+CREATE INDEX idx_reviews_status ON reviews (status);
+
+CREATE INDEX idx_seller_reviews_status ON seller_reviews (status);
+
+CREATE INDEX idx_review_reports_status ON review_reports (status);
+
+CREATE INDEX idx_seller_reviews_seller ON seller_reviews (seller_id, created_at);
 
 CREATE INDEX idx_seller_reviews_buyer ON seller_reviews (buyer_id);
 
@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
+)
+
+// Suggestion is a completion term served by SuggestIndex
+type Suggestion struct {
+	Text   string
+	Kind   string
+	Weight float64
+}
+
+// SuggestLoader returns the full set of terms to index
+type SuggestLoader func() ([]Suggestion, error)
+
+type suggestEntry struct {
+	key     string
+	index   int
+	leading bool
+}
+
+// SuggestIndex is an in-memory prefix index for autocomplete. Every word of a term is
+// indexed, so "red" completes "Gateron Red", and matching ignores case and diacritics.
+// Lookups only take a read lock, rebuilding happens in the background via Refresh.
+type SuggestIndex struct {
+	mu          sync.RWMutex
+	suggestions []Suggestion
+	entries     []suggestEntry
+	maxAge      time.Duration
+
+	loader     SuggestLoader
+	refreshMu  sync.Mutex
+	refreshing bool
+	pending    bool
+	loadedAt   time.Time
+}
+
+// NewSuggestIndex creates an empty index. A lookup on an index older than maxAge
+// schedules a background refresh, so slowly changing inputs such as popular queries
+// are picked up without explicit invalidation.
+func NewSuggestIndex(maxAge time.Duration) *SuggestIndex {
+	return &SuggestIndex{maxAge: maxAge}
+}
+
+// SetLoader sets the function used by Refresh to rebuild the index
+func (s *SuggestIndex) SetLoader(loader SuggestLoader) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	s.loader = loader
+}
+
+// Refresh rebuilds the index in the background. Calls made while a rebuild is running
+// are coalesced into a single follow-up rebuild.
+func (s *SuggestIndex) Refresh() {
+	s.refresh(true)
+}
+
+// refreshIfStale starts a rebuild when the last load is older than maxAge and none is running
+func (s *SuggestIndex) refreshIfStale() {
+	s.refreshMu.Lock()
+	stale := s.maxAge > 0 && time.Since(s.loadedAt) > s.maxAge
+	s.refreshMu.Unlock()
+
+	if stale {
+		s.refresh(false)
+	}
+}
+
+func (s *SuggestIndex) refresh(queue bool) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	if s.loader == nil {
+		return
+	}
+	if s.refreshing {
+		s.pending = s.pending || queue
+		return
+	}
+	s.refreshing = true
+	go s.rebuildLoop()
+}
+
+func (s *SuggestIndex) rebuildLoop() {
+	for {
+		s.refreshMu.Lock()
+		loader := s.loader
+		s.pending = false
+		s.loadedAt = time.Now()
+		s.refreshMu.Unlock()
+
+		suggestions, err := loader()
+		if err != nil {
+			logger.Errorf("Failed to load suggestions: %v", err)
+		} else {
+			s.Replace(suggestions)
+		}
+
+		s.refreshMu.Lock()
+		if !s.pending {
+			s.refreshing = false
+			s.refreshMu.Unlock()
+			return
+		}
+		s.refreshMu.Unlock()
+	}
+}
+
+// Replace swaps the indexed terms. Duplicate texts of the same kind keep the highest weight.
+func (s *SuggestIndex) Replace(suggestions []Suggestion) {
+	unique := make(map[string]int, len(suggestions))
+	deduped := make([]Suggestion, 0, len(suggestions))
+	for _, sg := range suggestions {
+		text := strings.TrimSpace(sg.Text)
+		if text == "" {
+			continue
+		}
+		sg.Text = text
+		id := sg.Kind + "\x00" + NormalizeSuggestKey(text)
+		if i, ok := unique[id]; ok {
+			if sg.Weight > deduped[i].Weight {
+				deduped[i].Weight = sg.Weight
+			}
+			continue
+		}
+		unique[id] = len(deduped)
+		deduped = append(deduped, sg)
+	}
+
+	entries := make([]suggestEntry, 0, len(deduped)*2)
+	for i, sg := range deduped {
+		key := NormalizeSuggestKey(sg.Text)
+		for pos := range wordStarts(key) {
+			entries = append(entries, suggestEntry{key: key[pos:], index: i, leading: pos == 0})
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].key < entries[b].key
+	})
+
+	s.mu.Lock()
+	s.suggestions = deduped
+	s.entries = entries
+	s.mu.Unlock()
+}
+
+// Search returns up to limit terms having a word that starts with prefix, best first.
+// Terms whose first word matches rank above mid-term matches.
+func (s *SuggestIndex) Search(prefix string, limit int) []Suggestion {
+	key := NormalizeSuggestKey(prefix)
+	if key == "" || limit <= 0 {
+		return []Suggestion{}
+	}
+
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type match struct {
+		suggestion Suggestion
+		score      float64
+	}
+	best := make(map[int]float64)
+	start := sort.Search(len(s.entries), func(i int) bool {
+		return s.entries[i].key >= key
+	})
+	for i := start; i < len(s.entries) && strings.HasPrefix(s.entries[i].key, key); i++ {
+		entry := s.entries[i]
+		sg := s.suggestions[entry.index]
+		score := sg.Weight
+		if entry.leading {
+			score += 1000
+		}
+		if current, ok := best[entry.index]; !ok || score > current {
+			best[entry.index] = score
+		}
+	}
+
+	matches := make([]match, 0, len(best))
+	for index, score := range best {
+		matches = append(matches, match{suggestion: s.suggestions[index], score: score})
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		return matches[a].suggestion.Text < matches[b].suggestion.Text
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]Suggestion, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.suggestion)
+	}
+	return result
+}
+
+// NormalizeSuggestKey lowercases, unaccents and collapses whitespace
+func NormalizeSuggestKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strtool.Unaccent(s))), " ")
+}
+
+// wordStarts returns the byte offsets at which words of the normalized key begin
+func wordStarts(key string) map[int]struct{} {
+	starts := map[int]struct{}{0: {}}
+	prevLetter := false
+	for pos, r := range key {
+		isLetter := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isLetter && !prevLetter {
+			starts[pos] = struct{}{}
+		}
+		prevLetter = isLetter
+	}
+	return starts
+}
@@ -2,17 +2,28 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
 
 var (
-	server   ServerCfg
-	dbCfg    DBCfg
-	services ServicesCfg
-	cors     CorsCfg
+	server     ServerCfg
+	dbCfg      DBCfg
+	services   ServicesCfg
+	cors       CorsCfg
+	storage    StorageCfg
+	market     MarketplaceCfg
+	moderation ModerationCfg
+	mail       MailCfg
+	account    AccountCfg
+	oauth      OAuthCfg
+	twoFactor  TwoFactorCfg
 )
 
+// DefaultJWTSecret is the placeholder JWT_SECRET, which production refuses to sign with
+const DefaultJWTSecret = "your-secret-key-change-this-in-production"
+
 type DBCfg struct {
 	PgHost            string `envconfig:"PG_HOST" default:"db"`
 	PgPort            string `envconfig:"PG_PORT" default:"5432"`
@@ -22,25 +33,107 @@ type DBCfg struct {
 	PgPoolSize        int    `envconfig:"PG_POOL_SIZE" default:"0"`
 	PgIdleConnTimeout int    `envconfig:"PG_IDLE_CONNECTION_TIMEOUT" default:"30"`
 	PgMaxConnAge      int    `envconfig:"PG_MAX_CONNECTION_AGE" default:"3000"`
+	PgAutoMigrate     bool   `envconfig:"PG_AUTO_MIGRATE" default:"false"`
 	MongoURI          string `envconfig:"MONGO_URI" default:"0.0.0.0"`
 }
 
 type ServerCfg struct {
-	ENV            string `envconfig:"ENVIRONMENT" default:"development"`
-	SERVERUrl      string `envconfig:"SERVER_URL" default:"0.0.0.0"`
-	GRPCPort       int    `envconfig:"USER_GRPC_PORT" default:"10000"`
-	HTTPPort       int    `envconfig:"PORT" default:"8081"`
-	LogLevel       string `envconfig:"LOG_LEVEL" default:"debug"`
-	Production     bool   `envconfig:"PRODUCTION" default:"false"`
-	GinMode        string `envconfig:"GIN_MODE" default:"debug"`
-	Logger         bool   `envconfig:"LOGGER" default:"false"`
-	CorsProduction bool   `envconfig:"CORS_PRODUCTION" default:"false"`
-	JWTSecret      string `envconfig:"JWT_SECRET" default:"your-secret-key-change-this-in-production"`
-	JWTExpiration  int    `envconfig:"JWT_EXPIRATION" default:"24"` // hours
+	ENV             string        `envconfig:"ENVIRONMENT" default:"development"`
+	SERVERUrl       string        `envconfig:"SERVER_URL" default:"0.0.0.0"`
+	GRPCPort        int           `envconfig:"USER_GRPC_PORT" default:"10000"`
+	HTTPPort        int           `envconfig:"PORT" default:"8081"`
+	LogLevel        string        `envconfig:"LOG_LEVEL" default:"debug"`
+	Production      bool          `envconfig:"PRODUCTION" default:"false"`
+	GinMode         string        `envconfig:"GIN_MODE" default:"debug"`
+	Logger          bool          `envconfig:"LOGGER" default:"false"`
+	CorsProduction  bool          `envconfig:"CORS_PRODUCTION" default:"false"`
+	JWTSecret       string        `envconfig:"JWT_SECRET" default:"your-secret-key-change-this-in-production"` // HS256, when no key directory is set
+	JWTKeyDir       string        `envconfig:"JWT_KEY_DIR"`                                                    // PEM signing keys, named <kid>.pem
+	JWTSigningKeyID string        `envconfig:"JWT_SIGNING_KEY_ID"`                                             // kid of the key new tokens are signed with
+	AccessTokenTTL  time.Duration `envconfig:"JWT_ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTTL time.Duration `envconfig:"JWT_REFRESH_TOKEN_TTL" default:"720h"` // unused this long a refresh token expires
 }
 
 type ServicesCfg struct{}
 
+type StorageCfg struct {
+	Driver    string `envconfig:"STORAGE_DRIVER" default:"local"` // local, s3
+	LocalDir  string `envconfig:"STORAGE_LOCAL_DIR" default:"./uploads"`
+	PublicURL string `envconfig:"STORAGE_PUBLIC_URL" default:"/internal/api/v1/media"` // local driver only
+
+	S3Endpoint  string `envconfig:"S3_ENDPOINT" default:"http://localhost:9000"`
+	S3Region    string `envconfig:"S3_REGION" default:"us-east-1"`
+	S3Bucket    string `envconfig:"S3_BUCKET" default:"chophimco"`
+	S3AccessKey string `envconfig:"S3_ACCESS_KEY" default:""`
+	S3SecretKey string `envconfig:"S3_SECRET_KEY" default:""`
+	S3PathStyle bool   `envconfig:"S3_PATH_STYLE" default:"true"`
+	S3PublicURL string `envconfig:"S3_PUBLIC_URL" default:""` // e.g. a CDN in front of the bucket
+
+	MaxUploadSize   int64 `envconfig:"UPLOAD_MAX_SIZE" default:"5242880"`           // bytes
+	MaxDocumentSize int64 `envconfig:"UPLOAD_MAX_DOCUMENT_SIZE" default:"10485760"` // bytes, seller verification documents
+}
+
+type MarketplaceCfg struct {
+	CommissionRate     float64       `envconfig:"COMMISSION_DEFAULT_RATE" default:"10"` // percent, when no category or seller rate applies
+	PayoutInterval     time.Duration `envconfig:"PAYOUT_INTERVAL" default:"168h"`       // 0 disables scheduled payout batches
+	PayoutHoldPeriod   time.Duration `envconfig:"PAYOUT_HOLD_PERIOD" default:"168h"`    // earnings are paid out once this old
+	PayoutMinAmount    float64       `envconfig:"PAYOUT_MIN_AMOUNT" default:"0"`
+	ReviewEditWindow   time.Duration `envconfig:"REVIEW_EDIT_WINDOW" default:"720h"`    // how long after posting a review can be edited
+	SellerReviewWindow time.Duration `envconfig:"SELLER_REVIEW_WINDOW" default:"2160h"` // how long after an order completes its seller can be reviewed
+}
+
+// ModerationCfg configures the automatic checks that hold reviews for a moderator
+type ModerationCfg struct {
+	BannedWords     []string `envconfig:"REVIEW_BANNED_WORDS"`                 // comma separated words or phrases
+	HoldLinks       bool     `envconfig:"REVIEW_HOLD_LINKS" default:"true"`    // hold reviews containing URLs
+	ReportThreshold int      `envconfig:"REVIEW_REPORT_THRESHOLD" default:"3"` // open reports that hide a review, 0 disables
+}
+
+// MailCfg selects how transactional emails are delivered
+type MailCfg struct {
+	Driver       string `envconfig:"MAIL_DRIVER" default:"file"` // smtp, file, memory
+	From         string `envconfig:"MAIL_FROM" default:"Chophimco <no-reply@chophimco.local>"`
+	SMTPHost     string `envconfig:"SMTP_HOST" default:""`
+	SMTPPort     int    `envconfig:"SMTP_PORT" default:"587"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME" default:""`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD" default:""`
+	FileDir      string `envconfig:"MAIL_FILE_DIR" default:"./mail"` // file driver only
+}
+
+// AccountCfg configures email verification and password reset
+type AccountCfg struct {
+	AppURL                string        `envconfig:"APP_URL" default:"http://localhost:5173"` // frontend the emailed links point to
+	EmailVerificationTTL  time.Duration `envconfig:"EMAIL_VERIFICATION_TTL" default:"48h"`
+	PasswordResetTTL      time.Duration `envconfig:"PASSWORD_RESET_TTL" default:"1h"`
+	AllowUnverifiedOrders bool          `envconfig:"ALLOW_UNVERIFIED_ORDERS" default:"true"` // whether users may order before verifying their email
+}
+
+// OAuthCfg configures social login. A provider is enabled once its client ID is set.
+type OAuthCfg struct {
+	CallbackURL string        `envconfig:"OAUTH_CALLBACK_URL" default:"http://localhost:8081/api/v1/user/oauth"` // <provider>/callback is appended
+	CompleteURL string        `envconfig:"OAUTH_COMPLETE_URL" default:"http://localhost:5173/oauth/complete"`    // frontend page the browser returns to
+	StateTTL    time.Duration `envconfig:"OAUTH_STATE_TTL" default:"10m"`                                        // how long a started login can be completed
+
+	GoogleClientID     string `envconfig:"GOOGLE_CLIENT_ID" default:""`
+	GoogleClientSecret string `envconfig:"GOOGLE_CLIENT_SECRET" default:""`
+	GoogleIssuer       string `envconfig:"GOOGLE_ISSUER" default:"https://accounts.google.com"`
+
+	FacebookClientID     string `envconfig:"FACEBOOK_CLIENT_ID" default:""`
+	FacebookClientSecret string `envconfig:"FACEBOOK_CLIENT_SECRET" default:""`
+	FacebookAuthURL      string `envconfig:"FACEBOOK_AUTH_URL" default:"https://www.facebook.com/v19.0/dialog/oauth"`
+	FacebookTokenURL     string `envconfig:"FACEBOOK_TOKEN_URL" default:"https://graph.facebook.com/v19.0/oauth/access_token"`
+	FacebookUserInfoURL  string `envconfig:"FACEBOOK_USERINFO_URL" default:"https://graph.facebook.com/v19.0/me?fields=id,name,email"`
+}
+
+// TwoFactorCfg configures TOTP two-factor authentication
+type TwoFactorCfg struct {
+	Issuer        string        `envconfig:"TWO_FACTOR_ISSUER" default:"Chophimco"` // account name shown in authenticator apps
+	RequiredRoles []string      `envconfig:"TWO_FACTOR_REQUIRED_ROLES"`             // comma separated, e.g. admin,seller
+	ChallengeTTL  time.Duration `envconfig:"TWO_FACTOR_CHALLENGE_TTL" default:"5m"` // how long after the password the code can be entered
+	MaxAttempts   int           `envconfig:"TWO_FACTOR_MAX_ATTEMPTS" default:"10"`  // wrong codes in a row, over any number of logins
+	Lockout       time.Duration `envconfig:"TWO_FACTOR_LOCKOUT" default:"15m"`      // how long the checks stay locked after MaxAttempts
+}
+
 type CorsCfg struct {
 	Google   string `envconfig:"GOOGLE" default:"https://www.google.com/"`
 	Facebook string `envconfig:"FACEBOOK" default:"https://www.facebook.com/"`
@@ -53,6 +146,13 @@ func InitConfig() {
 		&services,
 		&dbCfg,
 		&cors,
+		&storage,
+		&market,
+		&moderation,
+		&mail,
+		&account,
+		&oauth,
+		&twoFactor,
 	}
 	for _, instance := range configs {
 		err := envconfig.Process("", instance)
@@ -77,3 +177,31 @@ func DBConfig() DBCfg {
 func CorsConfig() CorsCfg {
 	return cors
 }
+
+func StorageConfig() StorageCfg {
+	return storage
+}
+
+func MarketplaceConfig() MarketplaceCfg {
+	return market
+}
+
+func ModerationConfig() ModerationCfg {
+	return moderation
+}
+
+func MailConfig() MailCfg {
+	return mail
+}
+
+func AccountConfig() AccountCfg {
+	return account
+}
+
+func OAuthConfig() OAuthCfg {
+	return oauth
+}
+
+func TwoFactorConfig() TwoFactorCfg {
+	return twoFactor
+}
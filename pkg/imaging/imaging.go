@@ -0,0 +1,163 @@
+// Package imaging validates uploaded images and renders resized copies in pure Go.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/leehai1107/chophimco-server/pkg/xhttp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+const (
+	jpegQuality = 85
+	// maxPixels bounds decoded image size, a small file can declare huge dimensions
+	maxPixels = 40_000_000
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type, use JPEG, PNG, WebP or GIF")
+	ErrTooLarge        = errors.New("image is too large")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// AllowedTypes are the MIME types accepted for upload
+var AllowedTypes = []string{xhttp.MIMEJPEG, xhttp.MIMEPNG, xhttp.MIMEWEBP, xhttp.MIMEGIF}
+
+// Size is a rendition bounded to MaxSize pixels on its longest side
+type Size struct {
+	Name    string
+	MaxSize int
+}
+
+// Rendition is an encoded image
+type Rendition struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// DetectType sniffs the MIME type from the content, ignoring what the client claims
+func DetectType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	for _, allowed := range AllowedTypes {
+		if contentType == allowed {
+			return contentType, nil
+		}
+	}
+	return "", ErrUnsupportedType
+}
+
+// Process validates an upload no larger than maxBytes and renders the original plus every
+// size. Sizes are never upscaled, a size larger than the original gets the original
+// dimensions. PNG and GIF are stored as PNG to keep transparency, the rest as JPEG.
+func Process(data []byte, maxBytes int64, sizes []Size) (original Rendition, renditions []Rendition, err error) {
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return Rendition{}, nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, maxBytes)
+	}
+	contentType, err := DetectType(data)
+	if err != nil {
+		return Rendition{}, nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Rendition{}, nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return Rendition{}, nil, fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Rendition{}, nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	lossless := contentType == xhttp.MIMEPNG || contentType == xhttp.MIMEGIF
+	bounds := img.Bounds()
+
+	// Re-encode the original too, which strips metadata such as EXIF location
+	original, err = encode("original", img, lossless)
+	if err != nil {
+		return Rendition{}, nil, err
+	}
+
+	for _, size := range sizes {
+		w, h := fit(bounds.Dx(), bounds.Dy(), size.MaxSize)
+		resized := img
+		if w != bounds.Dx() || h != bounds.Dy() {
+			resized = Resize(img, w, h)
+		}
+		rendition, err := encode(size.Name, resized, lossless)
+		if err != nil {
+			return Rendition{}, nil, err
+		}
+		renditions = append(renditions, rendition)
+	}
+	return original, renditions, nil
+}
+
+// Resize scales img to exactly w x h using Catmull-Rom resampling
+func Resize(img image.Image, w, h int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Over, nil)
+	return dst
+}
+
+// fit returns dimensions bounded by maxSize on the longest side, keeping the aspect ratio
+func fit(w, h, maxSize int) (int, int) {
+	if maxSize <= 0 || (w <= maxSize && h <= maxSize) {
+		return w, h
+	}
+	if w >= h {
+		return maxSize, max(1, h*maxSize/w)
+	}
+	return max(1, w*maxSize/h), maxSize
+}
+
+func encode(name string, img image.Image, lossless bool) (Rendition, error) {
+	var buf bytes.Buffer
+	rendition := Rendition{
+		Name:   name,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	if lossless {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return Rendition{}, err
+		}
+		rendition.ContentType, rendition.Extension = xhttp.MIMEPNG, ".png"
+	} else {
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Rendition{}, err
+		}
+		rendition.ContentType, rendition.Extension = xhttp.MIMEJPEG, ".jpg"
+	}
+
+	rendition.Data = buf.Bytes()
+	return rendition, nil
+}
+
+// flatten draws the image over white, JPEG has no alpha channel
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
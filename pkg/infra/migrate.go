@@ -1,7 +1,11 @@
 package infra
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"gorm.io/gorm"
 )
@@ -14,13 +18,23 @@ func RunMigrations(db *gorm.DB) error {
 	models := []interface{}{
 		&entity.Role{},
 		&entity.User{},
+		&entity.AuthSession{},
+		&entity.RefreshToken{},
+		&entity.ActionToken{},
+		&entity.UserIdentity{},
+		&entity.OAuthState{},
+		&entity.UserTwoFactor{},
+		&entity.RecoveryCode{},
 		&entity.Category{},
 		&entity.Brand{},
 		&entity.Switch{},
 		&entity.Product{},
 		&entity.ProductVariant{},
+		&entity.ProductImage{},
+		&entity.SellerProfile{},
 		&entity.Cart{},
 		&entity.CartItem{},
+		&entity.CartAddEvent{},
 		&entity.Voucher{},
 		&entity.UserVoucher{},
 		&entity.ProductDiscount{},
@@ -28,8 +42,38 @@ func RunMigrations(db *gorm.DB) error {
 		&entity.OrderItem{},
 		&entity.Payment{},
 		&entity.Review{},
+		&entity.ReviewImage{},
+		&entity.ReviewEdit{},
+		&entity.ReviewReply{},
+		&entity.ReviewVote{},
+		&entity.ReviewReport{},
+		&entity.FlashSale{},
+		&entity.FlashSaleItem{},
+		&entity.FlashSalePurchase{},
+		&entity.SearchQuery{},
+		&entity.AttributeDefinition{},
+		&entity.ProductVariantAttribute{},
+		&entity.ImportJob{},
+		&entity.ProductRevision{},
+		&entity.Notification{},
+		&entity.SellerKYC{},
+		&entity.SellerDocument{},
+		&entity.SellerVerificationEvent{},
+		&entity.LedgerEntry{},
+		&entity.LedgerLine{},
+		&entity.CommissionRule{},
+		&entity.OrderRefund{},
+		&entity.OrderRefundItem{},
+		&entity.PayoutBatch{},
+		&entity.Payout{},
+		&entity.ShopCollection{},
+		&entity.ShopCollectionItem{},
+		&entity.ShopFollow{},
 	}
 
+	// Users that predate email verification are only backfilled when the column is added
+	addingEmailVerification := !db.Migrator().HasColumn(&entity.User{}, "email_verified_at")
+
 	// Auto migrate all models
 	for _, model := range models {
 		if err := db.AutoMigrate(model); err != nil {
@@ -45,6 +89,128 @@ func RunMigrations(db *gorm.DB) error {
 		return err
 	}
 
+	// Give categories created before slugs existed one
+	if err := backfillCategorySlugs(db); err != nil {
+		logger.Errorf("Failed to backfill category slugs: %v", err)
+		return err
+	}
+
+	// Give shops created before storefronts existed a URL
+	if err := backfillShopSlugs(db); err != nil {
+		logger.Errorf("Failed to backfill shop slugs: %v", err)
+		return err
+	}
+
+	// Reviews written before purchases were checked are verified when the reviewer has a completed order of the product
+	if err := db.Exec(`UPDATE reviews SET is_verified_purchase = TRUE, order_id = (
+			SELECT o.id FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			JOIN product_variants pv ON pv.id = oi.product_variant_id
+			WHERE o.user_id = reviews.user_id AND pv.product_id = reviews.product_id AND o.status = 'completed'
+			ORDER BY o.created_at DESC LIMIT 1)
+		WHERE order_id IS NULL AND NOT is_verified_purchase AND EXISTS (
+			SELECT 1 FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			JOIN product_variants pv ON pv.id = oi.product_variant_id
+			WHERE o.user_id = reviews.user_id AND pv.product_id = reviews.product_id AND o.status = 'completed')`).Error; err != nil {
+		logger.Errorf("Failed to backfill verified purchase reviews: %v", err)
+		return err
+	}
+
+	// A buyer reviews a product once, earlier duplicates keep the first review
+	if err := dedupeProductReviews(db); err != nil {
+		logger.Errorf("Failed to remove duplicate product reviews: %v", err)
+		return err
+	}
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_product
+		ON reviews (user_id, product_id)`).Error; err != nil {
+		logger.Errorf("Failed to add product review index: %v", err)
+		return err
+	}
+
+	// Products reviewed before review aggregates were kept get theirs computed once
+	if err := db.Exec(`UPDATE products SET
+			review_count = agg.total,
+			rating_1_count = agg.r1, rating_2_count = agg.r2, rating_3_count = agg.r3,
+			rating_4_count = agg.r4, rating_5_count = agg.r5,
+			average_rating = ROUND(agg.average::numeric, 2)
+		FROM (
+			SELECT product_id, COUNT(*) AS total, AVG(rating) AS average,
+				COUNT(*) FILTER (WHERE rating = 1) AS r1, COUNT(*) FILTER (WHERE rating = 2) AS r2,
+				COUNT(*) FILTER (WHERE rating = 3) AS r3, COUNT(*) FILTER (WHERE rating = 4) AS r4,
+				COUNT(*) FILTER (WHERE rating = 5) AS r5
+			FROM reviews WHERE status = 'published' GROUP BY product_id
+		) agg
+		WHERE products.id = agg.product_id AND products.review_count = 0`).Error; err != nil {
+		logger.Errorf("Failed to backfill product review aggregates: %v", err)
+		return err
+	}
+
+	// Sellers waiting for review from before onboarding have nothing to review, send them back to draft
+	if err := db.Exec(`UPDATE seller_profiles SET verification_status = 'draft'
+		WHERE verification_status = 'pending' AND NOT EXISTS (
+			SELECT 1 FROM seller_kycs WHERE seller_kycs.seller_profile_id = seller_profiles.id
+				AND seller_kycs.submitted_at IS NOT NULL)`).Error; err != nil {
+		logger.Errorf("Failed to reset unsubmitted sellers: %v", err)
+		return err
+	}
+
+	// Items ordered before list prices were kept were sold at their regular price
+	if err := db.Exec(`UPDATE order_items SET list_price = price WHERE list_price = 0`).Error; err != nil {
+		logger.Errorf("Failed to backfill order item list prices: %v", err)
+		return err
+	}
+
+	// Orders completed before completion times were kept count as completed when placed
+	if err := db.Exec(`UPDATE orders SET completed_at = created_at
+		WHERE completed_at IS NULL AND status IN ('completed', 'refunded')`).Error; err != nil {
+		logger.Errorf("Failed to backfill order completion times: %v", err)
+		return err
+	}
+
+	// A buyer reviews a seller once per order, earlier duplicates keep the first review
+	if err := db.Exec(`DELETE FROM seller_reviews WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY buyer_id, seller_id, order_id ORDER BY id) AS n
+				FROM seller_reviews
+			) ranked WHERE n > 1)`).Error; err != nil {
+		logger.Errorf("Failed to remove duplicate seller reviews: %v", err)
+		return err
+	}
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_seller_reviews_buyer_seller_order
+		ON seller_reviews (buyer_id, seller_id, order_id)`).Error; err != nil {
+		logger.Errorf("Failed to add seller review index: %v", err)
+		return err
+	}
+	if err := db.Exec(`UPDATE seller_profiles SET average_rating = (
+			SELECT COALESCE(AVG(rating), 0) FROM seller_reviews
+			WHERE seller_reviews.seller_id = seller_profiles.user_id AND status = 'published')`).Error; err != nil {
+		logger.Errorf("Failed to recompute seller ratings: %v", err)
+		return err
+	}
+
+	// Accounts from before email verification keep ordering as if verified at sign up
+	if addingEmailVerification {
+		err := db.Exec(`UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`).Error
+		if err != nil {
+			logger.Errorf("Failed to backfill email verification: %v", err)
+			return err
+		}
+	}
+
+	// A code is unique per category, and among global attributes
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_attribute_definitions_scope_code
+		ON attribute_definitions (COALESCE(category_id, 0), code)`).Error; err != nil {
+		logger.Errorf("Failed to add attribute definition index: %v", err)
+		return err
+	}
+
+	// Add full-text search index for products
+	if err := addProductSearchIndex(db); err != nil {
+		logger.Errorf("Failed to add product search index: %v", err)
+		return err
+	}
+
 	logger.Info("Database migrations completed successfully")
 	return nil
 }
@@ -215,3 +381,274 @@ func addForeignKeys(db *gorm.DB) error {
 	logger.Info("Foreign key constraints added successfully")
 	return nil
 }
+
+// addProductSearchIndex maintains products.search_vector, a weighted tsvector over the
+// product name, brand, category, switch names, text attribute values and description. Text is unaccented so
+// Vietnamese queries match with or without diacritics, and a trigram index on the
+// name backs typo-tolerant matching.
+func addProductSearchIndex(db *gorm.DB) error {
+	logger.Info("Adding product search index...")
+
+	previousDocument, err := productSearchDocumentSource(db)
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS unaccent`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+
+		// unaccent() is only STABLE, an immutable wrapper is required for expression indexes
+		`CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS $$
+			SELECT public.unaccent('public.unaccent', $1)
+		$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'vn_unaccent') THEN
+				CREATE TEXT SEARCH CONFIGURATION vn_unaccent (COPY = simple);
+				ALTER TEXT SEARCH CONFIGURATION vn_unaccent
+					ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+			END IF;
+		END
+		$$`,
+
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+
+		`CREATE OR REPLACE FUNCTION product_search_document(p_id int) RETURNS tsvector AS $$
+			SELECT
+				setweight(to_tsvector('vn_unaccent', coalesce(p.name, '')), 'A') ||
+				setweight(to_tsvector('vn_unaccent', coalesce(b.name, '')), 'B') ||
+				setweight(to_tsvector('vn_unaccent', coalesce(c.name, '')), 'B') ||
+				setweight(to_tsvector('vn_unaccent', coalesce((
+					SELECT string_agg(DISTINCT s.name, ' ')
+					FROM product_variants pv JOIN switches s ON s.id = pv.switch_id
+					WHERE pv.product_id = p.id
+				), '')), 'B') ||
+				setweight(to_tsvector('vn_unaccent', coalesce((
+					SELECT string_agg(DISTINCT pva.value, ' ')
+					FROM product_variants pv
+					JOIN product_variant_attributes pva ON pva.product_variant_id = pv.id
+					JOIN attribute_definitions ad ON ad.id = pva.attribute_definition_id
+					WHERE pv.product_id = p.id AND ad.type IN ('text', 'enum')
+				), '')), 'C') ||
+				setweight(to_tsvector('vn_unaccent', coalesce(p.description, '')), 'C')
+			FROM products p
+			LEFT JOIN brands b ON b.id = p.brand_id
+			LEFT JOIN categories c ON c.id = p.category_id
+			WHERE p.id = p_id
+		$$ LANGUAGE sql STABLE`,
+
+		`CREATE OR REPLACE FUNCTION refresh_product_search_vector(p_id int) RETURNS void AS $$
+			UPDATE products SET search_vector = product_search_document(p_id) WHERE id = p_id
+		$$ LANGUAGE sql`,
+
+		// Only fires for the indexed columns, so updating search_vector does not recurse
+		`CREATE OR REPLACE FUNCTION products_search_trigger() RETURNS trigger AS $$
+		BEGIN
+			PERFORM refresh_product_search_vector(NEW.id);
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_products_search ON products`,
+		`CREATE TRIGGER trg_products_search
+			AFTER INSERT OR UPDATE OF name, description, brand_id, category_id ON products
+			FOR EACH ROW EXECUTE FUNCTION products_search_trigger()`,
+
+		`CREATE OR REPLACE FUNCTION product_variants_search_trigger() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP IN ('UPDATE', 'DELETE') THEN
+				PERFORM refresh_product_search_vector(OLD.product_id);
+			END IF;
+			IF TG_OP IN ('INSERT', 'UPDATE') THEN
+				PERFORM refresh_product_search_vector(NEW.product_id);
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_product_variants_search ON product_variants`,
+		`CREATE TRIGGER trg_product_variants_search
+			AFTER INSERT OR DELETE OR UPDATE OF switch_id, product_id ON product_variants
+			FOR EACH ROW EXECUTE FUNCTION product_variants_search_trigger()`,
+
+		`CREATE OR REPLACE FUNCTION variant_attributes_search_trigger() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP IN ('UPDATE', 'DELETE') THEN
+				PERFORM refresh_product_search_vector(product_id) FROM product_variants WHERE id = OLD.product_variant_id;
+			END IF;
+			IF TG_OP IN ('INSERT', 'UPDATE') THEN
+				PERFORM refresh_product_search_vector(product_id) FROM product_variants WHERE id = NEW.product_variant_id;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_variant_attributes_search ON product_variant_attributes`,
+		`CREATE TRIGGER trg_variant_attributes_search
+			AFTER INSERT OR DELETE OR UPDATE OF value ON product_variant_attributes
+			FOR EACH ROW EXECUTE FUNCTION variant_attributes_search_trigger()`,
+
+		`CREATE OR REPLACE FUNCTION catalog_search_trigger() RETURNS trigger AS $$
+		BEGIN
+			IF TG_TABLE_NAME = 'brands' THEN
+				PERFORM refresh_product_search_vector(id) FROM products WHERE brand_id = NEW.id;
+			ELSIF TG_TABLE_NAME = 'categories' THEN
+				PERFORM refresh_product_search_vector(id) FROM products WHERE category_id = NEW.id;
+			ELSIF TG_TABLE_NAME = 'switches' THEN
+				PERFORM refresh_product_search_vector(pv.product_id)
+				FROM (SELECT DISTINCT product_id FROM product_variants WHERE switch_id = NEW.id) pv;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_brands_search ON brands`,
+		`CREATE TRIGGER trg_brands_search AFTER UPDATE OF name ON brands
+			FOR EACH ROW EXECUTE FUNCTION catalog_search_trigger()`,
+		`DROP TRIGGER IF EXISTS trg_categories_search ON categories`,
+		`CREATE TRIGGER trg_categories_search AFTER UPDATE OF name ON categories
+			FOR EACH ROW EXECUTE FUNCTION catalog_search_trigger()`,
+		`DROP TRIGGER IF EXISTS trg_switches_search ON switches`,
+		`CREATE TRIGGER trg_switches_search AFTER UPDATE OF name ON switches
+			FOR EACH ROW EXECUTE FUNCTION catalog_search_trigger()`,
+
+		`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (f_unaccent(lower(name)) gin_trgm_ops)`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	// The triggers keep documents current, rows only need one when the column or the document
+	// definition is new. Rewriting the whole catalogue on every boot would lock and bloat it.
+	document, err := productSearchDocumentSource(db)
+	if err != nil {
+		return err
+	}
+	backfill := `UPDATE products SET search_vector = product_search_document(id) WHERE search_vector IS NULL`
+	if document != previousDocument {
+		logger.Info("Product search document changed, reindexing all products...")
+		backfill = `UPDATE products SET search_vector = product_search_document(id)`
+	}
+	if err := db.Exec(backfill).Error; err != nil {
+		return err
+	}
+
+	logger.Info("Product search index added successfully")
+	return nil
+}
+
+// productSearchDocumentSource returns the body of product_search_document, empty before it exists
+func productSearchDocumentSource(db *gorm.DB) (string, error) {
+	var source string
+	err := db.Raw(`SELECT COALESCE((SELECT prosrc FROM pg_proc WHERE proname = 'product_search_document'), '')`).
+		Row().Scan(&source)
+	return source, err
+}
+
+// backfillCategorySlugs derives a slug from the name of every category that lacks one
+func backfillCategorySlugs(db *gorm.DB) error {
+	var categories []entity.Category
+	if err := db.Where("slug IS NULL OR slug = ''").Find(&categories).Error; err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		slug := strtool.Slugify(category.Name)
+		if slug == "" {
+			slug = "category"
+		}
+
+		var taken int64
+		if err := db.Model(&entity.Category{}).Where("slug = ?", slug).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			slug = fmt.Sprintf("%s-%d", slug, category.ID)
+		}
+
+		if err := db.Model(&entity.Category{}).Where("id = ?", category.ID).Update("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillShopSlugs derives a slug from the shop name of every seller that lacks one
+func backfillShopSlugs(db *gorm.DB) error {
+	var profiles []entity.SellerProfile
+	if err := db.Where("slug IS NULL OR slug = ''").Find(&profiles).Error; err != nil {
+		return err
+	}
+
+	for _, profile := range profiles {
+		slug := strtool.Slugify(profile.ShopName)
+		if len(slug) > 100 {
+			// Leave room in the column for the user ID suffix
+			slug = strings.TrimRight(slug[:100], "-")
+		}
+		if slug == "" {
+			slug = "shop"
+		}
+
+		var taken int64
+		if err := db.Model(&entity.SellerProfile{}).Where("slug = ?", slug).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			slug = fmt.Sprintf("%s-%d", slug, profile.UserID)
+		}
+
+		if err := db.Model(&entity.SellerProfile{}).Where("id = ?", profile.ID).Update("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dedupeProductReviews drops all but the first review of a user for a product, and recounts the
+// aggregates of the products that had duplicates, which counted every copy
+func dedupeProductReviews(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var removed []struct {
+			ID        int
+			ProductID int
+		}
+		err := tx.Raw(`DELETE FROM reviews WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, product_id ORDER BY id) AS n
+					FROM reviews
+				) ranked WHERE n > 1)
+			RETURNING id, product_id`).Scan(&removed).Error
+		if err != nil || len(removed) == 0 {
+			return err
+		}
+
+		reviewIDs := make([]int, 0, len(removed))
+		productIDs := make([]int, 0, len(removed))
+		for _, review := range removed {
+			reviewIDs = append(reviewIDs, review.ID)
+			productIDs = append(productIDs, review.ProductID)
+		}
+		if err := tx.Exec(`DELETE FROM review_reports WHERE target_type = ? AND target_id IN ?`,
+			entity.ReviewTargetProduct, reviewIDs).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE products SET
+				review_count = agg.total,
+				rating_1_count = agg.r1, rating_2_count = agg.r2, rating_3_count = agg.r3,
+				rating_4_count = agg.r4, rating_5_count = agg.r5,
+				average_rating = ROUND(agg.average::numeric, 2)
+			FROM (
+				SELECT p.id AS product_id, COUNT(r.id) AS total, COALESCE(AVG(r.rating), 0) AS average,
+					COUNT(r.id) FILTER (WHERE r.rating = 1) AS r1, COUNT(r.id) FILTER (WHERE r.rating = 2) AS r2,
+					COUNT(r.id) FILTER (WHERE r.rating = 3) AS r3, COUNT(r.id) FILTER (WHERE r.rating = 4) AS r4,
+					COUNT(r.id) FILTER (WHERE r.rating = 5) AS r5
+				FROM products p
+				LEFT JOIN reviews r ON r.product_id = p.id AND r.status = 'published'
+				WHERE p.id IN ? GROUP BY p.id
+			) agg
+			WHERE products.id = agg.product_id`, productIDs).Error
+	})
+}
@@ -123,9 +123,9 @@ func InitPostgresql() {
 	logger.Info("Successfully connected to PostgreSQL database")
 	dbSingleton = db
 
-	// Run migrations if tables don't exist
-	if tableCount == 0 {
-		logger.Info("No tables found. Running migrations...")
+	// Run migrations if tables don't exist, or on every start when auto-migrate is enabled
+	if tableCount == 0 || dbCfg.PgAutoMigrate {
+		logger.Info("Running migrations...")
 		if err := RunMigrations(db); err != nil {
 			logger.Errorf("Failed to run migrations: %s", err.Error())
 			panic(fmt.Sprintf("Database migration failed: %s", err.Error()))
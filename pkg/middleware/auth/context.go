@@ -50,3 +50,18 @@ func GetUserRoleFromContext(c *gin.Context) (string, error) {
 
 	return roleStr, nil
 }
+
+// GetSessionIDFromContext extracts the login session of the access token from gin context
+func GetSessionIDFromContext(c *gin.Context) (string, error) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return "", errors.New("session ID not found in context")
+	}
+
+	sessionIDStr, ok := sessionID.(string)
+	if !ok {
+		return "", errors.New("invalid session ID type")
+	}
+
+	return sessionIDStr, nil
+}
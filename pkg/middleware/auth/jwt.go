@@ -8,57 +8,112 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/tools/random"
 )
 
 type JWTClaims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`           // login session the token was issued to, revoked on logout
+	TwoFactor bool   `json:"tfa,omitempty"` // the session passed two-factor authentication
+	jwt.RegisteredClaims
+}
+
+// ActionClaims authorize a single account action, such as resetting a password. The purpose
+// is the token's audience, so a token issued for one action is refused by every other.
+type ActionClaims struct {
+	UserID int `json:"user_id"`
 	jwt.RegisteredClaims
 }
 
 type IJWTService interface {
-	GenerateToken(userID int, email, role string) (string, error)
+	// GenerateToken issues a short-lived access token for a login session
+	GenerateToken(userID int, email, role, sessionID string, twoFactor bool) (string, time.Time, error)
 	ValidateToken(tokenString string) (*JWTClaims, error)
+	// GenerateActionToken issues a token for one account action, identified by its jti
+	GenerateActionToken(userID int, purpose string, ttl time.Duration) (string, *ActionClaims, error)
+	ValidateActionToken(tokenString string, purpose string) (*ActionClaims, error)
+	// JWKS returns the public keys other services verify our tokens with
+	JWKS() JWKSet
 }
 
 type jwtService struct {
-	secretKey  string
+	keys       map[string]*signingKey // by kid, the HMAC secret has an empty kid
+	active     *signingKey            // signs new tokens
 	expiration time.Duration
 }
 
-func NewJWTService() IJWTService {
+// NewJWTService signs with the JWT_SIGNING_KEY_ID key of JWT_KEY_DIR, or with JWT_SECRET
+// (HS256) when no key directory is configured. Every key in the directory verifies tokens,
+// so a new key can take over signing while tokens of the previous one are still live.
+func NewJWTService() (IJWTService, error) {
 	cfg := config.ServerConfig()
-	return &jwtService{
-		secretKey:  cfg.JWTSecret,
-		expiration: time.Duration(cfg.JWTExpiration) * time.Hour,
+	service := &jwtService{expiration: cfg.AccessTokenTTL}
+
+	if cfg.JWTKeyDir == "" {
+		if cfg.JWTSecret == "" {
+			return nil, errors.New("JWT_SECRET is empty")
+		}
+		if cfg.Production && cfg.JWTSecret == config.DefaultJWTSecret {
+			return nil, errors.New("JWT_SECRET is the default placeholder, set a secret or JWT_KEY_DIR in production")
+		}
+		service.active = &signingKey{
+			method:  jwt.SigningMethodHS256,
+			private: []byte(cfg.JWTSecret),
+			public:  []byte(cfg.JWTSecret),
+		}
+		service.keys = map[string]*signingKey{"": service.active}
+		return service, nil
+	}
+
+	keys, err := loadKeyDir(cfg.JWTKeyDir)
+	if err != nil {
+		return nil, err
+	}
+	active, ok := keys[cfg.JWTSigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("JWT signing key %q not found in %s", cfg.JWTSigningKeyID, cfg.JWTKeyDir)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("JWT signing key %q has no private key", cfg.JWTSigningKeyID)
 	}
+	service.keys = keys
+	service.active = active
+	return service, nil
 }
 
-func (j *jwtService) GenerateToken(userID int, email, role string) (string, error) {
+func (j *jwtService) GenerateToken(userID int, email, role, sessionID string, twoFactor bool) (string, time.Time, error) {
+	tokenID, err := random.UUIdV4()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(j.expiration)
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+		TwoFactor: twoFactor,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	signed, err := j.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
 func (j *jwtService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(j.secretKey), nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey)
 	if err != nil {
 		return nil, err
 	}
@@ -67,10 +122,80 @@ func (j *jwtService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	if !ok || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	// Tokens from before sessions existed cannot be revoked
+	if claims.SessionID == "" {
+		return nil, errors.New("token has no session")
+	}
+
+	return claims, nil
+}
+
+func (j *jwtService) GenerateActionToken(userID int, purpose string, ttl time.Duration) (string, *ActionClaims, error) {
+	tokenID, err := random.UUIdV4()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &ActionClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Audience:  jwt.ClaimStrings{purpose},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	signed, err := j.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+func (j *jwtService) ValidateActionToken(tokenString string, purpose string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, j.verificationKey,
+		jwt.WithAudience(purpose), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
 
+	claims, ok := token.Claims.(*ActionClaims)
+	if !ok || !token.Valid || claims.ID == "" {
+		return nil, errors.New("invalid token")
+	}
 	return claims, nil
 }
 
+// sign signs claims with the active key
+func (j *jwtService) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(j.active.method, claims)
+	if j.active.id != "" {
+		token.Header["kid"] = j.active.id
+	}
+	return token.SignedString(j.active.private)
+}
+
+// verificationKey picks the key a token claims to be signed with
+func (j *jwtService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	// The algorithm comes from our key, never from the token
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+func (j *jwtService) JWKS() JWKSet {
+	return jwks(j.keys)
+}
+
 // Helper function to extract user ID from string
 func GetUserIDFromString(userIDStr string) (int, error) {
 	userID, err := strconv.Atoi(userIDStr)
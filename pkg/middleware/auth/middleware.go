@@ -1,14 +1,24 @@
 package auth
 
 import (
+	"context"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
 )
 
+// RevocationList reports whether the session an access token belongs to was logged out
+// before the token expired
+type RevocationList interface {
+	IsRevoked(ctx context.Context, claims *JWTClaims) (bool, error)
+}
+
 // AuthMiddleware verifies JWT token and sets user info in context
-func AuthMiddleware(jwtService IJWTService) gin.HandlerFunc {
+func AuthMiddleware(jwtService IJWTService, revocations RevocationList) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Try to get token from cookie first
 		tokenString, err := c.Cookie("jwt_token")
@@ -36,17 +46,32 @@ func AuthMiddleware(jwtService IJWTService) gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := revocations.IsRevoked(c, claims)
+		if err != nil {
+			logger.EnhanceWith(c).Errorw("Failed to check token revocation", "error", err)
+			apiwrapper.SendInternalError(c, "failed to verify token")
+			c.Abort()
+			return
+		}
+		if revoked {
+			apiwrapper.SendUnauthorized(c, "token has been revoked")
+			c.Abort()
+			return
+		}
+
 		// Set user info in context for handlers to use
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("two_factor", claims.TwoFactor)
 
 		c.Next()
 	}
 }
 
 // OptionalAuthMiddleware sets user info if token is provided, but doesn't require it
-func OptionalAuthMiddleware(jwtService IJWTService) gin.HandlerFunc {
+func OptionalAuthMiddleware(jwtService IJWTService, revocations RevocationList) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Try to get token from cookie first
 		tokenString, err := c.Cookie("jwt_token")
@@ -64,9 +89,15 @@ func OptionalAuthMiddleware(jwtService IJWTService) gin.HandlerFunc {
 		if tokenString != "" {
 			claims, err := jwtService.ValidateToken(tokenString)
 			if err == nil {
-				c.Set("user_id", claims.UserID)
-				c.Set("user_email", claims.Email)
-				c.Set("user_role", claims.Role)
+				// Revoked tokens, or ones that cannot be checked, are treated as anonymous
+				revoked, err := revocations.IsRevoked(c, claims)
+				if err == nil && !revoked {
+					c.Set("user_id", claims.UserID)
+					c.Set("user_email", claims.Email)
+					c.Set("user_role", claims.Role)
+					c.Set("session_id", claims.SessionID)
+					c.Set("two_factor", claims.TwoFactor)
+				}
 			}
 		}
 
@@ -74,7 +105,8 @@ func OptionalAuthMiddleware(jwtService IJWTService) gin.HandlerFunc {
 	}
 }
 
-// RoleMiddleware checks if user has required role
+// RoleMiddleware checks if user has required role. Users of a role listed in
+// TWO_FACTOR_REQUIRED_ROLES also need a session that passed two-factor authentication.
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
@@ -87,6 +119,11 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		role := userRole.(string)
 		for _, allowedRole := range allowedRoles {
 			if role == allowedRole {
+				if RequiresTwoFactor(role) && !c.GetBool("two_factor") {
+					apiwrapper.SendUnauthorized(c, "two-factor authentication required")
+					c.Abort()
+					return
+				}
 				c.Next()
 				return
 			}
@@ -96,3 +133,22 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// JWKSHandler serves the public signing keys as a JSON Web Key Set, so other services can
+// verify our access tokens without sharing a secret
+func JWKSHandler(jwtService IJWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwtService.JWKS())
+	}
+}
+
+// RequiresTwoFactor reports whether users of the role must use two-factor authentication
+func RequiresTwoFactor(role string) bool {
+	for _, required := range config.TwoFactorConfig().RequiredRoles {
+		if strings.TrimSpace(required) == role {
+			return true
+		}
+	}
+	return false
+}
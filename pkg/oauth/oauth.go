@@ -0,0 +1,215 @@
+// Package oauth signs users in with external OAuth2 and OpenID Connect providers using the
+// authorization code flow with PKCE.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+)
+
+const (
+	ProviderGoogle   = "google"
+	ProviderFacebook = "facebook"
+
+	httpTimeout = 15 * time.Second
+	// Responses larger than this are not from a well-behaved provider
+	maxResponseSize = 1 << 20
+)
+
+var ErrUnknownProvider = errors.New("unknown login provider")
+
+// Identity is the user an external provider vouches for
+type Identity struct {
+	Provider      string
+	Subject       string // the provider's stable user ID
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider runs the authorization code flow with one identity provider
+type Provider interface {
+	Name() string
+	// AuthCodeURL is where the browser signs in. The nonce ends up in the ID token, the code
+	// challenge is the S256 PKCE challenge of the verifier later passed to Exchange.
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange trades the authorization code for the identity of the user
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// Options configures a provider
+type Options struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// OpenID Connect providers only need their issuer, the endpoints are discovered
+	Issuer string
+
+	// Plain OAuth2 providers
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	// TrustEmail marks emails from the user info endpoint as verified, for providers that
+	// only return confirmed addresses but have no email_verified field
+	TrustEmail bool
+}
+
+// Providers holds the enabled providers by name
+type Providers map[string]Provider
+
+// NewProviders creates the providers that have a client ID configured
+func NewProviders(cfg config.OAuthCfg) Providers {
+	callbackURL := strings.TrimSuffix(cfg.CallbackURL, "/")
+	providers := Providers{}
+
+	if cfg.GoogleClientID != "" {
+		providers[ProviderGoogle] = NewOIDCProvider(Options{
+			Name:         ProviderGoogle,
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  callbackURL + "/" + ProviderGoogle + "/callback",
+			Scopes:       []string{"openid", "email", "profile"},
+			Issuer:       cfg.GoogleIssuer,
+		})
+	}
+	if cfg.FacebookClientID != "" {
+		providers[ProviderFacebook] = NewOAuth2Provider(Options{
+			Name:         ProviderFacebook,
+			ClientID:     cfg.FacebookClientID,
+			ClientSecret: cfg.FacebookClientSecret,
+			RedirectURL:  callbackURL + "/" + ProviderFacebook + "/callback",
+			Scopes:       []string{"email", "public_profile"},
+			AuthURL:      cfg.FacebookAuthURL,
+			TokenURL:     cfg.FacebookTokenURL,
+			UserInfoURL:  cfg.FacebookUserInfoURL,
+			// Graph API only returns an email the user has confirmed with Facebook
+			TrustEmail: true,
+		})
+	}
+	return providers
+}
+
+func (p Providers) Get(name string) (Provider, error) {
+	provider, ok := p[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names lists the enabled providers, sorted
+func (p Providers) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RandomToken returns an unguessable URL-safe value for states, nonces and PKCE verifiers
+func RandomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// CodeChallenge is the S256 PKCE challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authCodeURL adds the authorization request parameters to the provider's endpoint
+func authCodeURL(endpoint string, opts Options, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", opts.ClientID)
+	query.Set("redirect_uri", opts.RedirectURL)
+	query.Set("scope", strings.Join(opts.Scopes, " "))
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// tokenResponse is the token endpoint's answer, RFC 6749 section 5
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode redeems the authorization code at the token endpoint
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL string, opts Options, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {opts.RedirectURL},
+		"client_id":     {opts.ClientID},
+		"client_secret": {opts.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	status, err := doJSON(client, req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token endpoint: %s: %s", token.Error, token.ErrorDescription)
+	}
+	if status != http.StatusOK || token.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned status %d", status)
+	}
+	return &token, nil
+}
+
+// doJSON sends the request and decodes a JSON body of any status into target
+func doJSON(client *http.Client, req *http.Request, target interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s returned status %d with an unreadable body", req.URL.Host, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,406 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "chophimco-web"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "http://localhost:8081/api/v1/user/oauth/google/callback"
+)
+
+// fakeOIDC is a local OpenID Connect provider serving discovery, a JWKS and the token endpoint
+type fakeOIDC struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu sync.Mutex
+	// issuer is announced by discovery, it defaults to the server URL
+	issuer string
+	// keys are published in the JWKS, signer signs new ID tokens
+	keys       map[string]interface{}
+	signerKid  string
+	jwksserved int
+	codes      map[string]*authorization
+}
+
+// authorization is a code issued by the authorization endpoint
+type authorization struct {
+	clientID      string
+	redirectURL   string
+	codeChallenge string
+	nonce         string
+	// tamper changes the ID token claims before signing
+	tamper func(jwt.MapClaims)
+}
+
+func newFakeOIDC(t *testing.T) *fakeOIDC {
+	f := &fakeOIDC{t: t, keys: map[string]interface{}{}, codes: map[string]*authorization{}}
+	f.addRSAKey("rsa-1")
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.srv.Close)
+	f.issuer = f.srv.URL
+	return f
+}
+
+func (f *fakeOIDC) addRSAKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[kid] = key
+	f.signerKid = kid
+}
+
+func (f *fakeOIDC) addECKey(kid string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[kid] = key
+	f.signerKid = kid
+}
+
+func (f *fakeOIDC) provider() *OIDCProvider {
+	return NewOIDCProvider(Options{
+		Name:         ProviderGoogle,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Issuer:       f.srv.URL,
+	})
+}
+
+func (f *fakeOIDC) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 f.issuer,
+			"authorization_endpoint": f.srv.URL + "/authorize",
+			"token_endpoint":         f.srv.URL + "/token",
+			"jwks_uri":               f.srv.URL + "/jwks",
+		})
+	case "/jwks":
+		f.jwksserved++
+		var keys []map[string]string
+		for kid, key := range f.keys {
+			keys = append(keys, publicJWK(kid, key))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": keys})
+	case "/token":
+		f.token(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// token redeems a code once, checking the client and the PKCE verifier
+func (f *fakeOIDC) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	code := r.PostForm.Get("code")
+	auth, ok := f.codes[code]
+	delete(f.codes, code)
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code" || !ok:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case r.PostForm.Get("client_id") != auth.clientID || r.PostForm.Get("client_secret") != testClientSecret:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	case r.PostForm.Get("redirect_uri") != auth.redirectURL:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "redirect_uri mismatch"})
+		return
+	case CodeChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            f.issuer,
+		"sub":            "google-user-1",
+		"aud":            auth.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          auth.nonce,
+		"email":          "buyer@example.com",
+		"email_verified": true,
+		"name":           "Buyer",
+	}
+	if auth.tamper != nil {
+		auth.tamper(claims)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"id_token":     f.sign(claims),
+	})
+}
+
+func (f *fakeOIDC) sign(claims jwt.MapClaims) string {
+	var method jwt.SigningMethod = jwt.SigningMethodRS256
+	key := f.keys[f.signerKid]
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwt.SigningMethodES256
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = f.signerKid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return signed
+}
+
+// authorize plays the browser signing in at the authorization URL and returns the code
+// the provider redirects back with
+func (f *fakeOIDC) authorize(authURL string, tamper func(jwt.MapClaims)) (code, state string) {
+	f.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" ||
+		q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirectURL || q.Get("scope") != "openid email profile" {
+		f.t.Fatalf("unexpected authorization request %s", authURL)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code = "code-" + q.Get("state")
+	f.codes[code] = &authorization{
+		clientID:      q.Get("client_id"),
+		redirectURL:   q.Get("redirect_uri"),
+		codeChallenge: q.Get("code_challenge"),
+		nonce:         q.Get("nonce"),
+		tamper:        tamper,
+	}
+	return code, q.Get("state")
+}
+
+func publicJWK(kid string, key interface{}) map[string]string {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+			"n": encode(k.N.Bytes()), "e": encode(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PrivateKey:
+		return map[string]string{"kty": "EC", "kid": kid, "use": "sig", "alg": "ES256", "crv": "P-256",
+			"x": encode(k.X.FillBytes(make([]byte, 32))), "y": encode(k.Y.FillBytes(make([]byte, 32)))}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// login runs a full authorization code flow with PKCE and returns the outcome of Exchange
+func login(t *testing.T, f *fakeOIDC, p *OIDCProvider, tamper func(jwt.MapClaims)) (*Identity, error) {
+	t.Helper()
+	ctx := context.Background()
+	state, nonce, verifier := mustRandomToken(t), mustRandomToken(t), mustRandomToken(t)
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, CodeChallenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, returnedState := f.authorize(authURL, tamper)
+	if returnedState != state {
+		t.Fatalf("state = %q, want %q", returnedState, state)
+	}
+	return p.Exchange(ctx, code, verifier, nonce)
+}
+
+func mustRandomToken(t *testing.T) string {
+	t.Helper()
+	token, err := RandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestOIDCLogin(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider()
+
+	identity, err := login(t, f, p, nil)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{Provider: ProviderGoogle, Subject: "google-user-1", Email: "buyer@example.com", EmailVerified: true, Name: "Buyer"}
+	if *identity != want {
+		t.Fatalf("identity = %+v, want %+v", *identity, want)
+	}
+
+	// Keys are cached between logins
+	if _, err := login(t, f, p, nil); err != nil {
+		t.Fatalf("second Exchange: %v", err)
+	}
+	if f.jwksserved != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", f.jwksserved)
+	}
+}
+
+func TestOIDCLoginWithECKey(t *testing.T) {
+	f := newFakeOIDC(t)
+	f.addECKey("ec-1")
+
+	identity, err := login(t, f, f.provider(), func(c jwt.MapClaims) { c["email_verified"] = "true" })
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if !identity.EmailVerified {
+		t.Fatal(`email_verified "true" was not accepted`)
+	}
+}
+
+func TestOIDCRejectsInvalidIDTokens(t *testing.T) {
+	cases := map[string]func(jwt.MapClaims){
+		"nonce":            func(c jwt.MapClaims) { c["nonce"] = "another-login" },
+		"missing nonce":    func(c jwt.MapClaims) { delete(c, "nonce") },
+		"issuer":           func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"audience":         func(c jwt.MapClaims) { c["aud"] = "another-client" },
+		"expired":          func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() },
+		"missing expiry":   func(c jwt.MapClaims) { delete(c, "exp") },
+		"issued in future": func(c jwt.MapClaims) { c["iat"] = time.Now().Add(5 * time.Minute).Unix() },
+		"missing subject":  func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	f := newFakeOIDC(t)
+	p := f.provider()
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			if identity, err := login(t, f, p, tamper); err == nil {
+				t.Fatalf("Exchange accepted the ID token: %+v", identity)
+			}
+		})
+	}
+}
+
+func TestOIDCRejectsUnsignedAndForeignTokens(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider()
+	if _, err := login(t, f, p, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// An ID token of the right shape signed by a key the provider never published
+	forger := newFakeOIDC(t)
+	forger.issuer = f.issuer
+	forger.mu.Lock()
+	forged := forger.sign(jwt.MapClaims{
+		"iss": f.issuer, "sub": "google-user-1", "aud": testClientID, "nonce": "n",
+		"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forger.mu.Unlock()
+	if _, err := p.verifyIDToken(context.Background(), p.discovery, forged, "n"); err == nil {
+		t.Fatal("accepted an ID token signed by a foreign key")
+	}
+
+	unsigned := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + f.issuer + `","sub":"x","aud":"` + testClientID + `","nonce":"n"}`)),
+		"",
+	}, ".")
+	if _, err := p.verifyIDToken(context.Background(), p.discovery, unsigned, "n"); err == nil {
+		t.Fatal("accepted an unsigned ID token")
+	}
+}
+
+func TestOIDCRejectsWrongPKCEVerifier(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider()
+	ctx := context.Background()
+	nonce, verifier := mustRandomToken(t), mustRandomToken(t)
+
+	authURL, err := p.AuthCodeURL(ctx, mustRandomToken(t), nonce, CodeChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := f.authorize(authURL, nil)
+	if _, err := p.Exchange(ctx, code, mustRandomToken(t), nonce); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("Exchange with a wrong verifier = %v, want invalid_grant", err)
+	}
+	// The code is spent even though the exchange failed
+	if _, err := p.Exchange(ctx, code, verifier, nonce); err == nil {
+		t.Fatal("a code was redeemed twice")
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	f := newFakeOIDC(t)
+	f.issuer = "https://accounts.example.com"
+
+	if _, err := f.provider().AuthCodeURL(context.Background(), "s", "n", "c"); err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Fatalf("AuthCodeURL = %v, want an issuer mismatch", err)
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider()
+	if _, err := login(t, f, p, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider publishes a new key and signs with it. An unknown kid right after a fetch
+	// does not hammer the JWKS endpoint...
+	f.addRSAKey("rsa-2")
+	if _, err := login(t, f, p, nil); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+		t.Fatalf("Exchange right after a fetch = %v, want unknown signing key", err)
+	}
+	if f.jwksserved != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", f.jwksserved)
+	}
+
+	// ...but once the refresh interval has passed it refetches the set and finds the new key
+	p.mu.Lock()
+	p.keysFetchedAt = time.Now().Add(-keyRefreshInterval)
+	p.mu.Unlock()
+	if _, err := login(t, f, p, nil); err != nil {
+		t.Fatalf("Exchange after rotation: %v", err)
+	}
+	if f.jwksserved != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", f.jwksserved)
+	}
+
+	// Tokens of the old key still verify while it is published
+	f.mu.Lock()
+	f.signerKid = "rsa-1"
+	f.mu.Unlock()
+	if _, err := login(t, f, p, nil); err != nil {
+		t.Fatalf("Exchange with the previous key: %v", err)
+	}
+}
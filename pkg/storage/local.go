@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBackend stores objects as files below a root directory
+type LocalBackend struct {
+	root      string
+	publicURL string
+}
+
+// NewLocalBackend creates root if needed. publicURL is the prefix the files are served under.
+func NewLocalBackend(root, publicURL string) (*LocalBackend, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalBackend{root: abs, publicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+// Root returns the directory holding the files
+func (b *LocalBackend) Root() string {
+	return b.root
+}
+
+// PublicFS serves the stored files, hiding everything below PrivatePrefix
+func (b *LocalBackend) PublicFS() http.FileSystem {
+	return publicFS{http.Dir(b.root)}
+}
+
+type publicFS struct {
+	http.FileSystem
+}
+
+func (p publicFS) Open(name string) (http.File, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if cleaned+"/" == PrivatePrefix || strings.HasPrefix(cleaned, PrivatePrefix) {
+		return nil, fs.ErrNotExist
+	}
+	return p.FileSystem.Open(name)
+}
+
+func (b *LocalBackend) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (b *LocalBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (b *LocalBackend) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		path, err := b.path(key)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		b.pruneDirs(filepath.Dir(path))
+	}
+	return nil
+}
+
+func (b *LocalBackend) URL(key string) string {
+	return b.publicURL + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (b *LocalBackend) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(b.root, filepath.FromSlash(key)), nil
+}
+
+// pruneDirs removes directories left empty by a delete, up to the root
+func (b *LocalBackend) pruneDirs(dir string) {
+	for dir != b.root && strings.HasPrefix(dir, b.root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalBackend(t *testing.T) (*LocalBackend, string) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "uploads")
+	backend, err := NewLocalBackend(root, "http://localhost:8080/uploads/")
+	if err != nil {
+		t.Fatalf("NewLocalBackend: %v", err)
+	}
+	return backend, dir
+}
+
+func TestLocalPutGetDelete(t *testing.T) {
+	backend, _ := newTestLocalBackend(t)
+	ctx := context.Background()
+	key := "products/12/ab34/original.jpg"
+
+	if err := backend.Put(ctx, key, strings.NewReader("first"), 5, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := backend.Put(ctx, key, strings.NewReader("second"), 6, "image/jpeg"); err != nil {
+		t.Fatalf("Put replacing: %v", err)
+	}
+
+	rc, err := backend.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(got) != "second" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(backend.Root(), "products", "12", "ab34"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("directory holds %d entries, %v: temporary files left behind", len(entries), err)
+	}
+
+	if err := backend.Delete(ctx, key, "products/missing.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := backend.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(backend.Root(), "products")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("empty directories were not pruned: %v", err)
+	}
+	if _, err := os.Stat(backend.Root()); err != nil {
+		t.Fatalf("root was pruned: %v", err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	backend, dir := newTestLocalBackend(t)
+	ctx := context.Background()
+
+	outside := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret.txt", "products/../../secret.txt", "/../secret.txt", "products/./x", "products//x", ""} {
+		if err := backend.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if rc, err := backend.Get(ctx, key); err == nil {
+			rc.Close()
+			t.Errorf("Get(%q) succeeded", key)
+		}
+		if err := backend.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+
+	if got, err := os.ReadFile(outside); err != nil || string(got) != "secret" {
+		t.Fatalf("file outside the root changed: %q, %v", got, err)
+	}
+}
+
+func TestLocalPublicFSHidesPrivateObjects(t *testing.T) {
+	backend, _ := newTestLocalBackend(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"products/1.jpg", PrivatePrefix + "kyc/7/id.jpg"} {
+		if err := backend.Put(ctx, key, strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+
+	public := backend.PublicFS()
+	f, err := public.Open("/products/1.jpg")
+	if err != nil {
+		t.Fatalf("Open public object: %v", err)
+	}
+	f.Close()
+
+	for _, name := range []string{"/private/kyc/7/id.jpg", "/private", "/products/../private/kyc/7/id.jpg", "private/kyc/7/id.jpg"} {
+		if f, err := public.Open(name); err == nil {
+			f.Close()
+			t.Errorf("Open(%q) served a private object", name)
+		}
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	backend, _ := newTestLocalBackend(t)
+	if got, want := backend.URL("/products/1.jpg"), "http://localhost:8080/uploads/products/1.jpg"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+// Package storage stores uploaded files behind a backend-agnostic interface.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// PrivatePrefix is the key prefix of objects that must never be served publicly, such as
+// identity documents. They are only read back through Get. With S3, keep the bucket policy
+// from granting public read on this prefix.
+const PrivatePrefix = "private/"
+
+var ErrNotFound = errors.New("object not found")
+
+// Backend stores objects under slash-separated keys, e.g. "products/12/ab34/original.jpg"
+type Backend interface {
+	// Put stores an object, replacing any object with the same key
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens an object for reading, it returns ErrNotFound for missing keys
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes objects, missing keys are not an error
+	Delete(ctx context.Context, keys ...string) error
+	// URL returns the public URL of an object
+	URL(key string) string
+}
+
+// New creates the backend selected by the storage config
+func New(cfg config.StorageCfg) (Backend, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case DriverLocal, "":
+		return NewLocalBackend(cfg.LocalDir, cfg.PublicURL)
+	case DriverS3:
+		return NewS3Backend(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.S3PublicURL,
+		})
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+// cleanKey rejects keys that could escape the storage root
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" {
+		return "", fmt.Errorf("empty storage key")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,110 @@
+// Package sheet reads and writes tabular files as rows of strings. It supports CSV and
+// the subset of XLSX needed for data exchange: the first worksheet, shared and inline
+// strings, numbers and booleans. Formatting and formulas are ignored.
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	MIMECSV  = "text/csv"
+	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported file format, use csv or xlsx")
+	ErrTooManyRows       = errors.New("file has too many rows")
+)
+
+// FormatFromFilename returns the format matching the file extension, or "" when unknown
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".xlsx":
+		return FormatXLSX
+	}
+	return ""
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return MIMEXLSX
+	}
+	return MIMECSV
+}
+
+// Read parses a file into rows. Row i of the result is line i+1 of the file, so blank
+// lines are kept as empty rows. maxRows <= 0 disables the row limit.
+func Read(data []byte, format string, maxRows int) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(data, maxRows)
+	case FormatXLSX:
+		return readXLSX(data, maxRows)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// Write encodes rows in the given format
+func Write(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+func readCSV(data []byte, maxRows int) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		// csv.Reader skips blank lines, pad them back so row numbers match the file
+		line, _ := reader.FieldPos(0)
+		for len(rows) < line-1 {
+			rows = append(rows, nil)
+		}
+		if maxRows > 0 && len(rows) >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		rows = append(rows, record)
+	}
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	// A BOM lets spreadsheet applications detect UTF-8
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -4,6 +4,11 @@ import (
 	"math/rand"
 	"strings"
 	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 func TrimRightSpace(s string) string {
@@ -33,3 +38,31 @@ func CompareStrings(str1, str2 string) bool {
 func CompareStringsIgnoreCase(str1, str2 string) bool {
 	return strings.EqualFold(str1, str2)
 }
+
+// Unaccent removes diacritics, e.g. "Bàn phím cơ Đen" becomes "Ban phim co Den"
+func Unaccent(s string) string {
+	s = strings.NewReplacer("đ", "d", "Đ", "D").Replace(s)
+	result, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// Slugify builds a URL-safe identifier, e.g. "Bàn phím 75%" becomes "ban-phim-75"
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(Unaccent(s)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
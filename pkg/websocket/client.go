@@ -62,6 +62,10 @@ func (c *Client) Read() {
 			logger.Errorf("Error: %v", err)
 			break
 		}
+		// Flash sale updates and notifications are only published by the server
+		if msg.Type == MessageTypeFlashSale.Value() || msg.Type == MessageTypeNotification.Value() {
+			continue
+		}
 		c.hub.broadcast <- msg
 	}
 }
@@ -6,6 +6,7 @@ const (
 	MessageTypeNotification MessageType = "notification"
 	MessageTypeMessage      MessageType = "message"
 	MessageTypeChat         MessageType = "chat"
+	MessageTypeFlashSale    MessageType = "flash_sale"
 )
 
 func (m MessageType) Value() string {
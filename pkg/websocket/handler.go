@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
 )
 
 var hubSingleton *Hub
@@ -14,3 +15,16 @@ func InitHub() {
 func ServeWs(ctx *gin.Context, roomId string) {
 	serveWS(ctx, roomId, hubSingleton)
 }
+
+// Publish pushes a server-side message to the hub, which routes it to its room.
+// It never blocks the caller: the message is dropped when the hub is backed up.
+func Publish(message Message) {
+	if hubSingleton == nil {
+		return
+	}
+	select {
+	case hubSingleton.broadcast <- message:
+	default:
+		logger.Errorf("Websocket hub is busy, dropped %s message for room: %s", message.Type, message.Recipient)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"github.com/leehai1107/chophimco-server/pkg/logger"
 )
 
+// Messages queued for the hub before Publish starts dropping them
+const broadcastBuffer = 1024
+
 // Hub is a struct that holds all the clients and the messages that are sent to them
 type Hub struct {
 	// Registered clients.
@@ -30,7 +33,7 @@ func NewHub() *Hub {
 		clients:    make(map[string]map[*Client]bool),
 		unregister: make(chan *Client),
 		register:   make(chan *Client),
-		broadcast:  make(chan Message),
+		broadcast:  make(chan Message, broadcastBuffer),
 	}
 }
 
@@ -79,29 +82,31 @@ func (h *Hub) HandleMessage(message Message) {
 
 	//Check if the message is a type of "message"
 	if message.Type == MessageTypeMessage.Value() {
-		clients := h.clients[message.ID]
-		for client := range clients {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				delete(h.clients[message.ID], client)
-			}
-		}
+		h.sendToRoom(message.ID, message)
 	}
 
 	//Check if the message is a type of "notification"
 	if message.Type == MessageTypeNotification.Value() {
 		logger.Infof("Notification: %s", message.Content)
-		clients := h.clients[message.Recipient]
-		for client := range clients {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				delete(h.clients[message.Recipient], client)
-			}
-		}
+		h.sendToRoom(message.Recipient, message)
+	}
+
+	//Check if the message is a type of "flash_sale"
+	if message.Type == MessageTypeFlashSale.Value() {
+		h.sendToRoom(message.Recipient, message)
 	}
 
 }
+
+// function to send a message to every client of a room
+func (h *Hub) sendToRoom(room string, message Message) {
+	clients := h.clients[room]
+	for client := range clients {
+		select {
+		case client.send <- message:
+		default:
+			close(client.send)
+			delete(h.clients[room], client)
+		}
+	}
+}
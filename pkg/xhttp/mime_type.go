@@ -13,4 +13,9 @@ const (
 	MIMEMSGPACK           = "application/x-msgpack"
 	MIMEMSGPACK2          = "application/msgpack"
 	MIMEYAML              = "application/x-yaml"
+	MIMEJPEG              = "image/jpeg"
+	MIMEPNG               = "image/png"
+	MIMEWEBP              = "image/webp"
+	MIMEGIF               = "image/gif"
+	MIMEPDF               = "application/pdf"
 )
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IAccountHandler interface {
+	VerifyEmail(ctx *gin.Context)
+	ResendVerification(ctx *gin.Context)
+	ForgotPassword(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm the user's email address with the token from the verification email.
+// @Description Each token works once.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.VerifyEmail true "Verification token"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/verify-email [post]
+func (h *Handler) VerifyEmail(ctx *gin.Context) {
+	var req request.VerifyEmail
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	if err := h.accountUsecase.VerifyEmail(ctx, req); err != nil {
+		h.sendAccountError(ctx, "Failed to verify email", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Email the current user a new verification link
+// @Tags user
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/resend-verification [post]
+func (h *Handler) ResendVerification(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.accountUsecase.SendVerificationEmail(ctx, userID); err != nil {
+		h.sendAccountError(ctx, "Failed to send verification email", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Verification email sent"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a password reset link to the address if it belongs to an account. The response
+// @Description is the same whether or not it does.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.ForgotPassword true "Account email"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/forgot-password [post]
+func (h *Handler) ForgotPassword(ctx *gin.Context) {
+	var req request.ForgotPassword
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	if err := h.accountUsecase.ForgotPassword(ctx, req); err != nil {
+		h.sendAccountError(ctx, "Failed to request password reset", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the token from the reset email. Every session of the user
+// @Description is logged out.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.ResetPassword true "Reset token and new password"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/reset-password [post]
+func (h *Handler) ResetPassword(ctx *gin.Context) {
+	var req request.ResetPassword
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	if err := h.accountUsecase.ResetPassword(ctx, req); err != nil {
+		h.sendAccountError(ctx, "Failed to reset password", err)
+		return
+	}
+
+	clearAuthCookies(ctx)
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Password reset successfully"})
+}
+
+func (h *Handler) sendAccountError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidActionToken),
+		errors.Is(err, usecase.ErrEmailAlreadyVerified):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
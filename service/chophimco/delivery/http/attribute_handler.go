@@ -0,0 +1,158 @@
+package http
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IAttributeHandler interface {
+	GetAttributeDefinitions(ctx *gin.Context)
+
+	// Admin
+	CreateAttributeDefinition(ctx *gin.Context)
+	UpdateAttributeDefinition(ctx *gin.Context)
+	DeleteAttributeDefinition(ctx *gin.Context)
+}
+
+// GetAttributeDefinitions godoc
+// @Summary Get attribute definitions
+// @Description Get the variant attributes applying to a category, including inherited and global ones. Without category_id only global attributes are returned
+// @Tags attribute
+// @Produce json
+// @Param category_id query int false "Category ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/attribute [get]
+func (h *Handler) GetAttributeDefinitions(ctx *gin.Context) {
+	var req request.GetAttributeDefinitions
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+
+	definitions, err := h.attributeUsecase.GetDefinitions(ctx, req.CategoryID)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get attribute definitions", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get attribute definitions")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, definitions)
+}
+
+// CreateAttributeDefinition godoc
+// @Summary Create attribute definition
+// @Description Admin - Define a variant attribute for a category and its subcategories
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.CreateAttributeDefinition true "Attribute definition"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/attribute [post]
+func (h *Handler) CreateAttributeDefinition(ctx *gin.Context) {
+	var req request.CreateAttributeDefinition
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	definition, err := h.attributeUsecase.CreateDefinition(ctx, req)
+	if err != nil {
+		h.sendAttributeError(ctx, "Failed to create attribute definition", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, definition)
+}
+
+// UpdateAttributeDefinition godoc
+// @Summary Update attribute definition
+// @Description Admin - Update name, allowed values, unit and flags of an attribute. Code, type and category cannot change
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateAttributeDefinition true "Attribute definition"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/attribute [put]
+func (h *Handler) UpdateAttributeDefinition(ctx *gin.Context) {
+	var req request.UpdateAttributeDefinition
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	definition, err := h.attributeUsecase.UpdateDefinition(ctx, req)
+	if err != nil {
+		h.sendAttributeError(ctx, "Failed to update attribute definition", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, definition)
+}
+
+// DeleteAttributeDefinition godoc
+// @Summary Delete attribute definition
+// @Description Admin - Delete an attribute definition and the variant values using it
+// @Tags admin
+// @Produce json
+// @Param id path int true "Attribute definition ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/attribute/{id} [delete]
+func (h *Handler) DeleteAttributeDefinition(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid attribute definition ID")
+		return
+	}
+
+	if err := h.attributeUsecase.DeleteDefinition(ctx, id); err != nil {
+		h.sendAttributeError(ctx, "Failed to delete attribute definition", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Attribute definition deleted successfully"})
+}
+
+func (h *Handler) sendAttributeError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrAttributeDefinitionNotFound),
+		errors.Is(err, usecase.ErrCategoryNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrInvalidAttribute),
+		errors.Is(err, usecase.ErrAttributeCodeTaken):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
+
+// bindAttributeFilters collects attr[<code>]=<value> query parameters. Values may repeat
+// or be comma separated, e.g. attr[layout]=TKL,75%
+func bindAttributeFilters(query url.Values) map[string][]string {
+	filters := make(map[string][]string)
+	for key, values := range query {
+		if !strings.HasPrefix(key, "attr[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		code := strings.TrimSpace(key[len("attr[") : len(key)-1])
+		if code == "" {
+			continue
+		}
+		for _, value := range values {
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					filters[code] = append(filters[code], v)
+				}
+			}
+		}
+	}
+	return filters
+}
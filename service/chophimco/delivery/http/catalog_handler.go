@@ -0,0 +1,381 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type ICatalogHandler interface {
+	// Public
+	GetCategoryTree(ctx *gin.Context)
+	GetCategoryBySlug(ctx *gin.Context)
+	GetCategoryProducts(ctx *gin.Context)
+	GetBrands(ctx *gin.Context)
+	GetSwitches(ctx *gin.Context)
+
+	// Admin
+	CreateCategory(ctx *gin.Context)
+	UpdateCategory(ctx *gin.Context)
+	DeleteCategory(ctx *gin.Context)
+	CreateBrand(ctx *gin.Context)
+	UpdateBrand(ctx *gin.Context)
+	DeleteBrand(ctx *gin.Context)
+	CreateSwitch(ctx *gin.Context)
+	UpdateSwitch(ctx *gin.Context)
+	DeleteSwitch(ctx *gin.Context)
+}
+
+// GetCategoryTree godoc
+// @Summary Get category tree
+// @Description Get all categories nested under their parents, in display order
+// @Tags category
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/category [get]
+func (h *Handler) GetCategoryTree(ctx *gin.Context) {
+	tree, err := h.catalogUsecase.GetCategoryTree(ctx)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get categories", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get categories")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, tree)
+}
+
+// GetCategoryBySlug godoc
+// @Summary Get category by slug
+// @Description Get a category with its subcategories and breadcrumb
+// @Tags category
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/category/{slug} [get]
+func (h *Handler) GetCategoryBySlug(ctx *gin.Context) {
+	category, err := h.catalogUsecase.GetCategoryBySlug(ctx, ctx.Param("slug"))
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to get category", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, category)
+}
+
+// GetCategoryProducts godoc
+// @Summary Get category products
+// @Description Paginated products of a category and all its subcategories. Accepts the same filters and sorting as /product/list
+// @Tags category
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Sort order" Enums(price_asc, price_desc, newest, rating, best_selling)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/category/{slug}/products [get]
+func (h *Handler) GetCategoryProducts(ctx *gin.Context) {
+	category, err := h.catalogUsecase.GetCategoryBySlug(ctx, ctx.Param("slug"))
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to get category", err)
+		return
+	}
+
+	var req request.ListProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+	req.Attributes = bindAttributeFilters(ctx.Request.URL.Query())
+	req.CategoryID = &category.ID
+
+	products, err := h.productUsecase.ListProducts(ctx, req)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to list category products", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to list products")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, products)
+}
+
+// GetBrands godoc
+// @Summary Get brands
+// @Description Get all brands
+// @Tags brand
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/brand [get]
+func (h *Handler) GetBrands(ctx *gin.Context) {
+	brands, err := h.catalogUsecase.GetBrands(ctx)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get brands", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get brands")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, brands)
+}
+
+// GetSwitches godoc
+// @Summary Get switches
+// @Description Get all switches
+// @Tags switch
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/switch [get]
+func (h *Handler) GetSwitches(ctx *gin.Context) {
+	switches, err := h.catalogUsecase.GetSwitches(ctx)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get switches", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get switches")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, switches)
+}
+
+// CreateCategory godoc
+// @Summary Create category
+// @Description Admin - Create a category, optionally under a parent
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.CreateCategory true "Category data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/category [post]
+func (h *Handler) CreateCategory(ctx *gin.Context) {
+	var req request.CreateCategory
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	category, err := h.catalogUsecase.CreateCategory(ctx, req)
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to create category", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, category)
+}
+
+// UpdateCategory godoc
+// @Summary Update category
+// @Description Admin - Rename, re-slug, reorder or move a category. parent_id 0 moves it to the root
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateCategory true "Category data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/category [put]
+func (h *Handler) UpdateCategory(ctx *gin.Context) {
+	var req request.UpdateCategory
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	category, err := h.catalogUsecase.UpdateCategory(ctx, req)
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to update category", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, category)
+}
+
+// DeleteCategory godoc
+// @Summary Delete category
+// @Description Admin - Delete a category without subcategories or products
+// @Tags admin
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/category/{id} [delete]
+func (h *Handler) DeleteCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid category ID")
+		return
+	}
+
+	if err := h.catalogUsecase.DeleteCategory(ctx, id); err != nil {
+		h.sendCatalogError(ctx, "Failed to delete category", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Category deleted successfully"})
+}
+
+// CreateBrand godoc
+// @Summary Create brand
+// @Description Admin - Create a brand
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.CreateBrand true "Brand data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/brand [post]
+func (h *Handler) CreateBrand(ctx *gin.Context) {
+	var req request.CreateBrand
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	brand, err := h.catalogUsecase.CreateBrand(ctx, req)
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to create brand", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, brand)
+}
+
+// UpdateBrand godoc
+// @Summary Update brand
+// @Description Admin - Rename a brand
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateBrand true "Brand data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/brand [put]
+func (h *Handler) UpdateBrand(ctx *gin.Context) {
+	var req request.UpdateBrand
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	brand, err := h.catalogUsecase.UpdateBrand(ctx, req)
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to update brand", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, brand)
+}
+
+// DeleteBrand godoc
+// @Summary Delete brand
+// @Description Admin - Delete a brand no product uses
+// @Tags admin
+// @Produce json
+// @Param id path int true "Brand ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/brand/{id} [delete]
+func (h *Handler) DeleteBrand(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid brand ID")
+		return
+	}
+
+	if err := h.catalogUsecase.DeleteBrand(ctx, id); err != nil {
+		h.sendCatalogError(ctx, "Failed to delete brand", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Brand deleted successfully"})
+}
+
+// CreateSwitch godoc
+// @Summary Create switch
+// @Description Admin - Create a switch
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.CreateSwitch true "Switch data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/switch [post]
+func (h *Handler) CreateSwitch(ctx *gin.Context) {
+	var req request.CreateSwitch
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	sw, err := h.catalogUsecase.CreateSwitch(ctx, req)
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to create switch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, sw)
+}
+
+// UpdateSwitch godoc
+// @Summary Update switch
+// @Description Admin - Update a switch
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateSwitch true "Switch data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/switch [put]
+func (h *Handler) UpdateSwitch(ctx *gin.Context) {
+	var req request.UpdateSwitch
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	sw, err := h.catalogUsecase.UpdateSwitch(ctx, req)
+	if err != nil {
+		h.sendCatalogError(ctx, "Failed to update switch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, sw)
+}
+
+// DeleteSwitch godoc
+// @Summary Delete switch
+// @Description Admin - Delete a switch no variant uses
+// @Tags admin
+// @Produce json
+// @Param id path int true "Switch ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/switch/{id} [delete]
+func (h *Handler) DeleteSwitch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid switch ID")
+		return
+	}
+
+	if err := h.catalogUsecase.DeleteSwitch(ctx, id); err != nil {
+		h.sendCatalogError(ctx, "Failed to delete switch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Switch deleted successfully"})
+}
+
+// sendCatalogError maps catalog usecase errors to responses, unexpected errors are logged
+func (h *Handler) sendCatalogError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrCategoryNotFound),
+		errors.Is(err, usecase.ErrBrandNotFound),
+		errors.Is(err, usecase.ErrSwitchNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrCategoryCycle),
+		errors.Is(err, usecase.ErrCategoryInUse),
+		errors.Is(err, usecase.ErrSlugTaken),
+		errors.Is(err, usecase.ErrBrandInUse),
+		errors.Is(err, usecase.ErrBrandExists),
+		errors.Is(err, usecase.ErrSwitchInUse),
+		errors.Is(err, usecase.ErrParentNotFound),
+		errors.Is(err, usecase.ErrInvalidSlug):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/websocket"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IFlashSaleHandler interface {
+	GetFlashSales(ctx *gin.Context)
+	GetFlashSaleByID(ctx *gin.Context)
+	SubscribeFlashSale(ctx *gin.Context)
+	PurchaseFlashSaleItem(ctx *gin.Context)
+
+	// Admin
+	CreateFlashSale(ctx *gin.Context)
+	CancelFlashSale(ctx *gin.Context)
+}
+
+// GetFlashSales godoc
+// @Summary Get flash sales
+// @Description Get upcoming and live flash sales with their countdown
+// @Tags flash-sale
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/flash-sale [get]
+func (h *Handler) GetFlashSales(ctx *gin.Context) {
+	sales, err := h.flashSaleUsecase.GetFlashSales(ctx)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get flash sales", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get flash sales")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, sales)
+}
+
+// GetFlashSaleByID godoc
+// @Summary Get flash sale by ID
+// @Description Get flash sale details and remaining quantities
+// @Tags flash-sale
+// @Produce json
+// @Param id path int true "Flash sale ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/flash-sale/{id} [get]
+func (h *Handler) GetFlashSaleByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid flash sale ID")
+		return
+	}
+
+	sale, err := h.flashSaleUsecase.GetFlashSaleByID(ctx, id)
+	if err != nil {
+		apiwrapper.SendNotFound(ctx, "Flash sale not found")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, sale)
+}
+
+// SubscribeFlashSale godoc
+// @Summary Subscribe to flash sale updates
+// @Description Open a websocket receiving remaining-quantity updates while the flash sale runs
+// @Tags flash-sale
+// @Param id path int true "Flash sale ID"
+// @Router /api/v1/flash-sale/{id}/ws [get]
+func (h *Handler) SubscribeFlashSale(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid flash sale ID")
+		return
+	}
+
+	websocket.ServeWs(ctx, usecase.FlashSaleRoom(id))
+}
+
+// PurchaseFlashSaleItem godoc
+// @Summary Purchase flash sale item
+// @Description Reserve flash sale quantity and create an order at the sale price
+// @Tags flash-sale
+// @Accept json
+// @Produce json
+// @Param request body request.PurchaseFlashSaleItem true "Purchase information"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/flash-sale/purchase [post]
+func (h *Handler) PurchaseFlashSaleItem(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	var req request.PurchaseFlashSaleItem
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	order, err := h.flashSaleUsecase.PurchaseFlashSaleItem(ctx, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrFlashSaleSoldOut),
+			errors.Is(err, repository.ErrFlashSaleLimitReached),
+			errors.Is(err, usecase.ErrFlashSaleNotLive),
+			errors.Is(err, usecase.ErrEmailNotVerified):
+			apiwrapper.SendBadRequest(ctx, err.Error())
+		default:
+			logger.EnhanceWith(ctx).Errorw("Failed to purchase flash sale item", "error", err)
+			apiwrapper.SendInternalError(ctx, "Failed to purchase flash sale item")
+		}
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, order)
+}
+
+// CreateFlashSale godoc
+// @Summary Create flash sale
+// @Description Admin - Schedule a flash sale for selected variants
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.CreateFlashSale true "Flash sale data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/flash-sale [post]
+func (h *Handler) CreateFlashSale(ctx *gin.Context) {
+	var req request.CreateFlashSale
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	sale, err := h.flashSaleUsecase.CreateFlashSale(ctx, userID, req)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to create flash sale", "error", err)
+		apiwrapper.SendBadRequest(ctx, err.Error())
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, sale)
+}
+
+// CancelFlashSale godoc
+// @Summary Cancel flash sale
+// @Description Admin - Cancel a scheduled or live flash sale
+// @Tags admin
+// @Produce json
+// @Param id path int true "Flash sale ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/flash-sale/{id} [delete]
+func (h *Handler) CancelFlashSale(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid flash sale ID")
+		return
+	}
+
+	if err := h.flashSaleUsecase.CancelFlashSale(ctx, id); err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to cancel flash sale", "error", err)
+		apiwrapper.SendNotFound(ctx, "Flash sale not found")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Flash sale cancelled"})
+}
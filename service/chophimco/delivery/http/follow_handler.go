@@ -0,0 +1,143 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IFollowHandler interface {
+	FollowShop(ctx *gin.Context)
+	UnfollowShop(ctx *gin.Context)
+	GetFollowedShops(ctx *gin.Context)
+	GetFeed(ctx *gin.Context)
+}
+
+// FollowShop godoc
+// @Summary Follow shop
+// @Description Follow a shop to see its launches and sales in the feed and be notified of new products
+// @Tags shop
+// @Produce json
+// @Param slug path string true "Shop slug"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/shop/{slug}/follow [post]
+func (h *Handler) FollowShop(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	follow, err := h.followUsecase.FollowShop(ctx, userID, ctx.Param("slug"))
+	if err != nil {
+		h.sendFollowError(ctx, "Failed to follow shop", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, follow)
+}
+
+// UnfollowShop godoc
+// @Summary Unfollow shop
+// @Description Stop following a shop
+// @Tags shop
+// @Produce json
+// @Param slug path string true "Shop slug"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/shop/{slug}/follow [delete]
+func (h *Handler) UnfollowShop(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	follow, err := h.followUsecase.UnfollowShop(ctx, userID, ctx.Param("slug"))
+	if err != nil {
+		h.sendFollowError(ctx, "Failed to unfollow shop", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, follow)
+}
+
+// GetFollowedShops godoc
+// @Summary Get followed shops
+// @Description Get the shops the user follows, most recently followed first
+// @Tags user
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/following [get]
+func (h *Handler) GetFollowedShops(ctx *gin.Context) {
+	var req request.GetFollowedShops
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	shops, err := h.followUsecase.GetFollowedShops(ctx, userID, req)
+	if err != nil {
+		h.sendFollowError(ctx, "Failed to get followed shops", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, shops)
+}
+
+// GetFeed godoc
+// @Summary Get feed
+// @Description Get newly approved products and running flash sales and discounts of the followed shops, newest first.
+// @Description Pass next_before and next_before_id of a page as before and before_id to get the next one.
+// @Tags feed
+// @Produce json
+// @Param before query string false "Only items older than this time (RFC 3339, default now)"
+// @Param before_id query int false "Also items at the before time with a lower product ID"
+// @Param limit query int false "Number of items (default 20, max 50)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/feed [get]
+func (h *Handler) GetFeed(ctx *gin.Context) {
+	var req request.GetFeed
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	feed, err := h.followUsecase.GetFeed(ctx, userID, req)
+	if err != nil {
+		h.sendFollowError(ctx, "Failed to get feed", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, feed)
+}
+
+func (h *Handler) sendFollowError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrShopNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrFollowOwnShop):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
@@ -13,17 +13,49 @@ type IHandler interface {
 	IVoucherHandler
 	IReviewHandler
 	ISellerHandler
+	IFlashSaleHandler
+	ICatalogHandler
+	IAttributeHandler
+	IVariantHandler
+	IImportHandler
+	INotificationHandler
+	ISellerKYCHandler
+	ILedgerHandler
+	ISellerAnalyticsHandler
+	IStorefrontHandler
+	IFollowHandler
+	IModerationHandler
+	IAccountHandler
+	IOAuthHandler
+	ITwoFactorHandler
 }
 
 // Handler implements all handler interfaces
 type Handler struct {
-	userUsecase    usecase.IUserUsecase
-	productUsecase usecase.IProductUsecase
-	cartUsecase    usecase.ICartUsecase
-	orderUsecase   usecase.IOrderUsecase
-	voucherUsecase usecase.IVoucherUsecase
-	reviewUsecase  usecase.IReviewUsecase
-	sellerUsecase  usecase.ISellerUsecase
+	userUsecase            usecase.IUserUsecase
+	productUsecase         usecase.IProductUsecase
+	cartUsecase            usecase.ICartUsecase
+	orderUsecase           usecase.IOrderUsecase
+	voucherUsecase         usecase.IVoucherUsecase
+	reviewUsecase          usecase.IReviewUsecase
+	sellerUsecase          usecase.ISellerUsecase
+	flashSaleUsecase       usecase.IFlashSaleUsecase
+	suggestUsecase         usecase.ISuggestUsecase
+	catalogUsecase         usecase.ICatalogUsecase
+	attributeUsecase       usecase.IAttributeUsecase
+	variantUsecase         usecase.IVariantUsecase
+	importUsecase          usecase.IImportUsecase
+	notificationUsecase    usecase.INotificationUsecase
+	sellerKYCUsecase       usecase.ISellerKYCUsecase
+	ledgerUsecase          usecase.ILedgerUsecase
+	sellerAnalyticsUsecase usecase.ISellerAnalyticsUsecase
+	storefrontUsecase      usecase.IStorefrontUsecase
+	followUsecase          usecase.IFollowUsecase
+	moderationUsecase      usecase.IModerationUsecase
+	sessionUsecase         usecase.ISessionUsecase
+	accountUsecase         usecase.IAccountUsecase
+	oauthUsecase           usecase.IOAuthUsecase
+	twoFactorUsecase       usecase.ITwoFactorUsecase
 }
 
 func NewHandler(
@@ -34,14 +66,48 @@ func NewHandler(
 	voucherUsecase usecase.IVoucherUsecase,
 	reviewUsecase usecase.IReviewUsecase,
 	sellerUsecase usecase.ISellerUsecase,
+	flashSaleUsecase usecase.IFlashSaleUsecase,
+	suggestUsecase usecase.ISuggestUsecase,
+	catalogUsecase usecase.ICatalogUsecase,
+	attributeUsecase usecase.IAttributeUsecase,
+	variantUsecase usecase.IVariantUsecase,
+	importUsecase usecase.IImportUsecase,
+	notificationUsecase usecase.INotificationUsecase,
+	sellerKYCUsecase usecase.ISellerKYCUsecase,
+	ledgerUsecase usecase.ILedgerUsecase,
+	sellerAnalyticsUsecase usecase.ISellerAnalyticsUsecase,
+	storefrontUsecase usecase.IStorefrontUsecase,
+	followUsecase usecase.IFollowUsecase,
+	moderationUsecase usecase.IModerationUsecase,
+	sessionUsecase usecase.ISessionUsecase,
+	accountUsecase usecase.IAccountUsecase,
+	oauthUsecase usecase.IOAuthUsecase,
+	twoFactorUsecase usecase.ITwoFactorUsecase,
 ) IHandler {
 	return &Handler{
-		userUsecase:    userUsecase,
-		productUsecase: productUsecase,
-		cartUsecase:    cartUsecase,
-		orderUsecase:   orderUsecase,
-		voucherUsecase: voucherUsecase,
-		reviewUsecase:  reviewUsecase,
-		sellerUsecase:  sellerUsecase,
+		userUsecase:            userUsecase,
+		productUsecase:         productUsecase,
+		cartUsecase:            cartUsecase,
+		orderUsecase:           orderUsecase,
+		voucherUsecase:         voucherUsecase,
+		reviewUsecase:          reviewUsecase,
+		sellerUsecase:          sellerUsecase,
+		flashSaleUsecase:       flashSaleUsecase,
+		suggestUsecase:         suggestUsecase,
+		catalogUsecase:         catalogUsecase,
+		attributeUsecase:       attributeUsecase,
+		variantUsecase:         variantUsecase,
+		importUsecase:          importUsecase,
+		notificationUsecase:    notificationUsecase,
+		sellerKYCUsecase:       sellerKYCUsecase,
+		ledgerUsecase:          ledgerUsecase,
+		sellerAnalyticsUsecase: sellerAnalyticsUsecase,
+		storefrontUsecase:      storefrontUsecase,
+		followUsecase:          followUsecase,
+		moderationUsecase:      moderationUsecase,
+		sessionUsecase:         sessionUsecase,
+		accountUsecase:         accountUsecase,
+		oauthUsecase:           oauthUsecase,
+		twoFactorUsecase:       twoFactorUsecase,
 	}
 }
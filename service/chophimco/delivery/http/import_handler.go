@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/tools/sheet"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IImportHandler interface {
+	ImportSellerProducts(ctx *gin.Context)
+	GetImportJob(ctx *gin.Context)
+	ExportSellerProducts(ctx *gin.Context)
+}
+
+// ImportSellerProducts godoc
+// @Summary Import products
+// @Description Import products, variants and image URLs from a CSV or XLSX file, one row per variant.
+// @Description Columns: product_ref, name, category (slug or name), brand, description, base_price, image_urls (separated by |),
+// @Description sku, switch, price, stock, layout, connection_type, hotswap, led_type and attr:<code> for category attributes.
+// @Description Nothing is imported when a row is invalid, the job lists the errors per row.
+// @Description Files with more than 200 rows are imported in the background, poll the job status.
+// @Tags seller
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file (max 10MB)"
+// @Param dry_run formData bool false "Validate only"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/product/import [post]
+func (h *Handler) ImportSellerProducts(ctx *gin.Context) {
+	var req request.ImportProducts
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "File is required")
+		return
+	}
+	if fileHeader.Size > usecase.MaxImportFileSize {
+		apiwrapper.SendBadRequest(ctx, "File is larger than 10MB")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Failed to read file")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, usecase.MaxImportFileSize))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Failed to read file")
+		return
+	}
+
+	job, err := h.importUsecase.ImportProducts(ctx, userID, fileHeader.Filename, data, req.DryRun)
+	if err != nil {
+		h.sendImportError(ctx, "Failed to import products", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, job)
+}
+
+// GetImportJob godoc
+// @Summary Get import job
+// @Description Get the status and row errors of a product import
+// @Tags seller
+// @Produce json
+// @Param id path int true "Import job ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/product/import/{id} [get]
+func (h *Handler) GetImportJob(ctx *gin.Context) {
+	jobID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid import job ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	job, err := h.importUsecase.GetImportJob(ctx, userID, jobID)
+	if err != nil {
+		h.sendImportError(ctx, "Failed to get import job", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, job)
+}
+
+// ExportSellerProducts godoc
+// @Summary Export products
+// @Description Download the seller's catalog in the import layout
+// @Tags seller
+// @Produce octet-stream
+// @Param format query string false "File format (default csv)" Enums(csv, xlsx)
+// @Success 200 {file} file
+// @Router /api/v1/seller/product/export [get]
+func (h *Handler) ExportSellerProducts(ctx *gin.Context) {
+	var req request.ExportProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+	if req.Format == "" {
+		req.Format = sheet.FormatCSV
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	data, err := h.importUsecase.ExportProducts(ctx, userID, req.Format)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to export products", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to export products")
+		return
+	}
+
+	fileName := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102"), req.Format)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	ctx.Data(http.StatusOK, sheet.ContentType(req.Format), data)
+}
+
+func (h *Handler) sendImportError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrImportJobNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrInvalidImportFile),
+		errors.Is(err, usecase.ErrSellerNotVerified):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
@@ -0,0 +1,406 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type ILedgerHandler interface {
+	// Seller earnings
+	GetSellerEarnings(ctx *gin.Context)
+	GetSellerLedger(ctx *gin.Context)
+
+	// Admin - refunds and commission rates
+	RefundOrder(ctx *gin.Context)
+	GetCommissionRules(ctx *gin.Context)
+	SetCommissionRule(ctx *gin.Context)
+	DeleteCommissionRule(ctx *gin.Context)
+
+	// Admin - payouts
+	CreatePayoutBatch(ctx *gin.Context)
+	GetPayoutBatches(ctx *gin.Context)
+	GetPayoutBatch(ctx *gin.Context)
+	ProcessPayoutBatch(ctx *gin.Context)
+	CancelPayoutBatch(ctx *gin.Context)
+	SettlePayout(ctx *gin.Context)
+
+	// Admin - reconciliation
+	GetReconciliationReport(ctx *gin.Context)
+}
+
+// GetSellerEarnings godoc
+// @Summary Get seller earnings
+// @Description Get gross sales, discounts, commission, refunds and net earnings over a period,
+// @Description with the current balance, the part available for payout and recent payouts
+// @Tags seller
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 30 days ago)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/earnings [get]
+func (h *Handler) GetSellerEarnings(ctx *gin.Context) {
+	var req request.GetSellerEarnings
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	earnings, err := h.ledgerUsecase.GetSellerEarnings(ctx, userID, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to get earnings", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, earnings)
+}
+
+// GetSellerLedger godoc
+// @Summary Get seller ledger
+// @Description Get every sale, discount, commission, refund and payout line on the seller's balance, newest first
+// @Tags seller
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/earnings/ledger [get]
+func (h *Handler) GetSellerLedger(ctx *gin.Context) {
+	var req request.GetSellerLedger
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	lines, err := h.ledgerUsecase.GetSellerLedger(ctx, userID, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to get ledger", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, lines)
+}
+
+// RefundOrder godoc
+// @Summary Refund order
+// @Description Admin - Refund some or all units of a completed order's items.
+// @Description The refund is taken back from the sellers' earnings and the commission on it is returned.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.RefundOrder true "Refund data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/order/refund [post]
+func (h *Handler) RefundOrder(ctx *gin.Context) {
+	var req request.RefundOrder
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.CreatedBy = userID
+
+	refund, err := h.ledgerUsecase.RefundOrder(ctx, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to refund order", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, refund)
+}
+
+// GetCommissionRules godoc
+// @Summary Get commission rates
+// @Description Admin - Get the default commission rate and the seller and category overrides
+// @Tags admin
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/commission [get]
+func (h *Handler) GetCommissionRules(ctx *gin.Context) {
+	rules, err := h.ledgerUsecase.GetCommissionRules(ctx)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to get commission rates", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, rules)
+}
+
+// SetCommissionRule godoc
+// @Summary Set commission rate
+// @Description Admin - Set the commission rate of a seller or a category and its subcategories.
+// @Description A seller rate wins over a category rate. Applies to orders completed from now on.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.SetCommissionRule true "Commission rate"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/commission [put]
+func (h *Handler) SetCommissionRule(ctx *gin.Context) {
+	var req request.SetCommissionRule
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.UpdatedBy = userID
+
+	rule, err := h.ledgerUsecase.SetCommissionRule(ctx, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to set commission rate", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, rule)
+}
+
+// DeleteCommissionRule godoc
+// @Summary Delete commission rate
+// @Description Admin - Remove a seller or category commission rate, falling back to the next applicable rate
+// @Tags admin
+// @Produce json
+// @Param id path int true "Commission rule ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/commission/{id} [delete]
+func (h *Handler) DeleteCommissionRule(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid commission rule ID")
+		return
+	}
+
+	if err := h.ledgerUsecase.DeleteCommissionRule(ctx, id); err != nil {
+		h.sendLedgerError(ctx, "Failed to delete commission rate", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Commission rate deleted"})
+}
+
+// CreatePayoutBatch godoc
+// @Summary Create payout batch
+// @Description Admin - Pay every verified seller their balance earned up to the period end,
+// @Description by default now less the payout hold period
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.CreatePayoutBatch true "Batch data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/payout/batch [post]
+func (h *Handler) CreatePayoutBatch(ctx *gin.Context) {
+	var req request.CreatePayoutBatch
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.CreatedBy = userID
+
+	batch, err := h.ledgerUsecase.CreatePayoutBatch(ctx, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to create payout batch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, batch)
+}
+
+// GetPayoutBatches godoc
+// @Summary Get payout batches
+// @Description Admin - Get payout batches, newest first
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/payout/batch [get]
+func (h *Handler) GetPayoutBatches(ctx *gin.Context) {
+	var req request.GetPayoutBatches
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	batches, err := h.ledgerUsecase.GetPayoutBatches(ctx, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to get payout batches", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, batches)
+}
+
+// GetPayoutBatch godoc
+// @Summary Get payout batch
+// @Description Admin - Get a payout batch with its payouts and the bank details they are sent to
+// @Tags admin
+// @Produce json
+// @Param id path int true "Payout batch ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/payout/batch/{id} [get]
+func (h *Handler) GetPayoutBatch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid payout batch ID")
+		return
+	}
+
+	batch, err := h.ledgerUsecase.GetPayoutBatch(ctx, id)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to get payout batch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, batch)
+}
+
+// ProcessPayoutBatch godoc
+// @Summary Process payout batch
+// @Description Admin - Mark a pending batch as sent to the bank, its payouts can then be settled
+// @Tags admin
+// @Produce json
+// @Param id path int true "Payout batch ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/payout/batch/{id}/process [post]
+func (h *Handler) ProcessPayoutBatch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid payout batch ID")
+		return
+	}
+
+	if err := h.ledgerUsecase.ProcessPayoutBatch(ctx, id); err != nil {
+		h.sendLedgerError(ctx, "Failed to process payout batch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Payout batch processing"})
+}
+
+// CancelPayoutBatch godoc
+// @Summary Cancel payout batch
+// @Description Admin - Cancel a pending batch, the amounts go back to the sellers' balances
+// @Tags admin
+// @Produce json
+// @Param id path int true "Payout batch ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/payout/batch/{id}/cancel [post]
+func (h *Handler) CancelPayoutBatch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid payout batch ID")
+		return
+	}
+
+	if err := h.ledgerUsecase.CancelPayoutBatch(ctx, id); err != nil {
+		h.sendLedgerError(ctx, "Failed to cancel payout batch", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Payout batch cancelled"})
+}
+
+// SettlePayout godoc
+// @Summary Settle payout
+// @Description Admin - Record a payout of a processing batch as paid, with the transfer reference,
+// @Description or as failed, with the reason. A failed payout goes back to the seller's balance.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.SettlePayout true "Settlement data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/payout/settle [post]
+func (h *Handler) SettlePayout(ctx *gin.Context) {
+	var req request.SettlePayout
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	if err := h.ledgerUsecase.SettlePayout(ctx, req); err != nil {
+		h.sendLedgerError(ctx, "Failed to settle payout", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Payout settled"})
+}
+
+// GetReconciliationReport godoc
+// @Summary Get reconciliation report
+// @Description Admin - Check that the ledger balances over a period, that every completed order
+// @Description is recorded and matches what was collected, and total payouts by status
+// @Tags admin
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 30 days ago)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/ledger/reconciliation [get]
+func (h *Handler) GetReconciliationReport(ctx *gin.Context) {
+	var req request.ReconciliationReport
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	report, err := h.ledgerUsecase.GetReconciliationReport(ctx, req)
+	if err != nil {
+		h.sendLedgerError(ctx, "Failed to get reconciliation report", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, report)
+}
+
+func (h *Handler) sendLedgerError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrOrderNotFound),
+		errors.Is(err, usecase.ErrCommissionRuleNotFound),
+		errors.Is(err, usecase.ErrPayoutBatchNotFound),
+		errors.Is(err, usecase.ErrPayoutNotFound),
+		errors.Is(err, usecase.ErrSellerProfileNotFound),
+		errors.Is(err, usecase.ErrCategoryNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrOrderNotCompleted),
+		errors.Is(err, usecase.ErrInvalidRefund),
+		errors.Is(err, usecase.ErrInvalidCommissionRule),
+		errors.Is(err, usecase.ErrNothingToPay),
+		errors.Is(err, usecase.ErrInvalidPayoutStatus),
+		errors.Is(err, usecase.ErrInvalidPayoutPeriod):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
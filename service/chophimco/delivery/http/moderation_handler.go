@@ -0,0 +1,211 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IModerationHandler interface {
+	VoteReviewHelpful(ctx *gin.Context)
+	RemoveReviewHelpfulVote(ctx *gin.Context)
+	ReportReview(ctx *gin.Context)
+
+	// Admin
+	GetModerationQueue(ctx *gin.Context)
+	GetReviewReports(ctx *gin.Context)
+	ModerateReview(ctx *gin.Context)
+}
+
+// VoteReviewHelpful godoc
+// @Summary Mark review as helpful
+// @Description Vote a product review as helpful, once per user. Voting again has no effect.
+// @Tags review
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/{id}/helpful [post]
+func (h *Handler) VoteReviewHelpful(ctx *gin.Context) {
+	reviewID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid review ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	vote, err := h.moderationUsecase.VoteHelpful(ctx, userID, reviewID)
+	if err != nil {
+		h.sendModerationError(ctx, "Failed to vote on review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, vote)
+}
+
+// RemoveReviewHelpfulVote godoc
+// @Summary Remove helpful vote
+// @Description Take back your helpful vote on a product review
+// @Tags review
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/{id}/helpful [delete]
+func (h *Handler) RemoveReviewHelpfulVote(ctx *gin.Context) {
+	reviewID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid review ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	vote, err := h.moderationUsecase.RemoveHelpfulVote(ctx, userID, reviewID)
+	if err != nil {
+		h.sendModerationError(ctx, "Failed to remove vote", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, vote)
+}
+
+// ReportReview godoc
+// @Summary Report review
+// @Description Report a product or seller review to the moderators, once per review.
+// @Description A review reported by enough users is hidden until a moderator decides.
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param request body request.ReportReview true "Report information"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/report [post]
+func (h *Handler) ReportReview(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	var req request.ReportReview
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	if err := h.moderationUsecase.ReportReview(ctx, userID, req); err != nil {
+		h.sendModerationError(ctx, "Failed to report review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Review reported successfully"})
+}
+
+// GetModerationQueue godoc
+// @Summary Get review moderation queue
+// @Description Reviews held by the automatic checks or by reports, and published reviews with open reports, oldest first
+// @Tags admin
+// @Produce json
+// @Param target_type query string true "Kind of review" Enums(product_review, seller_review)
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/review/queue [get]
+func (h *Handler) GetModerationQueue(ctx *gin.Context) {
+	var req request.GetModerationQueue
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+
+	queue, err := h.moderationUsecase.GetQueue(ctx, req)
+	if err != nil {
+		h.sendModerationError(ctx, "Failed to get moderation queue", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, queue)
+}
+
+// GetReviewReports godoc
+// @Summary Get review reports
+// @Description All reports filed against a review, newest first
+// @Tags admin
+// @Produce json
+// @Param target_type query string true "Kind of review" Enums(product_review, seller_review)
+// @Param target_id query int true "Review ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/review/reports [get]
+func (h *Handler) GetReviewReports(ctx *gin.Context) {
+	var req request.GetReviewReports
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+
+	reports, err := h.moderationUsecase.GetReports(ctx, req)
+	if err != nil {
+		h.sendModerationError(ctx, "Failed to get review reports", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, reports)
+}
+
+// ModerateReview godoc
+// @Summary Moderate review
+// @Description Approve a review to publish it and dismiss its open reports, or reject it to remove it,
+// @Description uphold its reports and notify the author. Ratings only count published reviews.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.ModerateReview true "Moderation decision"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/review/moderate [post]
+func (h *Handler) ModerateReview(ctx *gin.Context) {
+	var req request.ModerateReview
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	adminID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.moderationUsecase.ModerateReview(ctx, adminID, req); err != nil {
+		h.sendModerationError(ctx, "Failed to moderate review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Review moderated successfully"})
+}
+
+func (h *Handler) sendModerationError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrReviewNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrVoteOwnReview),
+		errors.Is(err, usecase.ErrReportOwnReview),
+		errors.Is(err, usecase.ErrAlreadyReported):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/websocket"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type INotificationHandler interface {
+	GetNotifications(ctx *gin.Context)
+	MarkNotificationRead(ctx *gin.Context)
+	MarkAllNotificationsRead(ctx *gin.Context)
+	SubscribeNotifications(ctx *gin.Context)
+}
+
+// GetNotifications godoc
+// @Summary Get notifications
+// @Description Get the authenticated user's notifications, newest first, with the unread count
+// @Tags notification
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size (max 100)"
+// @Param unread_only query bool false "Only unread notifications"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/notification [get]
+func (h *Handler) GetNotifications(ctx *gin.Context) {
+	var req request.GetNotifications
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	notifications, err := h.notificationUsecase.GetNotifications(ctx, userID, req)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get notifications", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get notifications")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, notifications)
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark notification as read
+// @Tags notification
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/notification/{id}/read [put]
+func (h *Handler) MarkNotificationRead(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid notification ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.notificationUsecase.MarkRead(ctx, userID, id); err != nil {
+		if errors.Is(err, usecase.ErrNotificationNotFound) {
+			apiwrapper.SendNotFound(ctx, err.Error())
+			return
+		}
+		logger.EnhanceWith(ctx).Errorw("Failed to mark notification as read", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to mark notification as read")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all notifications as read
+// @Tags notification
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/notification/read-all [put]
+func (h *Handler) MarkAllNotificationsRead(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.notificationUsecase.MarkAllRead(ctx, userID); err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to mark notifications as read", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to mark notifications as read")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "All notifications marked as read"})
+}
+
+// SubscribeNotifications godoc
+// @Summary Subscribe to notifications
+// @Description Open a websocket receiving the authenticated user's new notifications
+// @Tags notification
+// @Router /api/v1/notification/ws [get]
+func (h *Handler) SubscribeNotifications(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	websocket.ServeWs(ctx, usecase.NotificationRoom(userID))
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+const (
+	oauthStateCookie = "oauth_state"
+	// The state cookie is only sent back to the callback
+	oauthStateCookiePath = "/api/v1/user/oauth"
+)
+
+type IOAuthHandler interface {
+	GetOAuthProviders(ctx *gin.Context)
+	StartOAuthLogin(ctx *gin.Context)
+	OAuthCallback(ctx *gin.Context)
+}
+
+// GetOAuthProviders godoc
+// @Summary List social login providers
+// @Description List the providers users can log in with, e.g. google and facebook
+// @Tags user
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/oauth/providers [get]
+func (h *Handler) GetOAuthProviders(ctx *gin.Context) {
+	apiwrapper.SendSuccess(ctx, gin.H{"providers": h.oauthUsecase.GetProviders(ctx)})
+}
+
+// StartOAuthLogin godoc
+// @Summary Start social login
+// @Description Redirect the browser to the provider's login page. The provider sends it back to the
+// @Description callback, which must be requested from the same browser.
+// @Tags user
+// @Param provider path string true "Provider, e.g. google"
+// @Success 302 {string} string "Redirect"
+// @Failure 404 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/oauth/{provider}/login [get]
+func (h *Handler) StartOAuthLogin(ctx *gin.Context) {
+	start, err := h.oauthUsecase.StartLogin(ctx, ctx.Param("provider"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrOAuthProviderNotFound) {
+			apiwrapper.SendNotFound(ctx, err.Error())
+			return
+		}
+		logger.EnhanceWith(ctx).Errorw("Failed to start social login", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to start social login")
+		return
+	}
+
+	setAuthCookie(ctx, oauthStateCookie, start.State, oauthStateCookiePath, config.OAuthConfig().StateTTL)
+	ctx.Redirect(http.StatusFound, start.URL)
+}
+
+// OAuthCallback godoc
+// @Summary Social login callback
+// @Description The provider redirects here after login. Starts a session like the login endpoint,
+// @Description setting the same token cookies, then redirects to the frontend, with an error query
+// @Description parameter when the login failed, or a challenge_token one when two-factor
+// @Description authentication is due.
+// @Tags user
+// @Param provider path string true "Provider, e.g. google"
+// @Param code query string false "Authorization code"
+// @Param state query string true "State from the login redirect"
+// @Param error query string false "Set by the provider when the login was declined"
+// @Success 302 {string} string "Redirect"
+// @Router /api/v1/user/oauth/{provider}/callback [get]
+func (h *Handler) OAuthCallback(ctx *gin.Context) {
+	var req request.OAuthCallback
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		redirectOAuthError(ctx, usecase.ErrOAuthInvalidState.Error())
+		return
+	}
+	req.Provider = ctx.Param("provider")
+	req.BrowserState, _ = ctx.Cookie(oauthStateCookie)
+	req.UserAgent = ctx.Request.UserAgent()
+	req.IPAddress = ctx.ClientIP()
+
+	// The state is single use whatever the outcome
+	setAuthCookie(ctx, oauthStateCookie, "", oauthStateCookiePath, -1)
+
+	login, err := h.oauthUsecase.CompleteLogin(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrOAuthProviderNotFound),
+			errors.Is(err, usecase.ErrOAuthInvalidState),
+			errors.Is(err, usecase.ErrOAuthDenied),
+			errors.Is(err, usecase.ErrOAuthFailed),
+			errors.Is(err, usecase.ErrOAuthEmailNotVerified),
+			errors.Is(err, usecase.ErrOAuthAccountUnverified),
+			errors.Is(err, usecase.ErrTwoFactorLocked):
+			redirectOAuthError(ctx, err.Error())
+		default:
+			logger.EnhanceWith(ctx).Errorw("Social login failed", "provider", req.Provider, "error", err)
+			redirectOAuthError(ctx, "Social login failed")
+		}
+		return
+	}
+
+	// The frontend completes the login at /user/login/2fa
+	if login.Challenge != nil {
+		redirectOAuthComplete(ctx, url.Values{"challenge_token": {login.Challenge.ChallengeToken}})
+		return
+	}
+
+	setAuthCookies(ctx, &login.TokenResponse)
+	redirectOAuthComplete(ctx, nil)
+}
+
+func redirectOAuthError(ctx *gin.Context, message string) {
+	redirectOAuthComplete(ctx, url.Values{"error": {message}})
+}
+
+// redirectOAuthComplete returns the browser to the frontend, which reads the session from
+// the token cookies, or the query parameters when the login needs more
+func redirectOAuthComplete(ctx *gin.Context, params url.Values) {
+	target := config.OAuthConfig().CompleteURL
+	if len(params) > 0 {
+		u, err := url.Parse(target)
+		if err == nil {
+			query := u.Query()
+			for key, values := range params {
+				query[key] = values
+			}
+			u.RawQuery = query.Encode()
+			target = u.String()
+		}
+	}
+	ctx.Redirect(http.StatusFound, target)
+}
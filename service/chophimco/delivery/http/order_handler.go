@@ -1,12 +1,14 @@
 package http
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
 	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
 )
 
 type IOrderHandler interface {
@@ -40,6 +42,10 @@ func (h *Handler) CreateOrder(ctx *gin.Context) {
 
 	order, err := h.orderUsecase.CreateOrder(ctx, userID, req)
 	if err != nil {
+		if errors.Is(err, usecase.ErrEmailNotVerified) {
+			apiwrapper.SendBadRequest(ctx, err.Error())
+			return
+		}
 		apiwrapper.SendInternalError(ctx, err.Error())
 		return
 	}
@@ -111,7 +117,14 @@ func (h *Handler) UpdateOrderStatus(ctx *gin.Context) {
 	}
 
 	if err := h.orderUsecase.UpdateOrderStatus(ctx, req); err != nil {
-		apiwrapper.SendInternalError(ctx, "Failed to update order status")
+		switch {
+		case errors.Is(err, usecase.ErrOrderNotFound):
+			apiwrapper.SendNotFound(ctx, err.Error())
+		case errors.Is(err, usecase.ErrOrderSettled):
+			apiwrapper.SendBadRequest(ctx, err.Error())
+		default:
+			apiwrapper.SendInternalError(ctx, "Failed to update order status")
+		}
 		return
 	}
 
@@ -1,12 +1,15 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
 	"github.com/leehai1107/chophimco-server/pkg/logger"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
 )
 
 type IProductHandler interface {
@@ -14,6 +17,9 @@ type IProductHandler interface {
 	GetProductByID(ctx *gin.Context)
 	GetProductsByCategory(ctx *gin.Context)
 	GetProductsByBrand(ctx *gin.Context)
+	ListProducts(ctx *gin.Context)
+	SearchProducts(ctx *gin.Context)
+	SuggestProducts(ctx *gin.Context)
 	CreateProduct(ctx *gin.Context)
 	UpdateProduct(ctx *gin.Context)
 	DeleteProduct(ctx *gin.Context)
@@ -65,7 +71,7 @@ func (h *Handler) GetProductByID(ctx *gin.Context) {
 
 // GetProductsByCategory godoc
 // @Summary Get products by category
-// @Description Get all products in a category
+// @Description Get all products in a category and its subcategories
 // @Tags product
 // @Produce json
 // @Param category_id query int true "Category ID"
@@ -111,6 +117,104 @@ func (h *Handler) GetProductsByBrand(ctx *gin.Context) {
 	apiwrapper.SendSuccess(ctx, products)
 }
 
+// ListProducts godoc
+// @Summary List products
+// @Description Paginated product listing with sorting, combinable filters, facet counts and a price histogram
+// @Tags product
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Sort order" Enums(price_asc, price_desc, newest, rating, best_selling)
+// @Param category_id query int false "Category ID, includes subcategories"
+// @Param brand_id query int false "Brand ID"
+// @Param seller_id query int false "Seller ID"
+// @Param collection_id query int false "Shop collection ID"
+// @Param min_price query number false "Minimum variant price"
+// @Param max_price query number false "Maximum variant price"
+// @Param layout query []string false "Layouts (60%, 65%, TKL, Fullsize)" collectionFormat(multi)
+// @Param connection_type query []string false "Connection types" collectionFormat(multi)
+// @Param hotswap query bool false "Hotswap support"
+// @Param led_type query []string false "LED types" collectionFormat(multi)
+// @Param switch_type query []string false "Switch types (Linear, Tactile, Clicky)" collectionFormat(multi)
+// @Param in_stock query bool false "Only products with a variant in stock"
+// @Param attr[code] query string false "Attribute filter by code, e.g. attr[layout]=TKL. Values may be comma separated"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/product/list [get]
+func (h *Handler) ListProducts(ctx *gin.Context) {
+	var req request.ListProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+	req.Attributes = bindAttributeFilters(ctx.Request.URL.Query())
+
+	products, err := h.productUsecase.ListProducts(ctx, req)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to list products", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to list products")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, products)
+}
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Ranked full-text product search with unaccented and typo-tolerant matching. Accepts the same filters, sorting and pagination as /product/list
+// @Tags product
+// @Produce json
+// @Param q query string true "Search query"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Sort order, defaults to relevance" Enums(price_asc, price_desc, newest, rating, best_selling)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/product/search [get]
+func (h *Handler) SearchProducts(ctx *gin.Context) {
+	var req request.SearchProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+	req.Attributes = bindAttributeFilters(ctx.Request.URL.Query())
+
+	products, err := h.productUsecase.SearchProducts(ctx, req)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to search products", "error", err, "query", req.Query)
+		apiwrapper.SendInternalError(ctx, "Failed to search products")
+		return
+	}
+
+	// Count each search once, not once per page, and skip queries nobody can use
+	if req.Page <= 1 && products.Pagination.Total > 0 {
+		go func(query string) {
+			if err := h.suggestUsecase.LogQuery(context.Background(), query); err != nil {
+				logger.Errorf("Failed to log search query: %v", err)
+			}
+		}(req.Query)
+	}
+
+	apiwrapper.SendSuccess(ctx, products)
+}
+
+// SuggestProducts godoc
+// @Summary Suggest search completions
+// @Description Prefix completions over product names, brands, switches, categories and popular searches
+// @Tags product
+// @Produce json
+// @Param q query string true "Text typed so far"
+// @Param limit query int false "Maximum suggestions (default 8, max 20)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/product/suggest [get]
+func (h *Handler) SuggestProducts(ctx *gin.Context) {
+	var req request.SuggestProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, h.suggestUsecase.Suggest(ctx, req))
+}
+
 // CreateProduct godoc
 // @Summary Create new product
 // @Description Create a new product (Admin only)
@@ -198,7 +302,7 @@ func (h *Handler) CreateProductVariant(ctx *gin.Context) {
 	}
 
 	if err := h.productUsecase.CreateProductVariant(ctx, req); err != nil {
-		if err.Error() == "SKU already exists" {
+		if errors.Is(err, usecase.ErrSKUTaken) || errors.Is(err, usecase.ErrInvalidAttribute) {
 			apiwrapper.SendBadRequest(ctx, err.Error())
 			return
 		}
@@ -226,6 +330,10 @@ func (h *Handler) UpdateProductVariant(ctx *gin.Context) {
 	}
 
 	if err := h.productUsecase.UpdateProductVariant(ctx, req); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAttribute) {
+			apiwrapper.SendBadRequest(ctx, err.Error())
+			return
+		}
 		apiwrapper.SendInternalError(ctx, "Failed to update variant")
 		return
 	}
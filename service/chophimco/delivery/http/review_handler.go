@@ -1,46 +1,88 @@
 package http
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
 	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
 )
 
 type IReviewHandler interface {
 	GetProductReviews(ctx *gin.Context)
+	GetReviewHistory(ctx *gin.Context)
 	CreateReview(ctx *gin.Context)
+	UpdateReview(ctx *gin.Context)
+	DeleteReview(ctx *gin.Context)
+	UploadReviewPhoto(ctx *gin.Context)
+	DeleteReviewPhoto(ctx *gin.Context)
+
+	// Seller replies
+	ReplyToReview(ctx *gin.Context)
+	DeleteReviewReply(ctx *gin.Context)
 }
 
 // GetProductReviews godoc
 // @Summary Get product reviews
-// @Description Get all reviews for a product
+// @Description Paginated reviews of a product with their photos and the seller's reply.
+// @Description The product's average rating and star histogram are on the product itself.
 // @Tags review
 // @Produce json
 // @Param product_id query int true "Product ID"
+// @Param rating query int false "Only reviews with this many stars"
+// @Param with_photos query bool false "Only reviews with photos"
+// @Param sort query string false "Sort order, defaults to newest" Enums(newest, helpful)
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
 // @Success 200 {object} apiwrapper.APIResponse
 // @Router /api/v1/review [get]
 func (h *Handler) GetProductReviews(ctx *gin.Context) {
-	productID, err := strconv.Atoi(ctx.Query("product_id"))
-	if err != nil {
-		apiwrapper.SendBadRequest(ctx, "Invalid product ID")
+	var req request.ListReviews
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
 		return
 	}
 
-	reviews, err := h.reviewUsecase.GetProductReviews(ctx, productID)
+	reviews, err := h.reviewUsecase.ListReviews(ctx, req)
 	if err != nil {
-		apiwrapper.SendInternalError(ctx, "Failed to get reviews")
+		h.sendReviewError(ctx, "Failed to get reviews", err)
 		return
 	}
 
 	apiwrapper.SendSuccess(ctx, reviews)
 }
 
+// GetReviewHistory godoc
+// @Summary Get review edit history
+// @Description Get a review along with its earlier versions, newest first
+// @Tags review
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/{id}/history [get]
+func (h *Handler) GetReviewHistory(ctx *gin.Context) {
+	reviewID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid review ID")
+		return
+	}
+
+	history, err := h.reviewUsecase.GetReviewHistory(ctx, reviewID)
+	if err != nil {
+		h.sendReviewError(ctx, "Failed to get review history", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, history)
+}
+
 // CreateReview godoc
 // @Summary Create review
-// @Description Create a product review
+// @Description Review a product bought in one of your completed orders. The review is marked as a verified purchase.
 // @Tags review
 // @Accept json
 // @Produce json
@@ -60,14 +102,218 @@ func (h *Handler) CreateReview(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.reviewUsecase.CreateReview(ctx, userID, req); err != nil {
-		if err.Error() == "you have already reviewed this product" {
-			apiwrapper.SendBadRequest(ctx, err.Error())
-			return
-		}
-		apiwrapper.SendInternalError(ctx, "Failed to create review")
+	review, err := h.reviewUsecase.CreateReview(ctx, userID, req)
+	if err != nil {
+		h.sendReviewError(ctx, "Failed to create review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, review)
+}
+
+// UpdateReview godoc
+// @Summary Update review
+// @Description Change the rating or comment of your review while it is inside the edit window. The replaced version is kept in its history.
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateReview true "Review information"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/update [put]
+func (h *Handler) UpdateReview(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	var req request.UpdateReview
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	review, err := h.reviewUsecase.UpdateReview(ctx, userID, req)
+	if err != nil {
+		h.sendReviewError(ctx, "Failed to update review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, review)
+}
+
+// DeleteReview godoc
+// @Summary Delete review
+// @Description Delete your review along with its photos, history and the seller's reply
+// @Tags review
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/{id} [delete]
+func (h *Handler) DeleteReview(ctx *gin.Context) {
+	reviewID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid review ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.reviewUsecase.DeleteReview(ctx, userID, reviewID); err != nil {
+		h.sendReviewError(ctx, "Failed to delete review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Review deleted successfully"})
+}
+
+// UploadReviewPhoto godoc
+// @Summary Upload review photo
+// @Description Attach a JPEG, PNG, WebP or GIF photo to your review, up to 5 per review, while it is inside the edit window.
+// @Description Photos are stored with thumbnail (150px) and medium (640px) copies.
+// @Tags review
+// @Accept multipart/form-data
+// @Produce json
+// @Param review_id formData int true "Review ID"
+// @Param image formData file true "Image file"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/photo [post]
+func (h *Handler) UploadReviewPhoto(ctx *gin.Context) {
+	var req request.UploadReviewPhoto
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	data, ok := readUploadedImage(ctx, "image")
+	if !ok {
 		return
 	}
+	req.ImageData = data
 
-	apiwrapper.SendSuccess(ctx, gin.H{"message": "Review created successfully"})
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	photo, err := h.reviewUsecase.UploadReviewPhoto(ctx, userID, req)
+	if err != nil {
+		h.sendReviewError(ctx, "Failed to upload review photo", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, photo)
+}
+
+// DeleteReviewPhoto godoc
+// @Summary Delete review photo
+// @Description Remove a photo from your review
+// @Tags review
+// @Produce json
+// @Param id path int true "Photo ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/review/photo/{id} [delete]
+func (h *Handler) DeleteReviewPhoto(ctx *gin.Context) {
+	photoID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid photo ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.reviewUsecase.DeleteReviewPhoto(ctx, userID, photoID); err != nil {
+		h.sendReviewError(ctx, "Failed to delete review photo", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Photo deleted successfully"})
+}
+
+// ReplyToReview godoc
+// @Summary Reply to review
+// @Description Post or edit the public reply to a review of one of your products. Each review has at most one reply.
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.ReplyToReview true "Reply"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/product-review/reply [put]
+func (h *Handler) ReplyToReview(ctx *gin.Context) {
+	var req request.ReplyToReview
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	review, err := h.reviewUsecase.ReplyToReview(ctx, userID, req)
+	if err != nil {
+		h.sendReviewError(ctx, "Failed to reply to review", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, review)
+}
+
+// DeleteReviewReply godoc
+// @Summary Delete review reply
+// @Description Remove your reply from a review of one of your products
+// @Tags seller
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/product-review/{id}/reply [delete]
+func (h *Handler) DeleteReviewReply(ctx *gin.Context) {
+	reviewID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid review ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.reviewUsecase.DeleteReviewReply(ctx, userID, reviewID); err != nil {
+		h.sendReviewError(ctx, "Failed to delete review reply", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Reply deleted successfully"})
+}
+
+func (h *Handler) sendReviewError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrReviewNotFound),
+		errors.Is(err, usecase.ErrReviewPhotoNotFound),
+		errors.Is(err, usecase.ErrReviewReplyNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrNotVerifiedBuyer),
+		errors.Is(err, usecase.ErrReviewEditExpired),
+		errors.Is(err, usecase.ErrReviewRemoved),
+		errors.Is(err, usecase.ErrReviewExists),
+		errors.Is(err, usecase.ErrReviewPhotoLimit),
+		errors.Is(err, usecase.ErrInvalidUpload):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
 	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/storage"
 )
 
 type Router interface {
@@ -13,18 +14,30 @@ type Router interface {
 }
 
 type routerImpl struct {
-	handler    IHandler
-	jwtService auth.IJWTService
+	handler     IHandler
+	jwtService  auth.IJWTService
+	revocations auth.RevocationList
+	storage     storage.Backend
 }
 
-func NewRouter(handler IHandler, jwtService auth.IJWTService) Router {
+func NewRouter(
+	handler IHandler,
+	jwtService auth.IJWTService,
+	revocations auth.RevocationList,
+	storage storage.Backend,
+) Router {
 	return &routerImpl{
-		handler:    handler,
-		jwtService: jwtService,
+		handler:     handler,
+		jwtService:  jwtService,
+		revocations: revocations,
+		storage:     storage,
 	}
 }
 
 func (p *routerImpl) Register(r gin.IRouter) {
+	// Public keys for verifying our access tokens
+	r.GET("/.well-known/jwks.json", auth.JWKSHandler(p.jwtService))
+
 	// routes for chophimco service
 	api := r.Group("api/v1")
 	{
@@ -34,10 +47,16 @@ func (p *routerImpl) Register(r gin.IRouter) {
 				"timestamp": time.Now(),
 			})
 		})
+
+		// Uploaded files, S3 backends serve them from the bucket instead
+		if local, ok := p.storage.(*storage.LocalBackend); ok {
+			api.StaticFS("/media", local.PublicFS())
+		}
 	}
 
 	// Create auth middleware instance
-	authMiddleware := auth.AuthMiddleware(p.jwtService)
+	authMiddleware := auth.AuthMiddleware(p.jwtService, p.revocations)
+	optionalAuthMiddleware := auth.OptionalAuthMiddleware(p.jwtService, p.revocations)
 	adminMiddleware := auth.RoleMiddleware("admin")
 	sellerMiddleware := auth.RoleMiddleware("seller", "admin")
 
@@ -45,9 +64,25 @@ func (p *routerImpl) Register(r gin.IRouter) {
 	userApi := api.Group("user")
 	{
 		userApi.POST("/login", p.handler.Login)
+		userApi.POST("/login/2fa", p.handler.CompleteTwoFactorLogin)
 		userApi.POST("/register", p.handler.Register)
-		userApi.POST("/logout", p.handler.Logout)
+		userApi.POST("/refresh", p.handler.RefreshToken)
+		userApi.POST("/logout", optionalAuthMiddleware, p.handler.Logout)
+		userApi.POST("/logout-all", authMiddleware, p.handler.LogoutAll)
+		userApi.POST("/verify-email", p.handler.VerifyEmail)
+		userApi.POST("/resend-verification", authMiddleware, p.handler.ResendVerification)
+		userApi.POST("/forgot-password", p.handler.ForgotPassword)
+		userApi.POST("/reset-password", p.handler.ResetPassword)
+		userApi.GET("/oauth/providers", p.handler.GetOAuthProviders)
+		userApi.GET("/oauth/:provider/login", p.handler.StartOAuthLogin)
+		userApi.GET("/oauth/:provider/callback", p.handler.OAuthCallback)
+		userApi.GET("/2fa", authMiddleware, p.handler.GetTwoFactorStatus)
+		userApi.POST("/2fa/setup", authMiddleware, p.handler.SetupTwoFactor)
+		userApi.POST("/2fa/enable", authMiddleware, p.handler.EnableTwoFactor)
+		userApi.POST("/2fa/disable", authMiddleware, p.handler.DisableTwoFactor)
+		userApi.POST("/2fa/recovery-codes", authMiddleware, p.handler.RegenerateRecoveryCodes)
 		userApi.GET("/profile", authMiddleware, p.handler.GetProfile) // Protected
+		userApi.GET("/following", authMiddleware, p.handler.GetFollowedShops)
 	}
 
 	// Product routes
@@ -55,6 +90,9 @@ func (p *routerImpl) Register(r gin.IRouter) {
 	{
 		// Public routes
 		productApi.GET("/all", p.handler.GetAllProducts)
+		productApi.GET("/list", p.handler.ListProducts)
+		productApi.GET("/search", p.handler.SearchProducts)
+		productApi.GET("/suggest", p.handler.SuggestProducts)
 		productApi.GET("/:id", p.handler.GetProductByID)
 		productApi.GET("/category", p.handler.GetProductsByCategory)
 		productApi.GET("/brand", p.handler.GetProductsByBrand)
@@ -69,6 +107,17 @@ func (p *routerImpl) Register(r gin.IRouter) {
 		productApi.PUT("/variant/update", authMiddleware, adminMiddleware, p.handler.UpdateProductVariant)
 	}
 
+	// Catalog routes
+	categoryApi := api.Group("category")
+	{
+		categoryApi.GET("", p.handler.GetCategoryTree)
+		categoryApi.GET("/:slug", p.handler.GetCategoryBySlug)
+		categoryApi.GET("/:slug/products", p.handler.GetCategoryProducts)
+	}
+	api.GET("/brand", p.handler.GetBrands)
+	api.GET("/switch", p.handler.GetSwitches)
+	api.GET("/attribute", p.handler.GetAttributeDefinitions)
+
 	// Cart routes (all require authentication)
 	cartApi := api.Group("cart", authMiddleware)
 	{
@@ -107,36 +156,124 @@ func (p *routerImpl) Register(r gin.IRouter) {
 	reviewApi := api.Group("review")
 	{
 		reviewApi.GET("", p.handler.GetProductReviews)
-		reviewApi.POST("/create", authMiddleware, p.handler.CreateReview) // Protected
+		reviewApi.GET("/:id/history", p.handler.GetReviewHistory)
+
+		// Reviewer routes (protected)
+		reviewApi.POST("/create", authMiddleware, p.handler.CreateReview)
+		reviewApi.PUT("/update", authMiddleware, p.handler.UpdateReview)
+		reviewApi.DELETE("/:id", authMiddleware, p.handler.DeleteReview)
+		reviewApi.POST("/photo", authMiddleware, p.handler.UploadReviewPhoto)
+		reviewApi.DELETE("/photo/:id", authMiddleware, p.handler.DeleteReviewPhoto)
+
+		// Helpfulness votes and abuse reports (protected)
+		reviewApi.POST("/:id/helpful", authMiddleware, p.handler.VoteReviewHelpful)
+		reviewApi.DELETE("/:id/helpful", authMiddleware, p.handler.RemoveReviewHelpfulVote)
+		reviewApi.POST("/report", authMiddleware, p.handler.ReportReview)
+	}
+
+	// Public shop storefront routes
+	shopApi := api.Group("shop")
+	{
+		shopApi.GET("/:slug", optionalAuthMiddleware, p.handler.GetStorefront)
+		shopApi.GET("/:slug/products", p.handler.GetShopProducts)
+		shopApi.GET("/:slug/search", p.handler.SearchShopProducts)
+		shopApi.POST("/:slug/follow", authMiddleware, p.handler.FollowShop)
+		shopApi.DELETE("/:slug/follow", authMiddleware, p.handler.UnfollowShop)
+	}
+
+	// Feed of followed shops
+	feedApi := api.Group("feed", authMiddleware)
+	{
+		feedApi.GET("", p.handler.GetFeed)
 	}
 
 	// Seller routes
 	sellerApi := api.Group("seller")
 	{
 		// Public seller info
-		sellerApi.GET("/:id", p.handler.GetSellerByID)
+		sellerApi.GET("/:id", optionalAuthMiddleware, p.handler.GetSellerByID)
 		sellerApi.GET("/reviews", p.handler.GetSellerReviews)
 
 		// Seller profile management (requires seller or admin role)
 		sellerApi.POST("/profile", authMiddleware, sellerMiddleware, p.handler.CreateSellerProfile)
 		sellerApi.GET("/profile", authMiddleware, sellerMiddleware, p.handler.GetSellerProfile)
 		sellerApi.PUT("/profile", authMiddleware, sellerMiddleware, p.handler.UpdateSellerProfile)
+		sellerApi.POST("/profile/logo", authMiddleware, sellerMiddleware, p.handler.UploadSellerLogo)
+
+		// Seller onboarding and verification (requires seller or admin role)
+		sellerApi.GET("/kyc", authMiddleware, sellerMiddleware, p.handler.GetSellerKYC)
+		sellerApi.PUT("/kyc/business", authMiddleware, sellerMiddleware, p.handler.UpdateSellerBusiness)
+		sellerApi.PUT("/kyc/bank", authMiddleware, sellerMiddleware, p.handler.UpdateSellerBank)
+		sellerApi.POST("/kyc/document", authMiddleware, sellerMiddleware, p.handler.UploadSellerDocument)
+		sellerApi.GET("/kyc/document/:id", authMiddleware, sellerMiddleware, p.handler.GetSellerDocument)
+		sellerApi.DELETE("/kyc/document/:id", authMiddleware, sellerMiddleware, p.handler.DeleteSellerDocument)
+		sellerApi.POST("/kyc/submit", authMiddleware, sellerMiddleware, p.handler.SubmitSellerKYC)
 
 		// Seller product management (requires seller or admin role)
 		sellerApi.POST("/product", authMiddleware, sellerMiddleware, p.handler.CreateSellerProduct)
 		sellerApi.GET("/product", authMiddleware, sellerMiddleware, p.handler.GetSellerProducts)
 		sellerApi.PUT("/product", authMiddleware, sellerMiddleware, p.handler.UpdateSellerProduct)
 		sellerApi.DELETE("/product/:id", authMiddleware, sellerMiddleware, p.handler.DeleteSellerProduct)
+		sellerApi.GET("/product/:id/revisions", authMiddleware, sellerMiddleware, p.handler.GetSellerProductRevisions)
+		sellerApi.POST("/product/variant/matrix", authMiddleware, sellerMiddleware, p.handler.GenerateVariantMatrix)
+
+		// Bulk import and export (requires seller or admin role)
+		sellerApi.POST("/product/import", authMiddleware, sellerMiddleware, p.handler.ImportSellerProducts)
+		sellerApi.GET("/product/import/:id", authMiddleware, sellerMiddleware, p.handler.GetImportJob)
+		sellerApi.GET("/product/export", authMiddleware, sellerMiddleware, p.handler.ExportSellerProducts)
 
 		// Product image management (requires seller or admin role)
 		sellerApi.POST("/product/image", authMiddleware, sellerMiddleware, p.handler.UploadProductImage)
 		sellerApi.DELETE("/product/image/:id", authMiddleware, sellerMiddleware, p.handler.DeleteProductImage)
 		sellerApi.PUT("/product/image/primary", authMiddleware, sellerMiddleware, p.handler.SetPrimaryImage)
 
+		// Storefront collections (requires seller or admin role)
+		sellerApi.GET("/collection", authMiddleware, sellerMiddleware, p.handler.GetShopCollections)
+		sellerApi.POST("/collection", authMiddleware, sellerMiddleware, p.handler.CreateShopCollection)
+		sellerApi.PUT("/collection", authMiddleware, sellerMiddleware, p.handler.UpdateShopCollection)
+		sellerApi.PUT("/collection/products", authMiddleware, sellerMiddleware, p.handler.SetShopCollectionProducts)
+		sellerApi.DELETE("/collection/:id", authMiddleware, sellerMiddleware, p.handler.DeleteShopCollection)
+
+		// Replies to product reviews (requires seller or admin role)
+		sellerApi.PUT("/product-review/reply", authMiddleware, sellerMiddleware, p.handler.ReplyToReview)
+		sellerApi.DELETE("/product-review/:id/reply", authMiddleware, sellerMiddleware, p.handler.DeleteReviewReply)
+
+		// Earnings and payouts (requires seller or admin role)
+		sellerApi.GET("/earnings", authMiddleware, sellerMiddleware, p.handler.GetSellerEarnings)
+		sellerApi.GET("/earnings/ledger", authMiddleware, sellerMiddleware, p.handler.GetSellerLedger)
+
+		// Analytics (requires seller or admin role)
+		sellerApi.GET("/analytics/sales", authMiddleware, sellerMiddleware, p.handler.GetSalesAnalytics)
+		sellerApi.GET("/analytics/conversion", authMiddleware, sellerMiddleware, p.handler.GetConversionAnalytics)
+		sellerApi.GET("/analytics/ratings", authMiddleware, sellerMiddleware, p.handler.GetRatingAnalytics)
+		sellerApi.GET("/analytics/top-variants", authMiddleware, sellerMiddleware, p.handler.GetTopVariants)
+		sellerApi.GET("/analytics/low-stock", authMiddleware, sellerMiddleware, p.handler.GetLowStockVariants)
+
 		// Seller reviews (requires authentication)
 		sellerApi.POST("/reviews", authMiddleware, p.handler.CreateSellerReview)
 	}
 
+	// Flash sale routes
+	flashSaleApi := api.Group("flash-sale")
+	{
+		// Public routes
+		flashSaleApi.GET("", p.handler.GetFlashSales)
+		flashSaleApi.GET("/:id", p.handler.GetFlashSaleByID)
+		flashSaleApi.GET("/:id/ws", p.handler.SubscribeFlashSale)
+
+		// Purchase (requires authentication)
+		flashSaleApi.POST("/purchase", authMiddleware, p.handler.PurchaseFlashSaleItem)
+	}
+
+	// Notification routes (all require authentication)
+	notificationApi := api.Group("notification", authMiddleware)
+	{
+		notificationApi.GET("", p.handler.GetNotifications)
+		notificationApi.PUT("/:id/read", p.handler.MarkNotificationRead)
+		notificationApi.PUT("/read-all", p.handler.MarkAllNotificationsRead)
+		notificationApi.GET("/ws", p.handler.SubscribeNotifications)
+	}
+
 	// Admin routes (all require admin role)
 	adminApi := api.Group("admin", authMiddleware, adminMiddleware)
 	{
@@ -144,10 +281,54 @@ func (p *routerImpl) Register(r gin.IRouter) {
 		adminApi.GET("/seller/pending", p.handler.GetPendingSellers)
 		adminApi.POST("/seller/verify", p.handler.VerifySeller)
 		adminApi.POST("/seller/reject", p.handler.RejectSeller)
+		adminApi.POST("/seller/request-info", p.handler.RequestSellerInfo)
+		adminApi.GET("/seller/:id/kyc", p.handler.GetSellerKYCForReview)
+		adminApi.GET("/seller/document/:id", p.handler.GetSellerDocumentForReview)
+		adminApi.POST("/seller/document/review", p.handler.ReviewSellerDocument)
 
 		// Product approval
 		adminApi.GET("/product/pending", p.handler.GetPendingProducts)
 		adminApi.POST("/product/approve", p.handler.ApproveProduct)
 		adminApi.POST("/product/reject", p.handler.RejectProduct)
+		adminApi.GET("/product/:id/revisions", p.handler.GetProductRevisions)
+		adminApi.GET("/product/:id/diff", p.handler.GetProductRevisionDiff)
+
+		// Flash sale management
+		adminApi.POST("/flash-sale", p.handler.CreateFlashSale)
+		adminApi.DELETE("/flash-sale/:id", p.handler.CancelFlashSale)
+
+		// Catalog management
+		adminApi.POST("/category", p.handler.CreateCategory)
+		adminApi.PUT("/category", p.handler.UpdateCategory)
+		adminApi.DELETE("/category/:id", p.handler.DeleteCategory)
+		adminApi.POST("/brand", p.handler.CreateBrand)
+		adminApi.PUT("/brand", p.handler.UpdateBrand)
+		adminApi.DELETE("/brand/:id", p.handler.DeleteBrand)
+		adminApi.POST("/switch", p.handler.CreateSwitch)
+		adminApi.PUT("/switch", p.handler.UpdateSwitch)
+		adminApi.DELETE("/switch/:id", p.handler.DeleteSwitch)
+
+		// Attribute definitions
+		adminApi.POST("/attribute", p.handler.CreateAttributeDefinition)
+		adminApi.PUT("/attribute", p.handler.UpdateAttributeDefinition)
+		adminApi.DELETE("/attribute/:id", p.handler.DeleteAttributeDefinition)
+
+		// Refunds, commission and payouts
+		adminApi.POST("/order/refund", p.handler.RefundOrder)
+		adminApi.GET("/commission", p.handler.GetCommissionRules)
+		adminApi.PUT("/commission", p.handler.SetCommissionRule)
+		adminApi.DELETE("/commission/:id", p.handler.DeleteCommissionRule)
+		adminApi.POST("/payout/batch", p.handler.CreatePayoutBatch)
+		adminApi.GET("/payout/batch", p.handler.GetPayoutBatches)
+		adminApi.GET("/payout/batch/:id", p.handler.GetPayoutBatch)
+		adminApi.POST("/payout/batch/:id/process", p.handler.ProcessPayoutBatch)
+		adminApi.POST("/payout/batch/:id/cancel", p.handler.CancelPayoutBatch)
+		adminApi.POST("/payout/settle", p.handler.SettlePayout)
+		adminApi.GET("/ledger/reconciliation", p.handler.GetReconciliationReport)
+
+		// Review moderation
+		adminApi.GET("/review/queue", p.handler.GetModerationQueue)
+		adminApi.GET("/review/reports", p.handler.GetReviewReports)
+		adminApi.POST("/review/moderate", p.handler.ModerateReview)
 	}
 }
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type ISellerAnalyticsHandler interface {
+	GetSalesAnalytics(ctx *gin.Context)
+	GetConversionAnalytics(ctx *gin.Context)
+	GetRatingAnalytics(ctx *gin.Context)
+	GetTopVariants(ctx *gin.Context)
+	GetLowStockVariants(ctx *gin.Context)
+}
+
+// GetSalesAnalytics godoc
+// @Summary Get sales analytics
+// @Description Get the seller's revenue, orders, units sold and refund rate per day, week or month.
+// @Description Counts the seller's items in orders placed in the period, cancelled orders excluded.
+// @Tags seller
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 30 days ago)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Param interval query string false "Bucket size (default day)" Enums(day, week, month)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/analytics/sales [get]
+func (h *Handler) GetSalesAnalytics(ctx *gin.Context) {
+	var req request.SellerAnalytics
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	sales, err := h.sellerAnalyticsUsecase.GetSales(ctx, userID, req)
+	if err != nil {
+		h.sendAnalyticsError(ctx, "Failed to get sales analytics", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, sales)
+}
+
+// GetConversionAnalytics godoc
+// @Summary Get cart conversion analytics
+// @Description Get how many of the seller's items were added to carts per day, week or month,
+// @Description and how many of those the buyer went on to order
+// @Tags seller
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 30 days ago)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Param interval query string false "Bucket size (default day)" Enums(day, week, month)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/analytics/conversion [get]
+func (h *Handler) GetConversionAnalytics(ctx *gin.Context) {
+	var req request.SellerAnalytics
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	conversion, err := h.sellerAnalyticsUsecase.GetConversion(ctx, userID, req)
+	if err != nil {
+		h.sendAnalyticsError(ctx, "Failed to get conversion analytics", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, conversion)
+}
+
+// GetRatingAnalytics godoc
+// @Summary Get rating analytics
+// @Description Get the seller's review count and average rating per day, week or month, with the rating distribution
+// @Tags seller
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 30 days ago)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Param interval query string false "Bucket size (default day)" Enums(day, week, month)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/analytics/ratings [get]
+func (h *Handler) GetRatingAnalytics(ctx *gin.Context) {
+	var req request.SellerAnalytics
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	ratings, err := h.sellerAnalyticsUsecase.GetRatings(ctx, userID, req)
+	if err != nil {
+		h.sendAnalyticsError(ctx, "Failed to get rating analytics", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, ratings)
+}
+
+// GetTopVariants godoc
+// @Summary Get top selling variants
+// @Description Get the seller's best selling variants in the period, refunded units excluded
+// @Tags seller
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 30 days ago)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Param sort_by query string false "Rank by units sold or revenue (default units)" Enums(units, revenue)
+// @Param limit query int false "Number of variants (default 10, max 50)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/analytics/top-variants [get]
+func (h *Handler) GetTopVariants(ctx *gin.Context) {
+	var req request.TopVariants
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	variants, err := h.sellerAnalyticsUsecase.GetTopVariants(ctx, userID, req)
+	if err != nil {
+		h.sendAnalyticsError(ctx, "Failed to get top variants", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, variants)
+}
+
+// GetLowStockVariants godoc
+// @Summary Get low stock variants
+// @Description Get the seller's variants at or below the stock threshold, lowest first,
+// @Description with the last 30 days' sales and how many days the stock lasts at that pace
+// @Tags seller
+// @Produce json
+// @Param threshold query int false "Stock threshold (default 5)"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/analytics/low-stock [get]
+func (h *Handler) GetLowStockVariants(ctx *gin.Context) {
+	var req request.LowStockVariants
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	variants, err := h.sellerAnalyticsUsecase.GetLowStockVariants(ctx, userID, req)
+	if err != nil {
+		h.sendAnalyticsError(ctx, "Failed to get low stock variants", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, variants)
+}
+
+func (h *Handler) sendAnalyticsError(ctx *gin.Context, message string, err error) {
+	if errors.Is(err, usecase.ErrInvalidAnalyticsRange) {
+		apiwrapper.SendBadRequest(ctx, err.Error())
+		return
+	}
+	logger.EnhanceWith(ctx).Errorw(message, "error", err)
+	apiwrapper.SendInternalError(ctx, message)
+}
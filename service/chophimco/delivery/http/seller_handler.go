@@ -1,13 +1,19 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/config"
 	"github.com/leehai1107/chophimco-server/pkg/logger"
 	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
 )
 
 type ISellerHandler interface {
@@ -15,6 +21,7 @@ type ISellerHandler interface {
 	CreateSellerProfile(ctx *gin.Context)
 	GetSellerProfile(ctx *gin.Context)
 	UpdateSellerProfile(ctx *gin.Context)
+	UploadSellerLogo(ctx *gin.Context)
 	GetSellerByID(ctx *gin.Context)
 
 	// Seller product management
@@ -30,14 +37,17 @@ type ISellerHandler interface {
 
 	// Admin - seller verification
 	GetPendingSellers(ctx *gin.Context)
-	VerifySeller(ctx *gin.Context)
-	RejectSeller(ctx *gin.Context)
 
 	// Admin - product approval
 	GetPendingProducts(ctx *gin.Context)
 	ApproveProduct(ctx *gin.Context)
 	RejectProduct(ctx *gin.Context)
 
+	// Product revisions
+	GetSellerProductRevisions(ctx *gin.Context)
+	GetProductRevisions(ctx *gin.Context)
+	GetProductRevisionDiff(ctx *gin.Context)
+
 	// Seller reviews
 	GetSellerReviews(ctx *gin.Context)
 	CreateSellerReview(ctx *gin.Context)
@@ -69,8 +79,7 @@ func (h *Handler) CreateSellerProfile(ctx *gin.Context) {
 
 	profile, err := h.sellerUsecase.CreateSellerProfile(ctx, req)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to create seller profile", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to create seller profile")
+		h.sendSellerProfileError(ctx, "Failed to create seller profile", err)
 		return
 	}
 
@@ -125,8 +134,41 @@ func (h *Handler) UpdateSellerProfile(ctx *gin.Context) {
 
 	profile, err := h.sellerUsecase.UpdateSellerProfile(ctx, req)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to update seller profile", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to update seller profile")
+		h.sendSellerProfileError(ctx, "Failed to update seller profile", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, profile)
+}
+
+// UploadSellerLogo godoc
+// @Summary Upload seller logo
+// @Description Upload the shop logo (JPEG, PNG, WebP or GIF). It is resized to a 256px logo and a 96px thumbnail.
+// @Tags seller
+// @Accept multipart/form-data
+// @Produce json
+// @Param logo formData file true "Logo image"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/profile/logo [post]
+func (h *Handler) UploadSellerLogo(ctx *gin.Context) {
+	data, ok := readUploadedImage(ctx, "logo")
+	if !ok {
+		return
+	}
+	if len(data) == 0 {
+		apiwrapper.SendBadRequest(ctx, "Logo file is required")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	profile, err := h.sellerUsecase.UploadSellerLogo(ctx, userID, data)
+	if err != nil {
+		h.sendImageError(ctx, "Failed to upload logo", err)
 		return
 	}
 
@@ -135,10 +177,10 @@ func (h *Handler) UpdateSellerProfile(ctx *gin.Context) {
 
 // GetSellerByID godoc
 // @Summary Get seller by ID
-// @Description Get public seller information by ID
+// @Description Get the public storefront of a verified seller by seller profile ID, like /shop/{slug}
 // @Tags seller
 // @Produce json
-// @Param id path int true "Seller ID"
+// @Param id path int true "Seller profile ID"
 // @Success 200 {object} apiwrapper.APIResponse
 // @Router /api/v1/seller/{id} [get]
 func (h *Handler) GetSellerByID(ctx *gin.Context) {
@@ -148,9 +190,11 @@ func (h *Handler) GetSellerByID(ctx *gin.Context) {
 		return
 	}
 
-	seller, err := h.sellerUsecase.GetSellerByID(ctx, id)
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+
+	seller, err := h.storefrontUsecase.GetStorefrontByID(ctx, id, viewerID)
 	if err != nil {
-		apiwrapper.SendNotFound(ctx, "Seller not found")
+		h.sendStorefrontError(ctx, "Failed to get seller", err)
 		return
 	}
 
@@ -216,7 +260,8 @@ func (h *Handler) GetSellerProducts(ctx *gin.Context) {
 
 // UpdateSellerProduct godoc
 // @Summary Update seller's product
-// @Description Update product details (requires re-approval if major changes)
+// @Description Update product details. Changes to name, category, brand, description or base price of an
+// @Description approved product are submitted as a revision for review, the approved version stays live meanwhile.
 // @Tags seller
 // @Accept json
 // @Produce json
@@ -279,14 +324,17 @@ func (h *Handler) DeleteSellerProduct(ctx *gin.Context) {
 
 // UploadProductImage godoc
 // @Summary Upload product image
-// @Description Upload an image for a product
+// @Description Upload a JPEG, PNG, WebP or GIF image for a product, or link an external image with image_url.
+// @Description Uploads are stored with thumbnail (150px), small (320px), medium (640px) and large (1280px) copies.
 // @Tags seller
 // @Accept multipart/form-data
 // @Produce json
 // @Param product_id formData int true "Product ID"
-// @Param image formData file true "Image file"
+// @Param image formData file false "Image file"
+// @Param image_url formData string false "External image URL, used when no file is uploaded"
 // @Param is_primary formData bool false "Set as primary image"
 // @Param display_order formData int false "Display order"
+// @Param alt_text formData string false "Alternative text"
 // @Success 200 {object} apiwrapper.APIResponse
 // @Router /api/v1/seller/product/image [post]
 func (h *Handler) UploadProductImage(ctx *gin.Context) {
@@ -296,6 +344,12 @@ func (h *Handler) UploadProductImage(ctx *gin.Context) {
 		return
 	}
 
+	data, ok := readUploadedImage(ctx, "image")
+	if !ok {
+		return
+	}
+	req.ImageData = data
+
 	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
 		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
@@ -304,8 +358,7 @@ func (h *Handler) UploadProductImage(ctx *gin.Context) {
 
 	image, err := h.sellerUsecase.UploadProductImage(ctx, userID, req)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to upload image", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to upload image")
+		h.sendImageError(ctx, "Failed to upload image", err)
 		return
 	}
 
@@ -335,8 +388,7 @@ func (h *Handler) DeleteProductImage(ctx *gin.Context) {
 
 	err = h.sellerUsecase.DeleteProductImage(ctx, userID, imageID)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to delete image", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to delete image")
+		h.sendImageError(ctx, "Failed to delete image", err)
 		return
 	}
 
@@ -392,131 +444,179 @@ func (h *Handler) GetPendingSellers(ctx *gin.Context) {
 	apiwrapper.SendSuccess(ctx, sellers)
 }
 
-// VerifySeller godoc
-// @Summary Verify seller
-// @Description Admin - Verify a seller profile
+// GetPendingProducts godoc
+// @Summary Get pending products
+// @Description Admin - Get new products pending approval and approved products with changes waiting for review
+// @Tags admin
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/product/pending [get]
+func (h *Handler) GetPendingProducts(ctx *gin.Context) {
+	products, err := h.sellerUsecase.GetPendingProducts(ctx)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get pending products", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to get pending products")
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, products)
+}
+
+// ApproveProduct godoc
+// @Summary Approve product
+// @Description Admin - Approve the pending revision of a product, which makes it live. The seller is notified.
 // @Tags admin
 // @Accept json
 // @Produce json
-// @Param request body request.VerifySeller true "Verification data"
+// @Param request body request.ApproveProduct true "Approval data"
 // @Success 200 {object} apiwrapper.APIResponse
-// @Router /api/v1/admin/seller/verify [post]
-func (h *Handler) VerifySeller(ctx *gin.Context) {
-	var req request.VerifySeller
+// @Router /api/v1/admin/product/approve [post]
+func (h *Handler) ApproveProduct(ctx *gin.Context) {
+	var req request.ApproveProduct
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		apiwrapper.SendBadRequest(ctx, "Invalid request format")
 		return
 	}
 
-	err := h.sellerUsecase.VerifySeller(ctx, req)
+	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to verify seller", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to verify seller")
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
 		return
 	}
+	req.ReviewerID = userID
 
-	apiwrapper.SendSuccess(ctx, gin.H{"message": "Seller verified successfully"})
+	err = h.sellerUsecase.ApproveProduct(ctx, req)
+	if err != nil {
+		h.sendRevisionError(ctx, "Failed to approve product", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Product approved successfully"})
 }
 
-// RejectSeller godoc
-// @Summary Reject seller
-// @Description Admin - Reject a seller profile
+// RejectProduct godoc
+// @Summary Reject product
+// @Description Admin - Reject the pending revision of a product with a reason. An approved product keeps its
+// @Description live version. The seller is notified.
 // @Tags admin
 // @Accept json
 // @Produce json
-// @Param request body request.RejectSeller true "Rejection data"
+// @Param request body request.RejectProduct true "Rejection data"
 // @Success 200 {object} apiwrapper.APIResponse
-// @Router /api/v1/admin/seller/reject [post]
-func (h *Handler) RejectSeller(ctx *gin.Context) {
-	var req request.RejectSeller
+// @Router /api/v1/admin/product/reject [post]
+func (h *Handler) RejectProduct(ctx *gin.Context) {
+	var req request.RejectProduct
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		apiwrapper.SendBadRequest(ctx, "Invalid request format")
 		return
 	}
 
-	err := h.sellerUsecase.RejectSeller(ctx, req)
+	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to reject seller", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to reject seller")
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
 		return
 	}
+	req.ReviewerID = userID
 
-	apiwrapper.SendSuccess(ctx, gin.H{"message": "Seller rejected"})
+	err = h.sellerUsecase.RejectProduct(ctx, req)
+	if err != nil {
+		h.sendRevisionError(ctx, "Failed to reject product", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Product rejected"})
 }
 
-// GetPendingProducts godoc
-// @Summary Get pending products
-// @Description Admin - Get all products pending approval
-// @Tags admin
+// GetSellerProductRevisions godoc
+// @Summary Get product revision history
+// @Description Get every submitted revision of the seller's product with its review outcome, newest first
+// @Tags seller
 // @Produce json
+// @Param id path int true "Product ID"
 // @Success 200 {object} apiwrapper.APIResponse
-// @Router /api/v1/admin/product/pending [get]
-func (h *Handler) GetPendingProducts(ctx *gin.Context) {
-	products, err := h.sellerUsecase.GetPendingProducts(ctx)
+// @Router /api/v1/seller/product/{id}/revisions [get]
+func (h *Handler) GetSellerProductRevisions(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to get pending products", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to get pending products")
+		apiwrapper.SendBadRequest(ctx, "Invalid product ID")
 		return
 	}
 
-	apiwrapper.SendSuccess(ctx, products)
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	revisions, err := h.sellerUsecase.GetProductRevisions(ctx, userID, productID)
+	if err != nil {
+		h.sendRevisionError(ctx, "Failed to get product revisions", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, revisions)
 }
 
-// ApproveProduct godoc
-// @Summary Approve product
-// @Description Admin - Approve a product for listing
+// GetProductRevisions godoc
+// @Summary Get product revision history
+// @Description Admin - Get every submitted revision of a product with its review outcome, newest first
 // @Tags admin
-// @Accept json
 // @Produce json
-// @Param request body request.ApproveProduct true "Approval data"
+// @Param id path int true "Product ID"
 // @Success 200 {object} apiwrapper.APIResponse
-// @Router /api/v1/admin/product/approve [post]
-func (h *Handler) ApproveProduct(ctx *gin.Context) {
-	var req request.ApproveProduct
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+// @Router /api/v1/admin/product/{id}/revisions [get]
+func (h *Handler) GetProductRevisions(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid product ID")
 		return
 	}
 
-	err := h.sellerUsecase.ApproveProduct(ctx, req)
+	revisions, err := h.sellerUsecase.GetProductRevisions(ctx, 0, productID)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to approve product", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to approve product")
+		h.sendRevisionError(ctx, "Failed to get product revisions", err)
 		return
 	}
 
-	apiwrapper.SendSuccess(ctx, gin.H{"message": "Product approved successfully"})
+	apiwrapper.SendSuccess(ctx, revisions)
 }
 
-// RejectProduct godoc
-// @Summary Reject product
-// @Description Admin - Reject a product with reason
+// GetProductRevisionDiff godoc
+// @Summary Compare product revisions
+// @Description Admin - List the fields changed between two revisions. By default the pending (or latest) revision
+// @Description is compared with the approved version before it.
 // @Tags admin
-// @Accept json
 // @Produce json
-// @Param request body request.RejectProduct true "Rejection data"
+// @Param id path int true "Product ID"
+// @Param from query int false "Revision number to compare from"
+// @Param to query int false "Revision number to compare to"
 // @Success 200 {object} apiwrapper.APIResponse
-// @Router /api/v1/admin/product/reject [post]
-func (h *Handler) RejectProduct(ctx *gin.Context) {
-	var req request.RejectProduct
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+// @Router /api/v1/admin/product/{id}/diff [get]
+func (h *Handler) GetProductRevisionDiff(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid product ID")
+		return
+	}
+
+	var req request.ProductRevisionDiff
+	if err := ctx.ShouldBindQuery(&req); err != nil {
 		apiwrapper.SendBadRequest(ctx, "Invalid request format")
 		return
 	}
 
-	err := h.sellerUsecase.RejectProduct(ctx, req)
+	diff, err := h.sellerUsecase.GetProductRevisionDiff(ctx, productID, req)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to reject product", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to reject product")
+		h.sendRevisionError(ctx, "Failed to compare product revisions", err)
 		return
 	}
 
-	apiwrapper.SendSuccess(ctx, gin.H{"message": "Product rejected"})
+	apiwrapper.SendSuccess(ctx, diff)
 }
 
 // GetSellerReviews godoc
 // @Summary Get seller reviews
-// @Description Get all reviews for a seller
+// @Description Get the published reviews of a seller, newest first
 // @Tags seller
 // @Produce json
 // @Param seller_id query int true "Seller ID"
@@ -541,7 +641,8 @@ func (h *Handler) GetSellerReviews(ctx *gin.Context) {
 
 // CreateSellerReview godoc
 // @Summary Create seller review
-// @Description Create a review for a seller after order completion
+// @Description Review a seller for one of your completed orders containing their products, once per order.
+// @Description The order must have completed within the review window.
 // @Tags seller
 // @Accept json
 // @Produce json
@@ -564,10 +665,101 @@ func (h *Handler) CreateSellerReview(ctx *gin.Context) {
 
 	review, err := h.sellerUsecase.CreateSellerReview(ctx, req)
 	if err != nil {
-		logger.EnhanceWith(ctx).Errorw("Failed to create seller review", "error", err)
-		apiwrapper.SendInternalError(ctx, "Failed to create seller review")
+		h.sendSellerReviewError(ctx, "Failed to create seller review", err)
 		return
 	}
 
 	apiwrapper.SendSuccess(ctx, review)
 }
+
+// readUploadedImage reads an optional image file field. It returns false after sending an
+// error response, and nil data when the field is absent.
+func readUploadedImage(ctx *gin.Context, field string) ([]byte, bool) {
+	data, _, ok := readUploadedFile(ctx, field, config.StorageConfig().MaxUploadSize)
+	return data, ok
+}
+
+// readUploadedFile reads an optional file field of at most maxSize bytes, along with the
+// client's file name. It returns false after sending an error response.
+func readUploadedFile(ctx *gin.Context, field string, maxSize int64) ([]byte, string, bool) {
+	fileHeader, err := ctx.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, "", true
+	}
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid file upload")
+		return nil, "", false
+	}
+
+	if fileHeader.Size > maxSize {
+		apiwrapper.SendBadRequest(ctx, fmt.Sprintf("File is larger than %d bytes", maxSize))
+		return nil, "", false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Failed to read file")
+		return nil, "", false
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so a lying Content-Length is still caught
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Failed to read file")
+		return nil, "", false
+	}
+	if int64(len(data)) > maxSize {
+		apiwrapper.SendBadRequest(ctx, fmt.Sprintf("File is larger than %d bytes", maxSize))
+		return nil, "", false
+	}
+	return data, fileHeader.Filename, true
+}
+
+func (h *Handler) sendSellerProfileError(ctx *gin.Context, message string, err error) {
+	if errors.Is(err, usecase.ErrInvalidShopSlug) || errors.Is(err, usecase.ErrShopSlugTaken) {
+		apiwrapper.SendBadRequest(ctx, err.Error())
+		return
+	}
+	logger.EnhanceWith(ctx).Errorw(message, "error", err)
+	apiwrapper.SendInternalError(ctx, message)
+}
+
+func (h *Handler) sendImageError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrImageNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrInvalidUpload):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
+
+func (h *Handler) sendRevisionError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrProductNotFound),
+		errors.Is(err, usecase.ErrRevisionNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrNoPendingRevision):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
+
+func (h *Handler) sendSellerReviewError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrOrderNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrOrderNotCompleted),
+		errors.Is(err, usecase.ErrSellerReviewExpired),
+		errors.Is(err, usecase.ErrSellerReviewExists):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
@@ -0,0 +1,429 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type ISellerKYCHandler interface {
+	// Seller onboarding
+	GetSellerKYC(ctx *gin.Context)
+	UpdateSellerBusiness(ctx *gin.Context)
+	UpdateSellerBank(ctx *gin.Context)
+	UploadSellerDocument(ctx *gin.Context)
+	GetSellerDocument(ctx *gin.Context)
+	DeleteSellerDocument(ctx *gin.Context)
+	SubmitSellerKYC(ctx *gin.Context)
+
+	// Admin - seller verification
+	GetSellerKYCForReview(ctx *gin.Context)
+	GetSellerDocumentForReview(ctx *gin.Context)
+	ReviewSellerDocument(ctx *gin.Context)
+	RequestSellerInfo(ctx *gin.Context)
+	VerifySeller(ctx *gin.Context)
+	RejectSeller(ctx *gin.Context)
+}
+
+// GetSellerKYC godoc
+// @Summary Get seller onboarding details
+// @Description Get the seller's business, bank and document details, what is still missing and the verification history
+// @Tags seller
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/kyc [get]
+func (h *Handler) GetSellerKYC(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	kyc, err := h.sellerKYCUsecase.GetKYC(ctx, userID)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to get verification details", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, kyc)
+}
+
+// UpdateSellerBusiness godoc
+// @Summary Update seller business details
+// @Description Set the business type, legal name, registration number, address and tax ID.
+// @Description Only possible before submitting, or when the review asked for changes.
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateSellerBusiness true "Business details"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/kyc/business [put]
+func (h *Handler) UpdateSellerBusiness(ctx *gin.Context) {
+	var req request.UpdateSellerBusiness
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	kyc, err := h.sellerKYCUsecase.UpdateBusinessDetails(ctx, userID, req)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to update business details", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, kyc)
+}
+
+// UpdateSellerBank godoc
+// @Summary Update seller payout bank details
+// @Description Set the bank account payouts are sent to.
+// @Description Only possible before submitting, or when the review asked for changes.
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateSellerBank true "Bank details"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/kyc/bank [put]
+func (h *Handler) UpdateSellerBank(ctx *gin.Context) {
+	var req request.UpdateSellerBank
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	kyc, err := h.sellerKYCUsecase.UpdateBankDetails(ctx, userID, req)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to update bank details", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, kyc)
+}
+
+// UploadSellerDocument godoc
+// @Summary Upload verification document
+// @Description Upload a JPEG, PNG, WebP or PDF verification document. It replaces an earlier upload of the same type.
+// @Tags seller
+// @Accept multipart/form-data
+// @Produce json
+// @Param type formData string true "Document type" Enums(business_registration, tax_certificate, identity_front, identity_back, bank_statement)
+// @Param file formData file true "Document file"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/kyc/document [post]
+func (h *Handler) UploadSellerDocument(ctx *gin.Context) {
+	var req request.UploadSellerDocument
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	data, fileName, ok := readUploadedFile(ctx, "file", config.StorageConfig().MaxDocumentSize)
+	if !ok {
+		return
+	}
+	if len(data) == 0 {
+		apiwrapper.SendBadRequest(ctx, "Document file is required")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	doc, err := h.sellerKYCUsecase.UploadDocument(ctx, userID, req.Type, fileName, data)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to upload document", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, doc)
+}
+
+// GetSellerDocument godoc
+// @Summary Download verification document
+// @Description Download one of the seller's own verification documents
+// @Tags seller
+// @Produce octet-stream
+// @Param id path int true "Document ID"
+// @Success 200 {file} file
+// @Router /api/v1/seller/kyc/document/{id} [get]
+func (h *Handler) GetSellerDocument(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	h.sendSellerDocument(ctx, userID)
+}
+
+// DeleteSellerDocument godoc
+// @Summary Delete verification document
+// @Description Delete a document that has not been reviewed yet
+// @Tags seller
+// @Produce json
+// @Param id path int true "Document ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/kyc/document/{id} [delete]
+func (h *Handler) DeleteSellerDocument(ctx *gin.Context) {
+	documentID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid document ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.sellerKYCUsecase.DeleteDocument(ctx, userID, documentID); err != nil {
+		h.sendKYCError(ctx, "Failed to delete document", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Document deleted successfully"})
+}
+
+// SubmitSellerKYC godoc
+// @Summary Submit seller verification
+// @Description Submit the onboarding details for review. Everything listed as missing has to be provided first.
+// @Tags seller
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/kyc/submit [post]
+func (h *Handler) SubmitSellerKYC(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	kyc, err := h.sellerKYCUsecase.Submit(ctx, userID)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to submit verification", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, kyc)
+}
+
+// GetSellerKYCForReview godoc
+// @Summary Get seller onboarding details
+// @Description Admin - Get a seller's business, bank and document details with the verification history
+// @Tags admin
+// @Produce json
+// @Param id path int true "Seller user ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/seller/{id}/kyc [get]
+func (h *Handler) GetSellerKYCForReview(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid seller ID")
+		return
+	}
+
+	kyc, err := h.sellerKYCUsecase.GetKYC(ctx, userID)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to get verification details", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, kyc)
+}
+
+// GetSellerDocumentForReview godoc
+// @Summary Download verification document
+// @Description Admin - Download a seller's verification document
+// @Tags admin
+// @Produce octet-stream
+// @Param id path int true "Document ID"
+// @Success 200 {file} file
+// @Router /api/v1/admin/seller/document/{id} [get]
+func (h *Handler) GetSellerDocumentForReview(ctx *gin.Context) {
+	h.sendSellerDocument(ctx, 0)
+}
+
+// ReviewSellerDocument godoc
+// @Summary Review verification document
+// @Description Admin - Approve or reject one verification document. A note is required when rejecting.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.ReviewSellerDocument true "Review data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/seller/document/review [post]
+func (h *Handler) ReviewSellerDocument(ctx *gin.Context) {
+	var req request.ReviewSellerDocument
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.ReviewerID = userID
+
+	if err := h.sellerKYCUsecase.ReviewDocument(ctx, req); err != nil {
+		h.sendKYCError(ctx, "Failed to review document", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Document reviewed successfully"})
+}
+
+// RequestSellerInfo godoc
+// @Summary Request more seller information
+// @Description Admin - Send a submitted seller back to update their details, with the reason
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.RequestSellerInfo true "Request data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/seller/request-info [post]
+func (h *Handler) RequestSellerInfo(ctx *gin.Context) {
+	var req request.RequestSellerInfo
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.ReviewerID = userID
+
+	if err := h.sellerKYCUsecase.RequestInfo(ctx, req); err != nil {
+		h.sendKYCError(ctx, "Failed to request seller information", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Information requested from seller"})
+}
+
+// VerifySeller godoc
+// @Summary Verify seller
+// @Description Admin - Verify a submitted seller once all of their documents are approved
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.VerifySeller true "Verification data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/seller/verify [post]
+func (h *Handler) VerifySeller(ctx *gin.Context) {
+	var req request.VerifySeller
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.ReviewerID = userID
+
+	if err := h.sellerKYCUsecase.VerifySeller(ctx, req); err != nil {
+		h.sendKYCError(ctx, "Failed to verify seller", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Seller verified successfully"})
+}
+
+// RejectSeller godoc
+// @Summary Reject seller
+// @Description Admin - Reject a submitted seller with a reason. The seller can correct their details and submit again.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body request.RejectSeller true "Rejection data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/admin/seller/reject [post]
+func (h *Handler) RejectSeller(ctx *gin.Context) {
+	var req request.RejectSeller
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+	req.ReviewerID = userID
+
+	if err := h.sellerKYCUsecase.RejectSeller(ctx, req); err != nil {
+		h.sendKYCError(ctx, "Failed to reject seller", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Seller rejected"})
+}
+
+// sendSellerDocument streams a document, userID 0 serves any seller's document
+func (h *Handler) sendSellerDocument(ctx *gin.Context, userID int) {
+	documentID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid document ID")
+		return
+	}
+
+	doc, body, err := h.sellerKYCUsecase.OpenDocument(ctx, userID, documentID)
+	if err != nil {
+		h.sendKYCError(ctx, "Failed to get document", err)
+		return
+	}
+	defer body.Close()
+
+	ctx.Header("Cache-Control", "private, no-store")
+	ctx.DataFromReader(http.StatusOK, doc.SizeBytes, doc.ContentType, body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename=%q`, doc.FileName),
+	})
+}
+
+func (h *Handler) sendKYCError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrSellerProfileNotFound),
+		errors.Is(err, usecase.ErrDocumentNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrKYCLocked),
+		errors.Is(err, usecase.ErrKYCIncomplete),
+		errors.Is(err, usecase.ErrInvalidSellerStatus),
+		errors.Is(err, usecase.ErrInvalidDocument):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
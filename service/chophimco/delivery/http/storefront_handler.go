@@ -0,0 +1,267 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IStorefrontHandler interface {
+	// Public storefront
+	GetStorefront(ctx *gin.Context)
+	GetShopProducts(ctx *gin.Context)
+	SearchShopProducts(ctx *gin.Context)
+
+	// Seller featured collections
+	GetShopCollections(ctx *gin.Context)
+	CreateShopCollection(ctx *gin.Context)
+	UpdateShopCollection(ctx *gin.Context)
+	DeleteShopCollection(ctx *gin.Context)
+	SetShopCollectionProducts(ctx *gin.Context)
+}
+
+// GetStorefront godoc
+// @Summary Get shop storefront
+// @Description Get a verified shop's public page: shop info, rating summary, follower and product counts,
+// @Description and its featured collections with a preview of their products. Signed-in users also see whether they follow it.
+// @Tags shop
+// @Produce json
+// @Param slug path string true "Shop slug"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/shop/{slug} [get]
+func (h *Handler) GetStorefront(ctx *gin.Context) {
+	// Guests see the storefront too, only is_following needs a user
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+
+	storefront, err := h.storefrontUsecase.GetStorefront(ctx, ctx.Param("slug"), viewerID)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to get shop", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, storefront)
+}
+
+// GetShopProducts godoc
+// @Summary Get shop products
+// @Description Paginated active, approved products of a shop. Accepts the same filters and sorting as /product/list
+// @Tags shop
+// @Produce json
+// @Param slug path string true "Shop slug"
+// @Param collection_id query int false "Only products of this featured collection"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Sort order" Enums(price_asc, price_desc, newest, rating, best_selling)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/shop/{slug}/products [get]
+func (h *Handler) GetShopProducts(ctx *gin.Context) {
+	var req request.ListProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+	req.Attributes = bindAttributeFilters(ctx.Request.URL.Query())
+
+	products, err := h.storefrontUsecase.ListShopProducts(ctx, ctx.Param("slug"), req)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to list shop products", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, products)
+}
+
+// SearchShopProducts godoc
+// @Summary Search shop products
+// @Description Full-text search within a shop's active, approved products. Accepts the same filters, sorting and pagination as /product/search
+// @Tags shop
+// @Produce json
+// @Param slug path string true "Shop slug"
+// @Param q query string true "Search query"
+// @Param collection_id query int false "Only products of this featured collection"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Sort order, defaults to relevance" Enums(price_asc, price_desc, newest, rating, best_selling)
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/shop/{slug}/search [get]
+func (h *Handler) SearchShopProducts(ctx *gin.Context) {
+	var req request.SearchProducts
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid query parameters")
+		return
+	}
+	req.Attributes = bindAttributeFilters(ctx.Request.URL.Query())
+
+	products, err := h.storefrontUsecase.SearchShopProducts(ctx, ctx.Param("slug"), req)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to search shop products", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, products)
+}
+
+// GetShopCollections godoc
+// @Summary Get shop collections
+// @Description Get the seller's featured collections in display order, hidden ones included
+// @Tags seller
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/collection [get]
+func (h *Handler) GetShopCollections(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	collections, err := h.storefrontUsecase.GetCollections(ctx, userID)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to get collections", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, collections)
+}
+
+// CreateShopCollection godoc
+// @Summary Create shop collection
+// @Description Create a featured collection on the seller's storefront, up to 10 per shop
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.CreateShopCollection true "Collection data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/collection [post]
+func (h *Handler) CreateShopCollection(ctx *gin.Context) {
+	var req request.CreateShopCollection
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	collection, err := h.storefrontUsecase.CreateCollection(ctx, userID, req)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to create collection", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, collection)
+}
+
+// UpdateShopCollection godoc
+// @Summary Update shop collection
+// @Description Rename, reorder, hide or show a featured collection
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.UpdateShopCollection true "Collection data"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/collection [put]
+func (h *Handler) UpdateShopCollection(ctx *gin.Context) {
+	var req request.UpdateShopCollection
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	collection, err := h.storefrontUsecase.UpdateCollection(ctx, userID, req)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to update collection", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, collection)
+}
+
+// DeleteShopCollection godoc
+// @Summary Delete shop collection
+// @Description Delete a featured collection, its products stay in the shop
+// @Tags seller
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/collection/{id} [delete]
+func (h *Handler) DeleteShopCollection(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid collection ID")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.storefrontUsecase.DeleteCollection(ctx, userID, id); err != nil {
+		h.sendStorefrontError(ctx, "Failed to delete collection", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Collection deleted"})
+}
+
+// SetShopCollectionProducts godoc
+// @Summary Set shop collection products
+// @Description Replace the products of a featured collection, up to 50, in display order
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.SetShopCollectionProducts true "Collection products"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/collection/products [put]
+func (h *Handler) SetShopCollectionProducts(ctx *gin.Context) {
+	var req request.SetShopCollectionProducts
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	collection, err := h.storefrontUsecase.SetCollectionProducts(ctx, userID, req)
+	if err != nil {
+		h.sendStorefrontError(ctx, "Failed to set collection products", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, collection)
+}
+
+func (h *Handler) sendStorefrontError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrShopNotFound),
+		errors.Is(err, usecase.ErrCollectionNotFound):
+		apiwrapper.SendNotFound(ctx, err.Error())
+	case errors.Is(err, usecase.ErrCollectionLimit),
+		errors.Is(err, usecase.ErrInvalidCollectionProducts):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
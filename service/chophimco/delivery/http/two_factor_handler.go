@@ -0,0 +1,227 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type ITwoFactorHandler interface {
+	CompleteTwoFactorLogin(ctx *gin.Context)
+	GetTwoFactorStatus(ctx *gin.Context)
+	SetupTwoFactor(ctx *gin.Context)
+	EnableTwoFactor(ctx *gin.Context)
+	DisableTwoFactor(ctx *gin.Context)
+	RegenerateRecoveryCodes(ctx *gin.Context)
+}
+
+// CompleteTwoFactorLogin godoc
+// @Summary Complete a two-factor login
+// @Description Start the session of a login challenge with an authenticator or recovery code. Responds
+// @Description like the login endpoint and sets the same cookies. A challenge takes 5 wrong codes,
+// @Description too many wrong codes over several logins lock the user's two-factor checks for a while.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.TwoFactorLogin true "Challenge token and code"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/login/2fa [post]
+func (h *Handler) CompleteTwoFactorLogin(ctx *gin.Context) {
+	var req request.TwoFactorLogin
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+	req.UserAgent = ctx.Request.UserAgent()
+	req.IPAddress = ctx.ClientIP()
+
+	login, err := h.twoFactorUsecase.CompleteLogin(ctx, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidTwoFactorCode) ||
+			errors.Is(err, usecase.ErrInvalidLoginChallenge) ||
+			errors.Is(err, usecase.ErrTwoFactorLocked) {
+			apiwrapper.SendUnauthorized(ctx, err.Error())
+			return
+		}
+		h.sendTwoFactorError(ctx, "Login failed", err)
+		return
+	}
+
+	setAuthCookies(ctx, &login.TokenResponse)
+	apiwrapper.SendSuccess(ctx, login)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Get two-factor status
+// @Description Whether two-factor authentication is on, required for the user's role, and how many
+// @Description recovery codes are left
+// @Tags user
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/2fa [get]
+func (h *Handler) GetTwoFactorStatus(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	status, err := h.twoFactorUsecase.GetStatus(ctx, userID)
+	if err != nil {
+		h.sendTwoFactorError(ctx, "Failed to get two-factor status", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, status)
+}
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor setup
+// @Description Create an authenticator secret and its otpauth:// provisioning URI to show as a QR code.
+// @Description It takes effect once confirmed with a code at /user/2fa/enable.
+// @Tags user
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/2fa/setup [post]
+func (h *Handler) SetupTwoFactor(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	setup, err := h.twoFactorUsecase.Setup(ctx, userID)
+	if err != nil {
+		h.sendTwoFactorError(ctx, "Failed to set up two-factor authentication", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, setup)
+}
+
+// EnableTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description Confirm the authenticator with a code and turn two-factor authentication on. Returns
+// @Description recovery codes, shown only this once. Refresh the session afterwards for access
+// @Description tokens that pass two-factor checks.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.TwoFactorCode true "Authenticator code"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/2fa/enable [post]
+func (h *Handler) EnableTwoFactor(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	var req request.TwoFactorCode
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+	sessionID, _ := auth.GetSessionIDFromContext(ctx)
+
+	codes, err := h.twoFactorUsecase.Enable(ctx, userID, sessionID, req)
+	if err != nil {
+		h.sendTwoFactorError(ctx, "Failed to enable two-factor authentication", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, codes)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with an authenticator or recovery code. Not allowed
+// @Description for roles that require it.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.TwoFactorCode true "Authenticator or recovery code"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	var req request.TwoFactorCode
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	if err := h.twoFactorUsecase.Disable(ctx, userID, req); err != nil {
+		h.sendTwoFactorError(ctx, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes with new ones, confirmed with an authenticator code
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.TwoFactorCode true "Authenticator code"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 400 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	var req request.TwoFactorCode
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	codes, err := h.twoFactorUsecase.RegenerateRecoveryCodes(ctx, userID, req)
+	if err != nil {
+		h.sendTwoFactorError(ctx, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, codes)
+}
+
+func (h *Handler) sendTwoFactorError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, usecase.ErrTwoFactorNotEnabled),
+		errors.Is(err, usecase.ErrTwoFactorNotSetUp),
+		errors.Is(err, usecase.ErrTwoFactorRequired),
+		errors.Is(err, usecase.ErrInvalidTwoFactorCode),
+		errors.Is(err, usecase.ErrTwoFactorLocked):
+		apiwrapper.SendBadRequest(ctx, err.Error())
+	default:
+		logger.EnhanceWith(ctx).Errorw(message, "error", err)
+		apiwrapper.SendInternalError(ctx, message)
+	}
+}
@@ -1,23 +1,39 @@
 package http
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
 	"github.com/leehai1107/chophimco-server/pkg/logger"
 	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+const (
+	accessTokenCookie  = "jwt_token"
+	refreshTokenCookie = "refresh_token"
+	// The refresh token cookie is only sent to the refresh and logout endpoints
+	refreshTokenCookiePath = "/api/v1/user"
 )
 
 type IUserHandler interface {
 	Login(ctx *gin.Context)
 	Register(ctx *gin.Context)
 	GetProfile(ctx *gin.Context)
+	RefreshToken(ctx *gin.Context)
 	Logout(ctx *gin.Context)
+	LogoutAll(ctx *gin.Context)
 }
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate a user and return a token
+// @Description Authenticate a user and start a session. Returns a short-lived access token and a
+// @Description single-use refresh token, also set as HTTP-only cookies. Users with two-factor
+// @Description authentication instead get a challenge token to complete at /user/login/2fa.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -32,25 +48,27 @@ func (h *Handler) Login(ctx *gin.Context) {
 		apiwrapper.SendBadRequest(ctx, "Invalid request format")
 		return
 	}
+	req.UserAgent = ctx.Request.UserAgent()
+	req.IPAddress = ctx.ClientIP()
 
 	response, err := h.userUsecase.Login(ctx, req)
+	if errors.Is(err, usecase.ErrTwoFactorLocked) {
+		apiwrapper.SendUnauthorized(ctx, err.Error())
+		return
+	}
 	if err != nil {
 		logger.EnhanceWith(ctx).Errorw("Login failed", "error", err, "email", req.Email)
 		apiwrapper.SendUnauthorized(ctx, "Login failed")
 		return
 	}
 
-	// Set JWT token in HTTP-only cookie
-	ctx.SetCookie(
-		"jwt_token",    // name
-		response.Token, // value
-		3600*24,        // maxAge in seconds (24 hours)
-		"/",            // path
-		"",             // domain (empty = current domain)
-		false,          // secure (set to true in production with HTTPS)
-		true,           // httpOnly
-	)
+	// No session yet, the challenge is completed at /user/login/2fa
+	if response.Challenge != nil {
+		apiwrapper.SendSuccess(ctx, response.Challenge)
+		return
+	}
 
+	setAuthCookies(ctx, &response.TokenResponse)
 	apiwrapper.SendSuccess(ctx, response)
 }
 
@@ -114,24 +132,136 @@ func (h *Handler) GetProfile(ctx *gin.Context) {
 	apiwrapper.SendSuccess(ctx, profile)
 }
 
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token, from the body or the refresh token cookie, for a new access token and refresh token.
+// @Description Each refresh token works once. Presenting a used one logs its session out on every device holding it.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body request.RefreshToken false "Refresh token, when not sent as a cookie"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/refresh [post]
+func (h *Handler) RefreshToken(ctx *gin.Context) {
+	refreshToken, ok := readRefreshToken(ctx)
+	if !ok {
+		return
+	}
+	if refreshToken == "" {
+		apiwrapper.SendUnauthorized(ctx, "refresh token required")
+		return
+	}
+
+	tokens, err := h.sessionUsecase.Refresh(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidRefreshToken) || errors.Is(err, usecase.ErrRefreshTokenReused) {
+			clearAuthCookies(ctx)
+			apiwrapper.SendUnauthorized(ctx, err.Error())
+			return
+		}
+		logger.EnhanceWith(ctx).Errorw("Failed to refresh token", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to refresh token")
+		return
+	}
+
+	setAuthCookies(ctx, tokens)
+	apiwrapper.SendSuccess(ctx, tokens)
+}
+
 // Logout godoc
 // @Summary User logout
-// @Description Clear JWT token cookie
+// @Description End the current session, identified by the access token or else the refresh token, and clear the token cookies.
+// @Description Its access and refresh tokens stop working right away.
 // @Tags user
+// @Accept json
 // @Produce json
+// @Param request body request.RefreshToken false "Refresh token, when not sent as a cookie"
 // @Success 200 {object} apiwrapper.APIResponse
 // @Router /api/v1/user/logout [post]
 func (h *Handler) Logout(ctx *gin.Context) {
-	// Clear the JWT cookie
-	ctx.SetCookie(
-		"jwt_token",
-		"",
-		-1, // maxAge -1 deletes the cookie
-		"/",
-		"",
-		false,
-		true,
-	)
+	refreshToken, ok := readRefreshToken(ctx)
+	if !ok {
+		return
+	}
+	sessionID, _ := auth.GetSessionIDFromContext(ctx)
 
+	if err := h.sessionUsecase.Logout(ctx, sessionID, refreshToken); err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to log out", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to log out")
+		return
+	}
+
+	clearAuthCookies(ctx)
 	apiwrapper.SendSuccess(ctx, gin.H{"message": "Logged out successfully"})
 }
+
+// LogoutAll godoc
+// @Summary Log out all devices
+// @Description End every session of the user, including the current one
+// @Tags user
+// @Produce json
+// @Success 200 {object} apiwrapper.APIResponse
+// @Failure 401 {object} apiwrapper.APIResponse
+// @Router /api/v1/user/logout-all [post]
+func (h *Handler) LogoutAll(ctx *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	if err := h.sessionUsecase.LogoutAll(ctx, userID); err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to log out all devices", "error", err)
+		apiwrapper.SendInternalError(ctx, "Failed to log out all devices")
+		return
+	}
+
+	clearAuthCookies(ctx)
+	apiwrapper.SendSuccess(ctx, gin.H{"message": "Logged out of all devices"})
+}
+
+// readRefreshToken takes the refresh token from the JSON body, falling back to the cookie.
+// It returns false after sending an error response.
+func readRefreshToken(ctx *gin.Context) (string, bool) {
+	var req request.RefreshToken
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			apiwrapper.SendBadRequest(ctx, "Invalid request format")
+			return "", false
+		}
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken, true
+	}
+
+	refreshToken, _ := ctx.Cookie(refreshTokenCookie)
+	return refreshToken, true
+}
+
+// setAuthCookies stores the session's tokens in HTTP-only cookies that expire with them
+func setAuthCookies(ctx *gin.Context, tokens *response.TokenResponse) {
+	setAuthCookie(ctx, accessTokenCookie, tokens.Token, "/", time.Until(tokens.ExpiresAt))
+	setAuthCookie(ctx, refreshTokenCookie, tokens.RefreshToken, refreshTokenCookiePath, time.Until(tokens.RefreshExpiresAt))
+}
+
+func clearAuthCookies(ctx *gin.Context) {
+	setAuthCookie(ctx, accessTokenCookie, "", "/", -1)
+	setAuthCookie(ctx, refreshTokenCookie, "", refreshTokenCookiePath, -1)
+}
+
+func setAuthCookie(ctx *gin.Context, name, value, path string, maxAge time.Duration) {
+	seconds := int(maxAge.Seconds())
+	if maxAge < 0 {
+		seconds = -1 // deletes the cookie
+	}
+	ctx.SetCookie(
+		name,
+		value,
+		seconds,
+		path,
+		"",    // domain (empty = current domain)
+		false, // secure (set to true in production with HTTPS)
+		true,  // httpOnly
+	)
+}
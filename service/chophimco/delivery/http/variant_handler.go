@@ -0,0 +1,66 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leehai1107/chophimco-server/pkg/apiwrapper"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/usecase"
+)
+
+type IVariantHandler interface {
+	GenerateVariantMatrix(ctx *gin.Context)
+}
+
+// GenerateVariantMatrix godoc
+// @Summary Generate product variant matrix
+// @Description Create one variant per combination of option axes, e.g. 3 switches x 2 colours x 2 connection types.
+// @Description Axis codes are "switch" (values are switch IDs) or attribute codes of the product's category.
+// @Description Regenerating keeps the SKU and stock of variants whose options still exist, prices are reset
+// @Description to the default unless overridden. Use dry_run to preview.
+// @Tags seller
+// @Accept json
+// @Produce json
+// @Param request body request.GenerateVariantMatrix true "Matrix definition"
+// @Success 200 {object} apiwrapper.APIResponse
+// @Router /api/v1/seller/product/variant/matrix [post]
+func (h *Handler) GenerateVariantMatrix(ctx *gin.Context) {
+	var req request.GenerateVariantMatrix
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiwrapper.SendBadRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		apiwrapper.SendUnauthorized(ctx, "Unauthorized")
+		return
+	}
+
+	// Admins may generate variants for any product
+	sellerID := userID
+	if role, _ := auth.GetUserRoleFromContext(ctx); role == "admin" {
+		sellerID = 0
+	}
+
+	result, err := h.variantUsecase.GenerateVariantMatrix(ctx, sellerID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrProductNotFound):
+			apiwrapper.SendNotFound(ctx, err.Error())
+		case errors.Is(err, usecase.ErrInvalidVariantMatrix),
+			errors.Is(err, usecase.ErrInvalidAttribute),
+			errors.Is(err, usecase.ErrSKUTaken):
+			apiwrapper.SendBadRequest(ctx, err.Error())
+		default:
+			logger.EnhanceWith(ctx).Errorw("Failed to generate variant matrix", "error", err)
+			apiwrapper.SendInternalError(ctx, "Failed to generate variant matrix")
+		}
+		return
+	}
+
+	apiwrapper.SendSuccess(ctx, result)
+}
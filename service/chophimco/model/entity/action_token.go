@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+)
+
+// What an action token authorizes
+const (
+	ActionVerifyEmail    = "verify_email"
+	ActionResetPassword  = "reset_password"
+	ActionLoginTwoFactor = "login_2fa" // the password was right, the second factor is pending
+)
+
+// ActionToken records a signed one-time token issued to a user. The token itself is a JWT
+// whose jti is the ID, the record only tracks whether it has been used.
+type ActionToken struct {
+	ID        string     `gorm:"primaryKey;column:id;type:varchar(36)"`
+	UserID    int        `gorm:"column:user_id;not null;index"`
+	Purpose   string     `gorm:"column:purpose;type:varchar(20);not null"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	Attempts  int        `gorm:"column:attempts;not null;default:0"` // wrong codes entered against it
+	CreatedAt time.Time  `gorm:"column:created_at;default:now()"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID;references:ID"`
+}
@@ -0,0 +1,38 @@
+package entity
+
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+// AttributeDefinition describes a variant attribute of a category and its subcategories.
+// Definitions without a category apply to every product. A subcategory may redefine a
+// code, the definition closest to the product's category wins.
+type AttributeDefinition struct {
+	ID            int      `gorm:"primaryKey;column:id;autoIncrement"`
+	CategoryID    *int     `gorm:"column:category_id;index"`
+	Code          string   `gorm:"column:code;type:varchar(50);not null"`
+	Name          string   `gorm:"column:name;not null"`
+	Type          string   `gorm:"column:type;type:varchar(20);not null;default:text"`
+	AllowedValues []string `gorm:"column:allowed_values;type:jsonb;serializer:json"` // enum only
+	Unit          string   `gorm:"column:unit"`
+	IsRequired    bool     `gorm:"column:is_required;default:false"`
+	IsFilterable  bool     `gorm:"column:is_filterable;default:false"`
+	DisplayOrder  int      `gorm:"column:display_order;not null;default:0"`
+
+	// Relations
+	Category *Category `gorm:"foreignKey:CategoryID;references:ID"`
+}
+
+// ProductVariantAttribute is a validated attribute value of a variant, stored in canonical form
+type ProductVariantAttribute struct {
+	ID                    int    `gorm:"primaryKey;column:id;autoIncrement"`
+	ProductVariantID      int    `gorm:"column:product_variant_id;not null;uniqueIndex:idx_variant_attribute"`
+	AttributeDefinitionID int    `gorm:"column:attribute_definition_id;not null;uniqueIndex:idx_variant_attribute;index"`
+	Value                 string `gorm:"column:value;not null"`
+
+	// Relations
+	Definition *AttributeDefinition `gorm:"foreignKey:AttributeDefinitionID;references:ID"`
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+)
+
+// Why a login session ended
+const (
+	SessionRevokedLogout    = "logout"
+	SessionRevokedLogoutAll = "logout_all"  // the user logged out of all devices
+	SessionRevokedReuse     = "token_reuse" // a rotated refresh token was presented again
+)
+
+// AuthSession is one login of a user on a device. Access tokens carry its ID, so revoking
+// the session revokes them along with its refresh tokens.
+type AuthSession struct {
+	ID           string     `gorm:"primaryKey;column:id;type:varchar(36)"`
+	UserID       int        `gorm:"column:user_id;not null;index"`
+	UserAgent    string     `gorm:"column:user_agent;type:text"`
+	IPAddress    string     `gorm:"column:ip_address;type:varchar(64)"`
+	CreatedAt    time.Time  `gorm:"column:created_at;default:now()"`
+	LastUsedAt   time.Time  `gorm:"column:last_used_at;default:now()"` // last login or refresh
+	RevokedAt    *time.Time `gorm:"column:revoked_at"`
+	RevokeReason string     `gorm:"column:revoke_reason;type:varchar(20)"`
+	TwoFactor    bool       `gorm:"column:two_factor;not null;default:false"` // the login passed two-factor authentication
+
+	// Relations
+	User          *User          `gorm:"foreignKey:UserID;references:ID"`
+	RefreshTokens []RefreshToken `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+}
+
+// RefreshToken is one token in a session's rotation chain. Only its SHA-256 hash is stored.
+type RefreshToken struct {
+	ID        int        `gorm:"primaryKey;column:id;autoIncrement"`
+	SessionID string     `gorm:"column:session_id;type:varchar(36);not null;index"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"` // set when exchanged, presenting it again is reuse
+	CreatedAt time.Time  `gorm:"column:created_at;default:now()"`
+
+	// Relations
+	Session *AuthSession `gorm:"foreignKey:SessionID;references:ID"`
+}
@@ -24,3 +24,14 @@ type CartItem struct {
 	Cart           *Cart           `gorm:"foreignKey:CartID;references:ID"`
 	ProductVariant *ProductVariant `gorm:"foreignKey:ProductVariantID;references:ID"`
 }
+
+// CartAddEvent records an item added to a cart. Cart items go away at checkout, the events
+// are kept for conversion analytics.
+type CartAddEvent struct {
+	ID               int       `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID           int       `gorm:"column:user_id;not null"`
+	SellerID         int       `gorm:"column:seller_id;not null;index:idx_cart_add_events_seller"`
+	ProductVariantID int       `gorm:"column:product_variant_id;not null"`
+	Quantity         int       `gorm:"column:quantity;not null"`
+	CreatedAt        time.Time `gorm:"column:created_at;default:now();index:idx_cart_add_events_seller"`
+}
@@ -1,6 +1,13 @@
 package entity
 
 type Category struct {
-	ID   int    `gorm:"primaryKey;column:id;autoIncrement"`
-	Name string `gorm:"column:name;not null"`
+	ID           int    `gorm:"primaryKey;column:id;autoIncrement"`
+	ParentID     *int   `gorm:"column:parent_id;index"`
+	Name         string `gorm:"column:name;not null"`
+	Slug         string `gorm:"column:slug;type:varchar(120);uniqueIndex"`
+	DisplayOrder int    `gorm:"column:display_order;not null;default:0"`
+
+	// Relations
+	Parent   *Category  `gorm:"foreignKey:ParentID;references:ID"`
+	Children []Category `gorm:"foreignKey:ParentID"`
 }
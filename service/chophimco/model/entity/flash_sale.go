@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+)
+
+type FlashSale struct {
+	ID          int       `gorm:"primaryKey;column:id;autoIncrement"`
+	Name        string    `gorm:"column:name;not null"`
+	Description string    `gorm:"column:description;type:text"`
+	StartAt     time.Time `gorm:"column:start_at;not null;index"`
+	EndAt       time.Time `gorm:"column:end_at;not null;index"`
+	IsCancelled bool      `gorm:"column:is_cancelled;default:false"`
+	CreatedBy   int       `gorm:"column:created_by;not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;default:now()"`
+
+	// Relations
+	Items []FlashSaleItem `gorm:"foreignKey:FlashSaleID"`
+}
+
+type FlashSaleItem struct {
+	ID               int     `gorm:"primaryKey;column:id;autoIncrement"`
+	FlashSaleID      int     `gorm:"column:flash_sale_id;not null;uniqueIndex:idx_flash_sale_item_variant"`
+	ProductVariantID int     `gorm:"column:product_variant_id;not null;uniqueIndex:idx_flash_sale_item_variant"`
+	SalePrice        float64 `gorm:"column:sale_price;not null"`
+	SaleQuantity     int     `gorm:"column:sale_quantity;not null;check:sale_quantity > 0"`
+	SoldQuantity     int     `gorm:"column:sold_quantity;default:0;check:sold_quantity <= sale_quantity"`
+	PerUserLimit     int     `gorm:"column:per_user_limit;default:1"`
+
+	// Relations
+	FlashSale      *FlashSale      `gorm:"foreignKey:FlashSaleID;references:ID"`
+	ProductVariant *ProductVariant `gorm:"foreignKey:ProductVariantID;references:ID"`
+}
+
+// FlashSalePurchase tracks how many units of a flash sale item a user has bought,
+// one row per user and item so the per-user limit can be enforced atomically.
+type FlashSalePurchase struct {
+	ID              int       `gorm:"primaryKey;column:id;autoIncrement"`
+	FlashSaleItemID int       `gorm:"column:flash_sale_item_id;not null;uniqueIndex:idx_flash_sale_purchase_user"`
+	UserID          int       `gorm:"column:user_id;not null;uniqueIndex:idx_flash_sale_purchase_user"`
+	Quantity        int       `gorm:"column:quantity;not null;check:quantity >= 0"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;default:now()"`
+
+	// Relations
+	FlashSaleItem *FlashSaleItem `gorm:"foreignKey:FlashSaleItemID;references:ID"`
+	User          *User          `gorm:"foreignKey:UserID;references:ID"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportJob tracks a bulk product import of a seller. Validation problems are kept per row
+// in Errors, a job with errors imports nothing.
+type ImportJob struct {
+	ID         int              `gorm:"primaryKey;column:id;autoIncrement"`
+	SellerID   int              `gorm:"column:seller_id;not null;index"`
+	FileName   string           `gorm:"column:file_name"`
+	Format     string           `gorm:"column:format;type:varchar(10);not null"`
+	DryRun     bool             `gorm:"column:dry_run;default:false"`
+	Status     string           `gorm:"column:status;type:varchar(20);not null;default:pending"`
+	TotalRows  int              `gorm:"column:total_rows;default:0"`
+	Products   int              `gorm:"column:products;default:0"`
+	Variants   int              `gorm:"column:variants;default:0"`
+	Images     int              `gorm:"column:images;default:0"`
+	Errors     []ImportRowError `gorm:"column:errors;type:jsonb;serializer:json"`
+	Message    string           `gorm:"column:message;type:text"`
+	CreatedAt  time.Time        `gorm:"column:created_at;default:now()"`
+	FinishedAt *time.Time       `gorm:"column:finished_at"`
+
+	// Relations
+	Seller *User `gorm:"foreignKey:SellerID;references:ID"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ledger accounts. Every entry debits and credits these by the same total.
+const (
+	AccountPlatformCash      = "platform_cash"      // asset, money collected from buyers
+	AccountSellerPayable     = "seller_payable"     // liability, earnings owed to sellers
+	AccountPayoutsInTransit  = "payouts_in_transit" // liability, payouts sent to the bank but not confirmed
+	AccountCommissionRevenue = "commission_revenue" // income
+	AccountPromotionExpense  = "promotion_expense"  // expense, platform-funded vouchers
+)
+
+const (
+	LedgerEntrySale           = "sale"
+	LedgerEntryRefund         = "refund"
+	LedgerEntryPayout         = "payout"          // seller earnings moved into a payout
+	LedgerEntryPayoutPaid     = "payout_paid"     // the bank confirmed the transfer
+	LedgerEntryPayoutReversed = "payout_reversed" // the transfer failed or was cancelled, earnings go back
+)
+
+// Ledger line components, what a line stands for in seller earnings
+const (
+	ComponentGross              = "gross"
+	ComponentSellerDiscount     = "seller_discount"
+	ComponentPlatformDiscount   = "platform_discount"
+	ComponentCommission         = "commission"
+	ComponentRefund             = "refund"
+	ComponentCommissionReversal = "commission_reversal"
+	ComponentPayout             = "payout"
+)
+
+// LedgerEntry is one balanced journal entry. ReferenceKey makes recording it idempotent.
+type LedgerEntry struct {
+	ID           int       `gorm:"primaryKey;column:id;autoIncrement"`
+	Type         string    `gorm:"column:type;type:varchar(20);not null;index"`
+	ReferenceKey string    `gorm:"column:reference_key;not null;uniqueIndex"`
+	SellerID     *int      `gorm:"column:seller_id;index"`
+	OrderID      *int      `gorm:"column:order_id;index"`
+	RefundID     *int      `gorm:"column:refund_id"`
+	PayoutID     *int      `gorm:"column:payout_id"`
+	Description  string    `gorm:"column:description"`
+	CreatedAt    time.Time `gorm:"column:created_at;default:now();index"`
+
+	// Relations
+	Lines []LedgerLine `gorm:"foreignKey:EntryID;constraint:OnDelete:CASCADE"`
+}
+
+type LedgerLine struct {
+	ID          int       `gorm:"primaryKey;column:id;autoIncrement"`
+	EntryID     int       `gorm:"column:entry_id;not null;index"`
+	Account     string    `gorm:"column:account;type:varchar(50);not null;index:idx_ledger_lines_account"`
+	SellerID    *int      `gorm:"column:seller_id;index:idx_ledger_lines_account"`
+	Component   string    `gorm:"column:component;type:varchar(30);not null"`
+	OrderItemID *int      `gorm:"column:order_item_id;index"`
+	Debit       float64   `gorm:"column:debit;type:decimal(12,2);not null;default:0"`
+	Credit      float64   `gorm:"column:credit;type:decimal(12,2);not null;default:0"`
+	CreatedAt   time.Time `gorm:"column:created_at;default:now()"`
+}
+
+// SaleReferenceKey identifies the sale entry of one seller's items in an order
+func SaleReferenceKey(orderID, sellerID int) string {
+	return fmt.Sprintf("sale:%d:%d", orderID, sellerID)
+}
+
+func RefundReferenceKey(refundID, sellerID int) string {
+	return fmt.Sprintf("refund:%d:%d", refundID, sellerID)
+}
+
+func PayoutReferenceKey(entryType string, payoutID int) string {
+	return fmt.Sprintf("%s:%d", entryType, payoutID)
+}
+
+// CommissionRule overrides the default commission rate for one seller or one category
+// and its subcategories. A seller rule wins over a category rule.
+type CommissionRule struct {
+	ID         int       `gorm:"primaryKey;column:id;autoIncrement"`
+	SellerID   *int      `gorm:"column:seller_id;uniqueIndex"`
+	CategoryID *int      `gorm:"column:category_id;uniqueIndex"`
+	Rate       float64   `gorm:"column:rate;type:decimal(5,2);not null"` // percent
+	UpdatedBy  int       `gorm:"column:updated_by"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;default:now()"`
+
+	// Relations
+	Seller   *User     `gorm:"foreignKey:SellerID;references:ID"`
+	Category *Category `gorm:"foreignKey:CategoryID;references:ID"`
+}
+
+type OrderRefund struct {
+	ID        int       `gorm:"primaryKey;column:id;autoIncrement"`
+	OrderID   int       `gorm:"column:order_id;not null;index"`
+	Amount    float64   `gorm:"column:amount;type:decimal(12,2);not null"` // returned to the buyer
+	Reason    string    `gorm:"column:reason;type:text"`
+	CreatedBy int       `gorm:"column:created_by;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;default:now()"`
+
+	// Relations
+	Items []OrderRefundItem `gorm:"foreignKey:RefundID;constraint:OnDelete:CASCADE"`
+}
+
+type OrderRefundItem struct {
+	ID          int     `gorm:"primaryKey;column:id;autoIncrement"`
+	RefundID    int     `gorm:"column:refund_id;not null;index"`
+	OrderItemID int     `gorm:"column:order_item_id;not null"`
+	Quantity    int     `gorm:"column:quantity;not null;check:quantity > 0"`
+	Amount      float64 `gorm:"column:amount;type:decimal(12,2);not null"`
+}
+
+const (
+	PayoutBatchPending    = "pending"
+	PayoutBatchProcessing = "processing"
+	PayoutBatchCompleted  = "completed"
+	PayoutBatchCancelled  = "cancelled"
+)
+
+const (
+	PayoutPending   = "pending"
+	PayoutPaid      = "paid"
+	PayoutFailed    = "failed"
+	PayoutCancelled = "cancelled"
+)
+
+// PayoutBatch pays every seller their balance from earnings recorded up to PeriodEnd
+type PayoutBatch struct {
+	ID          int        `gorm:"primaryKey;column:id;autoIncrement"`
+	PeriodEnd   time.Time  `gorm:"column:period_end;not null"`
+	Status      string     `gorm:"column:status;type:varchar(20);not null;default:pending"`
+	TotalAmount float64    `gorm:"column:total_amount;type:decimal(12,2);default:0"`
+	CreatedBy   *int       `gorm:"column:created_by"` // nil for scheduled batches
+	Note        string     `gorm:"column:note;type:text"`
+	CreatedAt   time.Time  `gorm:"column:created_at;default:now()"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+
+	// Relations
+	Payouts []Payout `gorm:"foreignKey:BatchID"`
+}
+
+// Payout is one seller's transfer in a batch, with the bank details used for it
+type Payout struct {
+	ID                int        `gorm:"primaryKey;column:id;autoIncrement"`
+	BatchID           int        `gorm:"column:batch_id;not null;index"`
+	SellerID          int        `gorm:"column:seller_id;not null;index"`
+	Amount            float64    `gorm:"column:amount;type:decimal(12,2);not null"`
+	Status            string     `gorm:"column:status;type:varchar(20);not null;default:pending"`
+	BankName          string     `gorm:"column:bank_name"`
+	BankAccountName   string     `gorm:"column:bank_account_name"`
+	BankAccountNumber string     `gorm:"column:bank_account_number"`
+	TransferReference string     `gorm:"column:transfer_reference"`
+	FailureReason     string     `gorm:"column:failure_reason;type:text"`
+	CreatedAt         time.Time  `gorm:"column:created_at;default:now()"`
+	SettledAt         *time.Time `gorm:"column:settled_at"`
+
+	// Relations
+	Seller *User `gorm:"foreignKey:SellerID;references:ID"`
+}
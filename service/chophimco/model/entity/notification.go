@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	NotificationProductApproved = "product_approved"
+	NotificationProductRejected = "product_rejected"
+
+	NotificationSellerVerified      = "seller_verified"
+	NotificationSellerRejected      = "seller_rejected"
+	NotificationSellerInfoRequested = "seller_info_requested"
+	NotificationDocumentReviewed    = "seller_document_reviewed"
+
+	NotificationPayoutPaid   = "payout_paid"
+	NotificationPayoutFailed = "payout_failed"
+
+	NotificationShopProductLaunched = "shop_product_launched"
+
+	NotificationReviewReplied = "review_replied"
+	NotificationReviewRemoved = "review_removed"
+)
+
+// Notification is an in-app message for a user, also pushed over websocket when it is created
+type Notification struct {
+	ID            int        `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID        int        `gorm:"column:user_id;not null;index"`
+	Type          string     `gorm:"column:type;type:varchar(50);not null"`
+	Title         string     `gorm:"column:title;not null"`
+	Message       string     `gorm:"column:message;type:text"`
+	ReferenceType string     `gorm:"column:reference_type;type:varchar(50)"` // e.g. "product"
+	ReferenceID   *int       `gorm:"column:reference_id"`
+	IsRead        bool       `gorm:"column:is_read;default:false"`
+	CreatedAt     time.Time  `gorm:"column:created_at;default:now()"`
+	ReadAt        *time.Time `gorm:"column:read_at"`
+}
@@ -5,14 +5,15 @@ import (
 )
 
 type Order struct {
-	ID              int       `gorm:"primaryKey;column:id;autoIncrement"`
-	UserID          int       `gorm:"column:user_id;not null"`
-	VoucherID       *int      `gorm:"column:voucher_id"`
-	DiscountAmount  float64   `gorm:"column:discount_amount;default:0"`
-	TotalAmount     float64   `gorm:"column:total_amount;not null"`
-	Status          string    `gorm:"column:status;not null"` // pending, paid, shipped, completed, cancelled
-	ShippingAddress string    `gorm:"column:shipping_address;type:text"`
-	CreatedAt       time.Time `gorm:"column:created_at;default:now()"`
+	ID              int        `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID          int        `gorm:"column:user_id;not null"`
+	VoucherID       *int       `gorm:"column:voucher_id"`
+	DiscountAmount  float64    `gorm:"column:discount_amount;default:0"`
+	TotalAmount     float64    `gorm:"column:total_amount;not null"`
+	Status          string     `gorm:"column:status;not null"` // pending, paid, shipped, completed, cancelled, refunded
+	ShippingAddress string     `gorm:"column:shipping_address;type:text"`
+	CreatedAt       time.Time  `gorm:"column:created_at;default:now();index"`
+	CompletedAt     *time.Time `gorm:"column:completed_at"` // when the order was marked completed
 
 	// Relations
 	User       *User       `gorm:"foreignKey:UserID;references:ID"`
@@ -23,10 +24,13 @@ type Order struct {
 
 type OrderItem struct {
 	ID               int     `gorm:"primaryKey;column:id;autoIncrement"`
-	OrderID          int     `gorm:"column:order_id;not null"`
-	ProductVariantID int     `gorm:"column:product_variant_id;not null"`
+	OrderID          int     `gorm:"column:order_id;not null;index"`
+	ProductVariantID int     `gorm:"column:product_variant_id;not null;index"`
 	Price            float64 `gorm:"column:price;not null"`
+	ListPrice        float64 `gorm:"column:list_price;default:0"` // regular price, above Price when the seller marked it down
 	Quantity         int     `gorm:"column:quantity;not null;check:quantity > 0"`
+	RefundedQuantity int     `gorm:"column:refunded_quantity;default:0"`
+	FlashSaleItemID  *int    `gorm:"column:flash_sale_item_id;index"` // the units go back to the sale when the order is cancelled
 
 	// Relations
 	Order          *Order          `gorm:"foreignKey:OrderID;references:ID"`
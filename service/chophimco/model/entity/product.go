@@ -18,10 +18,23 @@ type Product struct {
 	CreatedAt       time.Time  `gorm:"column:created_at;default:now()"`
 	ApprovedAt      *time.Time `gorm:"column:approved_at"`
 
+	// Review aggregates, kept up to date by the review repository as reviews change.
+	// They are read-only here so saving a product never overwrites them with stale values.
+	AverageRating float64 `gorm:"->;column:average_rating;type:decimal(3,2);default:0"`
+	ReviewCount   int     `gorm:"->;column:review_count;default:0"`
+	Rating1Count  int     `gorm:"->;column:rating_1_count;default:0"`
+	Rating2Count  int     `gorm:"->;column:rating_2_count;default:0"`
+	Rating3Count  int     `gorm:"->;column:rating_3_count;default:0"`
+	Rating4Count  int     `gorm:"->;column:rating_4_count;default:0"`
+	Rating5Count  int     `gorm:"->;column:rating_5_count;default:0"`
+
 	// Relations
 	Seller   *User            `gorm:"foreignKey:SellerID;references:ID"`
 	Category *Category        `gorm:"foreignKey:CategoryID;references:ID"`
 	Brand    *Brand           `gorm:"foreignKey:BrandID;references:ID"`
 	Variants []ProductVariant `gorm:"foreignKey:ProductID"`
 	Images   []ProductImage   `gorm:"foreignKey:ProductID"`
+
+	// Revisions is only loaded where moderation state matters, e.g. the seller's own products
+	Revisions []ProductRevision `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
 }
@@ -7,12 +7,23 @@ import (
 type ProductImage struct {
 	ID           int       `gorm:"primaryKey;column:id;autoIncrement"`
 	ProductID    int       `gorm:"column:product_id;not null"`
-	ImageURL     string    `gorm:"column:image_url;type:text;not null"`
+	ImageURL     string    `gorm:"column:image_url;type:text;not null"` // original
 	IsPrimary    bool      `gorm:"column:is_primary;default:false"`
 	DisplayOrder int       `gorm:"column:display_order;default:0"`
 	AltText      string    `gorm:"column:alt_text"`
 	CreatedAt    time.Time `gorm:"column:created_at;default:now()"`
 
+	// Resized renditions, only set for uploaded images. External URLs leave them empty.
+	ThumbnailURL string `gorm:"column:thumbnail_url;type:text"`
+	SmallURL     string `gorm:"column:small_url;type:text"`
+	MediumURL    string `gorm:"column:medium_url;type:text"`
+	LargeURL     string `gorm:"column:large_url;type:text"`
+	StorageKey   string `gorm:"column:storage_key"` // key prefix of the stored files
+	ContentType  string `gorm:"column:content_type"`
+	Width        int    `gorm:"column:width;default:0"`
+	Height       int    `gorm:"column:height;default:0"`
+	SizeBytes    int64  `gorm:"column:size_bytes;default:0"`
+
 	// Relations
 	Product *Product `gorm:"foreignKey:ProductID;references:ID"`
 }
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	RevisionPending    = "pending"
+	RevisionApproved   = "approved"
+	RevisionRejected   = "rejected"
+	RevisionSuperseded = "superseded" // replaced by a newer edit before it was reviewed
+)
+
+// ProductRevision is a snapshot of the moderated product fields. Every submission of a seller
+// creates one, the product row keeps serving the last approved content until a revision is approved.
+type ProductRevision struct {
+	ID             int        `gorm:"primaryKey;column:id;autoIncrement"`
+	ProductID      int        `gorm:"column:product_id;not null;uniqueIndex:idx_product_revisions_number,priority:1"`
+	RevisionNumber int        `gorm:"column:revision_number;not null;uniqueIndex:idx_product_revisions_number,priority:2"`
+	SubmittedBy    int        `gorm:"column:submitted_by;not null"`
+	Name           string     `gorm:"column:name;not null"`
+	CategoryID     *int       `gorm:"column:category_id"`
+	BrandID        *int       `gorm:"column:brand_id"`
+	Description    string     `gorm:"column:description;type:text"`
+	BasePrice      float64    `gorm:"column:base_price;not null"`
+	Status         string     `gorm:"column:status;type:varchar(20);not null;default:pending;index"`
+	ReviewerID     *int       `gorm:"column:reviewer_id"`
+	ReviewNote     string     `gorm:"column:review_note;type:text"` // rejection reason or approval note
+	CreatedAt      time.Time  `gorm:"column:created_at;default:now()"`
+	ReviewedAt     *time.Time `gorm:"column:reviewed_at"`
+
+	// Relations
+	Category *Category `gorm:"foreignKey:CategoryID;references:ID"`
+	Brand    *Brand    `gorm:"foreignKey:BrandID;references:ID"`
+}
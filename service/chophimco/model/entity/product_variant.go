@@ -13,6 +13,7 @@ type ProductVariant struct {
 	SKU            string  `gorm:"column:sku;unique"`
 
 	// Relations
-	Product *Product `gorm:"foreignKey:ProductID;references:ID"`
-	Switch  *Switch  `gorm:"foreignKey:SwitchID;references:ID"`
+	Product    *Product                  `gorm:"foreignKey:ProductID;references:ID"`
+	Switch     *Switch                   `gorm:"foreignKey:SwitchID;references:ID"`
+	Attributes []ProductVariantAttribute `gorm:"foreignKey:ProductVariantID"`
 }
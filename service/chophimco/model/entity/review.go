@@ -5,14 +5,56 @@ import (
 )
 
 type Review struct {
-	ID        int       `gorm:"primaryKey;column:id;autoIncrement"`
-	UserID    int       `gorm:"column:user_id;not null"`
-	ProductID int       `gorm:"column:product_id;not null"`
-	Rating    int       `gorm:"column:rating;check:rating >= 1 AND rating <= 5"`
-	Comment   string    `gorm:"column:comment;type:text"`
-	CreatedAt time.Time `gorm:"column:created_at;default:now()"`
+	ID                 int        `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID             int        `gorm:"column:user_id;not null"`
+	ProductID          int        `gorm:"column:product_id;not null"`
+	OrderID            *int       `gorm:"column:order_id"` // the completed order the product was bought in
+	Rating             int        `gorm:"column:rating;check:rating >= 1 AND rating <= 5"`
+	Comment            string     `gorm:"column:comment;type:text"`
+	IsVerifiedPurchase bool       `gorm:"column:is_verified_purchase;default:false"`
+	HelpfulCount       int        `gorm:"column:helpful_count;default:0"` // users who found the review helpful
+	CreatedAt          time.Time  `gorm:"column:created_at;default:now()"`
+	EditedAt           *time.Time `gorm:"column:edited_at"` // last edit by the reviewer, nil when never edited
+	ReviewModeration
 
 	// Relations
-	User    *User    `gorm:"foreignKey:UserID;references:ID"`
-	Product *Product `gorm:"foreignKey:ProductID;references:ID"`
+	User    *User         `gorm:"foreignKey:UserID;references:ID"`
+	Product *Product      `gorm:"foreignKey:ProductID;references:ID"`
+	Images  []ReviewImage `gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE"`
+	Edits   []ReviewEdit  `gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE"`
+	Reply   *ReviewReply  `gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE"`
+}
+
+// ReviewImage is a photo the reviewer attached, stored with resized copies like product images
+type ReviewImage struct {
+	ID           int       `gorm:"primaryKey;column:id;autoIncrement"`
+	ReviewID     int       `gorm:"column:review_id;not null;index"`
+	ImageURL     string    `gorm:"column:image_url;type:text;not null"` // original
+	ThumbnailURL string    `gorm:"column:thumbnail_url;type:text"`
+	MediumURL    string    `gorm:"column:medium_url;type:text"`
+	StorageKey   string    `gorm:"column:storage_key"` // key prefix of the stored files
+	ContentType  string    `gorm:"column:content_type"`
+	Width        int       `gorm:"column:width;default:0"`
+	Height       int       `gorm:"column:height;default:0"`
+	SizeBytes    int64     `gorm:"column:size_bytes;default:0"`
+	CreatedAt    time.Time `gorm:"column:created_at;default:now()"`
+}
+
+// ReviewEdit keeps the rating and comment a review had before an edit
+type ReviewEdit struct {
+	ID       int       `gorm:"primaryKey;column:id;autoIncrement"`
+	ReviewID int       `gorm:"column:review_id;not null;index"`
+	Rating   int       `gorm:"column:rating;not null"`
+	Comment  string    `gorm:"column:comment;type:text"`
+	EditedAt time.Time `gorm:"column:edited_at;default:now()"` // when this version was replaced
+}
+
+// ReviewReply is the seller's public answer to a review, at most one per review
+type ReviewReply struct {
+	ID        int       `gorm:"primaryKey;column:id;autoIncrement"`
+	ReviewID  int       `gorm:"column:review_id;not null;uniqueIndex"`
+	SellerID  int       `gorm:"column:seller_id;not null"`
+	Comment   string    `gorm:"column:comment;type:text;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;default:now()"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:now()"`
 }
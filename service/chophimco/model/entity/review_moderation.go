@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"time"
+)
+
+// Moderation states of product and seller reviews. Only published reviews are shown and counted in ratings.
+const (
+	ReviewStatusPublished = "published"
+	ReviewStatusPending   = "pending" // held by an automatic check or by reports until a moderator decides
+	ReviewStatusRejected  = "rejected"
+)
+
+// Kinds of reviews the moderation pipeline covers
+const (
+	ReviewTargetProduct = "product_review"
+	ReviewTargetSeller  = "seller_review"
+)
+
+const (
+	ReportReasonSpam     = "spam"
+	ReportReasonAbuse    = "abuse"
+	ReportReasonOffTopic = "off_topic"
+	ReportReasonFake     = "fake" // not a genuine experience
+	ReportReasonOther    = "other"
+
+	ReportStatusOpen      = "open"
+	ReportStatusUpheld    = "upheld"    // the moderator removed the review
+	ReportStatusDismissed = "dismissed" // the moderator kept the review
+)
+
+// ReviewModeration is the moderation state shared by Review and SellerReview
+type ReviewModeration struct {
+	Status         string     `gorm:"column:status;type:varchar(20);not null;default:published;index"`
+	FlagReason     string     `gorm:"column:flag_reason;type:text"` // why the review was held
+	ModeratedBy    *int       `gorm:"column:moderated_by"`
+	ModeratedAt    *time.Time `gorm:"column:moderated_at"`
+	ModerationNote string     `gorm:"column:moderation_note;type:text"`
+}
+
+// ReviewVote is a user marking a product review as helpful
+type ReviewVote struct {
+	ID        int       `gorm:"primaryKey;column:id;autoIncrement"`
+	ReviewID  int       `gorm:"column:review_id;not null;uniqueIndex:idx_review_votes_review_user,priority:1"`
+	UserID    int       `gorm:"column:user_id;not null;uniqueIndex:idx_review_votes_review_user,priority:2"`
+	CreatedAt time.Time `gorm:"column:created_at;default:now()"`
+}
+
+// ReviewReport is a user flagging a product or seller review for a moderator, once per review
+type ReviewReport struct {
+	ID         int        `gorm:"primaryKey;column:id;autoIncrement"`
+	TargetType string     `gorm:"column:target_type;type:varchar(20);not null;uniqueIndex:idx_review_reports_target_reporter,priority:1"`
+	TargetID   int        `gorm:"column:target_id;not null;uniqueIndex:idx_review_reports_target_reporter,priority:2"`
+	ReporterID int        `gorm:"column:reporter_id;not null;uniqueIndex:idx_review_reports_target_reporter,priority:3"`
+	Reason     string     `gorm:"column:reason;type:varchar(20);not null"`
+	Details    string     `gorm:"column:details;type:text"`
+	Status     string     `gorm:"column:status;type:varchar(20);not null;default:open;index"`
+	ResolvedBy *int       `gorm:"column:resolved_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at;default:now()"`
+	ResolvedAt *time.Time `gorm:"column:resolved_at"`
+
+	// Relations
+	Reporter *User `gorm:"foreignKey:ReporterID;references:ID"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+import (
+	"time"
+)
+
+// SearchQuery counts how often a normalized search query returned results
+type SearchQuery struct {
+	ID             int       `gorm:"primaryKey;column:id;autoIncrement"`
+	Query          string    `gorm:"column:query;uniqueIndex;not null"`
+	Count          int       `gorm:"column:count;not null;default:0"`
+	LastSearchedAt time.Time `gorm:"column:last_searched_at;default:now()"`
+}
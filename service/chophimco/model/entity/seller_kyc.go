@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"time"
+)
+
+// Seller verification statuses, kept on SellerProfile.VerificationStatus
+const (
+	SellerStatusDraft         = "draft"   // onboarding details not submitted yet
+	SellerStatusPending       = "pending" // submitted, waiting for review
+	SellerStatusInfoRequested = "info_requested"
+	SellerStatusVerified      = "verified"
+	SellerStatusRejected      = "rejected"
+)
+
+const (
+	BusinessTypeIndividual = "individual"
+	BusinessTypeCompany    = "company"
+)
+
+const (
+	DocumentBusinessRegistration = "business_registration"
+	DocumentTaxCertificate       = "tax_certificate"
+	DocumentIdentityFront        = "identity_front"
+	DocumentIdentityBack         = "identity_back"
+	DocumentBankStatement        = "bank_statement"
+)
+
+const (
+	DocumentStatusPending    = "pending"
+	DocumentStatusApproved   = "approved"
+	DocumentStatusRejected   = "rejected"
+	DocumentStatusSuperseded = "superseded" // replaced by a newer upload of the same type
+)
+
+// SellerKYC holds the private onboarding details of a seller, kept apart from the public profile
+type SellerKYC struct {
+	ID                 int        `gorm:"primaryKey;column:id;autoIncrement"`
+	SellerProfileID    int        `gorm:"column:seller_profile_id;unique;not null"`
+	BusinessType       string     `gorm:"column:business_type;type:varchar(20)"`
+	LegalName          string     `gorm:"column:legal_name"`
+	RegistrationNumber string     `gorm:"column:registration_number"`
+	RegisteredAddress  string     `gorm:"column:registered_address;type:text"`
+	TaxID              string     `gorm:"column:tax_id"`
+	BankName           string     `gorm:"column:bank_name"`
+	BankBranch         string     `gorm:"column:bank_branch"`
+	BankAccountName    string     `gorm:"column:bank_account_name"`
+	BankAccountNumber  string     `gorm:"column:bank_account_number"`
+	SubmittedAt        *time.Time `gorm:"column:submitted_at"`
+	UpdatedAt          time.Time  `gorm:"column:updated_at;default:now()"`
+}
+
+// SellerDocument is an uploaded verification document, stored privately and reviewed on its own
+type SellerDocument struct {
+	ID              int        `gorm:"primaryKey;column:id;autoIncrement"`
+	SellerProfileID int        `gorm:"column:seller_profile_id;not null;index"`
+	Type            string     `gorm:"column:type;type:varchar(50);not null"`
+	FileName        string     `gorm:"column:file_name"`
+	StorageKey      string     `gorm:"column:storage_key;not null"`
+	ContentType     string     `gorm:"column:content_type"`
+	SizeBytes       int64      `gorm:"column:size_bytes;default:0"`
+	Status          string     `gorm:"column:status;type:varchar(20);not null;default:pending"`
+	ReviewerID      *int       `gorm:"column:reviewer_id"`
+	ReviewNote      string     `gorm:"column:review_note;type:text"`
+	CreatedAt       time.Time  `gorm:"column:created_at;default:now()"`
+	ReviewedAt      *time.Time `gorm:"column:reviewed_at"`
+}
+
+// SellerVerificationEvent records a status change of a seller or of one of its documents
+type SellerVerificationEvent struct {
+	ID              int       `gorm:"primaryKey;column:id;autoIncrement"`
+	SellerProfileID int       `gorm:"column:seller_profile_id;not null;index"`
+	DocumentID      *int      `gorm:"column:document_id"` // set for document reviews
+	FromStatus      string    `gorm:"column:from_status;type:varchar(20)"`
+	ToStatus        string    `gorm:"column:to_status;type:varchar(20);not null"`
+	ActorID         int       `gorm:"column:actor_id;not null"`
+	Reason          string    `gorm:"column:reason;type:text"`
+	CreatedAt       time.Time `gorm:"column:created_at;default:now()"`
+}
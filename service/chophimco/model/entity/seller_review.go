@@ -12,6 +12,7 @@ type SellerReview struct {
 	Rating    int       `gorm:"column:rating;check:rating >= 1 AND rating <= 5"`
 	Comment   string    `gorm:"column:comment;type:text"`
 	CreatedAt time.Time `gorm:"column:created_at;default:now()"`
+	ReviewModeration
 
 	// Relations
 	Buyer  *User  `gorm:"foreignKey:BuyerID;references:ID"`
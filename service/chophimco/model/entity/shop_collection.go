@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+)
+
+// ShopCollection is a featured group of products a seller shows on their storefront
+type ShopCollection struct {
+	ID          int       `gorm:"primaryKey;column:id;autoIncrement"`
+	SellerID    int       `gorm:"column:seller_id;not null;index"`
+	Name        string    `gorm:"column:name;type:varchar(100);not null"`
+	Description string    `gorm:"column:description;type:text"`
+	Position    int       `gorm:"column:position;default:0"`
+	IsActive    bool      `gorm:"column:is_active;default:true"`
+	CreatedAt   time.Time `gorm:"column:created_at;default:now()"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;default:now()"`
+
+	// Relations
+	Items []ShopCollectionItem `gorm:"foreignKey:CollectionID;constraint:OnDelete:CASCADE"`
+}
+
+type ShopCollectionItem struct {
+	ID           int `gorm:"primaryKey;column:id;autoIncrement"`
+	CollectionID int `gorm:"column:collection_id;not null;uniqueIndex:idx_shop_collection_items_product"`
+	ProductID    int `gorm:"column:product_id;not null;uniqueIndex:idx_shop_collection_items_product"`
+	Position     int `gorm:"column:position;default:0"`
+
+	// Relations
+	Product *Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+}
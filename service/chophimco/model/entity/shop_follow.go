@@ -0,0 +1,13 @@
+package entity
+
+import (
+	"time"
+)
+
+// ShopFollow is a user following a shop, to see its launches and sales in their feed
+type ShopFollow struct {
+	ID              int       `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID          int       `gorm:"column:user_id;not null;uniqueIndex:idx_shop_follows_user_shop"`
+	SellerProfileID int       `gorm:"column:seller_profile_id;not null;uniqueIndex:idx_shop_follows_user_shop;index"`
+	CreatedAt       time.Time `gorm:"column:created_at;default:now()"`
+}
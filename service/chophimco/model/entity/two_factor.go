@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+)
+
+// UserTwoFactor is a user's TOTP authenticator. It is pending until the user proves the app
+// produces codes, only then does login ask for them.
+type UserTwoFactor struct {
+	UserID       int        `gorm:"primaryKey;column:user_id;autoIncrement:false"`
+	Secret       string     `gorm:"column:secret;type:varchar(64);not null"` // base32
+	EnabledAt    *time.Time `gorm:"column:enabled_at"`
+	LastUsedStep int64      `gorm:"column:last_used_step;not null;default:0"` // a code is refused a second time
+	// Codes tried since the last accepted one, across login challenges. The last allowed
+	// attempt locks two-factor checks, and with them logins, until LockedUntil.
+	FailedAttempts int        `gorm:"column:failed_attempts;not null;default:0"`
+	LockedUntil    *time.Time `gorm:"column:locked_until"`
+	CreatedAt      time.Time  `gorm:"column:created_at;default:now()"`
+
+	// Relations
+	RecoveryCodes []RecoveryCode `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// RecoveryCode is a single use code replacing the authenticator app. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        int        `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID    int        `gorm:"column:user_id;not null;index"`
+	CodeHash  string     `gorm:"column:code_hash;type:varchar(64);not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;default:now()"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to their account at an external login provider
+type UserIdentity struct {
+	ID          int       `gorm:"primaryKey;column:id;autoIncrement"`
+	UserID      int       `gorm:"column:user_id;not null;index"`
+	Provider    string    `gorm:"column:provider;type:varchar(20);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject     string    `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"` // the provider's user ID
+	Email       string    `gorm:"column:email"`                                                                               // as reported at the last login
+	CreatedAt   time.Time `gorm:"column:created_at;default:now()"`
+	LastLoginAt time.Time `gorm:"column:last_login_at;default:now()"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID;references:ID"`
+}
+
+// OAuthState is a social login in progress, between the redirect to the provider and its
+// callback. It is deleted when the callback uses it.
+type OAuthState struct {
+	ID           string    `gorm:"primaryKey;column:id;type:varchar(64)"` // the state parameter
+	Provider     string    `gorm:"column:provider;type:varchar(20);not null"`
+	CodeVerifier string    `gorm:"column:code_verifier;type:varchar(64);not null"` // PKCE
+	Nonce        string    `gorm:"column:nonce;type:varchar(64);not null"`
+	ExpiresAt    time.Time `gorm:"column:expires_at;not null;index"`
+	CreatedAt    time.Time `gorm:"column:created_at;default:now()"`
+}
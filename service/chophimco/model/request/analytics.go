@@ -0,0 +1,21 @@
+package request
+
+import "time"
+
+// SellerAnalytics is the period and bucket size of a seller analytics series
+type SellerAnalytics struct {
+	From     *time.Time `form:"from" time_format:"2006-01-02"`
+	To       *time.Time `form:"to" time_format:"2006-01-02"`                       // inclusive
+	Interval string     `form:"interval" binding:"omitempty,oneof=day week month"` // defaults to day
+}
+
+type TopVariants struct {
+	From   *time.Time `form:"from" time_format:"2006-01-02"`
+	To     *time.Time `form:"to" time_format:"2006-01-02"` // inclusive
+	SortBy string     `form:"sort_by" binding:"omitempty,oneof=units revenue"`
+	Limit  int        `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+type LowStockVariants struct {
+	Threshold *int `form:"threshold" binding:"omitempty,min=0"` // defaults to 5
+}
@@ -0,0 +1,27 @@
+package request
+
+type CreateAttributeDefinition struct {
+	CategoryID    *int     `json:"category_id"` // empty for attributes of every product
+	Code          string   `json:"code" binding:"required,max=50"`
+	Name          string   `json:"name" binding:"required,max=100"`
+	Type          string   `json:"type" binding:"required,oneof=text number boolean enum"`
+	AllowedValues []string `json:"allowed_values" binding:"omitempty,dive,max=100"`
+	Unit          string   `json:"unit" binding:"omitempty,max=20"`
+	IsRequired    bool     `json:"is_required"`
+	IsFilterable  bool     `json:"is_filterable"`
+	DisplayOrder  int      `json:"display_order"`
+}
+
+type UpdateAttributeDefinition struct {
+	ID            int      `json:"id" binding:"required"`
+	Name          string   `json:"name" binding:"omitempty,max=100"`
+	AllowedValues []string `json:"allowed_values" binding:"omitempty,dive,max=100"`
+	Unit          *string  `json:"unit" binding:"omitempty,max=20"`
+	IsRequired    *bool    `json:"is_required"`
+	IsFilterable  *bool    `json:"is_filterable"`
+	DisplayOrder  *int     `json:"display_order"`
+}
+
+type GetAttributeDefinitions struct {
+	CategoryID *int `form:"category_id"`
+}
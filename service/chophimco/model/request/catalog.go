@@ -0,0 +1,38 @@
+package request
+
+type CreateCategory struct {
+	ParentID     *int   `json:"parent_id"`
+	Name         string `json:"name" binding:"required,max=100"`
+	Slug         string `json:"slug" binding:"omitempty,max=120"` // derived from the name when empty
+	DisplayOrder int    `json:"display_order"`
+}
+
+type UpdateCategory struct {
+	ID           int    `json:"id" binding:"required"`
+	ParentID     *int   `json:"parent_id"` // 0 moves the category to the root
+	Name         string `json:"name" binding:"omitempty,max=100"`
+	Slug         string `json:"slug" binding:"omitempty,max=120"`
+	DisplayOrder *int   `json:"display_order"`
+}
+
+type CreateBrand struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type UpdateBrand struct {
+	ID   int    `json:"id" binding:"required"`
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type CreateSwitch struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Type  string `json:"type" binding:"omitempty,oneof=Linear Tactile Clicky"`
+	Brand string `json:"brand" binding:"omitempty,max=100"`
+}
+
+type UpdateSwitch struct {
+	ID    int    `json:"id" binding:"required"`
+	Name  string `json:"name" binding:"omitempty,max=50"`
+	Type  string `json:"type" binding:"omitempty,oneof=Linear Tactile Clicky"`
+	Brand string `json:"brand" binding:"omitempty,max=100"`
+}
@@ -0,0 +1,24 @@
+package request
+
+import "time"
+
+type CreateFlashSale struct {
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	StartAt     time.Time             `json:"start_at" binding:"required"`
+	EndAt       time.Time             `json:"end_at" binding:"required"`
+	Items       []CreateFlashSaleItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type CreateFlashSaleItem struct {
+	ProductVariantID int     `json:"product_variant_id" binding:"required"`
+	SalePrice        float64 `json:"sale_price" binding:"required,gt=0"`
+	SaleQuantity     int     `json:"sale_quantity" binding:"required,gt=0"`
+	PerUserLimit     int     `json:"per_user_limit" binding:"gte=0"`
+}
+
+type PurchaseFlashSaleItem struct {
+	FlashSaleItemID int    `json:"flash_sale_item_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required,gt=0"`
+	ShippingAddress string `json:"shipping_address" binding:"required"`
+}
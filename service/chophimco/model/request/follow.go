@@ -0,0 +1,14 @@
+package request
+
+import "time"
+
+type GetFollowedShops struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type GetFeed struct {
+	Before   *time.Time `form:"before"`    // RFC 3339, next_before of the previous page
+	BeforeID *int       `form:"before_id"` // next_before_id of the previous page
+	Limit    int        `form:"limit" binding:"omitempty,min=1,max=50"`
+}
@@ -0,0 +1,9 @@
+package request
+
+type ImportProducts struct {
+	DryRun bool `form:"dry_run"`
+}
+
+type ExportProducts struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
@@ -0,0 +1,56 @@
+package request
+
+import "time"
+
+type RefundOrder struct {
+	OrderID   int               `json:"order_id" binding:"required"`
+	Items     []RefundOrderItem `json:"items" binding:"required,min=1,dive"`
+	Reason    string            `json:"reason" binding:"required"`
+	CreatedBy int               `json:"-"`
+}
+
+type RefundOrderItem struct {
+	OrderItemID int `json:"order_item_id" binding:"required"`
+	Quantity    int `json:"quantity" binding:"required,min=1"`
+}
+
+// SetCommissionRule sets the commission rate of exactly one seller or one category
+type SetCommissionRule struct {
+	SellerID   *int    `json:"seller_id"`
+	CategoryID *int    `json:"category_id"`
+	Rate       float64 `json:"rate" binding:"min=0,max=100"` // percent
+	UpdatedBy  int     `json:"-"`
+}
+
+type GetSellerEarnings struct {
+	From *time.Time `form:"from" time_format:"2006-01-02"`
+	To   *time.Time `form:"to" time_format:"2006-01-02"` // inclusive
+}
+
+type GetSellerLedger struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type CreatePayoutBatch struct {
+	PeriodEnd *time.Time `json:"period_end"` // defaults to now minus the payout hold period
+	Note      string     `json:"note"`
+	CreatedBy int        `json:"-"`
+}
+
+type GetPayoutBatches struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type SettlePayout struct {
+	PayoutID          int    `json:"payout_id" binding:"required"`
+	Status            string `json:"status" binding:"required,oneof=paid failed"`
+	TransferReference string `json:"transfer_reference"` // required when paid
+	FailureReason     string `json:"failure_reason"`     // required when failed
+}
+
+type ReconciliationReport struct {
+	From *time.Time `form:"from" time_format:"2006-01-02"`
+	To   *time.Time `form:"to" time_format:"2006-01-02"` // inclusive
+}
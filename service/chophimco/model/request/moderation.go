@@ -0,0 +1,32 @@
+package request
+
+type ReportReview struct {
+	TargetType string `json:"target_type" binding:"required,oneof=product_review seller_review"`
+	TargetID   int    `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,oneof=spam abuse off_topic fake other"`
+	Details    string `json:"details" binding:"max=1000"`
+}
+
+type GetModerationQueue struct {
+	TargetType string `form:"target_type" binding:"required,oneof=product_review seller_review"`
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	PageSize   int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type GetReviewReports struct {
+	TargetType string `form:"target_type" binding:"required,oneof=product_review seller_review"`
+	TargetID   int    `form:"target_id" binding:"required"`
+}
+
+// Moderation decisions
+const (
+	ModerationApprove = "approve"
+	ModerationReject  = "reject"
+)
+
+type ModerateReview struct {
+	TargetType string `json:"target_type" binding:"required,oneof=product_review seller_review"`
+	TargetID   int    `json:"target_id" binding:"required"`
+	Action     string `json:"action" binding:"required,oneof=approve reject"`
+	Note       string `json:"note" binding:"max=1000"`
+}
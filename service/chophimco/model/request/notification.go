@@ -0,0 +1,7 @@
+package request
+
+type GetNotifications struct {
+	Page       int  `form:"page" binding:"omitempty,min=1"`
+	PageSize   int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+	UnreadOnly bool `form:"unread_only"`
+}
@@ -29,6 +29,9 @@ type CreateProductVariant struct {
 	Price          float64 `json:"price" binding:"required,gt=0"`
 	Stock          int     `json:"stock" binding:"gte=0"`
 	SKU            string  `json:"sku" binding:"required"`
+
+	// Attributes holds values keyed by attribute code, validated against the category definitions
+	Attributes map[string]string `json:"attributes"`
 }
 
 type UpdateProductVariant struct {
@@ -40,4 +43,40 @@ type UpdateProductVariant struct {
 	LedType        string  `json:"led_type"`
 	Price          float64 `json:"price" binding:"gt=0"`
 	Stock          *int    `json:"stock" binding:"gte=0"`
+
+	// Attributes overrides values keyed by attribute code, an empty value removes the attribute
+	Attributes map[string]string `json:"attributes"`
+}
+
+type ListProducts struct {
+	Page           int      `form:"page" binding:"omitempty,min=1"`
+	PageSize       int      `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Sort           string   `form:"sort" binding:"omitempty,oneof=price_asc price_desc newest rating best_selling"`
+	CategoryID     *int     `form:"category_id"`
+	BrandID        *int     `form:"brand_id"`
+	SellerID       *int     `form:"seller_id"`
+	CollectionID   *int     `form:"collection_id"`
+	MinPrice       *float64 `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice       *float64 `form:"max_price" binding:"omitempty,gte=0"`
+	Layout         []string `form:"layout"`
+	ConnectionType []string `form:"connection_type"`
+	Hotswap        *bool    `form:"hotswap"`
+	LedType        []string `form:"led_type"`
+	SwitchType     []string `form:"switch_type"`
+	InStock        bool     `form:"in_stock"`
+
+	// Attributes filters by attribute code, bound from attr[<code>]=<value> query parameters
+	Attributes map[string][]string `form:"-"`
+	// OnlyApproved hides products not approved yet, set on every public listing and search
+	OnlyApproved bool `form:"-"`
+}
+
+type SearchProducts struct {
+	Query string `form:"q" binding:"required"`
+	ListProducts
+}
+
+type SuggestProducts struct {
+	Query string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"`
 }
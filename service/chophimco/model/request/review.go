@@ -1,7 +1,40 @@
 package request
 
+// Review list sort orders
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+)
+
+type ListReviews struct {
+	ProductID  int    `form:"product_id" binding:"required"`
+	Rating     *int   `form:"rating" binding:"omitempty,min=1,max=5"`
+	WithPhotos bool   `form:"with_photos"`
+	Sort       string `form:"sort" binding:"omitempty,oneof=newest helpful"`
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	PageSize   int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
 type CreateReview struct {
 	ProductID int    `json:"product_id" binding:"required"`
 	Rating    int    `json:"rating" binding:"required,min=1,max=5"`
-	Comment   string `json:"comment"`
+	Comment   string `json:"comment" binding:"max=5000"`
+}
+
+type UpdateReview struct {
+	ReviewID int    `json:"review_id" binding:"required"`
+	Rating   int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment  string `json:"comment" binding:"max=5000"`
+}
+
+type UploadReviewPhoto struct {
+	ReviewID int `form:"review_id" binding:"required"`
+
+	// ImageData is the uploaded file, bound by the handler
+	ImageData []byte `form:"-"`
+}
+
+type ReplyToReview struct {
+	ReviewID int    `json:"review_id" binding:"required"`
+	Comment  string `json:"comment" binding:"required,max=2000"`
 }
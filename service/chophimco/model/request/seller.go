@@ -12,6 +12,7 @@ type CreateSellerProfile struct {
 type UpdateSellerProfile struct {
 	UserID          int    `json:"user_id"`
 	ShopName        string `json:"shop_name"`
+	Slug            string `json:"slug" binding:"omitempty,max=120"` // shop URL, kept when empty
 	ShopDescription string `json:"shop_description"`
 	BusinessAddress string `json:"business_address"`
 	BusinessPhone   string `json:"business_phone"`
@@ -20,10 +21,13 @@ type UpdateSellerProfile struct {
 
 type UploadProductImage struct {
 	ProductID    int    `form:"product_id" binding:"required"`
-	ImageURL     string `form:"image_url" binding:"required"`
+	ImageURL     string `form:"image_url"` // used when no file is uploaded
 	IsPrimary    bool   `form:"is_primary"`
 	DisplayOrder int    `form:"display_order"`
 	AltText      string `form:"alt_text"`
+
+	// ImageData is the uploaded file, bound by the handler
+	ImageData []byte `form:"-"`
 }
 
 type SetPrimaryImage struct {
@@ -32,25 +36,38 @@ type SetPrimaryImage struct {
 }
 
 type VerifySeller struct {
-	UserID int `json:"user_id" binding:"required"`
+	UserID     int    `json:"user_id" binding:"required"`
+	Note       string `json:"note"`
+	ReviewerID int    `json:"-"`
 }
 
 type RejectSeller struct {
-	UserID int    `json:"user_id" binding:"required"`
-	Reason string `json:"reason"`
+	UserID     int    `json:"user_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	ReviewerID int    `json:"-"`
 }
 
 type ApproveProduct struct {
-	ProductID int `json:"product_id" binding:"required"`
+	ProductID  int    `json:"product_id" binding:"required"`
+	Note       string `json:"note"`
+	ReviewerID int    `json:"-"`
 }
 
 type RejectProduct struct {
-	ProductID int    `json:"product_id" binding:"required"`
-	Reason    string `json:"reason" binding:"required"`
+	ProductID  int    `json:"product_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	ReviewerID int    `json:"-"`
+}
+
+// ProductRevisionDiff selects the revisions to compare by revision number. To defaults to the
+// pending or latest revision, From to the approved version before it.
+type ProductRevisionDiff struct {
+	From *int `form:"from" binding:"omitempty,min=1"`
+	To   *int `form:"to" binding:"omitempty,min=1"`
 }
 
 type CreateSellerReview struct {
-	BuyerID  int    `json:"buyer_id"`
+	BuyerID  int    `json:"-"`
 	SellerID int    `json:"seller_id" binding:"required"`
 	OrderID  int    `json:"order_id" binding:"required"`
 	Rating   int    `json:"rating" binding:"required,min=1,max=5"`
@@ -0,0 +1,33 @@
+package request
+
+type UpdateSellerBusiness struct {
+	BusinessType       string `json:"business_type" binding:"required,oneof=individual company"`
+	LegalName          string `json:"legal_name" binding:"required,max=255"`
+	RegistrationNumber string `json:"registration_number" binding:"max=100"` // required for companies
+	RegisteredAddress  string `json:"registered_address" binding:"required"`
+	TaxID              string `json:"tax_id" binding:"required,max=50"`
+}
+
+type UpdateSellerBank struct {
+	BankName          string `json:"bank_name" binding:"required,max=255"`
+	BankBranch        string `json:"bank_branch" binding:"max=255"`
+	BankAccountName   string `json:"bank_account_name" binding:"required,max=255"`
+	BankAccountNumber string `json:"bank_account_number" binding:"required,max=50"`
+}
+
+type UploadSellerDocument struct {
+	Type string `form:"type" binding:"required,oneof=business_registration tax_certificate identity_front identity_back bank_statement"`
+}
+
+type ReviewSellerDocument struct {
+	DocumentID int    `json:"document_id" binding:"required"`
+	Status     string `json:"status" binding:"required,oneof=approved rejected"`
+	Note       string `json:"note"` // required when rejecting
+	ReviewerID int    `json:"-"`
+}
+
+type RequestSellerInfo struct {
+	UserID     int    `json:"user_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	ReviewerID int    `json:"-"`
+}
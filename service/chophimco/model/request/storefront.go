@@ -0,0 +1,22 @@
+package request
+
+type CreateShopCollection struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Position    int    `json:"position"`
+	IsActive    *bool  `json:"is_active"` // defaults to true
+}
+
+type UpdateShopCollection struct {
+	ID          int     `json:"id" binding:"required"`
+	Name        string  `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description"`
+	Position    *int    `json:"position"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// SetShopCollectionProducts replaces the products of a collection, in display order
+type SetShopCollectionProducts struct {
+	CollectionID int   `json:"collection_id" binding:"required"`
+	ProductIDs   []int `json:"product_ids" binding:"max=50"`
+}
@@ -1,8 +1,15 @@
 package request
 
 type Login struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// RefreshToken may be omitted when the refresh token cookie is sent
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type Register struct {
@@ -11,3 +18,40 @@ type Register struct {
 	Password string `json:"password" binding:"required,min=6"`
 	Phone    string `json:"phone"`
 }
+
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// OAuthCallback is the query the provider redirects the browser back with
+type OAuthCallback struct {
+	Code         string `form:"code"`
+	State        string `form:"state"`
+	Error        string `form:"error"` // set when the user declined or the provider failed
+	Provider     string `json:"-"`
+	BrowserState string `json:"-"` // state cookie set when the login started
+	UserAgent    string `json:"-"`
+	IPAddress    string `json:"-"`
+}
+
+// TwoFactorCode is an authenticator code, or a recovery code where the endpoint allows one
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorLogin completes a login that returned a two-factor challenge
+type TwoFactorLogin struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // authenticator or recovery code
+	UserAgent      string `json:"-"`
+	IPAddress      string `json:"-"`
+}
@@ -0,0 +1,37 @@
+package request
+
+type GenerateVariantMatrix struct {
+	ProductID int           `json:"product_id" binding:"required"`
+	Axes      []VariantAxis `json:"axes" binding:"required,min=1,max=5,dive"`
+
+	// Attributes holds values shared by every generated variant, keyed by attribute code
+	Attributes map[string]string `json:"attributes"`
+
+	// SKUPattern builds SKUs from {product}, {n} (1-based cell number) and {<axis code>}
+	// placeholders, e.g. "KB{product}-{switch}-{color}". Defaults to every axis joined by dashes.
+	SKUPattern   string                `json:"sku_pattern" binding:"max=100"`
+	DefaultPrice float64               `json:"default_price" binding:"required,gt=0"`
+	DefaultStock int                   `json:"default_stock" binding:"gte=0"`
+	Overrides    []VariantCellOverride `json:"overrides" binding:"omitempty,dive"`
+
+	// RemoveMissing deletes existing variants outside the matrix. Variants that were
+	// already ordered are kept with zero stock instead.
+	RemoveMissing bool `json:"remove_missing"`
+	// DryRun returns the resulting matrix without saving it
+	DryRun bool `json:"dry_run"`
+}
+
+// VariantAxis is an option dimension of the matrix. Code is "switch" with switch IDs as
+// values, or an attribute code of the product's category.
+type VariantAxis struct {
+	Code   string   `json:"code" binding:"required"`
+	Values []string `json:"values" binding:"required,min=1,dive,required"`
+}
+
+// VariantCellOverride applies to every cell matching all of its options
+type VariantCellOverride struct {
+	Options map[string]string `json:"options" binding:"required,min=1"`
+	Price   *float64          `json:"price" binding:"omitempty,gt=0"`
+	Stock   *int              `json:"stock" binding:"omitempty,gte=0"`
+	SKU     string            `json:"sku" binding:"max=100"`
+}
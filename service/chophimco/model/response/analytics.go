@@ -0,0 +1,97 @@
+package response
+
+import "time"
+
+type SalesAnalyticsResponse struct {
+	From     time.Time             `json:"from"`
+	To       time.Time             `json:"to"`
+	Interval string                `json:"interval"`
+	Totals   SalesBucketResponse   `json:"totals"`
+	Buckets  []SalesBucketResponse `json:"buckets"`
+}
+
+// SalesBucketResponse sums the seller's items in orders placed in the bucket, cancelled orders excluded
+type SalesBucketResponse struct {
+	Bucket            *time.Time `json:"bucket,omitempty"` // start of the bucket, not set on totals
+	Orders            int64      `json:"orders"`
+	UnitsOrdered      int64      `json:"units_ordered"`
+	UnitsRefunded     int64      `json:"units_refunded"`
+	UnitsSold         int64      `json:"units_sold"` // ordered less refunded
+	GrossRevenue      float64    `json:"gross_revenue"`
+	RefundedRevenue   float64    `json:"refunded_revenue"`
+	Revenue           float64    `json:"revenue"` // gross less refunded
+	AverageOrderValue float64    `json:"average_order_value"`
+	RefundRate        float64    `json:"refund_rate"` // percent of units ordered
+}
+
+type ConversionAnalyticsResponse struct {
+	From           time.Time                  `json:"from"`
+	To             time.Time                  `json:"to"`
+	Interval       string                     `json:"interval"`
+	CartAdds       int64                      `json:"cart_adds"`
+	Converted      int64                      `json:"converted"`
+	ConversionRate float64                    `json:"conversion_rate"` // percent
+	Buckets        []ConversionBucketResponse `json:"buckets"`
+}
+
+// ConversionBucketResponse counts the cart adds in the bucket, and those the buyer went on to order
+type ConversionBucketResponse struct {
+	Bucket         time.Time `json:"bucket"`
+	CartAdds       int64     `json:"cart_adds"`
+	Converted      int64     `json:"converted"`
+	ConversionRate float64   `json:"conversion_rate"` // percent
+}
+
+type RatingAnalyticsResponse struct {
+	From          time.Time              `json:"from"`
+	To            time.Time              `json:"to"`
+	Interval      string                 `json:"interval"`
+	Reviews       int64                  `json:"reviews"`
+	AverageRating float64                `json:"average_rating"`
+	Distribution  []RatingCountResponse  `json:"distribution"` // reviews per star, 5 to 1
+	Buckets       []RatingBucketResponse `json:"buckets"`
+}
+
+type RatingCountResponse struct {
+	Rating int   `json:"rating"`
+	Count  int64 `json:"count"`
+}
+
+type RatingBucketResponse struct {
+	Bucket        time.Time `json:"bucket"`
+	Reviews       int64     `json:"reviews"`
+	AverageRating float64   `json:"average_rating"`
+}
+
+type TopVariantsResponse struct {
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	SortBy   string                 `json:"sort_by"`
+	Variants []VariantSalesResponse `json:"variants"`
+}
+
+type VariantSalesResponse struct {
+	VariantID   int     `json:"variant_id"`
+	SKU         string  `json:"sku"`
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Orders      int64   `json:"orders"`
+	UnitsSold   int64   `json:"units_sold"`
+	Revenue     float64 `json:"revenue"`
+}
+
+type LowStockResponse struct {
+	Threshold int                       `json:"threshold"`
+	Variants  []LowStockVariantResponse `json:"variants"`
+}
+
+type LowStockVariantResponse struct {
+	VariantID   int      `json:"variant_id"`
+	SKU         string   `json:"sku"`
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	IsActive    bool     `json:"is_active"`
+	Stock       int      `json:"stock"`
+	UnitsSold   int64    `json:"units_sold"`              // over the last 30 days
+	DaysOfStock *float64 `json:"days_of_stock,omitempty"` // at the last 30 days' pace, not set without sales
+}
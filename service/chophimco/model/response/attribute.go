@@ -0,0 +1,31 @@
+package response
+
+type AttributeDefinitionResponse struct {
+	ID            int      `json:"id"`
+	CategoryID    *int     `json:"category_id"`
+	Code          string   `json:"code"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	AllowedValues []string `json:"allowed_values"`
+	Unit          string   `json:"unit,omitempty"`
+	IsRequired    bool     `json:"is_required"`
+	IsFilterable  bool     `json:"is_filterable"`
+	DisplayOrder  int      `json:"display_order"`
+}
+
+type VariantAttributeResponse struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Unit  string `json:"unit,omitempty"`
+}
+
+// AttributeFacet lists the values of a filterable attribute with their product counts
+type AttributeFacet struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Type   string       `json:"type"`
+	Unit   string       `json:"unit,omitempty"`
+	Values []FacetCount `json:"values"`
+}
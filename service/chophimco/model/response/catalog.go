@@ -0,0 +1,34 @@
+package response
+
+type CategoryResponse struct {
+	ID           int                `json:"id"`
+	ParentID     *int               `json:"parent_id"`
+	Name         string             `json:"name"`
+	Slug         string             `json:"slug"`
+	DisplayOrder int                `json:"display_order"`
+	Children     []CategoryResponse `json:"children"`
+}
+
+// CategoryDetailResponse is a category with its subtree and the path from the root
+type CategoryDetailResponse struct {
+	CategoryResponse
+	Ancestors []CategoryBreadcrumb `json:"ancestors"`
+}
+
+type CategoryBreadcrumb struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type BrandResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type SwitchResponse struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Brand string `json:"brand"`
+}
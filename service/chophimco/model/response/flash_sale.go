@@ -0,0 +1,34 @@
+package response
+
+import "time"
+
+type FlashSaleResponse struct {
+	ID                int                     `json:"id"`
+	Name              string                  `json:"name"`
+	Description       string                  `json:"description"`
+	StartAt           time.Time               `json:"start_at"`
+	EndAt             time.Time               `json:"end_at"`
+	Status            string                  `json:"status"` // upcoming, live, ended, cancelled
+	ServerTime        time.Time               `json:"server_time"`
+	SecondsUntilStart int64                   `json:"seconds_until_start"`
+	SecondsUntilEnd   int64                   `json:"seconds_until_end"`
+	Items             []FlashSaleItemResponse `json:"items"`
+}
+
+type FlashSaleItemResponse struct {
+	ID            int                    `json:"id"`
+	ProductName   string                 `json:"product_name"`
+	Variant       ProductVariantResponse `json:"variant"`
+	OriginalPrice float64                `json:"original_price"`
+	SalePrice     float64                `json:"sale_price"`
+	SaleQuantity  int                    `json:"sale_quantity"`
+	Remaining     int                    `json:"remaining"`
+	PerUserLimit  int                    `json:"per_user_limit"`
+}
+
+// FlashSaleStockUpdate is pushed to websocket subscribers of a live flash sale.
+type FlashSaleStockUpdate struct {
+	FlashSaleID     int `json:"flash_sale_id"`
+	FlashSaleItemID int `json:"flash_sale_item_id"`
+	Remaining       int `json:"remaining"`
+}
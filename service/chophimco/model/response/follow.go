@@ -0,0 +1,57 @@
+package response
+
+import "time"
+
+type FollowResponse struct {
+	ShopID        int    `json:"shop_id"`
+	Slug          string `json:"slug"`
+	IsFollowing   bool   `json:"is_following"`
+	FollowerCount int    `json:"follower_count"`
+}
+
+type FollowedShopResponse struct {
+	ShopID           int       `json:"shop_id"`
+	Slug             string    `json:"slug"`
+	ShopName         string    `json:"shop_name"`
+	LogoThumbnailURL string    `json:"logo_thumbnail_url,omitempty"`
+	FollowerCount    int       `json:"follower_count"`
+	FollowedAt       time.Time `json:"followed_at"`
+}
+
+type FollowedShopListResponse struct {
+	Shops      []FollowedShopResponse `json:"shops"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+type FeedResponse struct {
+	Items []FeedItemResponse `json:"items"`
+	// NextBefore and NextBeforeID are the before and before_id parameters of the next page,
+	// absent on the last page
+	NextBefore   *time.Time `json:"next_before,omitempty"`
+	NextBeforeID *int       `json:"next_before_id,omitempty"`
+}
+
+type FeedItemResponse struct {
+	Type       string            `json:"type"` // new_product, flash_sale or discount
+	OccurredAt time.Time         `json:"occurred_at"`
+	Shop       FeedShopResponse  `json:"shop"`
+	Product    ProductResponse   `json:"product"`
+	Sale       *FeedSaleResponse `json:"sale,omitempty"`
+}
+
+type FeedShopResponse struct {
+	ID               int    `json:"id"`
+	Slug             string `json:"slug"`
+	ShopName         string `json:"shop_name"`
+	LogoThumbnailURL string `json:"logo_thumbnail_url,omitempty"`
+}
+
+// FeedSaleResponse describes a flash sale or a discount running on the product
+type FeedSaleResponse struct {
+	FlashSaleID   *int       `json:"flash_sale_id,omitempty"`
+	Name          string     `json:"name,omitempty"`
+	SalePrice     *float64   `json:"sale_price,omitempty"` // lowest flash sale price among the variants
+	DiscountType  string     `json:"discount_type,omitempty"`
+	DiscountValue *float64   `json:"discount_value,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+}
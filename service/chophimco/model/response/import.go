@@ -0,0 +1,25 @@
+package response
+
+import "time"
+
+type ImportJobResponse struct {
+	ID         int              `json:"id"`
+	FileName   string           `json:"file_name"`
+	Format     string           `json:"format"`
+	DryRun     bool             `json:"dry_run"`
+	Status     string           `json:"status"`
+	TotalRows  int              `json:"total_rows"`
+	Products   int              `json:"products"`
+	Variants   int              `json:"variants"`
+	Images     int              `json:"images"`
+	Errors     []ImportRowError `json:"errors"`
+	Message    string           `json:"message,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
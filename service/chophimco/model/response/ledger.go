@@ -0,0 +1,135 @@
+package response
+
+import "time"
+
+type OrderRefundResponse struct {
+	ID        int                       `json:"id"`
+	OrderID   int                       `json:"order_id"`
+	Amount    float64                   `json:"amount"`
+	Reason    string                    `json:"reason"`
+	Items     []OrderRefundItemResponse `json:"items"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+type OrderRefundItemResponse struct {
+	OrderItemID int     `json:"order_item_id"`
+	Quantity    int     `json:"quantity"`
+	Amount      float64 `json:"amount"`
+}
+
+type CommissionRuleResponse struct {
+	ID           int       `json:"id"`
+	SellerID     *int      `json:"seller_id,omitempty"`
+	SellerName   string    `json:"seller_name,omitempty"`
+	CategoryID   *int      `json:"category_id,omitempty"`
+	CategoryName string    `json:"category_name,omitempty"`
+	Rate         float64   `json:"rate"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CommissionRulesResponse struct {
+	DefaultRate float64                  `json:"default_rate"`
+	Rules       []CommissionRuleResponse `json:"rules"`
+}
+
+// SellerEarningsResponse breaks down what the seller earned in a period, and what is owed now
+type SellerEarningsResponse struct {
+	From            time.Time        `json:"from"`
+	To              time.Time        `json:"to"`
+	GrossSales      float64          `json:"gross_sales"`
+	SellerDiscounts float64          `json:"seller_discounts"`
+	Commission      float64          `json:"commission"`
+	Refunds         float64          `json:"refunds"`
+	NetEarnings     float64          `json:"net_earnings"`
+	Balance         float64          `json:"balance"`    // owed to the seller, not yet in a payout
+	Available       float64          `json:"available"`  // part of the balance past the hold period
+	InTransit       float64          `json:"in_transit"` // in payouts waiting for the bank
+	PaidOut         float64          `json:"paid_out"`   // paid in the period
+	RecentPayouts   []PayoutResponse `json:"recent_payouts"`
+}
+
+type LedgerLineResponse struct {
+	ID          int       `json:"id"`
+	EntryType   string    `json:"entry_type"`
+	Component   string    `json:"component"`
+	OrderID     *int      `json:"order_id,omitempty"`
+	OrderItemID *int      `json:"order_item_id,omitempty"`
+	PayoutID    *int      `json:"payout_id,omitempty"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"` // positive when owed to the seller
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type LedgerLineListResponse struct {
+	Lines      []LedgerLineResponse `json:"lines"`
+	Pagination Pagination           `json:"pagination"`
+}
+
+type PayoutResponse struct {
+	ID                int        `json:"id"`
+	BatchID           int        `json:"batch_id"`
+	SellerID          int        `json:"seller_id"`
+	SellerName        string     `json:"seller_name,omitempty"`
+	Amount            float64    `json:"amount"`
+	Status            string     `json:"status"`
+	BankName          string     `json:"bank_name"`
+	BankAccountName   string     `json:"bank_account_name"`
+	BankAccountNumber string     `json:"bank_account_number"`
+	TransferReference string     `json:"transfer_reference,omitempty"`
+	FailureReason     string     `json:"failure_reason,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	SettledAt         *time.Time `json:"settled_at,omitempty"`
+}
+
+type PayoutBatchResponse struct {
+	ID          int              `json:"id"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Status      string           `json:"status"`
+	TotalAmount float64          `json:"total_amount"`
+	PayoutCount int              `json:"payout_count"`
+	CreatedBy   *int             `json:"created_by,omitempty"`
+	Note        string           `json:"note,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	Payouts     []PayoutResponse `json:"payouts,omitempty"`
+}
+
+type PayoutBatchListResponse struct {
+	Batches    []PayoutBatchResponse `json:"batches"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+type ReconciliationReportResponse struct {
+	From              time.Time                   `json:"from"`
+	To                time.Time                   `json:"to"`
+	Balanced          bool                        `json:"balanced"` // debits equal credits and every order matches
+	Accounts          []LedgerAccountResponse     `json:"accounts"`
+	TotalDebit        float64                     `json:"total_debit"`
+	TotalCredit       float64                     `json:"total_credit"`
+	UnbalancedEntries []int                       `json:"unbalanced_entries"`
+	OrdersChecked     int                         `json:"orders_checked"`
+	UnsettledOrders   []OrderSettlementResponse   `json:"unsettled_orders"`  // completed without a sale entry
+	MismatchedOrders  []OrderSettlementResponse   `json:"mismatched_orders"` // ledger cash differs from what was collected
+	Payouts           []PayoutStatusTotalResponse `json:"payouts"`
+}
+
+type LedgerAccountResponse struct {
+	Account string  `json:"account"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"` // debit minus credit
+}
+
+type OrderSettlementResponse struct {
+	OrderID    int     `json:"order_id"`
+	Status     string  `json:"status"`
+	Collected  float64 `json:"collected"` // order total less refunds
+	LedgerCash float64 `json:"ledger_cash"`
+	Difference float64 `json:"difference"`
+}
+
+type PayoutStatusTotalResponse struct {
+	Status string  `json:"status"`
+	Count  int64   `json:"count"`
+	Amount float64 `json:"amount"`
+}
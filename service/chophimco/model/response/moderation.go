@@ -0,0 +1,41 @@
+package response
+
+import "time"
+
+type HelpfulVoteResponse struct {
+	ReviewID     int  `json:"review_id"`
+	Voted        bool `json:"voted"`
+	HelpfulCount int  `json:"helpful_count"`
+}
+
+type ModerationQueueResponse struct {
+	Items      []ModerationQueueItem `json:"items"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+// ModerationQueueItem is a held or reported review waiting for a moderator
+type ModerationQueueItem struct {
+	TargetType  string    `json:"target_type"`
+	TargetID    int       `json:"target_id"`
+	AuthorID    int       `json:"author_id"`
+	AuthorName  string    `json:"author_name"`
+	SubjectID   int       `json:"subject_id"`   // product ID, or the seller's user ID
+	SubjectName string    `json:"subject_name"` // product name or shop name
+	Rating      int       `json:"rating"`
+	Comment     string    `json:"comment"`
+	Status      string    `json:"status"`
+	FlagReason  string    `json:"flag_reason,omitempty"`
+	OpenReports int64     `json:"open_reports"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ReviewReportResponse struct {
+	ID           int        `json:"id"`
+	ReporterID   int        `json:"reporter_id"`
+	ReporterName string     `json:"reporter_name"`
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+type NotificationResponse struct {
+	ID            int        `json:"id"`
+	Type          string     `json:"type"`
+	Title         string     `json:"title"`
+	Message       string     `json:"message"`
+	ReferenceType string     `json:"reference_type,omitempty"`
+	ReferenceID   *int       `json:"reference_id,omitempty"`
+	IsRead        bool       `json:"is_read"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	Unread        int64                  `json:"unread"`
+	Pagination    Pagination             `json:"pagination"`
+}
@@ -11,6 +11,7 @@ type OrderResponse struct {
 	Status          string              `json:"status"`
 	ShippingAddress string              `json:"shipping_address"`
 	CreatedAt       time.Time           `json:"created_at"`
+	CompletedAt     *time.Time          `json:"completed_at,omitempty"`
 	Items           []OrderItemResponse `json:"items"`
 	Payment         *PaymentResponse    `json:"payment,omitempty"`
 }
@@ -12,6 +12,10 @@ type ProductResponse struct {
 	IsActive    bool                     `json:"is_active"`
 	CreatedAt   time.Time                `json:"created_at"`
 	Variants    []ProductVariantResponse `json:"variants,omitempty"`
+
+	AverageRating   float64               `json:"average_rating"`
+	ReviewCount     int                   `json:"review_count"`
+	RatingHistogram []RatingCountResponse `json:"rating_histogram"` // reviews per star, 5 to 1
 }
 
 type ProductVariantResponse struct {
@@ -25,4 +29,60 @@ type ProductVariantResponse struct {
 	Price          float64 `json:"price"`
 	Stock          int     `json:"stock"`
 	SKU            string  `json:"sku"`
+
+	Attributes []VariantAttributeResponse `json:"attributes"`
+}
+
+type ProductListResponse struct {
+	Items      []ProductResponse `json:"items"`
+	Pagination Pagination        `json:"pagination"`
+	Facets     *ProductFacets    `json:"facets,omitempty"`
+}
+
+type ProductSearchResponse struct {
+	Items      []ProductSearchItem `json:"items"`
+	Pagination Pagination          `json:"pagination"`
+	Facets     *ProductFacets      `json:"facets,omitempty"`
+}
+
+type ProductSearchItem struct {
+	ProductResponse
+	Rank      float64         `json:"rank"`
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+// SearchHighlight holds snippets with matches wrapped in <mark></mark>. They are safe HTML:
+// the product text is escaped and <mark> is the only markup.
+type SearchHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProductFacets holds per-attribute product counts and a price histogram for the current filter set
+type ProductFacets struct {
+	Layout         []FacetCount  `json:"layout"`
+	SwitchType     []FacetCount  `json:"switch_type"`
+	ConnectionType []FacetCount  `json:"connection_type"`
+	Hotswap        []FacetCount  `json:"hotswap"`
+	LedType        []FacetCount  `json:"led_type"`
+	PriceHistogram []PriceBucket `json:"price_histogram"`
+
+	// Attributes holds the filterable attributes defined for the selected category
+	Attributes []AttributeFacet `json:"attributes"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type PriceBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+}
+
+type SuggestionResponse struct {
+	Text string `json:"text"`
+	Kind string `json:"kind"` // product, brand, switch, category, query
 }
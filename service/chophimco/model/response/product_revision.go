@@ -0,0 +1,39 @@
+package response
+
+import "time"
+
+type ProductRevisionResponse struct {
+	ID             int        `json:"id"`
+	ProductID      int        `json:"product_id"`
+	RevisionNumber int        `json:"revision_number"`
+	Status         string     `json:"status"`
+	SubmittedBy    int        `json:"submitted_by"`
+	Name           string     `json:"name"`
+	CategoryID     *int       `json:"category_id"`
+	Category       *string    `json:"category"`
+	BrandID        *int       `json:"brand_id"`
+	Brand          *string    `json:"brand"`
+	Description    string     `json:"description"`
+	BasePrice      float64    `json:"base_price"`
+	ReviewerID     *int       `json:"reviewer_id,omitempty"`
+	ReviewNote     string     `json:"review_note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// RevisionFieldChange is one changed field, category and brand are compared by name
+type RevisionFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type ProductRevisionDiff struct {
+	ProductID int `json:"product_id"`
+	// Base tells what From is: "revision", "live" for the current product when no approved
+	// revision exists yet, or "none" for a product that was never approved
+	Base    string                   `json:"base"`
+	From    *ProductRevisionResponse `json:"from,omitempty"`
+	To      ProductRevisionResponse  `json:"to"`
+	Changes []RevisionFieldChange    `json:"changes"`
+}
@@ -3,11 +3,62 @@ package response
 import "time"
 
 type ReviewResponse struct {
-	ID          int       `json:"id"`
-	UserName    string    `json:"user_name"`
-	ProductID   int       `json:"product_id"`
-	ProductName string    `json:"product_name"`
-	Rating      int       `json:"rating"`
-	Comment     string    `json:"comment"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID                 int                   `json:"id"`
+	UserName           string                `json:"user_name"`
+	ProductID          int                   `json:"product_id"`
+	ProductName        string                `json:"product_name"`
+	Rating             int                   `json:"rating"`
+	Comment            string                `json:"comment"`
+	IsVerifiedPurchase bool                  `json:"is_verified_purchase"`
+	HelpfulCount       int                   `json:"helpful_count"`
+	Status             string                `json:"status"` // published, or pending while a moderator looks at it
+	Photos             []ReviewPhotoResponse `json:"photos"`
+	Reply              *ReviewReplyResponse  `json:"reply,omitempty"`
+	CreatedAt          time.Time             `json:"created_at"`
+	EditedAt           *time.Time            `json:"edited_at,omitempty"`
+}
+
+type ReviewListResponse struct {
+	Reviews    []ReviewResponse `json:"reviews"`
+	Pagination Pagination       `json:"pagination"`
+}
+
+type ReviewPhotoResponse struct {
+	ID           int    `json:"id"`
+	ImageURL     string `json:"image_url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	MediumURL    string `json:"medium_url,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+// ReviewReplyResponse is the seller's public answer to a review
+type ReviewReplyResponse struct {
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReviewEditResponse is an earlier version of a review, replaced at EditedAt
+type ReviewEditResponse struct {
+	Rating   int       `json:"rating"`
+	Comment  string    `json:"comment"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+type ReviewHistoryResponse struct {
+	Review ReviewResponse       `json:"review"`
+	Edits  []ReviewEditResponse `json:"edits"` // newest first
+}
+
+// SellerReviewResponse is a buyer's review of a seller for one of their orders
+type SellerReviewResponse struct {
+	ID        int       `json:"id"`
+	BuyerName string    `json:"buyer_name"`
+	SellerID  int       `json:"seller_id"`
+	OrderID   int       `json:"order_id"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	Status    string    `json:"status"` // published, or pending while a moderator looks at it
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,55 @@
+package response
+
+import "time"
+
+type SellerKYCResponse struct {
+	UserID          int                               `json:"user_id"`
+	SellerProfileID int                               `json:"seller_profile_id"`
+	ShopName        string                            `json:"shop_name"`
+	Status          string                            `json:"status"`
+	Business        SellerBusinessDetails             `json:"business"`
+	Bank            SellerBankDetails                 `json:"bank"`
+	Documents       []SellerDocumentResponse          `json:"documents"`
+	Missing         []string                          `json:"missing"` // what is still needed before submitting
+	CanSubmit       bool                              `json:"can_submit"`
+	SubmittedAt     *time.Time                        `json:"submitted_at,omitempty"`
+	VerifiedAt      *time.Time                        `json:"verified_at,omitempty"`
+	History         []SellerVerificationEventResponse `json:"history"`
+}
+
+type SellerBusinessDetails struct {
+	BusinessType       string `json:"business_type"`
+	LegalName          string `json:"legal_name"`
+	RegistrationNumber string `json:"registration_number"`
+	RegisteredAddress  string `json:"registered_address"`
+	TaxID              string `json:"tax_id"`
+}
+
+type SellerBankDetails struct {
+	BankName          string `json:"bank_name"`
+	BankBranch        string `json:"bank_branch"`
+	BankAccountName   string `json:"bank_account_name"`
+	BankAccountNumber string `json:"bank_account_number"`
+}
+
+type SellerDocumentResponse struct {
+	ID          int        `json:"id"`
+	Type        string     `json:"type"`
+	FileName    string     `json:"file_name"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Status      string     `json:"status"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type SellerVerificationEventResponse struct {
+	ID         int       `json:"id"`
+	DocumentID *int      `json:"document_id,omitempty"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    int       `json:"actor_id"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
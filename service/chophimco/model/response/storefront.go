@@ -0,0 +1,43 @@
+package response
+
+import "time"
+
+// StorefrontResponse is the public page of a verified shop
+type StorefrontResponse struct {
+	ID               int                      `json:"id"`
+	SellerID         int                      `json:"seller_id"` // the seller's user ID, which products refer to
+	Slug             string                   `json:"slug"`
+	ShopName         string                   `json:"shop_name"`
+	ShopDescription  string                   `json:"shop_description"`
+	LogoURL          string                   `json:"logo_url,omitempty"`
+	LogoThumbnailURL string                   `json:"logo_thumbnail_url,omitempty"`
+	FollowerCount    int                      `json:"follower_count"`
+	IsFollowing      bool                     `json:"is_following"` // whether the signed-in viewer follows the shop
+	TotalSales       int                      `json:"total_sales"`
+	ProductCount     int64                    `json:"product_count"`
+	Rating           ShopRatingSummary        `json:"rating"`
+	Collections      []ShopCollectionResponse `json:"collections"`
+	CreatedAt        time.Time                `json:"created_at"`
+	VerifiedAt       *time.Time               `json:"verified_at,omitempty"`
+}
+
+type ShopRatingSummary struct {
+	Reviews       int64                 `json:"reviews"`
+	AverageRating float64               `json:"average_rating"`
+	Distribution  []RatingCountResponse `json:"distribution"` // reviews per star, 5 to 1
+}
+
+type ShopCollectionResponse struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Position     int       `json:"position"`
+	IsActive     bool      `json:"is_active"`
+	ProductCount int       `json:"product_count"`
+	ProductIDs   []int     `json:"product_ids,omitempty"` // seller view only
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Products previews the collection on the storefront, the full list is under
+	// /shop/{slug}/products?collection_id={id}
+	Products []ProductResponse `json:"products,omitempty"`
+}
@@ -3,15 +3,60 @@ package response
 import "time"
 
 type UserResponse struct {
-	ID        int       `json:"id"`
-	FullName  string    `json:"full_name"`
-	Email     string    `json:"email"`
-	Phone     string    `json:"phone"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int       `json:"id"`
+	FullName      string    `json:"full_name"`
+	Email         string    `json:"email"`
+	Phone         string    `json:"phone"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	HasPassword   bool      `json:"has_password"` // false for users who signed up with a social login
+	TwoFactor     bool      `json:"two_factor_enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TokenResponse is the credentials of a login session. The refresh token is single use,
+// each refresh returns a new one.
+type TokenResponse struct {
+	Token            string    `json:"token"` // access token
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	TokenResponse
+	User UserResponse `json:"user"`
+	// Challenge is set instead of the tokens when the user still has to pass two-factor authentication
+	Challenge *TwoFactorChallengeResponse `json:"-"`
+}
+
+// TwoFactorChallengeResponse is what login returns once the password is right but the second
+// factor is missing. The challenge token and a code complete the login.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"` // the user's role must use two-factor authentication
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// TwoFactorSetupResponse is the authenticator secret, shown once while enrolling
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to render as a QR code
+}
+
+// RecoveryCodesResponse lists new recovery codes, they cannot be shown again
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// OAuthStartResponse is where to send the browser to log in with a provider
+type OAuthStartResponse struct {
+	URL   string `json:"url"`
+	State string `json:"-"` // kept in a cookie, the callback must bring back the same state
 }
@@ -0,0 +1,17 @@
+package response
+
+type VariantMatrixResponse struct {
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Removed int  `json:"removed"`
+	Retired int  `json:"retired"` // kept with zero stock because they were ordered
+	DryRun  bool `json:"dry_run"`
+
+	Variants []VariantMatrixCell `json:"variants"`
+}
+
+type VariantMatrixCell struct {
+	ProductVariantResponse
+	Options map[string]string `json:"options"`
+	Status  string            `json:"status"` // created, updated
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type IAccountRepo interface {
+	CreateActionToken(ctx context.Context, token *entity.ActionToken) error
+	// VerifyEmail uses the verification token and marks the user's email verified. It reports
+	// false when the token was already used or does not belong to the user.
+	VerifyEmail(ctx context.Context, tokenID string, userID int) (bool, error)
+	// ResetPassword uses the reset token and sets the new password hash. Every other reset
+	// token of the user is used up with it, and the email counts as verified since the user
+	// received the link.
+	ResetPassword(ctx context.Context, tokenID string, userID int, passwordHash string) (bool, error)
+}
+
+type accountRepo struct {
+	db *gorm.DB
+}
+
+func NewAccountRepo(db *gorm.DB) IAccountRepo {
+	return &accountRepo{db: db}
+}
+
+func (r *accountRepo) CreateActionToken(ctx context.Context, token *entity.ActionToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *accountRepo) VerifyEmail(ctx context.Context, tokenID string, userID int) (bool, error) {
+	verified := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		used, err := useActionToken(tx, tokenID, userID, entity.ActionVerifyEmail, now)
+		if err != nil || !used {
+			return err
+		}
+		verified = true
+
+		return tx.Model(&entity.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userID).
+			Update("email_verified_at", now).Error
+	})
+	return verified, err
+}
+
+func (r *accountRepo) ResetPassword(ctx context.Context, tokenID string, userID int, passwordHash string) (bool, error) {
+	reset := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		used, err := useActionToken(tx, tokenID, userID, entity.ActionResetPassword, now)
+		if err != nil || !used {
+			return err
+		}
+		reset = true
+
+		err = tx.Model(&entity.ActionToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, entity.ActionResetPassword).
+			Update("used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.User{}).
+			Where("id = ?", userID).
+			Update("password_hash", passwordHash).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userID).
+			Update("email_verified_at", now).Error
+	})
+	return reset, err
+}
+
+// useActionToken marks an unused, unexpired token used, reporting whether it was
+func useActionToken(tx *gorm.DB, tokenID string, userID int, purpose string, now time.Time) (bool, error) {
+	result := tx.Model(&entity.ActionToken{}).
+		Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+			tokenID, userID, purpose, now).
+		Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
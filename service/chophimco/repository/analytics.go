@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IAnalyticsRepo interface {
+	// Series have one row per bucket of the interval (day, week or month) from from to to,
+	// buckets without data included
+	GetSalesSeries(ctx context.Context, sellerID int, interval string, from, to time.Time) ([]SalesBucketRow, error)
+	GetConversionSeries(ctx context.Context, sellerID int, interval string, from, to time.Time) ([]ConversionBucketRow, error)
+	GetRatingSeries(ctx context.Context, sellerID int, interval string, from, to time.Time) ([]RatingBucketRow, error)
+	GetRatingDistribution(ctx context.Context, sellerID int, from, to time.Time) ([]RatingCountRow, error)
+
+	GetTopVariants(ctx context.Context, sellerID int, from, to time.Time, byRevenue bool, limit int) ([]VariantSalesRow, error)
+	GetLowStockVariants(ctx context.Context, sellerID int, threshold int, soldSince time.Time) ([]LowStockRow, error)
+}
+
+type SalesBucketRow struct {
+	Bucket          time.Time `gorm:"column:bucket"`
+	Orders          int64     `gorm:"column:orders"`
+	UnitsOrdered    int64     `gorm:"column:units_ordered"`
+	UnitsRefunded   int64     `gorm:"column:units_refunded"`
+	GrossRevenue    float64   `gorm:"column:gross_revenue"`
+	RefundedRevenue float64   `gorm:"column:refunded_revenue"`
+}
+
+type ConversionBucketRow struct {
+	Bucket    time.Time `gorm:"column:bucket"`
+	CartAdds  int64     `gorm:"column:cart_adds"`
+	Converted int64     `gorm:"column:converted"`
+}
+
+type RatingBucketRow struct {
+	Bucket        time.Time `gorm:"column:bucket"`
+	Reviews       int64     `gorm:"column:reviews"`
+	AverageRating float64   `gorm:"column:average_rating"`
+}
+
+type RatingCountRow struct {
+	Rating int   `gorm:"column:rating"`
+	Count  int64 `gorm:"column:count"`
+}
+
+type VariantSalesRow struct {
+	VariantID   int     `gorm:"column:variant_id"`
+	SKU         string  `gorm:"column:sku"`
+	ProductID   int     `gorm:"column:product_id"`
+	ProductName string  `gorm:"column:product_name"`
+	Orders      int64   `gorm:"column:orders"`
+	UnitsSold   int64   `gorm:"column:units_sold"`
+	Revenue     float64 `gorm:"column:revenue"`
+}
+
+type LowStockRow struct {
+	VariantID   int    `gorm:"column:variant_id"`
+	SKU         string `gorm:"column:sku"`
+	ProductID   int    `gorm:"column:product_id"`
+	ProductName string `gorm:"column:product_name"`
+	IsActive    bool   `gorm:"column:is_active"`
+	Stock       int    `gorm:"column:stock"`
+	UnitsSold   int64  `gorm:"column:units_sold"`
+}
+
+// bucketsCTE lists the start of every bucket in [@from, @to)
+const bucketsCTE = `buckets AS (
+	SELECT generate_series(
+		date_trunc(@interval, CAST(@from AS timestamptz)),
+		CAST(@to AS timestamptz) - interval '1 microsecond',
+		CAST('1 ' || @interval AS interval)
+	) AS bucket
+)`
+
+// sellerItemsCTE is the seller's order items in orders placed in [@from, @to), cancelled orders excluded
+const sellerItemsCTE = `seller_items AS (
+	SELECT date_trunc(@interval, o.created_at) AS bucket, o.id AS order_id,
+		oi.product_variant_id, oi.price, oi.quantity, oi.refunded_quantity
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	JOIN product_variants v ON v.id = oi.product_variant_id
+	JOIN products p ON p.id = v.product_id
+	WHERE p.seller_id = @seller AND o.status <> 'cancelled'
+		AND o.created_at >= @from AND o.created_at < @to
+)`
+
+type analyticsRepo struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepo(db *gorm.DB) IAnalyticsRepo {
+	return &analyticsRepo{db: db}
+}
+
+func analyticsArgs(sellerID int, interval string, from, to time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"seller":   sellerID,
+		"interval": interval,
+		"from":     from,
+		"to":       to,
+	}
+}
+
+func (r *analyticsRepo) GetSalesSeries(ctx context.Context, sellerID int, interval string, from, to time.Time) ([]SalesBucketRow, error) {
+	var rows []SalesBucketRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH `+bucketsCTE+`, `+sellerItemsCTE+`
+		SELECT b.bucket,
+			COUNT(DISTINCT i.order_id) AS orders,
+			COALESCE(SUM(i.quantity), 0) AS units_ordered,
+			COALESCE(SUM(i.refunded_quantity), 0) AS units_refunded,
+			COALESCE(SUM(i.price * i.quantity), 0) AS gross_revenue,
+			COALESCE(SUM(i.price * i.refunded_quantity), 0) AS refunded_revenue
+		FROM buckets b
+		LEFT JOIN seller_items i ON i.bucket = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket`,
+		analyticsArgs(sellerID, interval, from, to)).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetConversionSeries counts a cart add as converted when the buyer ordered the variant afterwards
+func (r *analyticsRepo) GetConversionSeries(ctx context.Context, sellerID int, interval string, from, to time.Time) ([]ConversionBucketRow, error) {
+	var rows []ConversionBucketRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH `+bucketsCTE+`,
+		adds AS (
+			SELECT date_trunc(@interval, e.created_at) AS bucket,
+				EXISTS (
+					SELECT 1 FROM orders o
+					JOIN order_items oi ON oi.order_id = o.id
+					WHERE o.user_id = e.user_id AND oi.product_variant_id = e.product_variant_id
+						AND o.status <> 'cancelled' AND o.created_at >= e.created_at
+				) AS converted
+			FROM cart_add_events e
+			WHERE e.seller_id = @seller AND e.created_at >= @from AND e.created_at < @to
+		)
+		SELECT b.bucket,
+			COUNT(a.bucket) AS cart_adds,
+			COUNT(a.bucket) FILTER (WHERE a.converted) AS converted
+		FROM buckets b
+		LEFT JOIN adds a ON a.bucket = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket`,
+		analyticsArgs(sellerID, interval, from, to)).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *analyticsRepo) GetRatingSeries(ctx context.Context, sellerID int, interval string, from, to time.Time) ([]RatingBucketRow, error) {
+	var rows []RatingBucketRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH `+bucketsCTE+`,
+		seller_ratings AS (
+			SELECT date_trunc(@interval, sr.created_at) AS bucket, sr.rating
+			FROM seller_reviews sr
+			WHERE sr.seller_id = @seller AND sr.status = 'published' AND sr.created_at >= @from AND sr.created_at < @to
+		)
+		SELECT b.bucket,
+			COUNT(s.rating) AS reviews,
+			COALESCE(AVG(s.rating), 0) AS average_rating
+		FROM buckets b
+		LEFT JOIN seller_ratings s ON s.bucket = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket`,
+		analyticsArgs(sellerID, interval, from, to)).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *analyticsRepo) GetRatingDistribution(ctx context.Context, sellerID int, from, to time.Time) ([]RatingCountRow, error) {
+	var rows []RatingCountRow
+	err := r.db.WithContext(ctx).
+		Table("seller_reviews").
+		Select("rating, COUNT(*) AS count").
+		Where("seller_id = ? AND status = 'published' AND created_at >= ? AND created_at < ?", sellerID, from, to).
+		Group("rating").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *analyticsRepo) GetTopVariants(
+	ctx context.Context,
+	sellerID int,
+	from, to time.Time,
+	byRevenue bool,
+	limit int,
+) ([]VariantSalesRow, error) {
+	order := "units_sold DESC, revenue DESC"
+	if byRevenue {
+		order = "revenue DESC, units_sold DESC"
+	}
+
+	args := analyticsArgs(sellerID, "day", from, to)
+	args["limit"] = limit
+
+	var rows []VariantSalesRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH `+sellerItemsCTE+`
+		SELECT v.id AS variant_id, v.sku, p.id AS product_id, p.name AS product_name,
+			COUNT(DISTINCT i.order_id) AS orders,
+			SUM(i.quantity - i.refunded_quantity) AS units_sold,
+			SUM(i.price * (i.quantity - i.refunded_quantity)) AS revenue
+		FROM seller_items i
+		JOIN product_variants v ON v.id = i.product_variant_id
+		JOIN products p ON p.id = v.product_id
+		GROUP BY v.id, v.sku, p.id, p.name
+		ORDER BY `+order+`, v.id
+		LIMIT @limit`,
+		args).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *analyticsRepo) GetLowStockVariants(ctx context.Context, sellerID int, threshold int, soldSince time.Time) ([]LowStockRow, error) {
+	var rows []LowStockRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT v.id AS variant_id, v.sku, p.id AS product_id, p.name AS product_name, p.is_active,
+			v.stock, COALESCE(sold.units, 0) AS units_sold
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(oi.quantity - oi.refunded_quantity) AS units
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE oi.product_variant_id = v.id AND o.status <> 'cancelled' AND o.created_at >= @since
+		) sold ON true
+		WHERE p.seller_id = @seller AND v.stock <= @threshold
+		ORDER BY v.stock, units_sold DESC, v.id`,
+		map[string]interface{}{
+			"seller":    sellerID,
+			"threshold": threshold,
+			"since":     soldSince,
+		}).
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// effectiveDefinitionsSQL resolves the definitions applying to a category: its own, its
+// ancestors' and global ones. For a code defined at several levels the nearest one wins.
+const effectiveDefinitionsSQL = `
+	WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id, 0 AS depth FROM categories WHERE id = ?
+		UNION ALL
+		SELECT c.id, c.parent_id, a.depth + 1
+		FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		WHERE a.depth < 32
+	)
+	SELECT * FROM (
+		SELECT DISTINCT ON (ad.code) ad.*
+		FROM attribute_definitions ad
+		LEFT JOIN ancestors a ON a.id = ad.category_id
+		WHERE ad.category_id IS NULL OR a.id IS NOT NULL
+		ORDER BY ad.code, COALESCE(a.depth, 2147483647)
+	) effective
+	ORDER BY display_order ASC, code ASC`
+
+type IAttributeRepo interface {
+	// GetEffectiveDefinitions returns the definitions applying to a category, or only the
+	// global ones when categoryID is nil
+	GetEffectiveDefinitions(ctx context.Context, categoryID *int) ([]entity.AttributeDefinition, error)
+	GetDefinitionByID(ctx context.Context, id int) (*entity.AttributeDefinition, error)
+	DefinitionCodeExists(ctx context.Context, categoryID *int, code string, excludeID int) (bool, error)
+	CreateDefinition(ctx context.Context, definition *entity.AttributeDefinition) error
+	UpdateDefinition(ctx context.Context, definition *entity.AttributeDefinition) error
+	// DeleteDefinition removes the definition together with the variant values using it
+	DeleteDefinition(ctx context.Context, id int) error
+
+	// AddVariantAttributes inserts values, keeping any a variant already has for the definition
+	AddVariantAttributes(ctx context.Context, attributes []entity.ProductVariantAttribute) error
+	// GetVariantsInCategory returns the variants of products in a category subtree, or of all products when nil
+	GetVariantsInCategory(ctx context.Context, categoryID *int) ([]entity.ProductVariant, error)
+}
+
+type attributeRepo struct {
+	db *gorm.DB
+}
+
+func NewAttributeRepo(db *gorm.DB) IAttributeRepo {
+	return &attributeRepo{db: db}
+}
+
+func (r *attributeRepo) GetEffectiveDefinitions(ctx context.Context, categoryID *int) ([]entity.AttributeDefinition, error) {
+	var definitions []entity.AttributeDefinition
+	if categoryID == nil {
+		err := r.db.WithContext(ctx).
+			Where("category_id IS NULL").
+			Order("display_order ASC, code ASC").
+			Find(&definitions).Error
+		return definitions, err
+	}
+
+	err := r.db.WithContext(ctx).Raw(effectiveDefinitionsSQL, *categoryID).Scan(&definitions).Error
+	return definitions, err
+}
+
+func (r *attributeRepo) GetDefinitionByID(ctx context.Context, id int) (*entity.AttributeDefinition, error) {
+	var definition entity.AttributeDefinition
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&definition).Error
+	return &definition, err
+}
+
+func (r *attributeRepo) DefinitionCodeExists(ctx context.Context, categoryID *int, code string, excludeID int) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&entity.AttributeDefinition{}).
+		Where("code = ? AND id <> ?", code, excludeID)
+	if categoryID == nil {
+		query = query.Where("category_id IS NULL")
+	} else {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *attributeRepo) CreateDefinition(ctx context.Context, definition *entity.AttributeDefinition) error {
+	return r.db.WithContext(ctx).Create(definition).Error
+}
+
+func (r *attributeRepo) UpdateDefinition(ctx context.Context, definition *entity.AttributeDefinition) error {
+	return r.db.WithContext(ctx).Save(definition).Error
+}
+
+func (r *attributeRepo) DeleteDefinition(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attribute_definition_id = ?", id).Delete(&entity.ProductVariantAttribute{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.AttributeDefinition{}, id).Error
+	})
+}
+
+func (r *attributeRepo) AddVariantAttributes(ctx context.Context, attributes []entity.ProductVariantAttribute) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Omit("Definition").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&attributes, 500).Error
+}
+
+func (r *attributeRepo) GetVariantsInCategory(ctx context.Context, categoryID *int) ([]entity.ProductVariant, error) {
+	query := r.db.WithContext(ctx).Model(&entity.ProductVariant{})
+	if categoryID != nil {
+		query = query.
+			Joins("JOIN products p ON p.id = product_variants.product_id").
+			Where("p.category_id IN ("+categorySubtreeSQL+")", *categoryID)
+	}
+
+	var variants []entity.ProductVariant
+	err := query.Find(&variants).Error
+	return variants, err
+}
@@ -12,6 +12,7 @@ type ICartRepo interface {
 	UpdateCartItem(item *entity.CartItem) error
 	RemoveCartItem(itemID int) error
 	ClearCart(cartID int) error
+	RecordCartAdd(event *entity.CartAddEvent) error
 }
 
 type cartRepo struct {
@@ -52,3 +53,7 @@ func (r *cartRepo) RemoveCartItem(itemID int) error {
 func (r *cartRepo) ClearCart(cartID int) error {
 	return r.db.Where("cart_id = ?", cartID).Delete(&entity.CartItem{}).Error
 }
+
+func (r *cartRepo) RecordCartAdd(event *entity.CartAddEvent) error {
+	return r.db.Create(event).Error
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type ICatalogRepo interface {
+	// Categories
+	GetAllCategories(ctx context.Context) ([]entity.Category, error)
+	GetCategoryByID(ctx context.Context, id int) (*entity.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*entity.Category, error)
+	CategorySlugExists(ctx context.Context, slug string, excludeID int) (bool, error)
+	CreateCategory(ctx context.Context, category *entity.Category) error
+	UpdateCategory(ctx context.Context, category *entity.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+	CountChildCategories(ctx context.Context, id int) (int64, error)
+	CountProductsByCategory(ctx context.Context, id int) (int64, error)
+
+	// Brands
+	GetAllBrands(ctx context.Context) ([]entity.Brand, error)
+	GetBrandByID(ctx context.Context, id int) (*entity.Brand, error)
+	BrandNameExists(ctx context.Context, name string, excludeID int) (bool, error)
+	CreateBrand(ctx context.Context, brand *entity.Brand) error
+	UpdateBrand(ctx context.Context, brand *entity.Brand) error
+	DeleteBrand(ctx context.Context, id int) error
+	CountProductsByBrand(ctx context.Context, id int) (int64, error)
+
+	// Switches
+	GetAllSwitches(ctx context.Context) ([]entity.Switch, error)
+	GetSwitchByID(ctx context.Context, id int) (*entity.Switch, error)
+	CreateSwitch(ctx context.Context, sw *entity.Switch) error
+	UpdateSwitch(ctx context.Context, sw *entity.Switch) error
+	DeleteSwitch(ctx context.Context, id int) error
+	CountVariantsBySwitch(ctx context.Context, id int) (int64, error)
+}
+
+type catalogRepo struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepo(db *gorm.DB) ICatalogRepo {
+	return &catalogRepo{db: db}
+}
+
+// Categories
+func (r *catalogRepo) GetAllCategories(ctx context.Context) ([]entity.Category, error) {
+	var categories []entity.Category
+	err := r.db.WithContext(ctx).Order("display_order ASC, name ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *catalogRepo) GetCategoryByID(ctx context.Context, id int) (*entity.Category, error) {
+	var category entity.Category
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&category).Error
+	return &category, err
+}
+
+func (r *catalogRepo) GetCategoryBySlug(ctx context.Context, slug string) (*entity.Category, error) {
+	var category entity.Category
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error
+	return &category, err
+}
+
+func (r *catalogRepo) CategorySlugExists(ctx context.Context, slug string, excludeID int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Category{}).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *catalogRepo) CreateCategory(ctx context.Context, category *entity.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *catalogRepo) UpdateCategory(ctx context.Context, category *entity.Category) error {
+	return r.db.WithContext(ctx).Model(category).
+		Select("parent_id", "name", "slug", "display_order").
+		Updates(category).Error
+}
+
+func (r *catalogRepo) DeleteCategory(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&entity.Category{}, id).Error
+}
+
+func (r *catalogRepo) CountChildCategories(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *catalogRepo) CountProductsByCategory(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// Brands
+func (r *catalogRepo) GetAllBrands(ctx context.Context) ([]entity.Brand, error) {
+	var brands []entity.Brand
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&brands).Error
+	return brands, err
+}
+
+func (r *catalogRepo) GetBrandByID(ctx context.Context, id int) (*entity.Brand, error) {
+	var brand entity.Brand
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&brand).Error
+	return &brand, err
+}
+
+func (r *catalogRepo) BrandNameExists(ctx context.Context, name string, excludeID int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Brand{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *catalogRepo) CreateBrand(ctx context.Context, brand *entity.Brand) error {
+	return r.db.WithContext(ctx).Create(brand).Error
+}
+
+func (r *catalogRepo) UpdateBrand(ctx context.Context, brand *entity.Brand) error {
+	return r.db.WithContext(ctx).Save(brand).Error
+}
+
+func (r *catalogRepo) DeleteBrand(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&entity.Brand{}, id).Error
+}
+
+func (r *catalogRepo) CountProductsByBrand(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Product{}).Where("brand_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// Switches
+func (r *catalogRepo) GetAllSwitches(ctx context.Context) ([]entity.Switch, error) {
+	var switches []entity.Switch
+	err := r.db.WithContext(ctx).Order("brand ASC, name ASC").Find(&switches).Error
+	return switches, err
+}
+
+func (r *catalogRepo) GetSwitchByID(ctx context.Context, id int) (*entity.Switch, error) {
+	var sw entity.Switch
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&sw).Error
+	return &sw, err
+}
+
+func (r *catalogRepo) CreateSwitch(ctx context.Context, sw *entity.Switch) error {
+	return r.db.WithContext(ctx).Create(sw).Error
+}
+
+func (r *catalogRepo) UpdateSwitch(ctx context.Context, sw *entity.Switch) error {
+	return r.db.WithContext(ctx).Save(sw).Error
+}
+
+func (r *catalogRepo) DeleteSwitch(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&entity.Switch{}, id).Error
+}
+
+func (r *catalogRepo) CountVariantsBySwitch(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.ProductVariant{}).Where("switch_id = ?", id).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrFlashSaleSoldOut      = errors.New("flash sale item is sold out")
+	ErrFlashSaleLimitReached = errors.New("flash sale purchase limit reached")
+	ErrFlashSaleOverlap      = errors.New("another flash sale discounts the variant at the same time")
+)
+
+type IFlashSaleRepo interface {
+	// CreateFlashSale refuses, with ErrFlashSaleOverlap, a sale whose variants are in another
+	// sale that isn't cancelled and overlaps it in time
+	CreateFlashSale(ctx context.Context, sale *entity.FlashSale) error
+	GetFlashSaleByID(ctx context.Context, id int) (*entity.FlashSale, error)
+	GetVisibleFlashSales(ctx context.Context, now time.Time) ([]entity.FlashSale, error)
+	CancelFlashSale(ctx context.Context, id int) error
+	GetFlashSaleItemByID(ctx context.Context, id int) (*entity.FlashSaleItem, error)
+
+	// PurchaseFlashSaleItem reserves quantity units of the item for the user and
+	// creates the order in a single transaction. It returns the remaining sale quantity.
+	// The order item's list price is the variant's regular price, the markdown goes to the ledger.
+	PurchaseFlashSaleItem(ctx context.Context, item *entity.FlashSaleItem, userID int, quantity int, order *entity.Order) (int, error)
+}
+
+type flashSaleRepo struct {
+	db *gorm.DB
+}
+
+func NewFlashSaleRepo(db *gorm.DB) IFlashSaleRepo {
+	return &flashSaleRepo{db: db}
+}
+
+func (r *flashSaleRepo) CreateFlashSale(ctx context.Context, sale *entity.FlashSale) error {
+	variantIDs := make([]int, 0, len(sale.Items))
+	for _, item := range sale.Items {
+		variantIDs = append(variantIDs, item.ProductVariantID)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Sales of the same variants are created one at a time, so two can't both pass the check
+		var locked []int
+		err := tx.Model(&entity.ProductVariant{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", variantIDs).
+			Order("id").
+			Pluck("id", &locked).Error
+		if err != nil {
+			return err
+		}
+
+		var overlapping []int
+		err = tx.Table("flash_sale_items fsi").
+			Joins("JOIN flash_sales fs ON fs.id = fsi.flash_sale_id").
+			Where("fsi.product_variant_id IN ? AND NOT fs.is_cancelled AND fs.start_at < ? AND fs.end_at > ?",
+				variantIDs, sale.EndAt, sale.StartAt).
+			Order("fsi.product_variant_id").
+			Limit(1).
+			Pluck("fsi.product_variant_id", &overlapping).Error
+		if err != nil {
+			return err
+		}
+		if len(overlapping) > 0 {
+			return fmt.Errorf("variant %d: %w", overlapping[0], ErrFlashSaleOverlap)
+		}
+
+		return tx.Create(sale).Error
+	})
+}
+
+func (r *flashSaleRepo) GetFlashSaleByID(ctx context.Context, id int) (*entity.FlashSale, error) {
+	var sale entity.FlashSale
+	err := r.db.WithContext(ctx).
+		Preload("Items.ProductVariant.Product").
+		Preload("Items.ProductVariant.Switch").
+		Where("id = ?", id).
+		First(&sale).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+func (r *flashSaleRepo) GetVisibleFlashSales(ctx context.Context, now time.Time) ([]entity.FlashSale, error) {
+	var sales []entity.FlashSale
+	err := r.db.WithContext(ctx).
+		Preload("Items.ProductVariant.Product").
+		Preload("Items.ProductVariant.Switch").
+		Where("is_cancelled = ? AND end_at > ?", false, now).
+		Order("start_at ASC").
+		Find(&sales).Error
+	return sales, err
+}
+
+func (r *flashSaleRepo) CancelFlashSale(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.FlashSale{}).
+		Where("id = ?", id).
+		Update("is_cancelled", true).Error
+}
+
+func (r *flashSaleRepo) GetFlashSaleItemByID(ctx context.Context, id int) (*entity.FlashSaleItem, error) {
+	var item entity.FlashSaleItem
+	err := r.db.WithContext(ctx).
+		Preload("FlashSale").
+		Preload("ProductVariant.Product").
+		Where("id = ?", id).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *flashSaleRepo) PurchaseFlashSaleItem(
+	ctx context.Context,
+	item *entity.FlashSaleItem,
+	userID int,
+	quantity int,
+	order *entity.Order,
+) (int, error) {
+	var remaining int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Per-user limit: the upsert only applies while the running total stays within
+		// the limit, and concurrent requests of the same user serialize on the row.
+		result := tx.Exec(`
+			INSERT INTO flash_sale_purchases (flash_sale_item_id, user_id, quantity, updated_at)
+			SELECT id, ?, ?, now() FROM flash_sale_items WHERE id = ? AND ? <= per_user_limit
+			ON CONFLICT (flash_sale_item_id, user_id) DO UPDATE
+			SET quantity = flash_sale_purchases.quantity + EXCLUDED.quantity, updated_at = now()
+			WHERE flash_sale_purchases.quantity + EXCLUDED.quantity <= (
+				SELECT per_user_limit FROM flash_sale_items WHERE id = EXCLUDED.flash_sale_item_id
+			)`, userID, quantity, item.ID, quantity)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFlashSaleLimitReached
+		}
+
+		// Sale quantity: conditional decrement so the item can never be oversold.
+		sold := tx.Raw(`
+			UPDATE flash_sale_items SET sold_quantity = sold_quantity + ?
+			WHERE id = ? AND sold_quantity + ? <= sale_quantity
+			RETURNING sale_quantity - sold_quantity`, quantity, item.ID, quantity).
+			Scan(&remaining)
+		if sold.Error != nil {
+			return sold.Error
+		}
+		if sold.RowsAffected == 0 {
+			return ErrFlashSaleSoldOut
+		}
+
+		// Regular stock backs the sale quantity, so it is decremented as well.
+		var listPrice float64
+		stock := tx.Raw(`
+			UPDATE product_variants SET stock = stock - ?
+			WHERE id = ? AND stock >= ?
+			RETURNING price`, quantity, item.ProductVariantID, quantity).
+			Scan(&listPrice)
+		if stock.Error != nil {
+			return stock.Error
+		}
+		if stock.RowsAffected == 0 {
+			return ErrFlashSaleSoldOut
+		}
+
+		for i := range order.OrderItems {
+			if order.OrderItems[i].ProductVariantID == item.ProductVariantID {
+				order.OrderItems[i].ListPrice = listPrice
+			}
+		}
+		return tx.Create(order).Error
+	})
+	return remaining, err
+}
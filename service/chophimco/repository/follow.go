@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IFollowRepo interface {
+	// Follow and Unfollow keep the shop's follower count in step, and report whether anything changed
+	Follow(ctx context.Context, follow *entity.ShopFollow) (bool, error)
+	Unfollow(ctx context.Context, userID int, sellerProfileID int) (bool, error)
+	IsFollowing(ctx context.Context, userID int, sellerProfileID int) (bool, error)
+	// GetFollowedShops returns the shops a user follows, most recently followed first
+	GetFollowedShops(ctx context.Context, userID int, offset, limit int) ([]FollowedShopRow, int64, error)
+	GetFollowerIDs(ctx context.Context, sellerProfileID int) ([]int, error)
+
+	// GetFeed returns launches and running sales of the user's followed shops, newest first. Rows are
+	// ordered by (occurred_at, product_id) and only those before (before, beforeID) are returned.
+	GetFeed(ctx context.Context, userID int, before time.Time, beforeID int, limit int) ([]FeedRow, error)
+}
+
+type FollowedShopRow struct {
+	SellerProfileID  int       `gorm:"column:seller_profile_id"`
+	Slug             string    `gorm:"column:slug"`
+	ShopName         string    `gorm:"column:shop_name"`
+	LogoThumbnailURL string    `gorm:"column:logo_thumbnail_url"`
+	FollowerCount    int       `gorm:"column:follower_count"`
+	FollowedAt       time.Time `gorm:"column:followed_at"`
+}
+
+type FeedRow struct {
+	Type             string     `gorm:"column:type"`
+	OccurredAt       time.Time  `gorm:"column:occurred_at"`
+	SellerProfileID  int        `gorm:"column:seller_profile_id"`
+	Slug             string     `gorm:"column:slug"`
+	ShopName         string     `gorm:"column:shop_name"`
+	LogoThumbnailURL string     `gorm:"column:logo_thumbnail_url"`
+	ProductID        int        `gorm:"column:product_id"`
+	FlashSaleID      *int       `gorm:"column:flash_sale_id"`
+	SaleName         *string    `gorm:"column:sale_name"`
+	SalePrice        *float64   `gorm:"column:sale_price"`
+	DiscountType     *string    `gorm:"column:discount_type"`
+	DiscountValue    *float64   `gorm:"column:discount_value"`
+	EndsAt           *time.Time `gorm:"column:ends_at"`
+}
+
+// Feed row types
+const (
+	FeedTypeNewProduct = "new_product"
+	FeedTypeFlashSale  = "flash_sale"
+	FeedTypeDiscount   = "discount"
+)
+
+type followRepo struct {
+	db *gorm.DB
+}
+
+func NewFollowRepo(db *gorm.DB) IFollowRepo {
+	return &followRepo{db: db}
+}
+
+func (r *followRepo) Follow(ctx context.Context, follow *entity.ShopFollow) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(follow)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return tx.Model(&entity.SellerProfile{}).
+			Where("id = ?", follow.SellerProfileID).
+			Update("follower_count", gorm.Expr("follower_count + 1")).Error
+	})
+	return created, err
+}
+
+func (r *followRepo) Unfollow(ctx context.Context, userID int, sellerProfileID int) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND seller_profile_id = ?", userID, sellerProfileID).Delete(&entity.ShopFollow{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		deleted = true
+		return tx.Model(&entity.SellerProfile{}).
+			Where("id = ? AND follower_count > 0", sellerProfileID).
+			Update("follower_count", gorm.Expr("follower_count - 1")).Error
+	})
+	return deleted, err
+}
+
+func (r *followRepo) IsFollowing(ctx context.Context, userID int, sellerProfileID int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.ShopFollow{}).
+		Where("user_id = ? AND seller_profile_id = ?", userID, sellerProfileID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *followRepo) GetFollowedShops(ctx context.Context, userID int, offset, limit int) ([]FollowedShopRow, int64, error) {
+	query := r.db.WithContext(ctx).
+		Table("shop_follows f").
+		Joins("JOIN seller_profiles sp ON sp.id = f.seller_profile_id").
+		Where("f.user_id = ? AND sp.verification_status = ?", userID, entity.SellerStatusVerified)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []FollowedShopRow
+	err := query.
+		Select("sp.id AS seller_profile_id, sp.slug, sp.shop_name, sp.logo_thumbnail_url, sp.follower_count, f.created_at AS followed_at").
+		Order("f.created_at DESC, f.id DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, total, err
+}
+
+func (r *followRepo) GetFollowerIDs(ctx context.Context, sellerProfileID int) ([]int, error) {
+	var ids []int
+	err := r.db.WithContext(ctx).
+		Model(&entity.ShopFollow{}).
+		Where("seller_profile_id = ?", sellerProfileID).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// GetFeed merges three kinds of events of the followed shops' live products: their first approval,
+// flash sales running now, and product discounts running now
+func (r *followRepo) GetFeed(ctx context.Context, userID int, before time.Time, beforeID int, limit int) ([]FeedRow, error) {
+	var rows []FeedRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH followed AS (
+			SELECT sp.id AS seller_profile_id, sp.user_id, sp.slug, sp.shop_name, sp.logo_thumbnail_url
+			FROM shop_follows f
+			JOIN seller_profiles sp ON sp.id = f.seller_profile_id
+			WHERE f.user_id = @user AND sp.verification_status = @verified
+		),
+		live AS (
+			SELECT p.id, p.approved_at, fo.*
+			FROM products p
+			JOIN followed fo ON fo.user_id = p.seller_id
+			WHERE p.is_active AND p.approval_status = 'approved'
+		),
+		events AS (
+			SELECT CAST(@new_product AS text) AS type, l.approved_at AS occurred_at, l.seller_profile_id, l.slug, l.shop_name,
+				l.logo_thumbnail_url, l.id AS product_id, NULL::int AS flash_sale_id, NULL::text AS sale_name,
+				NULL::numeric AS sale_price, NULL::text AS discount_type, NULL::numeric AS discount_value,
+				NULL::timestamptz AS ends_at
+			FROM live l
+			WHERE l.approved_at IS NOT NULL
+			UNION ALL
+			SELECT CAST(@flash_sale AS text), fs.start_at, l.seller_profile_id, l.slug, l.shop_name,
+				l.logo_thumbnail_url, l.id, fs.id, fs.name,
+				MIN(fsi.sale_price), NULL, NULL,
+				fs.end_at
+			FROM flash_sales fs
+			JOIN flash_sale_items fsi ON fsi.flash_sale_id = fs.id
+			JOIN product_variants pv ON pv.id = fsi.product_variant_id
+			JOIN live l ON l.id = pv.product_id
+			WHERE NOT fs.is_cancelled AND fs.start_at <= now() AND fs.end_at > now()
+			GROUP BY fs.id, fs.name, fs.start_at, fs.end_at, l.seller_profile_id, l.slug, l.shop_name, l.logo_thumbnail_url, l.id
+			UNION ALL
+			SELECT CAST(@discount AS text), COALESCE(d.start_at, d.created_at), l.seller_profile_id, l.slug, l.shop_name,
+				l.logo_thumbnail_url, l.id, NULL, NULL,
+				NULL, d.discount_type, d.discount_value,
+				d.end_at
+			FROM product_discounts d
+			JOIN live l ON l.id = d.product_id
+			WHERE d.is_active AND (d.start_at IS NULL OR d.start_at <= now()) AND (d.end_at IS NULL OR d.end_at > now())
+		)
+		SELECT * FROM events
+		WHERE (occurred_at, product_id) < (@before, @before_id)
+		ORDER BY occurred_at DESC, product_id DESC
+		LIMIT @limit`,
+		map[string]interface{}{
+			"user":        userID,
+			"verified":    entity.SellerStatusVerified,
+			"new_product": FeedTypeNewProduct,
+			"flash_sale":  FeedTypeFlashSale,
+			"discount":    FeedTypeDiscount,
+			"before":      before,
+			"before_id":   beforeID,
+			"limit":       limit,
+		}).
+		Scan(&rows).Error
+	return rows, err
+}
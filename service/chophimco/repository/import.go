@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type IImportRepo interface {
+	CreateJob(ctx context.Context, job *entity.ImportJob) error
+	UpdateJob(ctx context.Context, job *entity.ImportJob) error
+	GetJobByIDAndSeller(ctx context.Context, id int, sellerID int) (*entity.ImportJob, error)
+	// FailUnfinishedJobs marks every pending or running job failed with message and returns how many were
+	FailUnfinishedJobs(ctx context.Context, message string) (int64, error)
+
+	// ImportProducts creates the products with their variants, attributes and images in one
+	// transaction, along with their first revision. Nested relations other than those must be nil.
+	ImportProducts(ctx context.Context, products []*entity.Product) error
+	// GetSellerCatalog returns all products of a seller with everything needed for export
+	GetSellerCatalog(ctx context.Context, sellerID int) ([]entity.Product, error)
+}
+
+type importRepo struct {
+	db *gorm.DB
+}
+
+func NewImportRepo(db *gorm.DB) IImportRepo {
+	return &importRepo{db: db}
+}
+
+func (r *importRepo) CreateJob(ctx context.Context, job *entity.ImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *importRepo) UpdateJob(ctx context.Context, job *entity.ImportJob) error {
+	return r.db.WithContext(ctx).Omit("Seller").Save(job).Error
+}
+
+func (r *importRepo) GetJobByIDAndSeller(ctx context.Context, id int, sellerID int) (*entity.ImportJob, error) {
+	var job entity.ImportJob
+	err := r.db.WithContext(ctx).Where("id = ? AND seller_id = ?", id, sellerID).First(&job).Error
+	return &job, err
+}
+
+func (r *importRepo) FailUnfinishedJobs(ctx context.Context, message string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&entity.ImportJob{}).
+		Where("status IN ?", []string{entity.ImportJobPending, entity.ImportJobRunning}).
+		Updates(map[string]interface{}{
+			"status":      entity.ImportJobFailed,
+			"message":     message,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *importRepo) ImportProducts(ctx context.Context, products []*entity.Product) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, product := range products {
+			if err := tx.Omit("Seller", "Category", "Brand").Create(product).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *importRepo) GetSellerCatalog(ctx context.Context, sellerID int) ([]entity.Product, error) {
+	var products []entity.Product
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Variants.Switch").
+		Preload("Variants.Attributes.Definition").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("display_order ASC, id ASC") }).
+		Where("seller_id = ?", sellerID).
+		Order("id ASC").
+		Find(&products).Error
+	return products, err
+}
@@ -0,0 +1,632 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrRefundExceedsQuantity = errors.New("refund quantity exceeds the quantity left to refund")
+
+// payoutLockKey is the advisory lock serializing payout batch creation across instances
+const payoutLockKey = 5_040_201
+
+type ILedgerRepo interface {
+	// GetOrderForSettlement returns an order with its items, variants and products
+	GetOrderForSettlement(ctx context.Context, orderID int) (*entity.Order, error)
+	// RecordSale saves the sale entries of an order and adds the units sold to the sellers'
+	// total sales. It returns false when the order was already recorded.
+	RecordSale(ctx context.Context, orderID int, entries []entity.LedgerEntry, unitsBySeller map[int]int) (bool, error)
+	GetSaleLines(ctx context.Context, orderID int) ([]entity.LedgerLine, error)
+	// RecordRefund saves a refund with its entries, keyed by seller, and raises the refunded
+	// quantities of the order items. The order becomes refunded once nothing is left to refund.
+	RecordRefund(ctx context.Context, refund *entity.OrderRefund, entries map[int]*entity.LedgerEntry, unitsBySeller map[int]int) error
+
+	// Commission rules
+	GetCommissionRules(ctx context.Context) ([]entity.CommissionRule, error)
+	GetCommissionRuleByID(ctx context.Context, id int) (*entity.CommissionRule, error)
+	// SaveCommissionRule creates the rule or replaces the rate of the existing rule for the same seller or category
+	SaveCommissionRule(ctx context.Context, rule *entity.CommissionRule) error
+	DeleteCommissionRule(ctx context.Context, id int) error
+
+	// Seller earnings
+	GetSellerComponentTotals(ctx context.Context, sellerID int, from, to time.Time) ([]LedgerComponentTotal, error)
+	// GetSellerBalance sums an account of the seller from earnings recorded up to earnedBefore
+	GetSellerBalance(ctx context.Context, sellerID int, account string, earnedBefore time.Time) (float64, error)
+	GetSellerPaidOut(ctx context.Context, sellerID int, from, to time.Time) (float64, error)
+	GetSellerLines(ctx context.Context, sellerID int, offset, limit int) ([]LedgerLineRow, int64, error)
+	GetPayoutsBySeller(ctx context.Context, sellerID int, limit int) ([]entity.Payout, error)
+
+	// Payouts
+	// CreatePayoutBatch pays out every verified seller whose balance from earnings recorded up to
+	// batch.PeriodEnd reaches minAmount. It returns gorm.ErrRecordNotFound when nobody is due.
+	CreatePayoutBatch(ctx context.Context, batch *entity.PayoutBatch, minAmount float64, newEntry func(*entity.Payout) entity.LedgerEntry) error
+	GetPayoutBatches(ctx context.Context, offset, limit int) ([]entity.PayoutBatch, int64, error)
+	GetPayoutBatchByID(ctx context.Context, id int) (*entity.PayoutBatch, error)
+	GetPayoutByID(ctx context.Context, id int) (*entity.Payout, error)
+	// ChangeBatchStatus moves a batch from one status to another, gorm.ErrRecordNotFound when it is no longer in from
+	ChangeBatchStatus(ctx context.Context, id int, from, to string) error
+	// CancelPayoutBatch cancels a pending batch and saves the reversal entries of its payouts
+	CancelPayoutBatch(ctx context.Context, id int, entries []entity.LedgerEntry) error
+	// SettlePayout saves the outcome of a pending payout with its entry, and completes the batch
+	// once no payout is pending. It returns gorm.ErrRecordNotFound when the payout is not pending.
+	SettlePayout(ctx context.Context, payout *entity.Payout, entry *entity.LedgerEntry) error
+
+	// Reconciliation
+	GetAccountTotals(ctx context.Context, from, to time.Time) ([]LedgerAccountTotal, error)
+	GetUnbalancedEntries(ctx context.Context, from, to time.Time) ([]int, error)
+	GetOrderSettlements(ctx context.Context, from, to time.Time) ([]OrderSettlementRow, error)
+	GetPayoutTotals(ctx context.Context, from, to time.Time) ([]PayoutStatusTotal, error)
+}
+
+// LedgerComponentTotal sums the seller payable lines of one component
+type LedgerComponentTotal struct {
+	Component string  `gorm:"column:component"`
+	Debit     float64 `gorm:"column:debit"`
+	Credit    float64 `gorm:"column:credit"`
+}
+
+// LedgerLineRow is a ledger line with the entry it belongs to
+type LedgerLineRow struct {
+	ID          int       `gorm:"column:id"`
+	EntryType   string    `gorm:"column:entry_type"`
+	OrderID     *int      `gorm:"column:order_id"`
+	PayoutID    *int      `gorm:"column:payout_id"`
+	Description string    `gorm:"column:description"`
+	Component   string    `gorm:"column:component"`
+	OrderItemID *int      `gorm:"column:order_item_id"`
+	Debit       float64   `gorm:"column:debit"`
+	Credit      float64   `gorm:"column:credit"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+type LedgerAccountTotal struct {
+	Account string  `gorm:"column:account"`
+	Debit   float64 `gorm:"column:debit"`
+	Credit  float64 `gorm:"column:credit"`
+}
+
+// OrderSettlementRow compares what a completed order collected with what the ledger recorded
+type OrderSettlementRow struct {
+	OrderID        int     `gorm:"column:order_id"`
+	Status         string  `gorm:"column:status"`
+	TotalAmount    float64 `gorm:"column:total_amount"`
+	RefundedAmount float64 `gorm:"column:refunded_amount"`
+	LedgerCash     float64 `gorm:"column:ledger_cash"`
+	Settled        bool    `gorm:"column:settled"`
+}
+
+type PayoutStatusTotal struct {
+	Status string  `gorm:"column:status"`
+	Count  int64   `gorm:"column:count"`
+	Amount float64 `gorm:"column:amount"`
+}
+
+type sellerBalanceRow struct {
+	SellerID int     `gorm:"column:seller_id"`
+	Balance  float64 `gorm:"column:balance"`
+}
+
+type ledgerRepo struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepo(db *gorm.DB) ILedgerRepo {
+	return &ledgerRepo{db: db}
+}
+
+func (r *ledgerRepo) GetOrderForSettlement(ctx context.Context, orderID int) (*entity.Order, error) {
+	var order entity.Order
+	err := r.db.WithContext(ctx).
+		Preload("OrderItems", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("OrderItems.ProductVariant.Product").
+		Where("id = ?", orderID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *ledgerRepo) RecordSale(
+	ctx context.Context,
+	orderID int,
+	entries []entity.LedgerEntry,
+	unitsBySeller map[int]int,
+) (bool, error) {
+	recorded := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Concurrent completions of the same order wait here, then see the recorded sale
+		var order entity.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Where("id = ?", orderID).First(&order).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&entity.LedgerEntry{}).
+			Where("order_id = ? AND type = ?", orderID, entity.LedgerEntrySale).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if len(entries) > 0 {
+			if err := tx.Create(&entries).Error; err != nil {
+				return err
+			}
+		}
+		if err := addSellerSales(tx, unitsBySeller, 1); err != nil {
+			return err
+		}
+		recorded = true
+		return nil
+	})
+	return recorded, err
+}
+
+func (r *ledgerRepo) GetSaleLines(ctx context.Context, orderID int) ([]entity.LedgerLine, error) {
+	var lines []entity.LedgerLine
+	err := r.db.WithContext(ctx).
+		Joins("JOIN ledger_entries ON ledger_entries.id = ledger_lines.entry_id").
+		Where("ledger_entries.order_id = ? AND ledger_entries.type = ?", orderID, entity.LedgerEntrySale).
+		Order("ledger_lines.id").
+		Find(&lines).Error
+	return lines, err
+}
+
+func (r *ledgerRepo) RecordRefund(
+	ctx context.Context,
+	refund *entity.OrderRefund,
+	entries map[int]*entity.LedgerEntry,
+	unitsBySeller map[int]int,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range refund.Items {
+			result := tx.Model(&entity.OrderItem{}).
+				Where("id = ? AND order_id = ? AND refunded_quantity + ? <= quantity", item.OrderItemID, refund.OrderID, item.Quantity).
+				Update("refunded_quantity", gorm.Expr("refunded_quantity + ?", item.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrRefundExceedsQuantity
+			}
+		}
+
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+		for sellerID, entry := range entries {
+			entry.RefundID = &refund.ID
+			entry.ReferenceKey = entity.RefundReferenceKey(refund.ID, sellerID)
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+		}
+		if err := addSellerSales(tx, unitsBySeller, -1); err != nil {
+			return err
+		}
+
+		var remaining int64
+		if err := tx.Model(&entity.OrderItem{}).
+			Where("order_id = ? AND refunded_quantity < quantity", refund.OrderID).
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return tx.Model(&entity.Order{}).Where("id = ?", refund.OrderID).Update("status", "refunded").Error
+		}
+		return nil
+	})
+}
+
+// addSellerSales adds, or with sign -1 removes, units sold to the sellers' total sales
+func addSellerSales(tx *gorm.DB, unitsBySeller map[int]int, sign int) error {
+	for sellerID, units := range unitsBySeller {
+		if err := tx.Model(&entity.SellerProfile{}).
+			Where("user_id = ?", sellerID).
+			Update("total_sales", gorm.Expr("GREATEST(total_sales + ?, 0)", sign*units)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commission rules
+func (r *ledgerRepo) GetCommissionRules(ctx context.Context) ([]entity.CommissionRule, error) {
+	var rules []entity.CommissionRule
+	err := r.db.WithContext(ctx).
+		Preload("Seller").
+		Preload("Category").
+		Order("id").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *ledgerRepo) GetCommissionRuleByID(ctx context.Context, id int) (*entity.CommissionRule, error) {
+	var rule entity.CommissionRule
+	err := r.db.WithContext(ctx).
+		Preload("Seller").
+		Preload("Category").
+		Where("id = ?", id).
+		First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ledgerRepo) SaveCommissionRule(ctx context.Context, rule *entity.CommissionRule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing entity.CommissionRule
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if rule.SellerID != nil {
+			query = query.Where("seller_id = ?", *rule.SellerID)
+		} else {
+			query = query.Where("category_id = ?", *rule.CategoryID)
+		}
+		err := query.First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(rule).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		rule.ID = existing.ID
+		return tx.Model(&existing).Updates(map[string]interface{}{
+			"rate":       rule.Rate,
+			"updated_by": rule.UpdatedBy,
+			"updated_at": rule.UpdatedAt,
+		}).Error
+	})
+}
+
+func (r *ledgerRepo) DeleteCommissionRule(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&entity.CommissionRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Seller earnings
+func (r *ledgerRepo) GetSellerComponentTotals(ctx context.Context, sellerID int, from, to time.Time) ([]LedgerComponentTotal, error) {
+	var totals []LedgerComponentTotal
+	err := r.db.WithContext(ctx).
+		Table("ledger_lines").
+		Select("ledger_lines.component, COALESCE(SUM(ledger_lines.debit), 0) AS debit, COALESCE(SUM(ledger_lines.credit), 0) AS credit").
+		Joins("JOIN ledger_entries ON ledger_entries.id = ledger_lines.entry_id").
+		Where("ledger_lines.account = ? AND ledger_lines.seller_id = ?", entity.AccountSellerPayable, sellerID).
+		Where("ledger_entries.created_at >= ? AND ledger_entries.created_at < ?", from, to).
+		Group("ledger_lines.component").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// sellerBalances sums an account per seller from earnings recorded up to earnedBefore. Payout
+// entries count whenever they happened, since a payout only ever pays earlier earnings.
+func sellerBalances(db *gorm.DB, account string, earnedBefore time.Time) *gorm.DB {
+	return db.Table("ledger_lines").
+		Select("ledger_lines.seller_id, SUM(ledger_lines.credit - ledger_lines.debit) AS balance").
+		Joins("JOIN ledger_entries ON ledger_entries.id = ledger_lines.entry_id").
+		Where("ledger_lines.account = ?", account).
+		Where("ledger_entries.created_at <= ? OR ledger_entries.type IN ?", earnedBefore,
+			[]string{entity.LedgerEntryPayout, entity.LedgerEntryPayoutReversed}).
+		Group("ledger_lines.seller_id")
+}
+
+func (r *ledgerRepo) GetSellerBalance(ctx context.Context, sellerID int, account string, earnedBefore time.Time) (float64, error) {
+	var row sellerBalanceRow
+	err := sellerBalances(r.db.WithContext(ctx), account, earnedBefore).
+		Where("ledger_lines.seller_id = ?", sellerID).
+		Scan(&row).Error
+	return row.Balance, err
+}
+
+func (r *ledgerRepo) GetSellerPaidOut(ctx context.Context, sellerID int, from, to time.Time) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).
+		Model(&entity.Payout{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("seller_id = ? AND status = ? AND settled_at >= ? AND settled_at < ?", sellerID, entity.PayoutPaid, from, to).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *ledgerRepo) GetSellerLines(ctx context.Context, sellerID int, offset, limit int) ([]LedgerLineRow, int64, error) {
+	query := r.db.WithContext(ctx).
+		Table("ledger_lines").
+		Joins("JOIN ledger_entries ON ledger_entries.id = ledger_lines.entry_id").
+		Where("ledger_lines.account = ? AND ledger_lines.seller_id = ?", entity.AccountSellerPayable, sellerID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []LedgerLineRow
+	err := query.
+		Select(`ledger_lines.id, ledger_entries.type AS entry_type, ledger_entries.order_id, ledger_entries.payout_id,
+			ledger_entries.description, ledger_lines.component, ledger_lines.order_item_id,
+			ledger_lines.debit, ledger_lines.credit, ledger_entries.created_at`).
+		Order("ledger_entries.created_at DESC, ledger_lines.id DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, total, err
+}
+
+func (r *ledgerRepo) GetPayoutsBySeller(ctx context.Context, sellerID int, limit int) ([]entity.Payout, error) {
+	var payouts []entity.Payout
+	err := r.db.WithContext(ctx).
+		Where("seller_id = ?", sellerID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&payouts).Error
+	return payouts, err
+}
+
+// Payouts
+func (r *ledgerRepo) CreatePayoutBatch(
+	ctx context.Context,
+	batch *entity.PayoutBatch,
+	minAmount float64,
+	newEntry func(*entity.Payout) entity.LedgerEntry,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", payoutLockKey).Error; err != nil {
+			return err
+		}
+
+		var balances []sellerBalanceRow
+		if err := sellerBalances(tx, entity.AccountSellerPayable, batch.PeriodEnd).
+			Having("SUM(ledger_lines.credit - ledger_lines.debit) > 0 AND SUM(ledger_lines.credit - ledger_lines.debit) >= ?", minAmount).
+			Order("ledger_lines.seller_id").
+			Scan(&balances).Error; err != nil {
+			return err
+		}
+		if len(balances) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		sellerIDs := make([]int, 0, len(balances))
+		for _, balance := range balances {
+			sellerIDs = append(sellerIDs, balance.SellerID)
+		}
+
+		// Only verified sellers with bank details on file can be paid
+		var accounts []struct {
+			UserID            int
+			BankName          string
+			BankAccountName   string
+			BankAccountNumber string
+		}
+		if err := tx.Table("seller_profiles").
+			Select("seller_profiles.user_id, seller_kycs.bank_name, seller_kycs.bank_account_name, seller_kycs.bank_account_number").
+			Joins("JOIN seller_kycs ON seller_kycs.seller_profile_id = seller_profiles.id").
+			Where("seller_profiles.user_id IN ? AND seller_profiles.verification_status = ?", sellerIDs, entity.SellerStatusVerified).
+			Where("seller_kycs.bank_account_number <> ''").
+			Scan(&accounts).Error; err != nil {
+			return err
+		}
+		bySeller := make(map[int]int, len(accounts))
+		for i, account := range accounts {
+			bySeller[account.UserID] = i
+		}
+
+		batch.Payouts = nil
+		batch.TotalAmount = 0
+		for _, balance := range balances {
+			i, ok := bySeller[balance.SellerID]
+			if !ok {
+				continue
+			}
+			batch.Payouts = append(batch.Payouts, entity.Payout{
+				SellerID:          balance.SellerID,
+				Amount:            balance.Balance,
+				Status:            entity.PayoutPending,
+				BankName:          accounts[i].BankName,
+				BankAccountName:   accounts[i].BankAccountName,
+				BankAccountNumber: accounts[i].BankAccountNumber,
+				CreatedAt:         batch.CreatedAt,
+			})
+			batch.TotalAmount += balance.Balance
+		}
+		if len(batch.Payouts) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for i := range batch.Payouts {
+			entry := newEntry(&batch.Payouts[i])
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ledgerRepo) GetPayoutBatches(ctx context.Context, offset, limit int) ([]entity.PayoutBatch, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.PayoutBatch{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var batches []entity.PayoutBatch
+	err := query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&batches).Error
+	return batches, total, err
+}
+
+func (r *ledgerRepo) GetPayoutBatchByID(ctx context.Context, id int) (*entity.PayoutBatch, error) {
+	var batch entity.PayoutBatch
+	err := r.db.WithContext(ctx).
+		Preload("Payouts", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Payouts.Seller").
+		Where("id = ?", id).
+		First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *ledgerRepo) GetPayoutByID(ctx context.Context, id int) (*entity.Payout, error) {
+	var payout entity.Payout
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&payout).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (r *ledgerRepo) ChangeBatchStatus(ctx context.Context, id int, from, to string) error {
+	result := r.db.WithContext(ctx).
+		Model(&entity.PayoutBatch{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *ledgerRepo) CancelPayoutBatch(ctx context.Context, id int, entries []entity.LedgerEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.PayoutBatch{}).
+			Where("id = ? AND status = ?", id, entity.PayoutBatchPending).
+			Update("status", entity.PayoutBatchCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&entity.Payout{}).
+			Where("batch_id = ? AND status = ?", id, entity.PayoutPending).
+			Updates(map[string]interface{}{"status": entity.PayoutCancelled, "settled_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return tx.Create(&entries).Error
+		}
+		return nil
+	})
+}
+
+func (r *ledgerRepo) SettlePayout(ctx context.Context, payout *entity.Payout, entry *entity.LedgerEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Payout{}).
+			Where("id = ? AND status = ?", payout.ID, entity.PayoutPending).
+			Updates(map[string]interface{}{
+				"status":             payout.Status,
+				"transfer_reference": payout.TransferReference,
+				"failure_reason":     payout.FailureReason,
+				"settled_at":         payout.SettledAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+
+		var pending int64
+		if err := tx.Model(&entity.Payout{}).
+			Where("batch_id = ? AND status = ?", payout.BatchID, entity.PayoutPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return nil
+		}
+		return tx.Model(&entity.PayoutBatch{}).
+			Where("id = ? AND status IN ?", payout.BatchID, []string{entity.PayoutBatchPending, entity.PayoutBatchProcessing}).
+			Updates(map[string]interface{}{"status": entity.PayoutBatchCompleted, "completed_at": payout.SettledAt}).Error
+	})
+}
+
+// Reconciliation
+func (r *ledgerRepo) GetAccountTotals(ctx context.Context, from, to time.Time) ([]LedgerAccountTotal, error) {
+	var totals []LedgerAccountTotal
+	err := r.db.WithContext(ctx).
+		Table("ledger_lines").
+		Select("ledger_lines.account, COALESCE(SUM(ledger_lines.debit), 0) AS debit, COALESCE(SUM(ledger_lines.credit), 0) AS credit").
+		Joins("JOIN ledger_entries ON ledger_entries.id = ledger_lines.entry_id").
+		Where("ledger_entries.created_at >= ? AND ledger_entries.created_at < ?", from, to).
+		Group("ledger_lines.account").
+		Order("ledger_lines.account").
+		Scan(&totals).Error
+	return totals, err
+}
+
+func (r *ledgerRepo) GetUnbalancedEntries(ctx context.Context, from, to time.Time) ([]int, error) {
+	var ids []int
+	err := r.db.WithContext(ctx).
+		Table("ledger_lines").
+		Select("ledger_lines.entry_id").
+		Joins("JOIN ledger_entries ON ledger_entries.id = ledger_lines.entry_id").
+		Where("ledger_entries.created_at >= ? AND ledger_entries.created_at < ?", from, to).
+		Group("ledger_lines.entry_id").
+		Having("SUM(ledger_lines.debit) <> SUM(ledger_lines.credit)").
+		Order("ledger_lines.entry_id").
+		Scan(&ids).Error
+	return ids, err
+}
+
+func (r *ledgerRepo) GetOrderSettlements(ctx context.Context, from, to time.Time) ([]OrderSettlementRow, error) {
+	var rows []OrderSettlementRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT o.id AS order_id, o.status, o.total_amount,
+			COALESCE((SELECT SUM(rf.amount) FROM order_refunds rf WHERE rf.order_id = o.id), 0) AS refunded_amount,
+			COALESCE((
+				SELECT SUM(l.debit - l.credit) FROM ledger_lines l
+				JOIN ledger_entries e ON e.id = l.entry_id
+				WHERE e.order_id = o.id AND l.account = ?
+			), 0) AS ledger_cash,
+			EXISTS (SELECT 1 FROM ledger_entries e WHERE e.order_id = o.id AND e.type = ?) AS settled
+		FROM orders o
+		WHERE o.status IN ('completed', 'refunded') AND o.created_at >= ? AND o.created_at < ?
+		ORDER BY o.id`,
+		entity.AccountPlatformCash, entity.LedgerEntrySale, from, to).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *ledgerRepo) GetPayoutTotals(ctx context.Context, from, to time.Time) ([]PayoutStatusTotal, error) {
+	var totals []PayoutStatusTotal
+	err := r.db.WithContext(ctx).
+		Model(&entity.Payout{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("status").
+		Order("status").
+		Scan(&totals).Error
+	return totals, err
+}
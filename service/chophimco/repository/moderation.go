@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IModerationRepo interface {
+	// Helpfulness votes on product reviews, reporting whether anything changed
+	AddHelpfulVote(ctx context.Context, vote *entity.ReviewVote) (bool, error)
+	RemoveHelpfulVote(ctx context.Context, reviewID, userID int) (bool, error)
+	GetHelpfulCount(ctx context.Context, reviewID int) (int, error)
+
+	// GetReviewTarget loads the moderated fields of a product or seller review
+	GetReviewTarget(ctx context.Context, targetType string, id int) (*ReviewTarget, error)
+	// SetReviewModeration changes the moderation state of a product or seller review,
+	// keeping the product's or seller's rating in step with what is published
+	SetReviewModeration(ctx context.Context, targetType string, id int, moderation entity.ReviewModeration) error
+
+	// Reports
+	CreateReport(ctx context.Context, report *entity.ReviewReport) (bool, error)
+	CountOpenReports(ctx context.Context, targetType string, targetID int) (int64, error)
+	GetReports(ctx context.Context, targetType string, targetID int) ([]entity.ReviewReport, error)
+	ResolveReports(ctx context.Context, targetType string, targetID int, status string, resolvedBy int) error
+
+	// GetQueue returns held reviews and reviews with open reports, oldest first
+	GetQueue(ctx context.Context, targetType string, offset, limit int) ([]ModerationQueueRow, int64, error)
+}
+
+// ReviewTarget is a product or seller review as the moderation pipeline sees it
+type ReviewTarget struct {
+	ID         int    `gorm:"column:id"`
+	AuthorID   int    `gorm:"column:author_id"`
+	SubjectID  int    `gorm:"column:subject_id"` // product ID or seller user ID
+	Rating     int    `gorm:"column:rating"`
+	Status     string `gorm:"column:status"`
+	FlagReason string `gorm:"column:flag_reason"`
+}
+
+type ModerationQueueRow struct {
+	ID          int       `gorm:"column:id"`
+	AuthorID    int       `gorm:"column:author_id"`
+	AuthorName  string    `gorm:"column:author_name"`
+	SubjectID   int       `gorm:"column:subject_id"`
+	SubjectName string    `gorm:"column:subject_name"`
+	Rating      int       `gorm:"column:rating"`
+	Comment     string    `gorm:"column:comment"`
+	Status      string    `gorm:"column:status"`
+	FlagReason  string    `gorm:"column:flag_reason"`
+	OpenReports int64     `gorm:"column:open_reports"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+// reviewTables describes where each kind of review lives
+var reviewTables = map[string]struct {
+	table         string
+	authorColumn  string
+	subjectColumn string
+	subjectJoin   string
+	subjectName   string
+}{
+	entity.ReviewTargetProduct: {
+		table:         "reviews",
+		authorColumn:  "user_id",
+		subjectColumn: "product_id",
+		subjectJoin:   "JOIN products s ON s.id = t.product_id",
+		subjectName:   "s.name",
+	},
+	entity.ReviewTargetSeller: {
+		table:         "seller_reviews",
+		authorColumn:  "buyer_id",
+		subjectColumn: "seller_id",
+		subjectJoin:   "LEFT JOIN seller_profiles s ON s.user_id = t.seller_id",
+		subjectName:   "COALESCE(s.shop_name, '')",
+	},
+}
+
+type moderationRepo struct {
+	db *gorm.DB
+}
+
+func NewModerationRepo(db *gorm.DB) IModerationRepo {
+	return &moderationRepo{db: db}
+}
+
+func (r *moderationRepo) AddHelpfulVote(ctx context.Context, vote *entity.ReviewVote) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return tx.Model(&entity.Review{}).
+			Where("id = ?", vote.ReviewID).
+			Update("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+	return created, err
+}
+
+func (r *moderationRepo) RemoveHelpfulVote(ctx context.Context, reviewID, userID int) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&entity.ReviewVote{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		deleted = true
+		return tx.Model(&entity.Review{}).
+			Where("id = ? AND helpful_count > 0", reviewID).
+			Update("helpful_count", gorm.Expr("helpful_count - 1")).Error
+	})
+	return deleted, err
+}
+
+func (r *moderationRepo) GetHelpfulCount(ctx context.Context, reviewID int) (int, error) {
+	var count int
+	err := r.db.WithContext(ctx).
+		Model(&entity.Review{}).
+		Where("id = ?", reviewID).
+		Select("helpful_count").
+		Scan(&count).Error
+	return count, err
+}
+
+func (r *moderationRepo) GetReviewTarget(ctx context.Context, targetType string, id int) (*ReviewTarget, error) {
+	return getReviewTarget(r.db.WithContext(ctx), targetType, id, false)
+}
+
+func getReviewTarget(tx *gorm.DB, targetType string, id int, lock bool) (*ReviewTarget, error) {
+	tables, ok := reviewTables[targetType]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	columns := "id, " + tables.authorColumn + " AS author_id, " + tables.subjectColumn + " AS subject_id, " +
+		"rating, status, flag_reason"
+	query := tx.Table(tables.table).Select(columns).Where("id = ?", id)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var targets []ReviewTarget
+	if err := query.Limit(1).Scan(&targets).Error; err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &targets[0], nil
+}
+
+func (r *moderationRepo) SetReviewModeration(
+	ctx context.Context,
+	targetType string,
+	id int,
+	moderation entity.ReviewModeration,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		target, err := getReviewTarget(tx, targetType, id, true)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Table(reviewTables[targetType].table).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":          moderation.Status,
+			"flag_reason":     moderation.FlagReason,
+			"moderated_by":    moderation.ModeratedBy,
+			"moderated_at":    moderation.ModeratedAt,
+			"moderation_note": moderation.ModerationNote,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		wasPublished := target.Status == entity.ReviewStatusPublished
+		isPublished := moderation.Status == entity.ReviewStatusPublished
+		if wasPublished == isPublished {
+			return nil
+		}
+
+		if targetType == entity.ReviewTargetSeller {
+			return updateSellerRating(tx, target.SubjectID)
+		}
+		if isPublished {
+			return applyRatingChange(tx, target.SubjectID, 0, target.Rating)
+		}
+		return applyRatingChange(tx, target.SubjectID, target.Rating, 0)
+	})
+}
+
+func (r *moderationRepo) CreateReport(ctx context.Context, report *entity.ReviewReport) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *moderationRepo) CountOpenReports(ctx context.Context, targetType string, targetID int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.ReviewReport{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, entity.ReportStatusOpen).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *moderationRepo) GetReports(ctx context.Context, targetType string, targetID int) ([]entity.ReviewReport, error) {
+	var reports []entity.ReviewReport
+	err := r.db.WithContext(ctx).
+		Preload("Reporter").
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("created_at DESC, id DESC").
+		Find(&reports).Error
+	return reports, err
+}
+
+func (r *moderationRepo) ResolveReports(
+	ctx context.Context,
+	targetType string,
+	targetID int,
+	status string,
+	resolvedBy int,
+) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.ReviewReport{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, entity.ReportStatusOpen).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_by": resolvedBy,
+			"resolved_at": time.Now(),
+		}).Error
+}
+
+func (r *moderationRepo) GetQueue(
+	ctx context.Context,
+	targetType string,
+	offset, limit int,
+) ([]ModerationQueueRow, int64, error) {
+	tables, ok := reviewTables[targetType]
+	if !ok {
+		return nil, 0, nil
+	}
+
+	query := r.db.WithContext(ctx).
+		Table(tables.table+" t").
+		Joins("JOIN users u ON u.id = t."+tables.authorColumn).
+		Joins(tables.subjectJoin).
+		Joins(`LEFT JOIN (
+				SELECT target_id, COUNT(*) AS open_reports FROM review_reports
+				WHERE target_type = ? AND status = ? GROUP BY target_id
+			) rep ON rep.target_id = t.id`, targetType, entity.ReportStatusOpen).
+		Where("t.status = ? OR (t.status = ? AND rep.open_reports > 0)",
+			entity.ReviewStatusPending, entity.ReviewStatusPublished)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []ModerationQueueRow
+	err := query.
+		Select(`t.id, t.` + tables.authorColumn + ` AS author_id, u.full_name AS author_name,
+			t.` + tables.subjectColumn + ` AS subject_id, ` + tables.subjectName + ` AS subject_name,
+			t.rating, t.comment, t.status, t.flag_reason, COALESCE(rep.open_reports, 0) AS open_reports, t.created_at`).
+		Order("t.created_at ASC, t.id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, total, err
+}
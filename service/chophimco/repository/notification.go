@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type INotificationRepo interface {
+	CreateNotification(ctx context.Context, notification *entity.Notification) error
+	CreateNotifications(ctx context.Context, notifications []entity.Notification) error
+	GetNotifications(ctx context.Context, userID int, unreadOnly bool, offset, limit int) ([]entity.Notification, int64, error)
+	CountUnread(ctx context.Context, userID int) (int64, error)
+	// MarkRead returns gorm.ErrRecordNotFound when the user has no such notification
+	MarkRead(ctx context.Context, userID int, id int) error
+	MarkAllRead(ctx context.Context, userID int) error
+}
+
+// notificationBatchSize bounds the rows of one insert when notifying many users at once
+const notificationBatchSize = 500
+
+type notificationRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepo(db *gorm.DB) INotificationRepo {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) CreateNotification(ctx context.Context, notification *entity.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *notificationRepo) CreateNotifications(ctx context.Context, notifications []entity.Notification) error {
+	return r.db.WithContext(ctx).CreateInBatches(notifications, notificationBatchSize).Error
+}
+
+func (r *notificationRepo) GetNotifications(
+	ctx context.Context,
+	userID int,
+	unreadOnly bool,
+	offset, limit int,
+) ([]entity.Notification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []entity.Notification
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *notificationRepo) CountUnread(ctx context.Context, userID int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, userID int, id int) error {
+	var notification entity.Notification
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		return err
+	}
+	if notification.IsRead {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Model(&notification).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
+}
+
+func (r *notificationRepo) MarkAllRead(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IOAuthRepo interface {
+	// CreateState stores a started login, clearing out expired ones
+	CreateState(ctx context.Context, state *entity.OAuthState) error
+	// TakeState deletes and returns a login state, so each one completes a single login
+	TakeState(ctx context.Context, id string) (*entity.OAuthState, error)
+
+	// GetIdentity finds the identity of a provider account, with its user and role
+	GetIdentity(ctx context.Context, provider, subject string) (*entity.UserIdentity, error)
+	// LinkIdentity adds an identity to an existing user. It reports false when the provider
+	// account is already linked.
+	LinkIdentity(ctx context.Context, identity *entity.UserIdentity) (bool, error)
+	// CreateUserWithIdentity signs up a user who has only the provider account to log in with
+	CreateUserWithIdentity(ctx context.Context, user *entity.User, identity *entity.UserIdentity) error
+	TouchIdentity(ctx context.Context, id int, email string) error
+}
+
+type oauthRepo struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepo(db *gorm.DB) IOAuthRepo {
+	return &oauthRepo{db: db}
+}
+
+func (r *oauthRepo) CreateState(ctx context.Context, state *entity.OAuthState) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&entity.OAuthState{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(state).Error
+	})
+}
+
+func (r *oauthRepo) TakeState(ctx context.Context, id string) (*entity.OAuthState, error) {
+	var states []entity.OAuthState
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("id = ?", id).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+func (r *oauthRepo) GetIdentity(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("User.Role").
+		// Logins through the identity must still ask for the user's second factor
+		Preload("User.TwoFactor").
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *oauthRepo) LinkIdentity(ctx context.Context, identity *entity.UserIdentity) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(identity)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *oauthRepo) CreateUserWithIdentity(ctx context.Context, user *entity.User, identity *entity.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *oauthRepo) TouchIdentity(ctx context.Context, id int, email string) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":         email,
+			"last_login_at": time.Now(),
+		}).Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"gorm.io/gorm"
 )
@@ -10,6 +12,9 @@ type IOrderRepo interface {
 	GetOrdersByUserID(userID int) ([]entity.Order, error)
 	CreateOrder(order *entity.Order) error
 	UpdateOrderStatus(orderID int, status string) error
+	// CancelOrder gives the units of flash sale items back to their sales and buyers.
+	// It reports false when the order was already cancelled.
+	CancelOrder(orderID int) (bool, error)
 	CreateOrderItems(items []entity.OrderItem) error
 }
 
@@ -45,7 +50,51 @@ func (r *orderRepo) CreateOrder(order *entity.Order) error {
 }
 
 func (r *orderRepo) UpdateOrderStatus(orderID int, status string) error {
-	return r.db.Model(&entity.Order{}).Where("id = ?", orderID).Update("status", status).Error
+	updates := map[string]interface{}{"status": status}
+	if status == "completed" {
+		updates["completed_at"] = time.Now()
+	}
+	return r.db.Model(&entity.Order{}).Where("id = ?", orderID).Updates(updates).Error
+}
+
+func (r *orderRepo) CancelOrder(orderID int) (bool, error) {
+	cancelled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var order entity.Order
+		result := tx.Raw(`
+			UPDATE orders SET status = 'cancelled'
+			WHERE id = ? AND status <> 'cancelled'
+			RETURNING id, user_id`, orderID).
+			Scan(&order)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		cancelled = true
+
+		var items []entity.OrderItem
+		if err := tx.Where("order_id = ? AND flash_sale_item_id IS NOT NULL", orderID).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			err := tx.Model(&entity.FlashSaleItem{}).
+				Where("id = ?", *item.FlashSaleItemID).
+				UpdateColumn("sold_quantity", gorm.Expr("GREATEST(sold_quantity - ?, 0)", item.Quantity)).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&entity.FlashSalePurchase{}).
+				Where("flash_sale_item_id = ? AND user_id = ?", *item.FlashSaleItemID, order.UserID).
+				UpdateColumn("quantity", gorm.Expr("GREATEST(quantity - ?, 0)", item.Quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return cancelled, err
 }
 
 func (r *orderRepo) CreateOrderItems(items []entity.OrderItem) error {
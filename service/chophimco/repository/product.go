@@ -2,14 +2,44 @@ package repository
 
 import (
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Effective product price used for sorting: cheapest variant, falling back to the base price
+	productPriceExpr = "COALESCE((SELECT MIN(pv.price) FROM product_variants pv WHERE pv.product_id = products.id), products.base_price)"
+
+	// productAverageRatingExpr derives a product's average rating from its per star review counts
+	productAverageRatingExpr = `COALESCE(ROUND((rating_1_count + 2 * rating_2_count + 3 * rating_3_count +
+		4 * rating_4_count + 5 * rating_5_count)::numeric / NULLIF(review_count, 0), 2), 0)`
+
+	productSoldExpr = `(SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+		JOIN product_variants pv ON pv.id = oi.product_variant_id
+		JOIN orders o ON o.id = oi.order_id
+		WHERE pv.product_id = products.id AND o.status <> 'cancelled')`
+
+	// categorySubtreeSQL selects a category and all of its descendants. UNION stops on cycles.
+	categorySubtreeSQL = `WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+		) SELECT id FROM subtree`
 )
 
 type IProductRepo interface {
 	GetAllProducts() ([]entity.Product, error)
 	GetProductByID(id int) (*entity.Product, error)
+	// GetApprovedProductByID only finds products shoppers may see
+	GetApprovedProductByID(id int) (*entity.Product, error)
 	GetProductsByCategory(categoryID int) ([]entity.Product, error)
 	GetProductsByBrand(brandID int) ([]entity.Product, error)
+	ListProducts(filter request.ListProducts) ([]entity.Product, int64, error)
+	SearchProducts(filter request.SearchProducts) ([]ProductSearchHit, int64, error)
+	GetProductsByIDs(ids []int) ([]entity.Product, error)
+	GetProductFacets(filter request.SearchProducts) (*ProductFacets, error)
+	GetAttributeFacets(filter request.SearchProducts, definitionIDs []int) ([]AttributeFacetCount, error)
 	CreateProduct(product *entity.Product) error
 	UpdateProduct(product *entity.Product) error
 	DeleteProduct(id int) error
@@ -20,8 +50,57 @@ type IProductRepo interface {
 	CreateVariant(variant *entity.ProductVariant) error
 	UpdateVariant(variant *entity.ProductVariant) error
 	UpdateVariantStock(variantID int, quantity int) error
+	GetVariantsBySKUs(skus []string) ([]entity.ProductVariant, error)
+	// ApplyVariantMatrix saves a generated variant matrix in one transaction. Stale variants
+	// are deleted, or kept with zero stock when orders or flash sales reference them.
+	ApplyVariantMatrix(create, update []*entity.ProductVariant, staleIDs []int) (removed, retired int, err error)
+}
+
+// Highlighted snippets wrap matches in these control characters instead of HTML, the
+// text around them is raw seller input and must be escaped before it is rendered
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// ProductSearchHit is a ranked full-text match with highlighted snippets
+type ProductSearchHit struct {
+	ProductID            int     `gorm:"column:product_id"`
+	Rank                 float64 `gorm:"column:rank"`
+	NameHighlight        string  `gorm:"column:name_highlight"`
+	DescriptionHighlight string  `gorm:"column:description_highlight"`
+}
+
+// FacetCount is the number of matching products having a given attribute value
+type FacetCount struct {
+	Value string `gorm:"column:value"`
+	Count int64  `gorm:"column:count"`
+}
+
+type PriceBucket struct {
+	Min   float64
+	Max   float64
+	Count int64
+}
+
+type ProductFacets struct {
+	Layout         []FacetCount
+	SwitchType     []FacetCount
+	ConnectionType []FacetCount
+	Hotswap        []FacetCount
+	LedType        []FacetCount
+	PriceHistogram []PriceBucket
 }
 
+// AttributeFacetCount is the number of matching products having a value of an attribute definition
+type AttributeFacetCount struct {
+	DefinitionID int    `gorm:"column:definition_id"`
+	Value        string `gorm:"column:value"`
+	Count        int64  `gorm:"column:count"`
+}
+
+const priceHistogramBuckets = 10
+
 type productRepo struct {
 	db *gorm.DB
 }
@@ -33,31 +112,333 @@ func NewProductRepo(db *gorm.DB) IProductRepo {
 func (r *productRepo) GetAllProducts() ([]entity.Product, error) {
 	var products []entity.Product
 	err := r.db.Preload("Category").Preload("Brand").Preload("Variants.Switch").
-		Where("is_active = ?", true).Find(&products).Error
+		Preload("Variants.Attributes.Definition").
+		Where("is_active = ? AND approval_status = ?", true, "approved").Find(&products).Error
 	return products, err
 }
 
 func (r *productRepo) GetProductByID(id int) (*entity.Product, error) {
 	var product entity.Product
 	err := r.db.Preload("Category").Preload("Brand").Preload("Variants.Switch").
+		Preload("Variants.Attributes.Definition").
 		Where("id = ?", id).First(&product).Error
 	return &product, err
 }
 
+func (r *productRepo) GetApprovedProductByID(id int) (*entity.Product, error) {
+	var product entity.Product
+	err := r.db.Preload("Category").Preload("Brand").Preload("Variants.Switch").
+		Preload("Variants.Attributes.Definition").
+		Where("id = ? AND approval_status = ?", id, "approved").First(&product).Error
+	return &product, err
+}
+
 func (r *productRepo) GetProductsByCategory(categoryID int) ([]entity.Product, error) {
 	var products []entity.Product
 	err := r.db.Preload("Category").Preload("Brand").Preload("Variants.Switch").
-		Where("category_id = ? AND is_active = ?", categoryID, true).Find(&products).Error
+		Preload("Variants.Attributes.Definition").
+		Where("category_id IN ("+categorySubtreeSQL+") AND is_active = ? AND approval_status = ?", categoryID, true, "approved").
+		Find(&products).Error
 	return products, err
 }
 
 func (r *productRepo) GetProductsByBrand(brandID int) ([]entity.Product, error) {
 	var products []entity.Product
 	err := r.db.Preload("Category").Preload("Brand").Preload("Variants.Switch").
-		Where("brand_id = ? AND is_active = ?", brandID, true).Find(&products).Error
+		Preload("Variants.Attributes.Definition").
+		Where("brand_id = ? AND is_active = ? AND approval_status = ?", brandID, true, "approved").Find(&products).Error
 	return products, err
 }
 
+func (r *productRepo) ListProducts(filter request.ListProducts) ([]entity.Product, int64, error) {
+	query := r.filterProducts(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var products []entity.Product
+	err := query.Preload("Category").Preload("Brand").Preload("Variants.Switch").
+		Preload("Variants.Attributes.Definition").
+		Order(productSortOrder(filter.Sort)).
+		Order("products.id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&products).Error
+	return products, total, err
+}
+
+var highlightOptions = `StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `"`
+
+func (r *productRepo) SearchProducts(filter request.SearchProducts) ([]ProductSearchHit, int64, error) {
+	query := r.searchProducts(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rankExpr := "(ts_rank_cd(products.search_vector, websearch_to_tsquery('vn_unaccent', ?)) + similarity(f_unaccent(lower(products.name)), f_unaccent(lower(?))))"
+
+	query = query.Select(`products.id AS product_id, `+rankExpr+` AS rank,
+			ts_headline('vn_unaccent', products.name, websearch_to_tsquery('vn_unaccent', ?),
+				?) AS name_highlight,
+			ts_headline('vn_unaccent', coalesce(products.description, ''), websearch_to_tsquery('vn_unaccent', ?),
+				?) AS description_highlight`,
+		filter.Query, filter.Query,
+		filter.Query, highlightOptions+", HighlightAll=true",
+		filter.Query, highlightOptions+", MaxFragments=2, MaxWords=20, MinWords=5")
+
+	if filter.Sort != "" {
+		query = query.Order(productSortOrder(filter.Sort)).Order("products.id DESC")
+	} else {
+		query = query.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  rankExpr + " DESC, products.id DESC",
+			Vars: []interface{}{filter.Query, filter.Query},
+		}})
+	}
+
+	var hits []ProductSearchHit
+	err := query.
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Scan(&hits).Error
+	return hits, total, err
+}
+
+func (r *productRepo) GetProductsByIDs(ids []int) ([]entity.Product, error) {
+	var products []entity.Product
+	err := r.db.Preload("Category").Preload("Brand").Preload("Variants.Switch").
+		Preload("Variants.Attributes.Definition").
+		Where("id IN ?", ids).Find(&products).Error
+	return products, err
+}
+
+func (r *productRepo) GetProductFacets(filter request.SearchProducts) (*ProductFacets, error) {
+	productIDs := r.searchProducts(filter).Select("products.id")
+
+	variantFacet := func(column string) ([]FacetCount, error) {
+		var counts []FacetCount
+		err := r.db.Table("product_variants pv").
+			Select(column+"::text AS value, COUNT(DISTINCT pv.product_id) AS count").
+			Joins("LEFT JOIN switches s ON s.id = pv.switch_id").
+			Where("pv.product_id IN (?)", productIDs).
+			Where(column + " IS NOT NULL").
+			Where(column + "::text <> ''").
+			Group(column).
+			Order("count DESC, value ASC").
+			Scan(&counts).Error
+		return counts, err
+	}
+
+	facets := &ProductFacets{}
+	var err error
+	if facets.Layout, err = variantFacet("pv.layout"); err != nil {
+		return nil, err
+	}
+	if facets.SwitchType, err = variantFacet("s.type"); err != nil {
+		return nil, err
+	}
+	if facets.ConnectionType, err = variantFacet("pv.connection_type"); err != nil {
+		return nil, err
+	}
+	if facets.Hotswap, err = variantFacet("pv.hotswap"); err != nil {
+		return nil, err
+	}
+	if facets.LedType, err = variantFacet("pv.led_type"); err != nil {
+		return nil, err
+	}
+	if facets.PriceHistogram, err = r.priceHistogram(productIDs); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+func (r *productRepo) GetAttributeFacets(filter request.SearchProducts, definitionIDs []int) ([]AttributeFacetCount, error) {
+	counts := []AttributeFacetCount{}
+	if len(definitionIDs) == 0 {
+		return counts, nil
+	}
+
+	productIDs := r.searchProducts(filter).Select("products.id")
+	err := r.db.Table("product_variant_attributes pva").
+		Select("pva.attribute_definition_id AS definition_id, pva.value, COUNT(DISTINCT pv.product_id) AS count").
+		Joins("JOIN product_variants pv ON pv.id = pva.product_variant_id").
+		Where("pv.product_id IN (?)", productIDs).
+		Where("pva.attribute_definition_id IN ?", definitionIDs).
+		Group("pva.attribute_definition_id, pva.value").
+		Order("count DESC, value ASC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// priceHistogram splits the effective prices of the given products into equal-width buckets
+func (r *productRepo) priceHistogram(productIDs *gorm.DB) ([]PriceBucket, error) {
+	prices := r.db.Model(&entity.Product{}).
+		Select(productPriceExpr+" AS price").
+		Where("products.id IN (?)", productIDs)
+
+	var bounds struct {
+		Low  *float64 `gorm:"column:low"`
+		High *float64 `gorm:"column:high"`
+	}
+	if err := r.db.Table("(?) AS prices", prices).
+		Select("MIN(price) AS low, MAX(price) AS high").
+		Scan(&bounds).Error; err != nil {
+		return nil, err
+	}
+	if bounds.Low == nil || bounds.High == nil {
+		return []PriceBucket{}, nil
+	}
+
+	low, high := *bounds.Low, *bounds.High
+	if low == high {
+		var count int64
+		if err := r.db.Table("(?) AS prices", prices).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		return []PriceBucket{{Min: low, Max: high, Count: count}}, nil
+	}
+
+	var rows []struct {
+		Bucket int   `gorm:"column:bucket"`
+		Count  int64 `gorm:"column:count"`
+	}
+	// width_bucket puts the maximum into bucket n+1, LEAST folds it into the last bucket
+	if err := r.db.Table("(?) AS prices", prices).
+		Select("LEAST(width_bucket(price, ?, ?, ?), ?) AS bucket, COUNT(*) AS count",
+			low, high, priceHistogramBuckets, priceHistogramBuckets).
+		Group("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	width := (high - low) / priceHistogramBuckets
+	buckets := make([]PriceBucket, priceHistogramBuckets)
+	for i := range buckets {
+		buckets[i] = PriceBucket{
+			Min: low + float64(i)*width,
+			Max: low + float64(i+1)*width,
+		}
+	}
+	buckets[priceHistogramBuckets-1].Max = high
+	for _, row := range rows {
+		if row.Bucket >= 1 && row.Bucket <= priceHistogramBuckets {
+			buckets[row.Bucket-1].Count = row.Count
+		}
+	}
+
+	return buckets, nil
+}
+
+// searchProducts narrows filterProducts down to the full-text query when one is given.
+// It matches either the unaccented document or, for typos, the trigram similarity of the name.
+func (r *productRepo) searchProducts(filter request.SearchProducts) *gorm.DB {
+	query := r.filterProducts(filter.ListProducts)
+	if filter.Query == "" {
+		return query
+	}
+	return query.
+		Where("products.search_vector @@ websearch_to_tsquery('vn_unaccent', ?) OR f_unaccent(lower(products.name)) % f_unaccent(lower(?))",
+			filter.Query, filter.Query).
+		Session(&gorm.Session{})
+}
+
+// filterProducts builds a reusable query of active products matching the filter.
+// Variant-level filters must all be satisfied by the same variant.
+func (r *productRepo) filterProducts(filter request.ListProducts) *gorm.DB {
+	query := r.db.Model(&entity.Product{}).Where("products.is_active = ?", true)
+
+	if filter.CategoryID != nil {
+		query = query.Where("products.category_id IN ("+categorySubtreeSQL+")", *filter.CategoryID)
+	}
+	if filter.BrandID != nil {
+		query = query.Where("products.brand_id = ?", *filter.BrandID)
+	}
+	if filter.SellerID != nil {
+		query = query.Where("products.seller_id = ?", *filter.SellerID)
+	}
+	if filter.CollectionID != nil {
+		query = query.Where("products.id IN (SELECT product_id FROM shop_collection_items WHERE collection_id = ?)", *filter.CollectionID)
+	}
+	if filter.OnlyApproved {
+		query = query.Where("products.approval_status = ?", "approved")
+	}
+
+	variants := r.db.Table("product_variants pv").
+		Select("1").
+		Joins("LEFT JOIN switches s ON s.id = pv.switch_id").
+		Where("pv.product_id = products.id")
+	hasVariantFilter := false
+
+	if filter.MinPrice != nil {
+		variants = variants.Where("pv.price >= ?", *filter.MinPrice)
+		hasVariantFilter = true
+	}
+	if filter.MaxPrice != nil {
+		variants = variants.Where("pv.price <= ?", *filter.MaxPrice)
+		hasVariantFilter = true
+	}
+	if len(filter.Layout) > 0 {
+		variants = variants.Where("pv.layout IN ?", filter.Layout)
+		hasVariantFilter = true
+	}
+	if len(filter.ConnectionType) > 0 {
+		variants = variants.Where("pv.connection_type IN ?", filter.ConnectionType)
+		hasVariantFilter = true
+	}
+	if filter.Hotswap != nil {
+		variants = variants.Where("pv.hotswap = ?", *filter.Hotswap)
+		hasVariantFilter = true
+	}
+	if len(filter.LedType) > 0 {
+		variants = variants.Where("pv.led_type IN ?", filter.LedType)
+		hasVariantFilter = true
+	}
+	if len(filter.SwitchType) > 0 {
+		variants = variants.Where("s.type IN ?", filter.SwitchType)
+		hasVariantFilter = true
+	}
+	if filter.InStock {
+		variants = variants.Where("pv.stock > 0")
+		hasVariantFilter = true
+	}
+	for code, values := range filter.Attributes {
+		if len(values) == 0 {
+			continue
+		}
+		attribute := r.db.Table("product_variant_attributes pva").
+			Select("1").
+			Joins("JOIN attribute_definitions ad ON ad.id = pva.attribute_definition_id").
+			Where("pva.product_variant_id = pv.id AND ad.code = ? AND pva.value IN ?", code, values)
+		variants = variants.Where("EXISTS (?)", attribute)
+		hasVariantFilter = true
+	}
+
+	if hasVariantFilter {
+		query = query.Where("EXISTS (?)", variants)
+	}
+
+	return query.Session(&gorm.Session{})
+}
+
+func productSortOrder(sort string) string {
+	switch sort {
+	case "price_asc":
+		return productPriceExpr + " ASC"
+	case "price_desc":
+		return productPriceExpr + " DESC"
+	case "rating":
+		return "products.average_rating DESC, products.review_count DESC"
+	case "best_selling":
+		return productSoldExpr + " DESC"
+	default:
+		return "products.created_at DESC"
+	}
+}
+
 func (r *productRepo) CreateProduct(product *entity.Product) error {
 	return r.db.Create(product).Error
 }
@@ -72,7 +453,8 @@ func (r *productRepo) DeleteProduct(id int) error {
 
 func (r *productRepo) GetVariantByID(id int) (*entity.ProductVariant, error) {
 	var variant entity.ProductVariant
-	err := r.db.Preload("Product").Preload("Switch").Where("id = ?", id).First(&variant).Error
+	err := r.db.Preload("Product").Preload("Switch").Preload("Attributes.Definition").
+		Where("id = ?", id).First(&variant).Error
 	return &variant, err
 }
 
@@ -86,8 +468,14 @@ func (r *productRepo) CreateVariant(variant *entity.ProductVariant) error {
 	return r.db.Create(variant).Error
 }
 
+// UpdateVariant saves the variant and replaces its attributes with variant.Attributes in one transaction
 func (r *productRepo) UpdateVariant(variant *entity.ProductVariant) error {
-	return r.db.Save(variant).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(variant).Error; err != nil {
+			return err
+		}
+		return replaceVariantAttributes(tx, variant)
+	})
 }
 
 func (r *productRepo) UpdateVariantStock(variantID int, quantity int) error {
@@ -95,3 +483,81 @@ func (r *productRepo) UpdateVariantStock(variantID int, quantity int) error {
 		Where("id = ?", variantID).
 		UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
 }
+
+func (r *productRepo) GetVariantsBySKUs(skus []string) ([]entity.ProductVariant, error) {
+	var variants []entity.ProductVariant
+	if len(skus) == 0 {
+		return variants, nil
+	}
+	err := r.db.Where("sku IN ?", skus).Find(&variants).Error
+	return variants, err
+}
+
+func (r *productRepo) ApplyVariantMatrix(create, update []*entity.ProductVariant, staleIDs []int) (removed, retired int, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		removed, retired = 0, 0
+
+		for _, variant := range update {
+			if err := tx.Omit(clause.Associations).Save(variant).Error; err != nil {
+				return err
+			}
+			if err := replaceVariantAttributes(tx, variant); err != nil {
+				return err
+			}
+		}
+		for _, variant := range create {
+			if err := tx.Omit(clause.Associations).Create(variant).Error; err != nil {
+				return err
+			}
+			if err := replaceVariantAttributes(tx, variant); err != nil {
+				return err
+			}
+		}
+
+		for _, id := range staleIDs {
+			var referenced bool
+			err := tx.Raw(`SELECT EXISTS (SELECT 1 FROM order_items WHERE product_variant_id = ?)
+				OR EXISTS (SELECT 1 FROM flash_sale_items WHERE product_variant_id = ?)`, id, id).
+				Scan(&referenced).Error
+			if err != nil {
+				return err
+			}
+
+			if referenced {
+				if err := tx.Model(&entity.ProductVariant{}).Where("id = ?", id).Update("stock", 0).Error; err != nil {
+					return err
+				}
+				retired++
+				continue
+			}
+
+			if err := tx.Where("product_variant_id = ?", id).Delete(&entity.CartItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("product_variant_id = ?", id).Delete(&entity.ProductVariantAttribute{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&entity.ProductVariant{}, id).Error; err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, retired, err
+}
+
+// replaceVariantAttributes stores the attributes of a saved variant in place of its current ones
+func replaceVariantAttributes(tx *gorm.DB, variant *entity.ProductVariant) error {
+	if err := tx.Where("product_variant_id = ?", variant.ID).Delete(&entity.ProductVariantAttribute{}).Error; err != nil {
+		return err
+	}
+	if len(variant.Attributes) == 0 {
+		return nil
+	}
+	for i := range variant.Attributes {
+		variant.Attributes[i].ID = 0
+		variant.Attributes[i].ProductVariantID = variant.ID
+	}
+	return tx.Omit("Definition").Create(&variant.Attributes).Error
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IProductRevisionRepo interface {
+	// GetProduct returns a product of any seller with its category and brand
+	GetProduct(ctx context.Context, productID int) (*entity.Product, error)
+	GetRevisionsByProduct(ctx context.Context, productID int) ([]entity.ProductRevision, error)
+	GetRevisionByNumber(ctx context.Context, productID int, number int) (*entity.ProductRevision, error)
+	GetPendingRevision(ctx context.Context, productID int) (*entity.ProductRevision, error)
+	// GetLastApprovedRevision returns the newest approved revision numbered below beforeNumber
+	GetLastApprovedRevision(ctx context.Context, productID int, beforeNumber int) (*entity.ProductRevision, error)
+
+	// SubmitChanges supersedes the pending revision of the product, creates revision when it is
+	// not nil and updates productFields on the product row, all in one transaction
+	SubmitChanges(ctx context.Context, productID int, revision *entity.ProductRevision, productFields map[string]interface{}) error
+	// ReviewRevision saves the review of a pending revision and updates productFields on the
+	// product row. It returns gorm.ErrRecordNotFound when the revision is no longer pending.
+	ReviewRevision(ctx context.Context, revision *entity.ProductRevision, productFields map[string]interface{}) error
+}
+
+type productRevisionRepo struct {
+	db *gorm.DB
+}
+
+func NewProductRevisionRepo(db *gorm.DB) IProductRevisionRepo {
+	return &productRevisionRepo{db: db}
+}
+
+func (r *productRevisionRepo) GetProduct(ctx context.Context, productID int) (*entity.Product, error) {
+	var product entity.Product
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Where("id = ?", productID).
+		First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *productRevisionRepo) GetRevisionsByProduct(ctx context.Context, productID int) ([]entity.ProductRevision, error) {
+	var revisions []entity.ProductRevision
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Where("product_id = ?", productID).
+		Order("revision_number DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *productRevisionRepo) GetRevisionByNumber(ctx context.Context, productID int, number int) (*entity.ProductRevision, error) {
+	var revision entity.ProductRevision
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Where("product_id = ? AND revision_number = ?", productID, number).
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *productRevisionRepo) GetPendingRevision(ctx context.Context, productID int) (*entity.ProductRevision, error) {
+	var revision entity.ProductRevision
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Where("product_id = ? AND status = ?", productID, entity.RevisionPending).
+		Order("revision_number DESC").
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *productRevisionRepo) GetLastApprovedRevision(ctx context.Context, productID int, beforeNumber int) (*entity.ProductRevision, error) {
+	var revision entity.ProductRevision
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Where("product_id = ? AND status = ? AND revision_number < ?", productID, entity.RevisionApproved, beforeNumber).
+		Order("revision_number DESC").
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *productRevisionRepo) SubmitChanges(
+	ctx context.Context,
+	productID int,
+	revision *entity.ProductRevision,
+	productFields map[string]interface{},
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the product so concurrent edits get consecutive revision numbers
+		var product entity.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", productID).
+			First(&product).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.ProductRevision{}).
+			Where("product_id = ? AND status = ?", productID, entity.RevisionPending).
+			Update("status", entity.RevisionSuperseded).Error; err != nil {
+			return err
+		}
+
+		if revision != nil {
+			var last int
+			if err := tx.Model(&entity.ProductRevision{}).
+				Where("product_id = ?", productID).
+				Select("COALESCE(MAX(revision_number), 0)").
+				Scan(&last).Error; err != nil {
+				return err
+			}
+			revision.ProductID = productID
+			revision.RevisionNumber = last + 1
+			if err := tx.Omit(clause.Associations).Create(revision).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(productFields) == 0 {
+			return nil
+		}
+		return tx.Model(&entity.Product{}).Where("id = ?", productID).Updates(productFields).Error
+	})
+}
+
+func (r *productRevisionRepo) ReviewRevision(
+	ctx context.Context,
+	revision *entity.ProductRevision,
+	productFields map[string]interface{},
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.ProductRevision{}).
+			Where("id = ? AND status = ?", revision.ID, entity.RevisionPending).
+			Updates(map[string]interface{}{
+				"status":      revision.Status,
+				"reviewer_id": revision.ReviewerID,
+				"review_note": revision.ReviewNote,
+				"reviewed_at": revision.ReviewedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if len(productFields) == 0 {
+			return nil
+		}
+		return tx.Model(&entity.Product{}).Where("id = ?", revision.ProductID).Updates(productFields).Error
+	})
+}
@@ -1,16 +1,41 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// IReviewRepo keeps the review aggregates of products (average, count and per star counts)
+// in step with every published review it creates, updates or deletes
 type IReviewRepo interface {
-	GetReviewsByProductID(productID int) ([]entity.Review, error)
-	GetReviewByUserAndProduct(userID, productID int) (*entity.Review, error)
-	CreateReview(review *entity.Review) error
-	UpdateReview(review *entity.Review) error
-	DeleteReview(id int) error
+	// ListReviews returns the published reviews of a product
+	ListReviews(ctx context.Context, filter request.ListReviews, offset, limit int) ([]entity.Review, int64, error)
+	GetReviewByID(ctx context.Context, id int) (*entity.Review, error)
+	GetReviewByUserAndProduct(ctx context.Context, userID, productID int) (*entity.Review, error)
+	// GetCompletedOrderWithProduct returns the latest completed order of the user that contains the product
+	GetCompletedOrderWithProduct(ctx context.Context, userID, productID int) (int, error)
+	// CreateReview adds the review to the product's aggregates. It reports false when the user
+	// already reviewed the product.
+	CreateReview(ctx context.Context, review *entity.Review) (bool, error)
+	// UpdateReview saves the review along with the version it replaces
+	UpdateReview(ctx context.Context, review *entity.Review, previous *entity.ReviewEdit) error
+	DeleteReview(ctx context.Context, id int) error
+	GetReviewEdits(ctx context.Context, reviewID int) ([]entity.ReviewEdit, error)
+
+	// Photos
+	CountReviewImages(ctx context.Context, reviewID int) (int64, error)
+	CreateReviewImage(ctx context.Context, image *entity.ReviewImage) error
+	GetReviewImageByID(ctx context.Context, id int) (*entity.ReviewImage, error)
+	DeleteReviewImage(ctx context.Context, id int) error
+
+	// Seller replies
+	SaveReviewReply(ctx context.Context, reply *entity.ReviewReply) error
+	DeleteReviewReply(ctx context.Context, reviewID int) (bool, error)
 }
 
 type reviewRepo struct {
@@ -21,27 +46,214 @@ func NewReviewRepo(db *gorm.DB) IReviewRepo {
 	return &reviewRepo{db: db}
 }
 
-func (r *reviewRepo) GetReviewsByProductID(productID int) ([]entity.Review, error) {
+func (r *reviewRepo) ListReviews(
+	ctx context.Context,
+	filter request.ListReviews,
+	offset, limit int,
+) ([]entity.Review, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&entity.Review{}).
+		Where("reviews.product_id = ? AND reviews.status = ?", filter.ProductID, entity.ReviewStatusPublished)
+	if filter.Rating != nil {
+		query = query.Where("reviews.rating = ?", *filter.Rating)
+	}
+	if filter.WithPhotos {
+		query = query.Where("EXISTS (SELECT 1 FROM review_images ri WHERE ri.review_id = reviews.id)")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "reviews.created_at DESC, reviews.id DESC"
+	if filter.Sort == request.ReviewSortHelpful {
+		order = "reviews.helpful_count DESC, " + order
+	}
+
 	var reviews []entity.Review
-	err := r.db.Preload("User").Where("product_id = ?", productID).
-		Order("created_at DESC").Find(&reviews).Error
-	return reviews, err
+	err := query.
+		Preload("User").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Reply").
+		Order(order).
+		Offset(offset).
+		Limit(limit).
+		Find(&reviews).Error
+	return reviews, total, err
 }
 
-func (r *reviewRepo) GetReviewByUserAndProduct(userID, productID int) (*entity.Review, error) {
+func (r *reviewRepo) GetReviewByID(ctx context.Context, id int) (*entity.Review, error) {
 	var review entity.Review
-	err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&review).Error
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Product").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Reply").
+		First(&review, id).Error
 	return &review, err
 }
 
-func (r *reviewRepo) CreateReview(review *entity.Review) error {
-	return r.db.Create(review).Error
+func (r *reviewRepo) GetReviewByUserAndProduct(ctx context.Context, userID, productID int) (*entity.Review, error) {
+	var review entity.Review
+	err := r.db.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID).First(&review).Error
+	return &review, err
+}
+
+func (r *reviewRepo) GetCompletedOrderWithProduct(ctx context.Context, userID, productID int) (int, error) {
+	var orderIDs []int
+	err := r.db.WithContext(ctx).
+		Table("orders o").
+		Joins("JOIN order_items oi ON oi.order_id = o.id").
+		Joins("JOIN product_variants pv ON pv.id = oi.product_variant_id").
+		Where("o.user_id = ? AND o.status = ? AND pv.product_id = ?", userID, "completed", productID).
+		Order("o.created_at DESC").
+		Limit(1).
+		Pluck("o.id", &orderIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(orderIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return orderIDs[0], nil
+}
+
+func (r *reviewRepo) CreateReview(ctx context.Context, review *entity.Review) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(review)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return applyRatingChange(tx, review.ProductID, 0, publishedRating(review.Status, review.Rating))
+	})
+	return created, err
+}
+
+func (r *reviewRepo) UpdateReview(ctx context.Context, review *entity.Review, previous *entity.ReviewEdit) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Take the replaced rating from the locked row, a concurrent edit may have changed it
+		var current entity.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, review.ID).Error; err != nil {
+			return err
+		}
+		previous.Rating = current.Rating
+		previous.Comment = current.Comment
+
+		if err := tx.Create(previous).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&entity.Review{}).Where("id = ?", review.ID).Updates(map[string]interface{}{
+			"rating":      review.Rating,
+			"comment":     review.Comment,
+			"edited_at":   review.EditedAt,
+			"status":      review.Status,
+			"flag_reason": review.FlagReason,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		removed := publishedRating(current.Status, current.Rating)
+		added := publishedRating(review.Status, review.Rating)
+		if removed == added {
+			return nil
+		}
+		return applyRatingChange(tx, review.ProductID, removed, added)
+	})
+}
+
+func (r *reviewRepo) DeleteReview(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var review entity.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entity.Review{}, id).Error; err != nil {
+			return err
+		}
+		return applyRatingChange(tx, review.ProductID, publishedRating(review.Status, review.Rating), 0)
+	})
+}
+
+// publishedRating is the rating a review contributes to its product's aggregates, 0 when it is not shown
+func publishedRating(status string, rating int) int {
+	if status != entity.ReviewStatusPublished {
+		return 0
+	}
+	return rating
+}
+
+// applyRatingChange moves one review of a product from the removed star count to the added one,
+// 0 standing for none, and derives the average from the new counts
+func applyRatingChange(tx *gorm.DB, productID int, removed, added int) error {
+	updates := map[string]interface{}{}
+	if removed >= 1 && removed <= 5 {
+		column := fmt.Sprintf("rating_%d_count", removed)
+		updates[column] = gorm.Expr(column + " - 1")
+	}
+	if added >= 1 && added <= 5 {
+		column := fmt.Sprintf("rating_%d_count", added)
+		updates[column] = gorm.Expr(column + " + 1")
+	}
+	switch {
+	case removed == 0 && added != 0:
+		updates["review_count"] = gorm.Expr("review_count + 1")
+	case removed != 0 && added == 0:
+		updates["review_count"] = gorm.Expr("review_count - 1")
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := tx.Table("products").Where("id = ?", productID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return tx.Table("products").Where("id = ?", productID).Update("average_rating", gorm.Expr(productAverageRatingExpr)).Error
+}
+
+func (r *reviewRepo) GetReviewEdits(ctx context.Context, reviewID int) ([]entity.ReviewEdit, error) {
+	var edits []entity.ReviewEdit
+	err := r.db.WithContext(ctx).
+		Where("review_id = ?", reviewID).
+		Order("edited_at DESC, id DESC").
+		Find(&edits).Error
+	return edits, err
+}
+
+func (r *reviewRepo) CountReviewImages(ctx context.Context, reviewID int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.ReviewImage{}).Where("review_id = ?", reviewID).Count(&count).Error
+	return count, err
+}
+
+func (r *reviewRepo) CreateReviewImage(ctx context.Context, image *entity.ReviewImage) error {
+	return r.db.WithContext(ctx).Create(image).Error
+}
+
+func (r *reviewRepo) GetReviewImageByID(ctx context.Context, id int) (*entity.ReviewImage, error) {
+	var image entity.ReviewImage
+	err := r.db.WithContext(ctx).First(&image, id).Error
+	return &image, err
+}
+
+func (r *reviewRepo) DeleteReviewImage(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&entity.ReviewImage{}, id).Error
 }
 
-func (r *reviewRepo) UpdateReview(review *entity.Review) error {
-	return r.db.Save(review).Error
+func (r *reviewRepo) SaveReviewReply(ctx context.Context, reply *entity.ReviewReply) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "review_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"comment", "updated_at"}),
+	}).Create(reply).Error
 }
 
-func (r *reviewRepo) DeleteReview(id int) error {
-	return r.db.Delete(&entity.Review{}, id).Error
+func (r *reviewRepo) DeleteReviewReply(ctx context.Context, reviewID int) (bool, error) {
+	result := r.db.WithContext(ctx).Where("review_id = ?", reviewID).Delete(&entity.ReviewReply{})
+	return result.RowsAffected > 0, result.Error
 }
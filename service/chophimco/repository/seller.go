@@ -5,6 +5,7 @@ import (
 
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ISellerRepository interface {
@@ -12,6 +13,9 @@ type ISellerRepository interface {
 	CreateSellerProfile(ctx context.Context, profile *entity.SellerProfile) error
 	GetSellerProfileByUserID(ctx context.Context, userID int) (*entity.SellerProfile, error)
 	GetSellerProfileByID(ctx context.Context, id int) (*entity.SellerProfile, error)
+	GetSellerProfileBySlug(ctx context.Context, slug string) (*entity.SellerProfile, error)
+	// SellerSlugExists reports whether another profile than excludeID uses the slug
+	SellerSlugExists(ctx context.Context, slug string, excludeID int) (bool, error)
 	UpdateSellerProfile(ctx context.Context, profile *entity.SellerProfile) error
 	GetPendingSellers(ctx context.Context) ([]entity.SellerProfile, error)
 
@@ -20,21 +24,26 @@ type ISellerRepository interface {
 	GetProductsBySeller(ctx context.Context, sellerID int) ([]entity.Product, error)
 	GetProductByIDAndSeller(ctx context.Context, productID int, sellerID int) (*entity.Product, error)
 	UpdateProduct(ctx context.Context, product *entity.Product) error
+	UpdateProductFields(ctx context.Context, productID int, fields map[string]interface{}) error
 	DeleteProduct(ctx context.Context, productID int) error
 	GetPendingProducts(ctx context.Context) ([]entity.Product, error)
 
 	// Product images
 	CreateProductImage(ctx context.Context, image *entity.ProductImage) error
 	GetImageByIDAndProduct(ctx context.Context, imageID int, productID int) (*entity.ProductImage, error)
+	GetImageByIDAndSeller(ctx context.Context, imageID int, sellerID int) (*entity.ProductImage, error)
 	DeleteProductImage(ctx context.Context, imageID int) error
 	UnsetPrimaryImages(ctx context.Context, productID int) error
 	SetPrimaryImage(ctx context.Context, imageID int) error
 
 	// Seller reviews
 	GetSellerReviews(ctx context.Context, sellerID int) ([]entity.SellerReview, error)
-	CreateSellerReview(ctx context.Context, review *entity.SellerReview) error
-	GetSellerAverageRating(ctx context.Context, sellerID int) (float64, error)
-	UpdateSellerRating(ctx context.Context, sellerID int, rating float64) error
+	// GetBuyerOrderWithSeller loads a buyer's order containing products of the seller
+	GetBuyerOrderWithSeller(ctx context.Context, buyerID, sellerID, orderID int) (*entity.Order, error)
+	HasSellerReview(ctx context.Context, buyerID, sellerID, orderID int) (bool, error)
+	// CreateSellerReview adds the review and recomputes the seller's rating. It reports false
+	// when the buyer already reviewed the seller for the order.
+	CreateSellerReview(ctx context.Context, review *entity.SellerReview) (bool, error)
 }
 
 type sellerRepo struct {
@@ -68,6 +77,24 @@ func (r *sellerRepo) GetSellerProfileByID(ctx context.Context, id int) (*entity.
 	return &profile, nil
 }
 
+func (r *sellerRepo) GetSellerProfileBySlug(ctx context.Context, slug string) (*entity.SellerProfile, error) {
+	var profile entity.SellerProfile
+	err := r.db.WithContext(ctx).Preload("User").Where("slug = ?", slug).First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *sellerRepo) SellerSlugExists(ctx context.Context, slug string, excludeID int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.SellerProfile{}).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *sellerRepo) UpdateSellerProfile(ctx context.Context, profile *entity.SellerProfile) error {
 	return r.db.WithContext(ctx).Save(profile).Error
 }
@@ -76,7 +103,7 @@ func (r *sellerRepo) GetPendingSellers(ctx context.Context) ([]entity.SellerProf
 	var sellers []entity.SellerProfile
 	err := r.db.WithContext(ctx).
 		Preload("User").
-		Where("verification_status = ?", "pending").
+		Where("verification_status = ?", entity.SellerStatusPending).
 		Order("created_at DESC").
 		Find(&sellers).Error
 	return sellers, err
@@ -94,6 +121,7 @@ func (r *sellerRepo) GetProductsBySeller(ctx context.Context, sellerID int) ([]e
 		Preload("Brand").
 		Preload("Variants").
 		Preload("Images").
+		Preload("Revisions", "status = ?", entity.RevisionPending).
 		Where("seller_id = ?", sellerID).
 		Order("created_at DESC").
 		Find(&products).Error
@@ -119,6 +147,10 @@ func (r *sellerRepo) UpdateProduct(ctx context.Context, product *entity.Product)
 	return r.db.WithContext(ctx).Save(product).Error
 }
 
+func (r *sellerRepo) UpdateProductFields(ctx context.Context, productID int, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Product{}).Where("id = ?", productID).Updates(fields).Error
+}
+
 func (r *sellerRepo) DeleteProduct(ctx context.Context, productID int) error {
 	return r.db.WithContext(ctx).Delete(&entity.Product{}, productID).Error
 }
@@ -130,7 +162,11 @@ func (r *sellerRepo) GetPendingProducts(ctx context.Context) ([]entity.Product,
 		Preload("Brand").
 		Preload("Seller").
 		Preload("Images").
+		Preload("Revisions", "status = ?", entity.RevisionPending).
 		Where("approval_status = ?", "pending").
+		// Approved products with edits waiting for review
+		Or("EXISTS (SELECT 1 FROM product_revisions WHERE product_revisions.product_id = products.id AND product_revisions.status = ?)",
+			entity.RevisionPending).
 		Order("created_at DESC").
 		Find(&products).Error
 	return products, err
@@ -152,6 +188,18 @@ func (r *sellerRepo) GetImageByIDAndProduct(ctx context.Context, imageID int, pr
 	return &image, nil
 }
 
+func (r *sellerRepo) GetImageByIDAndSeller(ctx context.Context, imageID int, sellerID int) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	err := r.db.WithContext(ctx).
+		Joins("JOIN products ON products.id = product_images.product_id").
+		Where("product_images.id = ? AND products.seller_id = ?", imageID, sellerID).
+		First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
 func (r *sellerRepo) DeleteProductImage(ctx context.Context, imageID int) error {
 	return r.db.WithContext(ctx).Delete(&entity.ProductImage{}, imageID).Error
 }
@@ -176,29 +224,58 @@ func (r *sellerRepo) GetSellerReviews(ctx context.Context, sellerID int) ([]enti
 	err := r.db.WithContext(ctx).
 		Preload("Buyer").
 		Preload("Order").
-		Where("seller_id = ?", sellerID).
+		Where("seller_id = ? AND status = ?", sellerID, entity.ReviewStatusPublished).
 		Order("created_at DESC").
 		Find(&reviews).Error
 	return reviews, err
 }
 
-func (r *sellerRepo) CreateSellerReview(ctx context.Context, review *entity.SellerReview) error {
-	return r.db.WithContext(ctx).Create(review).Error
+func (r *sellerRepo) GetBuyerOrderWithSeller(ctx context.Context, buyerID, sellerID, orderID int) (*entity.Order, error) {
+	var order entity.Order
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", orderID, buyerID).
+		Where(`EXISTS (
+			SELECT 1 FROM order_items oi
+			JOIN product_variants pv ON pv.id = oi.product_variant_id
+			JOIN products p ON p.id = pv.product_id
+			WHERE oi.order_id = orders.id AND p.seller_id = ?)`, sellerID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
 }
 
-func (r *sellerRepo) GetSellerAverageRating(ctx context.Context, sellerID int) (float64, error) {
-	var avgRating float64
+func (r *sellerRepo) HasSellerReview(ctx context.Context, buyerID, sellerID, orderID int) (bool, error) {
+	var count int64
 	err := r.db.WithContext(ctx).
 		Model(&entity.SellerReview{}).
-		Where("seller_id = ?", sellerID).
-		Select("COALESCE(AVG(rating), 0)").
-		Scan(&avgRating).Error
-	return avgRating, err
+		Where("buyer_id = ? AND seller_id = ? AND order_id = ?", buyerID, sellerID, orderID).
+		Count(&count).Error
+	return count > 0, err
 }
 
-func (r *sellerRepo) UpdateSellerRating(ctx context.Context, sellerID int, rating float64) error {
-	return r.db.WithContext(ctx).
-		Model(&entity.SellerProfile{}).
+func (r *sellerRepo) CreateSellerReview(ctx context.Context, review *entity.SellerReview) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(review)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return updateSellerRating(tx, review.SellerID)
+	})
+	return created, err
+}
+
+// updateSellerRating recomputes a seller's average rating from their published reviews
+func updateSellerRating(tx *gorm.DB, sellerID int) error {
+	return tx.Model(&entity.SellerProfile{}).
 		Where("user_id = ?", sellerID).
-		Update("average_rating", rating).Error
+		Update("average_rating", gorm.Expr(
+			"(SELECT COALESCE(AVG(rating), 0) FROM seller_reviews WHERE seller_id = ? AND status = ?)",
+			sellerID, entity.ReviewStatusPublished)).Error
 }
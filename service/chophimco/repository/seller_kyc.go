@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type ISellerKYCRepo interface {
+	// GetKYC returns gorm.ErrRecordNotFound when the seller has not entered any details yet
+	GetKYC(ctx context.Context, sellerProfileID int) (*entity.SellerKYC, error)
+	SaveKYC(ctx context.Context, kyc *entity.SellerKYC) error
+
+	// GetDocuments returns every document of a seller including superseded ones, newest first
+	GetDocuments(ctx context.Context, sellerProfileID int) ([]entity.SellerDocument, error)
+	GetDocumentByID(ctx context.Context, id int) (*entity.SellerDocument, error)
+	// AddDocument supersedes the earlier documents of the same type and creates doc
+	AddDocument(ctx context.Context, doc *entity.SellerDocument) error
+	DeleteDocument(ctx context.Context, id int) error
+	// ReviewDocument saves the review of a pending document and records the event. It returns
+	// gorm.ErrRecordNotFound when the document is no longer pending.
+	ReviewDocument(ctx context.Context, doc *entity.SellerDocument, event *entity.SellerVerificationEvent) error
+
+	// ChangeStatus moves the seller from event.FromStatus to event.ToStatus, records the event,
+	// updates profileFields and keeps the user's seller flag in sync. It returns
+	// gorm.ErrRecordNotFound when the seller is no longer in event.FromStatus.
+	ChangeStatus(ctx context.Context, profile *entity.SellerProfile, event *entity.SellerVerificationEvent, profileFields map[string]interface{}) error
+	GetEvents(ctx context.Context, sellerProfileID int) ([]entity.SellerVerificationEvent, error)
+}
+
+type sellerKYCRepo struct {
+	db *gorm.DB
+}
+
+func NewSellerKYCRepo(db *gorm.DB) ISellerKYCRepo {
+	return &sellerKYCRepo{db: db}
+}
+
+func (r *sellerKYCRepo) GetKYC(ctx context.Context, sellerProfileID int) (*entity.SellerKYC, error) {
+	var kyc entity.SellerKYC
+	err := r.db.WithContext(ctx).Where("seller_profile_id = ?", sellerProfileID).First(&kyc).Error
+	if err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+func (r *sellerKYCRepo) SaveKYC(ctx context.Context, kyc *entity.SellerKYC) error {
+	return r.db.WithContext(ctx).Save(kyc).Error
+}
+
+func (r *sellerKYCRepo) GetDocuments(ctx context.Context, sellerProfileID int) ([]entity.SellerDocument, error) {
+	var docs []entity.SellerDocument
+	err := r.db.WithContext(ctx).
+		Where("seller_profile_id = ?", sellerProfileID).
+		Order("created_at DESC, id DESC").
+		Find(&docs).Error
+	return docs, err
+}
+
+func (r *sellerKYCRepo) GetDocumentByID(ctx context.Context, id int) (*entity.SellerDocument, error) {
+	var doc entity.SellerDocument
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&doc).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *sellerKYCRepo) AddDocument(ctx context.Context, doc *entity.SellerDocument) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.SellerDocument{}).
+			Where("seller_profile_id = ? AND type = ? AND status <> ?",
+				doc.SellerProfileID, doc.Type, entity.DocumentStatusSuperseded).
+			Update("status", entity.DocumentStatusSuperseded).Error; err != nil {
+			return err
+		}
+		return tx.Create(doc).Error
+	})
+}
+
+func (r *sellerKYCRepo) DeleteDocument(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&entity.SellerDocument{}, id).Error
+}
+
+func (r *sellerKYCRepo) ReviewDocument(
+	ctx context.Context,
+	doc *entity.SellerDocument,
+	event *entity.SellerVerificationEvent,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.SellerDocument{}).
+			Where("id = ? AND status = ?", doc.ID, entity.DocumentStatusPending).
+			Updates(map[string]interface{}{
+				"status":      doc.Status,
+				"reviewer_id": doc.ReviewerID,
+				"review_note": doc.ReviewNote,
+				"reviewed_at": doc.ReviewedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(event).Error
+	})
+}
+
+func (r *sellerKYCRepo) ChangeStatus(
+	ctx context.Context,
+	profile *entity.SellerProfile,
+	event *entity.SellerVerificationEvent,
+	profileFields map[string]interface{},
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fields := map[string]interface{}{"verification_status": event.ToStatus}
+		for field, value := range profileFields {
+			fields[field] = value
+		}
+		result := tx.Model(&entity.SellerProfile{}).
+			Where("id = ? AND verification_status = ?", profile.ID, event.FromStatus).
+			Updates(fields)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&entity.User{}).
+			Where("id = ?", profile.UserID).
+			Update("is_seller_verified", event.ToStatus == entity.SellerStatusVerified).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+func (r *sellerKYCRepo) GetEvents(ctx context.Context, sellerProfileID int) ([]entity.SellerVerificationEvent, error) {
+	var events []entity.SellerVerificationEvent
+	err := r.db.WithContext(ctx).
+		Where("seller_profile_id = ?", sellerProfileID).
+		Order("created_at DESC, id DESC").
+		Find(&events).Error
+	return events, err
+}
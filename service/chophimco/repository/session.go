@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type ISessionRepo interface {
+	// CreateSession stores a new login session with its first refresh token
+	CreateSession(ctx context.Context, session *entity.AuthSession, token *entity.RefreshToken) error
+	// GetRefreshToken finds a refresh token by hash, with its session
+	GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	// RotateRefreshToken marks the token used and stores its successor. It reports false when
+	// the token was already used, by a concurrent refresh or a replay.
+	RotateRefreshToken(ctx context.Context, token *entity.RefreshToken, next *entity.RefreshToken) (bool, error)
+
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	// MarkTwoFactor records that the session passed two-factor authentication
+	MarkTwoFactor(ctx context.Context, sessionID string) error
+	RevokeSession(ctx context.Context, sessionID string, reason string) error
+	// RevokeUserSessions revokes every active session of a user and returns how many there were
+	RevokeUserSessions(ctx context.Context, userID int, reason string) (int64, error)
+}
+
+type sessionRepo struct {
+	db *gorm.DB
+}
+
+func NewSessionRepo(db *gorm.DB) ISessionRepo {
+	return &sessionRepo{db: db}
+}
+
+func (r *sessionRepo) CreateSession(ctx context.Context, session *entity.AuthSession, token *entity.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+func (r *sessionRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.db.WithContext(ctx).
+		Preload("Session").
+		Preload("Session.User").
+		Preload("Session.User.Role").
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *sessionRepo) RotateRefreshToken(
+	ctx context.Context,
+	token *entity.RefreshToken,
+	next *entity.RefreshToken,
+) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&entity.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		rotated = true
+
+		next.SessionID = token.SessionID
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		// Expired tokens of the chain are no longer worth keeping for reuse detection
+		err := tx.Where("session_id = ? AND expires_at < ?", token.SessionID, now).
+			Delete(&entity.RefreshToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.AuthSession{}).
+			Where("id = ?", token.SessionID).
+			Update("last_used_at", now).Error
+	})
+	return rotated, err
+}
+
+func (r *sessionRepo) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sessionRepo) MarkTwoFactor(ctx context.Context, sessionID string) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("two_factor", true).Error
+}
+
+func (r *sessionRepo) RevokeSession(ctx context.Context, sessionID string, reason string) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		}).Error
+}
+
+func (r *sessionRepo) RevokeUserSessions(ctx context.Context, userID int, reason string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		})
+	return result.RowsAffected, result.Error
+}
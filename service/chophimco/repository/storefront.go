@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type IStorefrontRepo interface {
+	// CountShopProducts counts the seller's active, approved products
+	CountShopProducts(ctx context.Context, sellerID int) (int64, error)
+	GetShopRatingDistribution(ctx context.Context, sellerID int) ([]RatingCountRow, error)
+	// GetShopProductsByIDs returns the active, approved products among ids, in no particular order
+	GetShopProductsByIDs(ctx context.Context, sellerID int, ids []int) ([]entity.Product, error)
+	// CountSellerProducts counts how many of ids belong to the seller
+	CountSellerProducts(ctx context.Context, sellerID int, ids []int) (int64, error)
+
+	// GetCollections returns the seller's collections in display order with their items
+	GetCollections(ctx context.Context, sellerID int, activeOnly bool) ([]entity.ShopCollection, error)
+	GetCollectionByID(ctx context.Context, id int) (*entity.ShopCollection, error)
+	CountCollections(ctx context.Context, sellerID int) (int64, error)
+	CreateCollection(ctx context.Context, collection *entity.ShopCollection) error
+	UpdateCollection(ctx context.Context, collection *entity.ShopCollection) error
+	DeleteCollection(ctx context.Context, id int) error
+	// SetCollectionProducts replaces the items of a collection, positioned in the order of productIDs
+	SetCollectionProducts(ctx context.Context, collectionID int, productIDs []int) error
+}
+
+type storefrontRepo struct {
+	db *gorm.DB
+}
+
+func NewStorefrontRepo(db *gorm.DB) IStorefrontRepo {
+	return &storefrontRepo{db: db}
+}
+
+func (r *storefrontRepo) shopProducts(ctx context.Context, sellerID int) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("seller_id = ? AND is_active = ? AND approval_status = ?", sellerID, true, "approved")
+}
+
+func (r *storefrontRepo) CountShopProducts(ctx context.Context, sellerID int) (int64, error) {
+	var count int64
+	err := r.shopProducts(ctx, sellerID).Count(&count).Error
+	return count, err
+}
+
+func (r *storefrontRepo) GetShopRatingDistribution(ctx context.Context, sellerID int) ([]RatingCountRow, error) {
+	var rows []RatingCountRow
+	err := r.db.WithContext(ctx).
+		Table("seller_reviews").
+		Select("rating, COUNT(*) AS count").
+		Where("seller_id = ? AND status = ?", sellerID, entity.ReviewStatusPublished).
+		Group("rating").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *storefrontRepo) GetShopProductsByIDs(ctx context.Context, sellerID int, ids []int) ([]entity.Product, error) {
+	var products []entity.Product
+	err := r.shopProducts(ctx, sellerID).
+		Preload("Category").Preload("Brand").Preload("Variants.Switch").
+		Preload("Variants.Attributes.Definition").
+		Where("id IN ?", ids).
+		Find(&products).Error
+	return products, err
+}
+
+func (r *storefrontRepo) CountSellerProducts(ctx context.Context, sellerID int, ids []int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("seller_id = ? AND id IN ?", sellerID, ids).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *storefrontRepo) GetCollections(ctx context.Context, sellerID int, activeOnly bool) ([]entity.ShopCollection, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position, id")
+		}).
+		Where("seller_id = ?", sellerID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var collections []entity.ShopCollection
+	err := query.Order("position, id").Find(&collections).Error
+	return collections, err
+}
+
+func (r *storefrontRepo) GetCollectionByID(ctx context.Context, id int) (*entity.ShopCollection, error) {
+	var collection entity.ShopCollection
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position, id")
+		}).
+		Where("id = ?", id).
+		First(&collection).Error
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *storefrontRepo) CountCollections(ctx context.Context, sellerID int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.ShopCollection{}).
+		Where("seller_id = ?", sellerID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *storefrontRepo) CreateCollection(ctx context.Context, collection *entity.ShopCollection) error {
+	return r.db.WithContext(ctx).Create(collection).Error
+}
+
+func (r *storefrontRepo) UpdateCollection(ctx context.Context, collection *entity.ShopCollection) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(collection).Error
+}
+
+func (r *storefrontRepo) DeleteCollection(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", id).Delete(&entity.ShopCollectionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.ShopCollection{}, id).Error
+	})
+}
+
+func (r *storefrontRepo) SetCollectionProducts(ctx context.Context, collectionID int, productIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.ShopCollection{}).
+			Where("id = ?", collectionID).
+			Update("updated_at", gorm.Expr("now()")).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("collection_id = ?", collectionID).Delete(&entity.ShopCollectionItem{}).Error; err != nil {
+			return err
+		}
+		if len(productIDs) == 0 {
+			return nil
+		}
+
+		items := make([]entity.ShopCollectionItem, 0, len(productIDs))
+		for i, productID := range productIDs {
+			items = append(items, entity.ShopCollectionItem{
+				CollectionID: collectionID,
+				ProductID:    productID,
+				Position:     i,
+			})
+		}
+		return tx.Create(&items).Error
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type ISuggestRepo interface {
+	GetSuggestableProductNames(ctx context.Context) ([]string, error)
+	GetBrandNames(ctx context.Context) ([]string, error)
+	GetSwitchNames(ctx context.Context) ([]string, error)
+	GetCategoryNames(ctx context.Context) ([]string, error)
+
+	// LogSearchQuery increments the counter of an already normalized query
+	LogSearchQuery(ctx context.Context, query string, at time.Time) error
+	// GetPopularQueries returns queries searched at least minCount times, most popular first
+	GetPopularQueries(ctx context.Context, minCount int, limit int) ([]entity.SearchQuery, error)
+}
+
+type suggestRepo struct {
+	db *gorm.DB
+}
+
+func NewSuggestRepo(db *gorm.DB) ISuggestRepo {
+	return &suggestRepo{db: db}
+}
+
+func (r *suggestRepo) GetSuggestableProductNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&entity.Product{}).
+		Where("is_active = ? AND approval_status = ?", true, "approved").
+		Distinct().Pluck("name", &names).Error
+	return names, err
+}
+
+func (r *suggestRepo) GetBrandNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&entity.Brand{}).Pluck("name", &names).Error
+	return names, err
+}
+
+func (r *suggestRepo) GetSwitchNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&entity.Switch{}).Distinct().Pluck("name", &names).Error
+	return names, err
+}
+
+func (r *suggestRepo) GetCategoryNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&entity.Category{}).Distinct().Pluck("name", &names).Error
+	return names, err
+}
+
+func (r *suggestRepo) LogSearchQuery(ctx context.Context, query string, at time.Time) error {
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO search_queries (query, count, last_searched_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (query) DO UPDATE
+		SET count = search_queries.count + 1, last_searched_at = EXCLUDED.last_searched_at
+	`, query, at).Error
+}
+
+func (r *suggestRepo) GetPopularQueries(ctx context.Context, minCount int, limit int) ([]entity.SearchQuery, error) {
+	var queries []entity.SearchQuery
+	err := r.db.WithContext(ctx).
+		Where("count >= ?", minCount).
+		Order("count DESC").
+		Limit(limit).
+		Find(&queries).Error
+	return queries, err
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+type ITwoFactorRepo interface {
+	GetTwoFactor(ctx context.Context, userID int) (*entity.UserTwoFactor, error)
+	// SaveSetup stores a pending authenticator, replacing an earlier pending one
+	SaveSetup(ctx context.Context, twoFactor *entity.UserTwoFactor) error
+	// Enable turns the pending authenticator on with its first code's step and the recovery
+	// codes. It reports false when it is already on or the step was used.
+	Enable(ctx context.Context, userID int, step int64, codeHashes []string) (bool, error)
+	Disable(ctx context.Context, userID int) error
+	// UseStep accepts a code's time step once, reporting false for a replayed code
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+	// TakeAttempt counts a code about to be checked, reporting false while the user is locked
+	// out. The maxAttempts-th attempt since the last accepted code locks them out for lockout.
+	TakeAttempt(ctx context.Context, userID int, maxAttempts int, lockout time.Duration) (bool, error)
+	// ResetAttempts clears the count and the lockout once a code is accepted
+	ResetAttempts(ctx context.Context, userID int) error
+
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, userID int) (int64, error)
+
+	// Login challenges are action tokens between the password and the second factor
+	CreateChallenge(ctx context.Context, challenge *entity.ActionToken) error
+	GetActiveChallenge(ctx context.Context, id string, userID int) (*entity.ActionToken, error)
+	// RecordChallengeFailure counts a wrong code, using the challenge up at maxAttempts
+	RecordChallengeFailure(ctx context.Context, id string, maxAttempts int) error
+	UseChallenge(ctx context.Context, id string) (bool, error)
+}
+
+type twoFactorRepo struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepo(db *gorm.DB) ITwoFactorRepo {
+	return &twoFactorRepo{db: db}
+}
+
+func (r *twoFactorRepo) GetTwoFactor(ctx context.Context, userID int) (*entity.UserTwoFactor, error) {
+	var twoFactor entity.UserTwoFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&twoFactor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepo) SaveSetup(ctx context.Context, twoFactor *entity.UserTwoFactor) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND enabled_at IS NULL", twoFactor.UserID).
+			Delete(&entity.UserTwoFactor{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(twoFactor).Error
+	})
+}
+
+func (r *twoFactorRepo) Enable(ctx context.Context, userID int, step int64, codeHashes []string) (bool, error) {
+	enabled := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.UserTwoFactor{}).
+			Where("user_id = ? AND enabled_at IS NULL AND last_used_step < ?", userID, step).
+			Updates(map[string]interface{}{
+				"enabled_at":     time.Now(),
+				"last_used_step": step,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		enabled = true
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+	return enabled, err
+}
+
+func (r *twoFactorRepo) Disable(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entity.UserTwoFactor{}).Error
+	})
+}
+
+func (r *twoFactorRepo) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.UserTwoFactor{}).
+		Where("user_id = ? AND enabled_at IS NOT NULL AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepo) TakeAttempt(
+	ctx context.Context,
+	userID int,
+	maxAttempts int,
+	lockout time.Duration,
+) (bool, error) {
+	now := time.Now()
+	// Counting and locking in one statement, concurrent guesses can't slip past the limit
+	result := r.db.WithContext(ctx).
+		Model(&entity.UserTwoFactor{}).
+		Where("user_id = ? AND (locked_until IS NULL OR locked_until <= ?)", userID, now).
+		Updates(map[string]interface{}{
+			"failed_attempts": gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN 0 ELSE failed_attempts + 1 END", maxAttempts),
+			"locked_until":    gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN ?::timestamp END", maxAttempts, now.Add(lockout)),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepo) ResetAttempts(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.UserTwoFactor{}).
+		Where("user_id = ? AND (failed_attempts > 0 OR locked_until IS NOT NULL)", userID).
+		Updates(map[string]interface{}{
+			"failed_attempts": 0,
+			"locked_until":    nil,
+		}).Error
+}
+
+func (r *twoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *twoFactorRepo) CountRecoveryCodes(ctx context.Context, userID int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *twoFactorRepo) CreateChallenge(ctx context.Context, challenge *entity.ActionToken) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+func (r *twoFactorRepo) GetActiveChallenge(ctx context.Context, id string, userID int) (*entity.ActionToken, error) {
+	var challenge entity.ActionToken
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+			id, userID, entity.ActionLoginTwoFactor, time.Now()).
+		First(&challenge).Error
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *twoFactorRepo) RecordChallengeFailure(ctx context.Context, id string, maxAttempts int) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.ActionToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"used_at":  gorm.Expr("CASE WHEN attempts + 1 >= ? THEN NOW() END", maxAttempts),
+		}).Error
+}
+
+func (r *twoFactorRepo) UseChallenge(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.ActionToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// replaceRecoveryCodes drops the user's recovery codes, used or not, for new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID int, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	codes := make([]entity.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, entity.RecoveryCode{UserID: userID, CodeHash: hash, CreatedAt: now})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
@@ -26,7 +26,7 @@ func NewUserRepo(db *gorm.DB) IUserRepo {
 func (r *userRepo) GetUserByEmail(email string) (*entity.User, error) {
 	logger.Info("GetUserByEmail repository method called")
 	var user entity.User
-	result := r.db.Preload("Role").Where("email = ?", email).First(&user)
+	result := r.db.Preload("Role").Preload("TwoFactor").Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, gorm.ErrRecordNotFound
@@ -38,7 +38,7 @@ func (r *userRepo) GetUserByEmail(email string) (*entity.User, error) {
 
 func (r *userRepo) GetUserByID(id int) (*entity.User, error) {
 	var user entity.User
-	result := r.db.Preload("Role").Where("id = ?", id).First(&user)
+	result := r.db.Preload("Role").Preload("TwoFactor").Where("id = ?", id).First(&user)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/mailer"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidActionToken   = errors.New("invalid or expired link")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrEmailNotVerified     = errors.New("verify your email address before placing orders")
+)
+
+type IAccountUsecase interface {
+	// SendVerificationEmail emails the user a link to verify their address
+	SendVerificationEmail(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, req request.VerifyEmail) error
+	// ForgotPassword emails a reset link when the address belongs to a user. It succeeds
+	// either way, so it cannot be used to find out which addresses are registered.
+	ForgotPassword(ctx context.Context, req request.ForgotPassword) error
+	// ResetPassword sets a new password and logs the user out everywhere
+	ResetPassword(ctx context.Context, req request.ResetPassword) error
+
+	// CheckCanPlaceOrder returns ErrEmailNotVerified when unverified users may not order
+	CheckCanPlaceOrder(ctx context.Context, userID int) error
+}
+
+type accountUsecase struct {
+	accountRepo    repository.IAccountRepo
+	userRepo       repository.IUserRepo
+	jwtService     auth.IJWTService
+	mailer         mailer.Mailer
+	sessionUsecase ISessionUsecase
+}
+
+func NewAccountUsecase(
+	accountRepo repository.IAccountRepo,
+	userRepo repository.IUserRepo,
+	jwtService auth.IJWTService,
+	mailer mailer.Mailer,
+	sessionUsecase ISessionUsecase,
+) IAccountUsecase {
+	return &accountUsecase{
+		accountRepo:    accountRepo,
+		userRepo:       userRepo,
+		jwtService:     jwtService,
+		mailer:         mailer,
+		sessionUsecase: sessionUsecase,
+	}
+}
+
+func (u *accountUsecase) SendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	ttl := config.AccountConfig().EmailVerificationTTL
+	link, err := u.issueLink(ctx, user.ID, entity.ActionVerifyEmail, ttl, "/verify-email")
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Text: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not create an account, ignore this email.\n",
+			user.FullName, link, ttl),
+	})
+}
+
+func (u *accountUsecase) VerifyEmail(ctx context.Context, req request.VerifyEmail) error {
+	claims, err := u.jwtService.ValidateActionToken(req.Token, entity.ActionVerifyEmail)
+	if err != nil {
+		return ErrInvalidActionToken
+	}
+
+	verified, err := u.accountRepo.VerifyEmail(ctx, claims.ID, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrInvalidActionToken
+	}
+	return nil
+}
+
+func (u *accountUsecase) ForgotPassword(ctx context.Context, req request.ForgotPassword) error {
+	user, err := u.userRepo.GetUserByEmail(strings.TrimSpace(req.Email))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ttl := config.AccountConfig().PasswordResetTTL
+	link, err := u.issueLink(ctx, user.ID, entity.ActionResetPassword, ttl, "/reset-password")
+	if err != nil {
+		return err
+	}
+
+	err = u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. Choose a new "+
+			"password here:\n\n%s\n\nThe link expires in %s. If it was not you, ignore this email, "+
+			"your password stays unchanged.\n",
+			user.FullName, link, ttl),
+	})
+	// A delivery failure must look the same as an unknown address to the caller
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+func (u *accountUsecase) ResetPassword(ctx context.Context, req request.ResetPassword) error {
+	claims, err := u.jwtService.ValidateActionToken(req.Token, entity.ActionResetPassword)
+	if err != nil {
+		return ErrInvalidActionToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	reset, err := u.accountRepo.ResetPassword(ctx, claims.ID, claims.UserID, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrInvalidActionToken
+	}
+
+	// Whoever knew the old password may still be logged in
+	return u.sessionUsecase.LogoutAll(ctx, claims.UserID)
+}
+
+func (u *accountUsecase) CheckCanPlaceOrder(ctx context.Context, userID int) error {
+	if config.AccountConfig().AllowUnverifiedOrders {
+		return nil
+	}
+
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// issueLink records a one-time token and returns the frontend link carrying it
+func (u *accountUsecase) issueLink(
+	ctx context.Context,
+	userID int,
+	purpose string,
+	ttl time.Duration,
+	path string,
+) (string, error) {
+	signed, claims, err := u.jwtService.GenerateActionToken(userID, purpose, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	err = u.accountRepo.CreateActionToken(ctx, &entity.ActionToken{
+		ID:        claims.ID,
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: claims.ExpiresAt.Time,
+		CreatedAt: claims.IssuedAt.Time,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	appURL := strings.TrimSuffix(config.AccountConfig().AppURL, "/")
+	return appURL + path + "?token=" + url.QueryEscape(signed), nil
+}
@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/mailer"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type accountFixture struct {
+	store    *memoryStore
+	mailer   *mailer.MemoryMailer
+	jwt      auth.IJWTService
+	sessions ISessionUsecase
+	account  IAccountUsecase
+	userID   int
+}
+
+func newAccountFixture(t *testing.T) *accountFixture {
+	t.Helper()
+	jwtService, err := auth.NewJWTService()
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+
+	store := newMemoryStore()
+	hash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := store.addUser(entity.User{Email: "buyer@example.com", FullName: "Buyer", PasswordHash: string(hash)})
+
+	memoryMailer := mailer.NewMemoryMailer()
+	sessions := NewSessionUsecase(memorySessionRepo{store}, jwtService)
+	return &accountFixture{
+		store:    store,
+		mailer:   memoryMailer,
+		jwt:      jwtService,
+		sessions: sessions,
+		account:  NewAccountUsecase(memoryAccountRepo{store}, memoryUserRepo{store}, jwtService, memoryMailer, sessions),
+		userID:   userID,
+	}
+}
+
+var linkTokenPattern = regexp.MustCompile(`\?token=(\S+)`)
+
+// lastLinkToken returns the token of the link in the latest email, which must have gone to the user
+func (f *accountFixture) lastLinkToken(t *testing.T, subject string) string {
+	t.Helper()
+	sent := f.mailer.Sent()
+	if len(sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	msg := sent[len(sent)-1]
+	if msg.To != "buyer@example.com" || msg.Subject != subject {
+		t.Fatalf("last email = %q to %q, want %q to buyer@example.com", msg.Subject, msg.To, subject)
+	}
+	match := linkTokenPattern.FindStringSubmatch(msg.Text)
+	if match == nil {
+		t.Fatalf("no link in email:\n%s", msg.Text)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (f *accountFixture) sendVerification(t *testing.T) string {
+	t.Helper()
+	if err := f.account.SendVerificationEmail(context.Background(), f.userID); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	return f.lastLinkToken(t, "Verify your email address")
+}
+
+func (f *accountFixture) forgotPassword(t *testing.T) string {
+	t.Helper()
+	if err := f.account.ForgotPassword(context.Background(), request.ForgotPassword{Email: "buyer@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	return f.lastLinkToken(t, "Reset your password")
+}
+
+func (f *accountFixture) passwordIs(t *testing.T, password string) bool {
+	t.Helper()
+	user := f.store.user(t, f.userID)
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func TestVerifyEmailTokenWorksOnce(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	token := f.sendVerification(t)
+
+	if err := f.account.VerifyEmail(ctx, request.VerifyEmail{Token: token}); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if f.store.user(t, f.userID).EmailVerifiedAt == nil {
+		t.Fatal("email not verified")
+	}
+
+	if err := f.account.VerifyEmail(ctx, request.VerifyEmail{Token: token}); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("second VerifyEmail = %v, want ErrInvalidActionToken", err)
+	}
+	if err := f.account.SendVerificationEmail(ctx, f.userID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Fatalf("SendVerificationEmail after verifying = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+func TestResetPasswordTokenWorksOnce(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	earlier := f.forgotPassword(t)
+	token := f.forgotPassword(t)
+
+	if err := f.account.ResetPassword(ctx, request.ResetPassword{Token: token, Password: "new-password"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if !f.passwordIs(t, "new-password") {
+		t.Fatal("password not changed")
+	}
+	if f.store.user(t, f.userID).EmailVerifiedAt == nil {
+		t.Fatal("a password reset should verify the email")
+	}
+
+	err := f.account.ResetPassword(ctx, request.ResetPassword{Token: token, Password: "third-password"})
+	if !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("second ResetPassword = %v, want ErrInvalidActionToken", err)
+	}
+	// Resetting uses up every other reset link sent before
+	err = f.account.ResetPassword(ctx, request.ResetPassword{Token: earlier, Password: "third-password"})
+	if !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("ResetPassword with an earlier link = %v, want ErrInvalidActionToken", err)
+	}
+	if !f.passwordIs(t, "new-password") {
+		t.Fatal("a used token changed the password")
+	}
+}
+
+func TestExpiredActionTokensAreRejected(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expired signature", func(t *testing.T) {
+		f := newAccountFixture(t)
+		token, claims, err := f.jwt.GenerateActionToken(f.userID, entity.ActionVerifyEmail, -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = memoryAccountRepo{f.store}.CreateActionToken(ctx, &entity.ActionToken{
+			ID:        claims.ID,
+			UserID:    f.userID,
+			Purpose:   entity.ActionVerifyEmail,
+			ExpiresAt: claims.ExpiresAt.Time,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := f.account.VerifyEmail(ctx, request.VerifyEmail{Token: token}); !errors.Is(err, ErrInvalidActionToken) {
+			t.Fatalf("VerifyEmail = %v, want ErrInvalidActionToken", err)
+		}
+		if f.store.user(t, f.userID).EmailVerifiedAt != nil {
+			t.Fatal("expired token verified the email")
+		}
+	})
+
+	t.Run("expired record", func(t *testing.T) {
+		f := newAccountFixture(t)
+		token := f.forgotPassword(t)
+		f.store.mu.Lock()
+		for _, record := range f.store.actionTokens {
+			record.ExpiresAt = time.Now().Add(-time.Second)
+		}
+		f.store.mu.Unlock()
+
+		err := f.account.ResetPassword(ctx, request.ResetPassword{Token: token, Password: "new-password"})
+		if !errors.Is(err, ErrInvalidActionToken) {
+			t.Fatalf("ResetPassword = %v, want ErrInvalidActionToken", err)
+		}
+		if !f.passwordIs(t, "old-password") {
+			t.Fatal("expired token changed the password")
+		}
+	})
+}
+
+func TestActionTokensOnlyWorkForTheirPurpose(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	verifyToken := f.sendVerification(t)
+	resetToken := f.forgotPassword(t)
+
+	err := f.account.ResetPassword(ctx, request.ResetPassword{Token: verifyToken, Password: "new-password"})
+	if !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("ResetPassword with a verify token = %v, want ErrInvalidActionToken", err)
+	}
+	if !f.passwordIs(t, "old-password") {
+		t.Fatal("verify token changed the password")
+	}
+
+	if err := f.account.VerifyEmail(ctx, request.VerifyEmail{Token: resetToken}); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("VerifyEmail with a reset token = %v, want ErrInvalidActionToken", err)
+	}
+	if f.store.user(t, f.userID).EmailVerifiedAt != nil {
+		t.Fatal("reset token verified the email")
+	}
+
+	// Neither token was used up by the failed attempts
+	if err := f.account.VerifyEmail(ctx, request.VerifyEmail{Token: verifyToken}); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if err := f.account.ResetPassword(ctx, request.ResetPassword{Token: resetToken, Password: "new-password"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+}
+
+func TestResetPasswordRevokesSessions(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	user := f.store.user(t, f.userID)
+
+	var refreshTokens []string
+	var sessionIDs []string
+	for _, device := range []string{"phone", "laptop"} {
+		tokens, err := f.sessions.CreateSession(ctx, &user, device, "127.0.0.1", false)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		claims, err := f.jwt.ValidateToken(tokens.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refreshTokens = append(refreshTokens, tokens.RefreshToken)
+		sessionIDs = append(sessionIDs, claims.SessionID)
+	}
+
+	token := f.forgotPassword(t)
+	if err := f.account.ResetPassword(ctx, request.ResetPassword{Token: token, Password: "new-password"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	for i := range sessionIDs {
+		revoked, err := f.sessions.IsRevoked(ctx, &auth.JWTClaims{SessionID: sessionIDs[i]})
+		if err != nil || !revoked {
+			t.Errorf("session %d revoked = %v, %v", i, revoked, err)
+		}
+		if _, err := f.sessions.Refresh(ctx, refreshTokens[i]); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Refresh of session %d = %v, want ErrInvalidRefreshToken", i, err)
+		}
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	f := newAccountFixture(t)
+	err := f.account.ForgotPassword(context.Background(), request.ForgotPassword{Email: "nobody@example.com"})
+	if err != nil {
+		t.Fatalf("ForgotPassword = %v, want nil", err)
+	}
+	if sent := f.mailer.Sent(); len(sent) != 0 {
+		t.Fatalf("sent %d emails to an unknown address", len(sent))
+	}
+}
@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/utils/mathutil"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+)
+
+var ErrInvalidAnalyticsRange = errors.New("invalid analytics range")
+
+const (
+	AnalyticsIntervalDay   = "day"
+	AnalyticsIntervalWeek  = "week"
+	AnalyticsIntervalMonth = "month"
+)
+
+const (
+	AnalyticsSortByUnits   = "units"
+	AnalyticsSortByRevenue = "revenue"
+)
+
+const (
+	// maxAnalyticsBuckets bounds the length of a series, a year of days
+	maxAnalyticsBuckets = 366
+	// lowStockSalesPeriod is the sales pace low stock is measured against
+	lowStockSalesPeriod      = 30 * 24 * time.Hour
+	defaultLowStockThreshold = 5
+	defaultTopVariantsLimit  = 10
+)
+
+type ISellerAnalyticsUsecase interface {
+	GetSales(ctx context.Context, sellerID int, req request.SellerAnalytics) (*response.SalesAnalyticsResponse, error)
+	GetConversion(ctx context.Context, sellerID int, req request.SellerAnalytics) (*response.ConversionAnalyticsResponse, error)
+	GetRatings(ctx context.Context, sellerID int, req request.SellerAnalytics) (*response.RatingAnalyticsResponse, error)
+	GetTopVariants(ctx context.Context, sellerID int, req request.TopVariants) (*response.TopVariantsResponse, error)
+	GetLowStockVariants(ctx context.Context, sellerID int, req request.LowStockVariants) (*response.LowStockResponse, error)
+}
+
+type sellerAnalyticsUsecase struct {
+	analyticsRepo repository.IAnalyticsRepo
+}
+
+func NewSellerAnalyticsUsecase(analyticsRepo repository.IAnalyticsRepo) ISellerAnalyticsUsecase {
+	return &sellerAnalyticsUsecase{
+		analyticsRepo: analyticsRepo,
+	}
+}
+
+func (u *sellerAnalyticsUsecase) GetSales(
+	ctx context.Context,
+	sellerID int,
+	req request.SellerAnalytics,
+) (*response.SalesAnalyticsResponse, error) {
+	from, to, interval, err := analyticsPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.analyticsRepo.GetSalesSeries(ctx, sellerID, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.SalesAnalyticsResponse{
+		From:     from,
+		To:       to,
+		Interval: interval,
+		Buckets:  make([]response.SalesBucketResponse, 0, len(rows)),
+	}
+	var totals repository.SalesBucketRow
+	for _, row := range rows {
+		bucket := mapSalesBucket(row)
+		bucket.Bucket = &row.Bucket
+		resp.Buckets = append(resp.Buckets, bucket)
+
+		// An order is placed in one bucket only, so orders add up too
+		totals.Orders += row.Orders
+		totals.UnitsOrdered += row.UnitsOrdered
+		totals.UnitsRefunded += row.UnitsRefunded
+		totals.GrossRevenue += row.GrossRevenue
+		totals.RefundedRevenue += row.RefundedRevenue
+	}
+	resp.Totals = mapSalesBucket(totals)
+	return resp, nil
+}
+
+func mapSalesBucket(row repository.SalesBucketRow) response.SalesBucketResponse {
+	bucket := response.SalesBucketResponse{
+		Orders:          row.Orders,
+		UnitsOrdered:    row.UnitsOrdered,
+		UnitsRefunded:   row.UnitsRefunded,
+		UnitsSold:       row.UnitsOrdered - row.UnitsRefunded,
+		GrossRevenue:    roundMoney(row.GrossRevenue),
+		RefundedRevenue: roundMoney(row.RefundedRevenue),
+		Revenue:         roundMoney(row.GrossRevenue - row.RefundedRevenue),
+		RefundRate:      percent(row.UnitsRefunded, row.UnitsOrdered),
+	}
+	if row.Orders > 0 {
+		bucket.AverageOrderValue = roundMoney(bucket.Revenue / float64(row.Orders))
+	}
+	return bucket
+}
+
+func (u *sellerAnalyticsUsecase) GetConversion(
+	ctx context.Context,
+	sellerID int,
+	req request.SellerAnalytics,
+) (*response.ConversionAnalyticsResponse, error) {
+	from, to, interval, err := analyticsPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.analyticsRepo.GetConversionSeries(ctx, sellerID, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.ConversionAnalyticsResponse{
+		From:     from,
+		To:       to,
+		Interval: interval,
+		Buckets:  make([]response.ConversionBucketResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		resp.Buckets = append(resp.Buckets, response.ConversionBucketResponse{
+			Bucket:         row.Bucket,
+			CartAdds:       row.CartAdds,
+			Converted:      row.Converted,
+			ConversionRate: percent(row.Converted, row.CartAdds),
+		})
+		resp.CartAdds += row.CartAdds
+		resp.Converted += row.Converted
+	}
+	resp.ConversionRate = percent(resp.Converted, resp.CartAdds)
+	return resp, nil
+}
+
+func (u *sellerAnalyticsUsecase) GetRatings(
+	ctx context.Context,
+	sellerID int,
+	req request.SellerAnalytics,
+) (*response.RatingAnalyticsResponse, error) {
+	from, to, interval, err := analyticsPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.analyticsRepo.GetRatingSeries(ctx, sellerID, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := u.analyticsRepo.GetRatingDistribution(ctx, sellerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := mapRatingSummary(counts)
+	resp := &response.RatingAnalyticsResponse{
+		From:          from,
+		To:            to,
+		Interval:      interval,
+		Reviews:       summary.Reviews,
+		AverageRating: summary.AverageRating,
+		Distribution:  summary.Distribution,
+		Buckets:       make([]response.RatingBucketResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		resp.Buckets = append(resp.Buckets, response.RatingBucketResponse{
+			Bucket:        row.Bucket,
+			Reviews:       row.Reviews,
+			AverageRating: mathutil.RoundToFloat(row.AverageRating, 2),
+		})
+	}
+	return resp, nil
+}
+
+func (u *sellerAnalyticsUsecase) GetTopVariants(
+	ctx context.Context,
+	sellerID int,
+	req request.TopVariants,
+) (*response.TopVariantsResponse, error) {
+	from, to := reportPeriod(req.From, req.To)
+	if !from.Before(to) {
+		return nil, ErrInvalidAnalyticsRange
+	}
+	if req.SortBy == "" {
+		req.SortBy = AnalyticsSortByUnits
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultTopVariantsLimit
+	}
+
+	rows, err := u.analyticsRepo.GetTopVariants(ctx, sellerID, from, to, req.SortBy == AnalyticsSortByRevenue, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.TopVariantsResponse{
+		From:     from,
+		To:       to,
+		SortBy:   req.SortBy,
+		Variants: make([]response.VariantSalesResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		resp.Variants = append(resp.Variants, response.VariantSalesResponse{
+			VariantID:   row.VariantID,
+			SKU:         row.SKU,
+			ProductID:   row.ProductID,
+			ProductName: row.ProductName,
+			Orders:      row.Orders,
+			UnitsSold:   row.UnitsSold,
+			Revenue:     roundMoney(row.Revenue),
+		})
+	}
+	return resp, nil
+}
+
+func (u *sellerAnalyticsUsecase) GetLowStockVariants(
+	ctx context.Context,
+	sellerID int,
+	req request.LowStockVariants,
+) (*response.LowStockResponse, error) {
+	threshold := defaultLowStockThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+
+	rows, err := u.analyticsRepo.GetLowStockVariants(ctx, sellerID, threshold, time.Now().Add(-lowStockSalesPeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	periodDays := lowStockSalesPeriod.Hours() / 24
+	resp := &response.LowStockResponse{
+		Threshold: threshold,
+		Variants:  make([]response.LowStockVariantResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		variant := response.LowStockVariantResponse{
+			VariantID:   row.VariantID,
+			SKU:         row.SKU,
+			ProductID:   row.ProductID,
+			ProductName: row.ProductName,
+			IsActive:    row.IsActive,
+			Stock:       row.Stock,
+			UnitsSold:   row.UnitsSold,
+		}
+		if row.UnitsSold > 0 {
+			days := mathutil.RoundToFloat(float64(row.Stock)/(float64(row.UnitsSold)/periodDays), 1)
+			variant.DaysOfStock = &days
+		}
+		resp.Variants = append(resp.Variants, variant)
+	}
+	return resp, nil
+}
+
+// analyticsPeriod resolves the range and interval of a series, the last 30 days by day by default
+func analyticsPeriod(req request.SellerAnalytics) (time.Time, time.Time, string, error) {
+	from, to := reportPeriod(req.From, req.To)
+	if !from.Before(to) {
+		return from, to, "", ErrInvalidAnalyticsRange
+	}
+
+	interval := req.Interval
+	if interval == "" {
+		interval = AnalyticsIntervalDay
+	}
+
+	days := to.Sub(from).Hours() / 24
+	buckets := days
+	switch interval {
+	case AnalyticsIntervalWeek:
+		buckets = days / 7
+	case AnalyticsIntervalMonth:
+		buckets = days / 28
+	}
+	if buckets > maxAnalyticsBuckets {
+		return from, to, "", fmt.Errorf("%w: at most %d buckets, use a longer interval", ErrInvalidAnalyticsRange, maxAnalyticsBuckets)
+	}
+	return from, to, interval, nil
+}
+
+// percent is part of total in percent, 0 without a total
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return mathutil.RoundToFloat(float64(part)*100/float64(total), 2)
+}
@@ -0,0 +1,411 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+)
+
+const maxTextAttributeLength = 255
+
+// Attribute codes mirrored into the legacy keyboard columns of ProductVariant
+const (
+	AttributeCodeLayout         = "layout"
+	AttributeCodeConnectionType = "connection_type"
+	AttributeCodeHotswap        = "hotswap"
+	AttributeCodeLedType        = "led_type"
+)
+
+var (
+	ErrInvalidAttribute            = errors.New("invalid attribute")
+	ErrAttributeDefinitionNotFound = errors.New("attribute definition not found")
+	ErrAttributeCodeTaken          = errors.New("attribute code is already defined for this category")
+)
+
+type IAttributeUsecase interface {
+	GetDefinitions(ctx context.Context, categoryID *int) ([]response.AttributeDefinitionResponse, error)
+
+	// Admin
+	CreateDefinition(ctx context.Context, req request.CreateAttributeDefinition) (*response.AttributeDefinitionResponse, error)
+	UpdateDefinition(ctx context.Context, req request.UpdateAttributeDefinition) (*response.AttributeDefinitionResponse, error)
+	DeleteDefinition(ctx context.Context, id int) error
+}
+
+type attributeUsecase struct {
+	attributeRepo repository.IAttributeRepo
+	catalogRepo   repository.ICatalogRepo
+}
+
+func NewAttributeUsecase(attributeRepo repository.IAttributeRepo, catalogRepo repository.ICatalogRepo) IAttributeUsecase {
+	return &attributeUsecase{
+		attributeRepo: attributeRepo,
+		catalogRepo:   catalogRepo,
+	}
+}
+
+func (u *attributeUsecase) GetDefinitions(ctx context.Context, categoryID *int) ([]response.AttributeDefinitionResponse, error) {
+	definitions, err := u.attributeRepo.GetEffectiveDefinitions(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.AttributeDefinitionResponse, 0, len(definitions))
+	for i := range definitions {
+		result = append(result, mapAttributeDefinitionToResponse(&definitions[i]))
+	}
+	return result, nil
+}
+
+func (u *attributeUsecase) CreateDefinition(ctx context.Context, req request.CreateAttributeDefinition) (*response.AttributeDefinitionResponse, error) {
+	if req.CategoryID != nil {
+		if _, err := u.catalogRepo.GetCategoryByID(ctx, *req.CategoryID); err != nil {
+			return nil, ErrCategoryNotFound
+		}
+	}
+
+	definition := &entity.AttributeDefinition{
+		CategoryID:    req.CategoryID,
+		Code:          strings.ReplaceAll(strtool.Slugify(req.Code), "-", "_"),
+		Name:          strings.TrimSpace(req.Name),
+		Type:          req.Type,
+		AllowedValues: req.AllowedValues,
+		Unit:          strings.TrimSpace(req.Unit),
+		IsRequired:    req.IsRequired,
+		IsFilterable:  req.IsFilterable,
+		DisplayOrder:  req.DisplayOrder,
+	}
+	if err := u.checkDefinition(ctx, definition); err != nil {
+		return nil, err
+	}
+
+	if err := u.attributeRepo.CreateDefinition(ctx, definition); err != nil {
+		return nil, err
+	}
+	if err := u.backfillLegacyValues(ctx, definition); err != nil {
+		return nil, err
+	}
+
+	resp := mapAttributeDefinitionToResponse(definition)
+	return &resp, nil
+}
+
+// UpdateDefinition changes how an attribute is presented and validated. Code, type and
+// category are fixed once created since stored values depend on them.
+func (u *attributeUsecase) UpdateDefinition(ctx context.Context, req request.UpdateAttributeDefinition) (*response.AttributeDefinitionResponse, error) {
+	definition, err := u.attributeRepo.GetDefinitionByID(ctx, req.ID)
+	if err != nil {
+		return nil, ErrAttributeDefinitionNotFound
+	}
+
+	if req.Name != "" {
+		definition.Name = strings.TrimSpace(req.Name)
+	}
+	if req.AllowedValues != nil {
+		definition.AllowedValues = req.AllowedValues
+	}
+	if req.Unit != nil {
+		definition.Unit = strings.TrimSpace(*req.Unit)
+	}
+	if req.IsRequired != nil {
+		definition.IsRequired = *req.IsRequired
+	}
+	if req.IsFilterable != nil {
+		definition.IsFilterable = *req.IsFilterable
+	}
+	if req.DisplayOrder != nil {
+		definition.DisplayOrder = *req.DisplayOrder
+	}
+	if err := u.checkDefinition(ctx, definition); err != nil {
+		return nil, err
+	}
+
+	if err := u.attributeRepo.UpdateDefinition(ctx, definition); err != nil {
+		return nil, err
+	}
+
+	resp := mapAttributeDefinitionToResponse(definition)
+	return &resp, nil
+}
+
+func (u *attributeUsecase) DeleteDefinition(ctx context.Context, id int) error {
+	if _, err := u.attributeRepo.GetDefinitionByID(ctx, id); err != nil {
+		return ErrAttributeDefinitionNotFound
+	}
+	return u.attributeRepo.DeleteDefinition(ctx, id)
+}
+
+// backfillLegacyValues copies the matching legacy keyboard column of existing variants into a
+// newly defined attribute with a legacy code. Values failing validation are left out.
+func (u *attributeUsecase) backfillLegacyValues(ctx context.Context, definition *entity.AttributeDefinition) error {
+	switch definition.Code {
+	case AttributeCodeLayout, AttributeCodeConnectionType, AttributeCodeHotswap, AttributeCodeLedType:
+	default:
+		return nil
+	}
+
+	variants, err := u.attributeRepo.GetVariantsInCategory(ctx, definition.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	attributes := make([]entity.ProductVariantAttribute, 0, len(variants))
+	for i := range variants {
+		raw, ok := legacyAttributeValues(&variants[i])[definition.Code]
+		if !ok {
+			continue
+		}
+		value, err := normalizeAttributeValue(definition, raw)
+		if err != nil {
+			continue
+		}
+		attributes = append(attributes, entity.ProductVariantAttribute{
+			ProductVariantID:      variants[i].ID,
+			AttributeDefinitionID: definition.ID,
+			Value:                 value,
+		})
+	}
+	return u.attributeRepo.AddVariantAttributes(ctx, attributes)
+}
+
+func (u *attributeUsecase) checkDefinition(ctx context.Context, definition *entity.AttributeDefinition) error {
+	if definition.Code == "" {
+		return fmt.Errorf("%w: code must contain letters or digits", ErrInvalidAttribute)
+	}
+
+	if definition.Type == entity.AttributeTypeEnum {
+		allowed := make([]string, 0, len(definition.AllowedValues))
+		seen := make(map[string]bool)
+		for _, v := range definition.AllowedValues {
+			v = strings.TrimSpace(v)
+			if v == "" || seen[strings.ToLower(v)] {
+				continue
+			}
+			seen[strings.ToLower(v)] = true
+			allowed = append(allowed, v)
+		}
+		if len(allowed) == 0 {
+			return fmt.Errorf("%w: enum attributes need allowed values", ErrInvalidAttribute)
+		}
+		definition.AllowedValues = allowed
+	} else {
+		definition.AllowedValues = nil
+	}
+
+	taken, err := u.attributeRepo.DefinitionCodeExists(ctx, definition.CategoryID, definition.Code, definition.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrAttributeCodeTaken
+	}
+	return nil
+}
+
+// buildVariantAttributes validates attribute values keyed by code against the definitions
+// of the product's category and returns them in canonical form. Legacy keyboard columns
+// are used for definitions the values do not mention, and are updated from the result.
+func buildVariantAttributes(
+	definitions []entity.AttributeDefinition,
+	values map[string]string,
+	variant *entity.ProductVariant,
+) ([]entity.ProductVariantAttribute, error) {
+	byCode := make(map[string]*entity.AttributeDefinition, len(definitions))
+	for i := range definitions {
+		byCode[definitions[i].Code] = &definitions[i]
+	}
+
+	merged := make(map[string]string, len(values))
+	for code, value := range legacyAttributeValues(variant) {
+		if _, ok := byCode[code]; ok {
+			merged[code] = value
+		}
+	}
+	for code, value := range values {
+		merged[code] = value
+	}
+
+	attributes := make([]entity.ProductVariantAttribute, 0, len(merged))
+	canonical := make(map[string]string, len(merged))
+	for code, raw := range merged {
+		definition, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not defined for this category", ErrInvalidAttribute, code)
+		}
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		value, err := normalizeAttributeValue(definition, raw)
+		if err != nil {
+			return nil, err
+		}
+		canonical[code] = value
+		attributes = append(attributes, entity.ProductVariantAttribute{
+			AttributeDefinitionID: definition.ID,
+			Value:                 value,
+			Definition:            definition,
+		})
+	}
+
+	for _, definition := range definitions {
+		if _, ok := canonical[definition.Code]; definition.IsRequired && !ok {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidAttribute, definition.Code)
+		}
+	}
+
+	applyLegacyAttributeValues(variant, canonical)
+	return attributes, nil
+}
+
+// buildVariantAttributesWithLegacy works like buildVariantAttributes, but also accepts the
+// legacy keyboard codes when the category does not define them. Those only set the columns.
+func buildVariantAttributesWithLegacy(
+	definitions []entity.AttributeDefinition,
+	values map[string]string,
+	variant *entity.ProductVariant,
+) ([]entity.ProductVariantAttribute, error) {
+	defined := make(map[string]bool, len(definitions))
+	for _, d := range definitions {
+		defined[d.Code] = true
+	}
+
+	attributeValues := make(map[string]string, len(values))
+	legacy := make(map[string]string)
+	for code, value := range values {
+		if defined[code] {
+			attributeValues[code] = value
+			continue
+		}
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		normalized, err := normalizeLegacyValue(code, value)
+		if err != nil {
+			return nil, err
+		}
+		legacy[code] = normalized
+	}
+
+	attributes, err := buildVariantAttributes(definitions, attributeValues, variant)
+	if err != nil {
+		return nil, err
+	}
+	applyLegacyAttributeValues(variant, legacy)
+	return attributes, nil
+}
+
+// normalizeLegacyValue validates a value for a legacy keyboard column without a definition
+func normalizeLegacyValue(code, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch code {
+	case AttributeCodeHotswap:
+		flag, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, code)
+		}
+		return strconv.FormatBool(flag), nil
+	case AttributeCodeLayout, AttributeCodeConnectionType, AttributeCodeLedType:
+		return raw, nil
+	}
+	return "", fmt.Errorf("%w: %s is not defined for this category", ErrInvalidAttribute, code)
+}
+
+func normalizeAttributeValue(definition *entity.AttributeDefinition, raw string) (string, error) {
+	switch definition.Type {
+	case entity.AttributeTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, definition.Code)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case entity.AttributeTypeBoolean:
+		flag, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, definition.Code)
+		}
+		return strconv.FormatBool(flag), nil
+	case entity.AttributeTypeEnum:
+		for _, allowed := range definition.AllowedValues {
+			if strings.EqualFold(allowed, raw) {
+				return allowed, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, definition.Code,
+			strings.Join(definition.AllowedValues, ", "))
+	default:
+		if len(raw) > maxTextAttributeLength {
+			return "", fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidAttribute, definition.Code, maxTextAttributeLength)
+		}
+		return raw, nil
+	}
+}
+
+// currentAttributeValues returns the stored attribute values of a variant keyed by code
+func currentAttributeValues(variant *entity.ProductVariant) map[string]string {
+	values := make(map[string]string, len(variant.Attributes))
+	for _, attr := range variant.Attributes {
+		if attr.Definition != nil {
+			values[attr.Definition.Code] = attr.Value
+		}
+	}
+	return values
+}
+
+func legacyAttributeValues(variant *entity.ProductVariant) map[string]string {
+	values := map[string]string{
+		AttributeCodeHotswap: strconv.FormatBool(variant.Hotswap),
+	}
+	if variant.Layout != "" {
+		values[AttributeCodeLayout] = variant.Layout
+	}
+	if variant.ConnectionType != "" {
+		values[AttributeCodeConnectionType] = variant.ConnectionType
+	}
+	if variant.LedType != "" {
+		values[AttributeCodeLedType] = variant.LedType
+	}
+	return values
+}
+
+func applyLegacyAttributeValues(variant *entity.ProductVariant, values map[string]string) {
+	if v, ok := values[AttributeCodeLayout]; ok {
+		variant.Layout = v
+	}
+	if v, ok := values[AttributeCodeConnectionType]; ok {
+		variant.ConnectionType = v
+	}
+	if v, ok := values[AttributeCodeHotswap]; ok {
+		variant.Hotswap = v == "true"
+	}
+	if v, ok := values[AttributeCodeLedType]; ok {
+		variant.LedType = v
+	}
+}
+
+func mapAttributeDefinitionToResponse(d *entity.AttributeDefinition) response.AttributeDefinitionResponse {
+	allowed := d.AllowedValues
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return response.AttributeDefinitionResponse{
+		ID:            d.ID,
+		CategoryID:    d.CategoryID,
+		Code:          d.Code,
+		Name:          d.Name,
+		Type:          d.Type,
+		AllowedValues: allowed,
+		Unit:          d.Unit,
+		IsRequired:    d.IsRequired,
+		IsFilterable:  d.IsFilterable,
+		DisplayOrder:  d.DisplayOrder,
+	}
+}
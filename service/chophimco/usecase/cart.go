@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/leehai1107/chophimco-server/pkg/logger"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
@@ -92,7 +93,24 @@ func (u *cartUsecase) AddToCart(ctx context.Context, userID int, req request.Add
 		Quantity:         req.Quantity,
 	}
 
-	return u.cartRepo.AddItemToCart(item)
+	if err := u.cartRepo.AddItemToCart(item); err != nil {
+		return err
+	}
+
+	// Kept for the seller's conversion analytics, the cart itself does not depend on it
+	if variant.Product != nil {
+		event := &entity.CartAddEvent{
+			UserID:           userID,
+			SellerID:         variant.Product.SellerID,
+			ProductVariantID: variant.ID,
+			Quantity:         req.Quantity,
+			CreatedAt:        time.Now(),
+		}
+		if err := u.cartRepo.RecordCartAdd(event); err != nil {
+			logger.EnhanceWith(ctx).Errorw("Failed to record cart add", "variant_id", variant.ID, "error", err)
+		}
+	}
+	return nil
 }
 
 func (u *cartUsecase) UpdateCartItem(ctx context.Context, req request.UpdateCartItem) error {
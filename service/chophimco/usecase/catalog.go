@@ -0,0 +1,451 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/leehai1107/chophimco-server/pkg/cache"
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrParentNotFound   = errors.New("parent category not found")
+	ErrInvalidSlug      = errors.New("slug must contain letters or digits")
+	ErrCategoryCycle    = errors.New("category cannot be moved under itself or one of its descendants")
+	ErrCategoryInUse    = errors.New("category still has subcategories or products")
+	ErrSlugTaken        = errors.New("slug is already used by another category")
+	ErrBrandNotFound    = errors.New("brand not found")
+	ErrBrandInUse       = errors.New("brand is still used by products")
+	ErrBrandExists      = errors.New("brand already exists")
+	ErrSwitchNotFound   = errors.New("switch not found")
+	ErrSwitchInUse      = errors.New("switch is still used by product variants")
+)
+
+type ICatalogUsecase interface {
+	// Categories
+	GetCategoryTree(ctx context.Context) ([]response.CategoryResponse, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*response.CategoryDetailResponse, error)
+	CreateCategory(ctx context.Context, req request.CreateCategory) (*response.CategoryResponse, error)
+	UpdateCategory(ctx context.Context, req request.UpdateCategory) (*response.CategoryResponse, error)
+	DeleteCategory(ctx context.Context, id int) error
+
+	// Brands
+	GetBrands(ctx context.Context) ([]response.BrandResponse, error)
+	CreateBrand(ctx context.Context, req request.CreateBrand) (*response.BrandResponse, error)
+	UpdateBrand(ctx context.Context, req request.UpdateBrand) (*response.BrandResponse, error)
+	DeleteBrand(ctx context.Context, id int) error
+
+	// Switches
+	GetSwitches(ctx context.Context) ([]response.SwitchResponse, error)
+	CreateSwitch(ctx context.Context, req request.CreateSwitch) (*response.SwitchResponse, error)
+	UpdateSwitch(ctx context.Context, req request.UpdateSwitch) (*response.SwitchResponse, error)
+	DeleteSwitch(ctx context.Context, id int) error
+}
+
+type catalogUsecase struct {
+	catalogRepo  repository.ICatalogRepo
+	suggestIndex *cache.SuggestIndex
+}
+
+func NewCatalogUsecase(catalogRepo repository.ICatalogRepo, suggestIndex *cache.SuggestIndex) ICatalogUsecase {
+	return &catalogUsecase{
+		catalogRepo:  catalogRepo,
+		suggestIndex: suggestIndex,
+	}
+}
+
+// Categories
+func (u *catalogUsecase) GetCategoryTree(ctx context.Context) ([]response.CategoryResponse, error) {
+	categories, err := u.catalogRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories, nil), nil
+}
+
+func (u *catalogUsecase) GetCategoryBySlug(ctx context.Context, slug string) (*response.CategoryDetailResponse, error) {
+	categories, err := u.catalogRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*entity.Category, len(categories))
+	var found *entity.Category
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+		if categories[i].Slug == slug {
+			found = &categories[i]
+		}
+	}
+	if found == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	ancestors := []response.CategoryBreadcrumb{}
+	seen := map[int]bool{found.ID: true}
+	for parentID := found.ParentID; parentID != nil && !seen[*parentID]; {
+		parent, ok := byID[*parentID]
+		if !ok {
+			break
+		}
+		seen[parent.ID] = true
+		ancestors = append([]response.CategoryBreadcrumb{{ID: parent.ID, Name: parent.Name, Slug: parent.Slug}}, ancestors...)
+		parentID = parent.ParentID
+	}
+
+	resp := mapCategoryToResponse(found)
+	resp.Children = buildCategoryTree(categories, &found.ID)
+	return &response.CategoryDetailResponse{
+		CategoryResponse: resp,
+		Ancestors:        ancestors,
+	}, nil
+}
+
+func (u *catalogUsecase) CreateCategory(ctx context.Context, req request.CreateCategory) (*response.CategoryResponse, error) {
+	if req.ParentID != nil {
+		if _, err := u.catalogRepo.GetCategoryByID(ctx, *req.ParentID); err != nil {
+			return nil, ErrParentNotFound
+		}
+	}
+
+	slug, err := u.resolveCategorySlug(ctx, req.Slug, req.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	category := &entity.Category{
+		ParentID:     req.ParentID,
+		Name:         strings.TrimSpace(req.Name),
+		Slug:         slug,
+		DisplayOrder: req.DisplayOrder,
+	}
+	if err := u.catalogRepo.CreateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	u.suggestIndex.Refresh()
+
+	resp := mapCategoryToResponse(category)
+	return &resp, nil
+}
+
+func (u *catalogUsecase) UpdateCategory(ctx context.Context, req request.UpdateCategory) (*response.CategoryResponse, error) {
+	category, err := u.catalogRepo.GetCategoryByID(ctx, req.ID)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			if err := u.checkCategoryParent(ctx, category.ID, *req.ParentID); err != nil {
+				return nil, err
+			}
+			parentID := *req.ParentID
+			category.ParentID = &parentID
+		}
+	}
+	if req.Name != "" {
+		category.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Slug != "" {
+		slug, err := u.resolveCategorySlug(ctx, req.Slug, category.Name, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		category.Slug = slug
+	}
+	if req.DisplayOrder != nil {
+		category.DisplayOrder = *req.DisplayOrder
+	}
+
+	if err := u.catalogRepo.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	u.suggestIndex.Refresh()
+
+	resp := mapCategoryToResponse(category)
+	return &resp, nil
+}
+
+func (u *catalogUsecase) DeleteCategory(ctx context.Context, id int) error {
+	if _, err := u.catalogRepo.GetCategoryByID(ctx, id); err != nil {
+		return ErrCategoryNotFound
+	}
+
+	children, err := u.catalogRepo.CountChildCategories(ctx, id)
+	if err != nil {
+		return err
+	}
+	products, err := u.catalogRepo.CountProductsByCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 || products > 0 {
+		return ErrCategoryInUse
+	}
+
+	if err := u.catalogRepo.DeleteCategory(ctx, id); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+	return nil
+}
+
+// checkCategoryParent rejects a parent that does not exist or lies in the subtree of the category
+func (u *catalogUsecase) checkCategoryParent(ctx context.Context, categoryID, parentID int) error {
+	categories, err := u.catalogRepo.GetAllCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	parentOf := make(map[int]*int, len(categories))
+	for _, c := range categories {
+		parentOf[c.ID] = c.ParentID
+	}
+	if _, ok := parentOf[parentID]; !ok {
+		return ErrParentNotFound
+	}
+
+	// Walk up from the new parent, reaching the category itself means a cycle
+	seen := make(map[int]bool)
+	for current := &parentID; current != nil && !seen[*current]; current = parentOf[*current] {
+		if *current == categoryID {
+			return ErrCategoryCycle
+		}
+		seen[*current] = true
+	}
+	return nil
+}
+
+// resolveCategorySlug normalizes the requested slug, or derives one from the name, and checks it is free
+func (u *catalogUsecase) resolveCategorySlug(ctx context.Context, slug, name string, excludeID int) (string, error) {
+	if slug == "" {
+		slug = name
+	}
+	slug = strtool.Slugify(slug)
+	if slug == "" {
+		return "", ErrInvalidSlug
+	}
+
+	taken, err := u.catalogRepo.CategorySlugExists(ctx, slug, excludeID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", ErrSlugTaken
+	}
+	return slug, nil
+}
+
+// Brands
+func (u *catalogUsecase) GetBrands(ctx context.Context) ([]response.BrandResponse, error) {
+	brands, err := u.catalogRepo.GetAllBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.BrandResponse, 0, len(brands))
+	for _, b := range brands {
+		result = append(result, response.BrandResponse{ID: b.ID, Name: b.Name})
+	}
+	return result, nil
+}
+
+func (u *catalogUsecase) CreateBrand(ctx context.Context, req request.CreateBrand) (*response.BrandResponse, error) {
+	brand := &entity.Brand{Name: strings.TrimSpace(req.Name)}
+	if err := u.checkBrandName(ctx, brand.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := u.catalogRepo.CreateBrand(ctx, brand); err != nil {
+		return nil, err
+	}
+	u.suggestIndex.Refresh()
+
+	return &response.BrandResponse{ID: brand.ID, Name: brand.Name}, nil
+}
+
+func (u *catalogUsecase) UpdateBrand(ctx context.Context, req request.UpdateBrand) (*response.BrandResponse, error) {
+	brand, err := u.catalogRepo.GetBrandByID(ctx, req.ID)
+	if err != nil {
+		return nil, ErrBrandNotFound
+	}
+
+	brand.Name = strings.TrimSpace(req.Name)
+	if err := u.checkBrandName(ctx, brand.Name, brand.ID); err != nil {
+		return nil, err
+	}
+	if err := u.catalogRepo.UpdateBrand(ctx, brand); err != nil {
+		return nil, err
+	}
+	u.suggestIndex.Refresh()
+
+	return &response.BrandResponse{ID: brand.ID, Name: brand.Name}, nil
+}
+
+func (u *catalogUsecase) DeleteBrand(ctx context.Context, id int) error {
+	if _, err := u.catalogRepo.GetBrandByID(ctx, id); err != nil {
+		return ErrBrandNotFound
+	}
+
+	products, err := u.catalogRepo.CountProductsByBrand(ctx, id)
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return ErrBrandInUse
+	}
+
+	if err := u.catalogRepo.DeleteBrand(ctx, id); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+	return nil
+}
+
+func (u *catalogUsecase) checkBrandName(ctx context.Context, name string, excludeID int) error {
+	exists, err := u.catalogRepo.BrandNameExists(ctx, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrBrandExists
+	}
+	return nil
+}
+
+// Switches
+func (u *catalogUsecase) GetSwitches(ctx context.Context) ([]response.SwitchResponse, error) {
+	switches, err := u.catalogRepo.GetAllSwitches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.SwitchResponse, 0, len(switches))
+	for _, s := range switches {
+		result = append(result, mapSwitchToResponse(&s))
+	}
+	return result, nil
+}
+
+func (u *catalogUsecase) CreateSwitch(ctx context.Context, req request.CreateSwitch) (*response.SwitchResponse, error) {
+	sw := &entity.Switch{
+		Name:  strings.TrimSpace(req.Name),
+		Type:  req.Type,
+		Brand: strings.TrimSpace(req.Brand),
+	}
+	if err := u.catalogRepo.CreateSwitch(ctx, sw); err != nil {
+		return nil, err
+	}
+	u.suggestIndex.Refresh()
+
+	resp := mapSwitchToResponse(sw)
+	return &resp, nil
+}
+
+func (u *catalogUsecase) UpdateSwitch(ctx context.Context, req request.UpdateSwitch) (*response.SwitchResponse, error) {
+	sw, err := u.catalogRepo.GetSwitchByID(ctx, req.ID)
+	if err != nil {
+		return nil, ErrSwitchNotFound
+	}
+
+	if req.Name != "" {
+		sw.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Type != "" {
+		sw.Type = req.Type
+	}
+	if req.Brand != "" {
+		sw.Brand = strings.TrimSpace(req.Brand)
+	}
+
+	if err := u.catalogRepo.UpdateSwitch(ctx, sw); err != nil {
+		return nil, err
+	}
+	u.suggestIndex.Refresh()
+
+	resp := mapSwitchToResponse(sw)
+	return &resp, nil
+}
+
+func (u *catalogUsecase) DeleteSwitch(ctx context.Context, id int) error {
+	if _, err := u.catalogRepo.GetSwitchByID(ctx, id); err != nil {
+		return ErrSwitchNotFound
+	}
+
+	variants, err := u.catalogRepo.CountVariantsBySwitch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if variants > 0 {
+		return ErrSwitchInUse
+	}
+
+	if err := u.catalogRepo.DeleteSwitch(ctx, id); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+	return nil
+}
+
+// buildCategoryTree nests the children of parentID, categories must already be in display order
+func buildCategoryTree(categories []entity.Category, parentID *int) []response.CategoryResponse {
+	childrenOf := make(map[int][]*entity.Category)
+	var roots []*entity.Category
+	for i := range categories {
+		c := &categories[i]
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		childrenOf[*c.ParentID] = append(childrenOf[*c.ParentID], c)
+	}
+
+	start := roots
+	if parentID != nil {
+		start = childrenOf[*parentID]
+	}
+
+	visited := make(map[int]bool)
+	var build func(nodes []*entity.Category) []response.CategoryResponse
+	build = func(nodes []*entity.Category) []response.CategoryResponse {
+		result := make([]response.CategoryResponse, 0, len(nodes))
+		for _, node := range nodes {
+			if visited[node.ID] {
+				continue
+			}
+			visited[node.ID] = true
+			resp := mapCategoryToResponse(node)
+			resp.Children = build(childrenOf[node.ID])
+			result = append(result, resp)
+		}
+		return result
+	}
+	return build(start)
+}
+
+func mapCategoryToResponse(c *entity.Category) response.CategoryResponse {
+	return response.CategoryResponse{
+		ID:           c.ID,
+		ParentID:     c.ParentID,
+		Name:         c.Name,
+		Slug:         c.Slug,
+		DisplayOrder: c.DisplayOrder,
+		Children:     []response.CategoryResponse{},
+	}
+}
+
+func mapSwitchToResponse(s *entity.Switch) response.SwitchResponse {
+	return response.SwitchResponse{
+		ID:    s.ID,
+		Name:  s.Name,
+		Type:  s.Type,
+		Brand: s.Brand,
+	}
+}
@@ -0,0 +1,537 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// Defaults of every setting, the JWT service signs with the development secret
+	config.InitConfig()
+	os.Exit(m.Run())
+}
+
+// memoryStore is an in-memory stand-in for the database behind the repositories the account,
+// session, two-factor and OAuth usecases use. Conditional updates follow the SQL they replace.
+type memoryStore struct {
+	mu            sync.Mutex
+	nextUserID    int
+	users         map[int]*entity.User
+	roles         map[int]*entity.Role
+	actionTokens  map[string]*entity.ActionToken
+	sessions      map[string]*entity.AuthSession
+	refreshTokens map[string]*entity.RefreshToken // by hash
+	oauthStates   map[string]*entity.OAuthState
+	identities    []*entity.UserIdentity
+	twoFactors    map[int]*entity.UserTwoFactor
+	recoveryCodes []*entity.RecoveryCode
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		nextUserID: 1,
+		users:      map[int]*entity.User{},
+		roles: map[int]*entity.Role{
+			1: {ID: 1, Name: "admin"},
+			2: {ID: 2, Name: "customer"},
+			3: {ID: 3, Name: "seller"},
+		},
+		actionTokens:  map[string]*entity.ActionToken{},
+		sessions:      map[string]*entity.AuthSession{},
+		refreshTokens: map[string]*entity.RefreshToken{},
+		oauthStates:   map[string]*entity.OAuthState{},
+		twoFactors:    map[int]*entity.UserTwoFactor{},
+	}
+}
+
+// addUser stores a user and returns its ID
+func (s *memoryStore) addUser(user entity.User) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.RoleID == 0 {
+		user.RoleID = 2
+	}
+	user.ID = s.nextUserID
+	s.nextUserID++
+	user.Role = nil
+	user.TwoFactor = nil
+	s.users[user.ID] = &user
+	return user.ID
+}
+
+// user returns a copy of the stored user, failing the test when it is missing
+func (s *memoryStore) user(t *testing.T, id int) entity.User {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		t.Fatalf("user %d not found", id)
+	}
+	return *user
+}
+
+func (s *memoryStore) loadUser(user *entity.User) *entity.User {
+	loaded := *user
+	loaded.Role = s.roles[user.RoleID]
+	loaded.TwoFactor = nil
+	if twoFactor, ok := s.twoFactors[user.ID]; ok {
+		copied := *twoFactor
+		loaded.TwoFactor = &copied
+	}
+	return &loaded
+}
+
+// memoryUserRepo implements repository.IUserRepo
+type memoryUserRepo struct{ *memoryStore }
+
+func (r memoryUserRepo) GetUserByEmail(email string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return r.loadUser(user), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r memoryUserRepo) GetUserByID(id int) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.loadUser(user), nil
+}
+
+func (r memoryUserRepo) CreateUser(user *entity.User) error {
+	user.ID = r.addUser(*user)
+	return nil
+}
+
+func (r memoryUserRepo) UpdateUser(user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *user
+	stored.Role = nil
+	stored.TwoFactor = nil
+	r.users[user.ID] = &stored
+	return nil
+}
+
+// memoryAccountRepo implements repository.IAccountRepo
+type memoryAccountRepo struct{ *memoryStore }
+
+func (r memoryAccountRepo) CreateActionToken(ctx context.Context, token *entity.ActionToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *token
+	r.actionTokens[token.ID] = &stored
+	return nil
+}
+
+func (r memoryAccountRepo) VerifyEmail(ctx context.Context, tokenID string, userID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if !r.useActionToken(tokenID, userID, entity.ActionVerifyEmail, now) {
+		return false, nil
+	}
+	if user := r.users[userID]; user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	return true, nil
+}
+
+func (r memoryAccountRepo) ResetPassword(ctx context.Context, tokenID string, userID int, passwordHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if !r.useActionToken(tokenID, userID, entity.ActionResetPassword, now) {
+		return false, nil
+	}
+	for _, token := range r.actionTokens {
+		if token.UserID == userID && token.Purpose == entity.ActionResetPassword && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	user := r.users[userID]
+	user.PasswordHash = passwordHash
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	return true, nil
+}
+
+// useActionToken mirrors the repository helper, the caller holds the lock
+func (s *memoryStore) useActionToken(tokenID string, userID int, purpose string, now time.Time) bool {
+	token, ok := s.actionTokens[tokenID]
+	if !ok || token.UserID != userID || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return false
+	}
+	token.UsedAt = &now
+	return true
+}
+
+// memorySessionRepo implements repository.ISessionRepo
+type memorySessionRepo struct{ *memoryStore }
+
+func (r memorySessionRepo) CreateSession(ctx context.Context, session *entity.AuthSession, token *entity.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *session
+	r.sessions[session.ID] = &stored
+	storedToken := *token
+	storedToken.SessionID = session.ID
+	r.refreshTokens[token.TokenHash] = &storedToken
+	return nil
+}
+
+func (r memorySessionRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	loaded := *token
+	session := *r.sessions[token.SessionID]
+	if user, ok := r.users[session.UserID]; ok {
+		session.User = r.loadUser(user)
+	}
+	loaded.Session = &session
+	return &loaded, nil
+}
+
+func (r memorySessionRepo) RotateRefreshToken(ctx context.Context, token *entity.RefreshToken, next *entity.RefreshToken) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.refreshTokens[token.TokenHash]
+	if !ok || current.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	current.UsedAt = &now
+	stored := *next
+	stored.SessionID = current.SessionID
+	r.refreshTokens[next.TokenHash] = &stored
+	r.sessions[current.SessionID].LastUsedAt = now
+	return true, nil
+}
+
+func (r memorySessionRepo) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	return ok && session.RevokedAt == nil, nil
+}
+
+func (r memorySessionRepo) MarkTwoFactor(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[sessionID]; ok && session.RevokedAt == nil {
+		session.TwoFactor = true
+	}
+	return nil
+}
+
+func (r memorySessionRepo) RevokeSession(ctx context.Context, sessionID string, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[sessionID]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+		session.RevokeReason = reason
+	}
+	return nil
+}
+
+func (r memorySessionRepo) RevokeUserSessions(ctx context.Context, userID int, reason string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var revoked int64
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			session.RevokeReason = reason
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// memoryOAuthRepo implements repository.IOAuthRepo
+type memoryOAuthRepo struct{ *memoryStore }
+
+func (r memoryOAuthRepo) CreateState(ctx context.Context, state *entity.OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *state
+	r.oauthStates[state.ID] = &stored
+	return nil
+}
+
+func (r memoryOAuthRepo) TakeState(ctx context.Context, id string) (*entity.OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.oauthStates[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.oauthStates, id)
+	return state, nil
+}
+
+func (r memoryOAuthRepo) GetIdentity(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			loaded := *identity
+			if user, ok := r.users[identity.UserID]; ok {
+				loaded.User = r.loadUser(user)
+			}
+			return &loaded, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r memoryOAuthRepo) LinkIdentity(ctx context.Context, identity *entity.UserIdentity) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return false, nil
+		}
+	}
+	r.linkIdentity(identity)
+	return true, nil
+}
+
+func (r memoryOAuthRepo) CreateUserWithIdentity(ctx context.Context, user *entity.User, identity *entity.UserIdentity) error {
+	user.ID = r.addUser(*user)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity.UserID = user.ID
+	r.linkIdentity(identity)
+	return nil
+}
+
+func (r memoryOAuthRepo) TouchIdentity(ctx context.Context, id int, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.ID == id {
+			identity.Email = email
+			identity.LastLoginAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// linkIdentity stores an identity, the caller holds the lock
+func (s *memoryStore) linkIdentity(identity *entity.UserIdentity) {
+	identity.ID = len(s.identities) + 1
+	stored := *identity
+	stored.User = nil
+	s.identities = append(s.identities, &stored)
+}
+
+// identitiesOf returns the provider subjects linked to a user
+func (s *memoryStore) identitiesOf(userID int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subjects []string
+	for _, identity := range s.identities {
+		if identity.UserID == userID {
+			subjects = append(subjects, identity.Provider+":"+identity.Subject)
+		}
+	}
+	return subjects
+}
+
+// memoryTwoFactorRepo implements repository.ITwoFactorRepo, challenges are kept with the
+// other action tokens
+type memoryTwoFactorRepo struct{ *memoryStore }
+
+func (r memoryTwoFactorRepo) GetTwoFactor(ctx context.Context, userID int) (*entity.UserTwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	loaded := *twoFactor
+	return &loaded, nil
+}
+
+func (r memoryTwoFactorRepo) SaveSetup(ctx context.Context, twoFactor *entity.UserTwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.twoFactors[twoFactor.UserID]; ok && existing.EnabledAt != nil {
+		return errors.New("duplicate key value violates unique constraint")
+	}
+	stored := *twoFactor
+	r.twoFactors[twoFactor.UserID] = &stored
+	return nil
+}
+
+func (r memoryTwoFactorRepo) Enable(ctx context.Context, userID int, step int64, codeHashes []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok || twoFactor.EnabledAt != nil || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	now := time.Now()
+	twoFactor.EnabledAt = &now
+	twoFactor.LastUsedStep = step
+	r.replaceRecoveryCodes(userID, codeHashes)
+	return true, nil
+}
+
+func (r memoryTwoFactorRepo) Disable(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.twoFactors, userID)
+	r.replaceRecoveryCodes(userID, nil)
+	return nil
+}
+
+func (r memoryTwoFactorRepo) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok || twoFactor.EnabledAt == nil || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (r memoryTwoFactorRepo) TakeAttempt(ctx context.Context, userID int, maxAttempts int, lockout time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok || twoFactor.LockedUntil != nil && twoFactor.LockedUntil.After(now) {
+		return false, nil
+	}
+	twoFactor.FailedAttempts++
+	twoFactor.LockedUntil = nil
+	if twoFactor.FailedAttempts >= maxAttempts {
+		lockedUntil := now.Add(lockout)
+		twoFactor.FailedAttempts = 0
+		twoFactor.LockedUntil = &lockedUntil
+	}
+	return true, nil
+}
+
+func (r memoryTwoFactorRepo) ResetAttempts(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if twoFactor, ok := r.twoFactors[userID]; ok {
+		twoFactor.FailedAttempts = 0
+		twoFactor.LockedUntil = nil
+	}
+	return nil
+}
+
+func (r memoryTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, code := range r.recoveryCodes {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+func (r memoryTwoFactorRepo) CountRecoveryCodes(ctx context.Context, userID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, code := range r.recoveryCodes {
+		if code.UserID == userID && code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r memoryTwoFactorRepo) CreateChallenge(ctx context.Context, challenge *entity.ActionToken) error {
+	return memoryAccountRepo(r).CreateActionToken(ctx, challenge)
+}
+
+func (r memoryTwoFactorRepo) GetActiveChallenge(ctx context.Context, id string, userID int) (*entity.ActionToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge, ok := r.actionTokens[id]
+	if !ok || challenge.UserID != userID || challenge.Purpose != entity.ActionLoginTwoFactor ||
+		challenge.UsedAt != nil || !challenge.ExpiresAt.After(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	loaded := *challenge
+	return &loaded, nil
+}
+
+func (r memoryTwoFactorRepo) RecordChallengeFailure(ctx context.Context, id string, maxAttempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if challenge, ok := r.actionTokens[id]; ok && challenge.UsedAt == nil {
+		challenge.Attempts++
+		if challenge.Attempts >= maxAttempts {
+			now := time.Now()
+			challenge.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r memoryTwoFactorRepo) UseChallenge(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge, ok := r.actionTokens[id]
+	if !ok || challenge.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	challenge.UsedAt = &now
+	return true, nil
+}
+
+// replaceRecoveryCodes mirrors the repository helper, the caller holds the lock
+func (s *memoryStore) replaceRecoveryCodes(userID int, codeHashes []string) {
+	kept := s.recoveryCodes[:0]
+	for _, code := range s.recoveryCodes {
+		if code.UserID != userID {
+			kept = append(kept, code)
+		}
+	}
+	s.recoveryCodes = kept
+	for _, hash := range codeHashes {
+		s.recoveryCodes = append(s.recoveryCodes, &entity.RecoveryCode{UserID: userID, CodeHash: hash, CreatedAt: time.Now()})
+	}
+}
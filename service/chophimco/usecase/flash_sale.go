@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/websocket"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+)
+
+const (
+	FlashSaleStatusUpcoming  = "upcoming"
+	FlashSaleStatusLive      = "live"
+	FlashSaleStatusEnded     = "ended"
+	FlashSaleStatusCancelled = "cancelled"
+)
+
+var (
+	ErrFlashSaleNotLive     = errors.New("flash sale is not live")
+	ErrFlashSaleInvalidTime = errors.New("flash sale must end after it starts")
+)
+
+type IFlashSaleUsecase interface {
+	// Public
+	GetFlashSales(ctx context.Context) ([]response.FlashSaleResponse, error)
+	GetFlashSaleByID(ctx context.Context, id int) (*response.FlashSaleResponse, error)
+	PurchaseFlashSaleItem(ctx context.Context, userID int, req request.PurchaseFlashSaleItem) (*response.OrderResponse, error)
+
+	// Admin
+	CreateFlashSale(ctx context.Context, adminID int, req request.CreateFlashSale) (*response.FlashSaleResponse, error)
+	CancelFlashSale(ctx context.Context, id int) error
+}
+
+type flashSaleUsecase struct {
+	flashSaleRepo  repository.IFlashSaleRepo
+	productRepo    repository.IProductRepo
+	orderUsecase   IOrderUsecase
+	accountUsecase IAccountUsecase
+}
+
+func NewFlashSaleUsecase(
+	flashSaleRepo repository.IFlashSaleRepo,
+	productRepo repository.IProductRepo,
+	orderUsecase IOrderUsecase,
+	accountUsecase IAccountUsecase,
+) IFlashSaleUsecase {
+	return &flashSaleUsecase{
+		flashSaleRepo:  flashSaleRepo,
+		productRepo:    productRepo,
+		orderUsecase:   orderUsecase,
+		accountUsecase: accountUsecase,
+	}
+}
+
+// FlashSaleRoom is the websocket room that receives remaining-quantity updates of a flash sale
+func FlashSaleRoom(flashSaleID int) string {
+	return fmt.Sprintf("flash_sale_%d", flashSaleID)
+}
+
+func (u *flashSaleUsecase) GetFlashSales(ctx context.Context) ([]response.FlashSaleResponse, error) {
+	now := time.Now()
+	sales, err := u.flashSaleRepo.GetVisibleFlashSales(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.FlashSaleResponse, 0, len(sales))
+	for _, sale := range sales {
+		result = append(result, u.mapFlashSaleToResponse(&sale, now))
+	}
+	return result, nil
+}
+
+func (u *flashSaleUsecase) GetFlashSaleByID(ctx context.Context, id int) (*response.FlashSaleResponse, error) {
+	sale, err := u.flashSaleRepo.GetFlashSaleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := u.mapFlashSaleToResponse(sale, time.Now())
+	return &resp, nil
+}
+
+func (u *flashSaleUsecase) PurchaseFlashSaleItem(ctx context.Context, userID int, req request.PurchaseFlashSaleItem) (*response.OrderResponse, error) {
+	item, err := u.flashSaleRepo.GetFlashSaleItemByID(ctx, req.FlashSaleItemID)
+	if err != nil {
+		return nil, errors.New("flash sale item not found")
+	}
+
+	now := time.Now()
+	if item.FlashSale == nil || flashSaleStatus(item.FlashSale, now) != FlashSaleStatusLive {
+		return nil, ErrFlashSaleNotLive
+	}
+
+	if err := u.accountUsecase.CheckCanPlaceOrder(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	order := &entity.Order{
+		UserID:          userID,
+		TotalAmount:     item.SalePrice * float64(req.Quantity),
+		Status:          "pending",
+		ShippingAddress: req.ShippingAddress,
+		CreatedAt:       now,
+		OrderItems: []entity.OrderItem{
+			{
+				ProductVariantID: item.ProductVariantID,
+				Price:            item.SalePrice,
+				Quantity:         req.Quantity,
+				// The markdown from the regular price is funded by the seller, the repository
+				// sets the list price from the variant row it locks
+				FlashSaleItemID: &item.ID,
+			},
+		},
+	}
+
+	remaining, err := u.flashSaleRepo.PurchaseFlashSaleItem(ctx, item, userID, req.Quantity, order)
+	if err != nil {
+		return nil, err
+	}
+
+	u.publishRemaining(ctx, item.FlashSaleID, item.ID, remaining)
+
+	return u.orderUsecase.GetOrderByID(ctx, order.ID)
+}
+
+func (u *flashSaleUsecase) CreateFlashSale(ctx context.Context, adminID int, req request.CreateFlashSale) (*response.FlashSaleResponse, error) {
+	if !req.EndAt.After(req.StartAt) {
+		return nil, ErrFlashSaleInvalidTime
+	}
+	if !req.EndAt.After(time.Now()) {
+		return nil, errors.New("flash sale has already ended")
+	}
+
+	seen := make(map[int]bool, len(req.Items))
+	items := make([]entity.FlashSaleItem, 0, len(req.Items))
+	for _, it := range req.Items {
+		if seen[it.ProductVariantID] {
+			return nil, fmt.Errorf("variant %d is listed more than once", it.ProductVariantID)
+		}
+		seen[it.ProductVariantID] = true
+
+		variant, err := u.productRepo.GetVariantByID(it.ProductVariantID)
+		if err != nil {
+			return nil, fmt.Errorf("variant %d not found", it.ProductVariantID)
+		}
+		if it.SalePrice >= variant.Price {
+			return nil, fmt.Errorf("sale price of variant %d must be lower than its price", it.ProductVariantID)
+		}
+		if it.SaleQuantity > variant.Stock {
+			return nil, fmt.Errorf("sale quantity of variant %d exceeds its stock", it.ProductVariantID)
+		}
+
+		perUserLimit := it.PerUserLimit
+		if perUserLimit == 0 {
+			perUserLimit = 1
+		}
+
+		items = append(items, entity.FlashSaleItem{
+			ProductVariantID: it.ProductVariantID,
+			SalePrice:        it.SalePrice,
+			SaleQuantity:     it.SaleQuantity,
+			PerUserLimit:     perUserLimit,
+		})
+	}
+
+	sale := &entity.FlashSale{
+		Name:        req.Name,
+		Description: req.Description,
+		StartAt:     req.StartAt,
+		EndAt:       req.EndAt,
+		CreatedBy:   adminID,
+		CreatedAt:   time.Now(),
+		Items:       items,
+	}
+
+	if err := u.flashSaleRepo.CreateFlashSale(ctx, sale); err != nil {
+		return nil, err
+	}
+
+	return u.GetFlashSaleByID(ctx, sale.ID)
+}
+
+func (u *flashSaleUsecase) CancelFlashSale(ctx context.Context, id int) error {
+	if _, err := u.flashSaleRepo.GetFlashSaleByID(ctx, id); err != nil {
+		return err
+	}
+	return u.flashSaleRepo.CancelFlashSale(ctx, id)
+}
+
+func (u *flashSaleUsecase) publishRemaining(ctx context.Context, flashSaleID, itemID, remaining int) {
+	content, err := json.Marshal(response.FlashSaleStockUpdate{
+		FlashSaleID:     flashSaleID,
+		FlashSaleItemID: itemID,
+		Remaining:       remaining,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to encode flash sale update", "error", err)
+		return
+	}
+
+	websocket.Publish(websocket.Message{
+		Type:      websocket.MessageTypeFlashSale.Value(),
+		Sender:    "server",
+		Recipient: FlashSaleRoom(flashSaleID),
+		Content:   string(content),
+		ID:        fmt.Sprintf("%d", itemID),
+	})
+}
+
+func flashSaleStatus(sale *entity.FlashSale, now time.Time) string {
+	switch {
+	case sale.IsCancelled:
+		return FlashSaleStatusCancelled
+	case now.Before(sale.StartAt):
+		return FlashSaleStatusUpcoming
+	case now.Before(sale.EndAt):
+		return FlashSaleStatusLive
+	default:
+		return FlashSaleStatusEnded
+	}
+}
+
+func (u *flashSaleUsecase) mapFlashSaleToResponse(sale *entity.FlashSale, now time.Time) response.FlashSaleResponse {
+	resp := response.FlashSaleResponse{
+		ID:          sale.ID,
+		Name:        sale.Name,
+		Description: sale.Description,
+		StartAt:     sale.StartAt,
+		EndAt:       sale.EndAt,
+		Status:      flashSaleStatus(sale, now),
+		ServerTime:  now,
+	}
+
+	if now.Before(sale.StartAt) {
+		resp.SecondsUntilStart = int64(sale.StartAt.Sub(now).Seconds())
+	}
+	if now.Before(sale.EndAt) {
+		resp.SecondsUntilEnd = int64(sale.EndAt.Sub(now).Seconds())
+	}
+
+	items := make([]response.FlashSaleItemResponse, 0, len(sale.Items))
+	for _, item := range sale.Items {
+		itemResp := response.FlashSaleItemResponse{
+			ID:           item.ID,
+			SalePrice:    item.SalePrice,
+			SaleQuantity: item.SaleQuantity,
+			Remaining:    item.SaleQuantity - item.SoldQuantity,
+			PerUserLimit: item.PerUserLimit,
+		}
+		if item.ProductVariant != nil {
+			itemResp.Variant = mapVariantToResponse(item.ProductVariant)
+			itemResp.OriginalPrice = item.ProductVariant.Price
+			if item.ProductVariant.Product != nil {
+				itemResp.ProductName = item.ProductVariant.Product.Name
+			}
+		}
+		items = append(items, itemResp)
+	}
+	resp.Items = items
+
+	return resp
+}
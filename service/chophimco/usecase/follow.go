@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var ErrFollowOwnShop = errors.New("cannot follow your own shop")
+
+const defaultFeedLimit = 20
+
+type IFollowUsecase interface {
+	FollowShop(ctx context.Context, userID int, slug string) (*response.FollowResponse, error)
+	UnfollowShop(ctx context.Context, userID int, slug string) (*response.FollowResponse, error)
+	GetFollowedShops(ctx context.Context, userID int, req request.GetFollowedShops) (*response.FollowedShopListResponse, error)
+	GetFeed(ctx context.Context, userID int, req request.GetFeed) (*response.FeedResponse, error)
+
+	// NotifyProductLaunch tells the followers of the seller's shop about a newly approved product
+	NotifyProductLaunch(ctx context.Context, product *entity.Product)
+}
+
+type followUsecase struct {
+	followRepo          repository.IFollowRepo
+	sellerRepo          repository.ISellerRepository
+	productRepo         repository.IProductRepo
+	notificationUsecase INotificationUsecase
+}
+
+func NewFollowUsecase(
+	followRepo repository.IFollowRepo,
+	sellerRepo repository.ISellerRepository,
+	productRepo repository.IProductRepo,
+	notificationUsecase INotificationUsecase,
+) IFollowUsecase {
+	return &followUsecase{
+		followRepo:          followRepo,
+		sellerRepo:          sellerRepo,
+		productRepo:         productRepo,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+func (u *followUsecase) FollowShop(ctx context.Context, userID int, slug string) (*response.FollowResponse, error) {
+	profile, err := findVerifiedShop(ctx, u.sellerRepo, slug)
+	if err != nil {
+		return nil, err
+	}
+	if profile.UserID == userID {
+		return nil, ErrFollowOwnShop
+	}
+
+	created, err := u.followRepo.Follow(ctx, &entity.ShopFollow{
+		UserID:          userID,
+		SellerProfileID: profile.ID,
+		CreatedAt:       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	followers := profile.FollowerCount
+	if created {
+		followers++
+	}
+	return &response.FollowResponse{
+		ShopID:        profile.ID,
+		Slug:          profile.Slug,
+		IsFollowing:   true,
+		FollowerCount: followers,
+	}, nil
+}
+
+func (u *followUsecase) UnfollowShop(ctx context.Context, userID int, slug string) (*response.FollowResponse, error) {
+	profile, err := findVerifiedShop(ctx, u.sellerRepo, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := u.followRepo.Unfollow(ctx, userID, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	followers := profile.FollowerCount
+	if deleted && followers > 0 {
+		followers--
+	}
+	return &response.FollowResponse{
+		ShopID:        profile.ID,
+		Slug:          profile.Slug,
+		IsFollowing:   false,
+		FollowerCount: followers,
+	}, nil
+}
+
+func (u *followUsecase) GetFollowedShops(
+	ctx context.Context,
+	userID int,
+	req request.GetFollowedShops,
+) (*response.FollowedShopListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	rows, total, err := u.followRepo.GetFollowedShops(ctx, userID, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.FollowedShopListResponse{
+		Shops:      make([]response.FollowedShopResponse, 0, len(rows)),
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+	}
+	for _, row := range rows {
+		resp.Shops = append(resp.Shops, response.FollowedShopResponse{
+			ShopID:           row.SellerProfileID,
+			Slug:             row.Slug,
+			ShopName:         row.ShopName,
+			LogoThumbnailURL: row.LogoThumbnailURL,
+			FollowerCount:    row.FollowerCount,
+			FollowedAt:       row.FollowedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (u *followUsecase) GetFeed(ctx context.Context, userID int, req request.GetFeed) (*response.FeedResponse, error) {
+	before := time.Now()
+	if req.Before != nil {
+		before = *req.Before
+	}
+	// Without before_id every row at the before time counts as older
+	beforeID := math.MaxInt32
+	if req.Before != nil && req.BeforeID != nil {
+		beforeID = *req.BeforeID
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultFeedLimit
+	}
+
+	rows, err := u.followRepo.GetFeed(ctx, userID, before, beforeID, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ProductID)
+	}
+	productByID := make(map[int]*entity.Product, len(ids))
+	if len(ids) > 0 {
+		products, err := u.productRepo.GetProductsByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range products {
+			productByID[products[i].ID] = &products[i]
+		}
+	}
+
+	resp := &response.FeedResponse{
+		Items: make([]response.FeedItemResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		product, ok := productByID[row.ProductID]
+		if !ok {
+			continue
+		}
+		resp.Items = append(resp.Items, mapFeedItem(row, product))
+	}
+	if len(rows) == req.Limit {
+		last := rows[len(rows)-1]
+		resp.NextBefore = &last.OccurredAt
+		resp.NextBeforeID = &last.ProductID
+	}
+	return resp, nil
+}
+
+func mapFeedItem(row repository.FeedRow, product *entity.Product) response.FeedItemResponse {
+	item := response.FeedItemResponse{
+		Type:       row.Type,
+		OccurredAt: row.OccurredAt,
+		Shop: response.FeedShopResponse{
+			ID:               row.SellerProfileID,
+			Slug:             row.Slug,
+			ShopName:         row.ShopName,
+			LogoThumbnailURL: row.LogoThumbnailURL,
+		},
+		Product: mapProductToResponse(product),
+	}
+
+	switch row.Type {
+	case repository.FeedTypeFlashSale:
+		item.Sale = &response.FeedSaleResponse{
+			FlashSaleID: row.FlashSaleID,
+			SalePrice:   row.SalePrice,
+			EndsAt:      row.EndsAt,
+		}
+		if row.SaleName != nil {
+			item.Sale.Name = *row.SaleName
+		}
+	case repository.FeedTypeDiscount:
+		item.Sale = &response.FeedSaleResponse{
+			DiscountValue: row.DiscountValue,
+			EndsAt:        row.EndsAt,
+		}
+		if row.DiscountType != nil {
+			item.Sale.DiscountType = *row.DiscountType
+		}
+	}
+	return item
+}
+
+func (u *followUsecase) NotifyProductLaunch(ctx context.Context, product *entity.Product) {
+	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, product.SellerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get shop of launched product", "product_id", product.ID, "error", err)
+		return
+	}
+
+	followers, err := u.followRepo.GetFollowerIDs(ctx, profile.ID)
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to get shop followers", "seller_profile_id", profile.ID, "error", err)
+		return
+	}
+
+	productID := product.ID
+	err = u.notificationUsecase.NotifyAll(ctx, followers, entity.Notification{
+		Type:          entity.NotificationShopProductLaunched,
+		Title:         fmt.Sprintf("New from %s", profile.ShopName),
+		Message:       fmt.Sprintf("%s just launched \"%s\".", profile.ShopName, product.Name),
+		ReferenceType: "product",
+		ReferenceID:   &productID,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to notify shop followers", "product_id", product.ID, "error", err)
+	}
+}
@@ -0,0 +1,806 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/tools/sheet"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxImportFileSize is the largest accepted import upload
+	MaxImportFileSize = 10 << 20
+
+	maxImportRows = 10000
+	// Imports with more data rows than this run as a background job
+	backgroundImportRows = 200
+	maxImportErrors      = 500
+	maxImportWorkers     = 2
+	skuLookupBatchSize   = 500
+
+	importAttributePrefix = "attr:"
+	importImageSeparator  = "|"
+)
+
+// Import file columns. Rows sharing a product_ref (the name when empty) form one product,
+// product columns may be left empty after its first row. Every row with a SKU is a variant.
+const (
+	importColProductRef     = "product_ref"
+	importColName           = "name"
+	importColCategory       = "category"
+	importColBrand          = "brand"
+	importColDescription    = "description"
+	importColBasePrice      = "base_price"
+	importColImageURLs      = "image_urls"
+	importColSKU            = "sku"
+	importColSwitch         = "switch"
+	importColPrice          = "price"
+	importColStock          = "stock"
+	importColLayout         = AttributeCodeLayout
+	importColConnectionType = AttributeCodeConnectionType
+	importColHotswap        = AttributeCodeHotswap
+	importColLedType        = AttributeCodeLedType
+)
+
+var importColumns = []string{
+	importColProductRef, importColName, importColCategory, importColBrand, importColDescription,
+	importColBasePrice, importColImageURLs, importColSKU, importColSwitch, importColPrice, importColStock,
+	importColLayout, importColConnectionType, importColHotswap, importColLedType,
+}
+
+var (
+	ErrSellerNotVerified = errors.New("seller is not verified")
+	ErrInvalidImportFile = errors.New("invalid import file")
+	ErrImportJobNotFound = errors.New("import job not found")
+)
+
+type IImportUsecase interface {
+	// ImportProducts validates and imports a CSV or XLSX file. Large files are imported by a
+	// background job, poll GetImportJob for the result.
+	ImportProducts(ctx context.Context, sellerID int, fileName string, data []byte, dryRun bool) (*response.ImportJobResponse, error)
+	GetImportJob(ctx context.Context, sellerID int, jobID int) (*response.ImportJobResponse, error)
+	// ExportProducts writes the seller's catalog in the import layout
+	ExportProducts(ctx context.Context, sellerID int, format string) ([]byte, error)
+	// FailInterruptedJobs fails the jobs left unfinished by a previous run of the server, call it on startup
+	FailInterruptedJobs(ctx context.Context)
+}
+
+type importUsecase struct {
+	importRepo    repository.IImportRepo
+	productRepo   repository.IProductRepo
+	attributeRepo repository.IAttributeRepo
+	catalogRepo   repository.ICatalogRepo
+	sellerRepo    repository.ISellerRepository
+	workers       chan struct{}
+}
+
+func NewImportUsecase(
+	importRepo repository.IImportRepo,
+	productRepo repository.IProductRepo,
+	attributeRepo repository.IAttributeRepo,
+	catalogRepo repository.ICatalogRepo,
+	sellerRepo repository.ISellerRepository,
+) IImportUsecase {
+	return &importUsecase{
+		importRepo:    importRepo,
+		productRepo:   productRepo,
+		attributeRepo: attributeRepo,
+		catalogRepo:   catalogRepo,
+		sellerRepo:    sellerRepo,
+		workers:       make(chan struct{}, maxImportWorkers),
+	}
+}
+
+func (u *importUsecase) ImportProducts(
+	ctx context.Context,
+	sellerID int,
+	fileName string,
+	data []byte,
+	dryRun bool,
+) (*response.ImportJobResponse, error) {
+	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, sellerID)
+	if err != nil || profile.VerificationStatus != entity.SellerStatusVerified {
+		return nil, ErrSellerNotVerified
+	}
+
+	format := sheet.FormatFromFilename(fileName)
+	if format == "" {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, sheet.ErrUnsupportedFormat)
+	}
+	rows, err := sheet.Read(data, format, maxImportRows+1)
+	if err != nil {
+		if errors.Is(err, sheet.ErrTooManyRows) {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImportFile, maxImportRows)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidImportFile)
+	}
+
+	job := &entity.ImportJob{
+		SellerID:  sellerID,
+		FileName:  fileName,
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    entity.ImportJobPending,
+		TotalRows: countDataRows(rows),
+		CreatedAt: time.Now(),
+	}
+	if err := u.importRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if job.TotalRows > backgroundImportRows {
+		queued := *job
+		go u.runJob(context.Background(), &queued, rows)
+	} else {
+		u.runJob(ctx, job, rows)
+	}
+
+	result := mapImportJobToResponse(job)
+	return &result, nil
+}
+
+func (u *importUsecase) GetImportJob(ctx context.Context, sellerID int, jobID int) (*response.ImportJobResponse, error) {
+	job, err := u.importRepo.GetJobByIDAndSeller(ctx, jobID, sellerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, err
+	}
+	result := mapImportJobToResponse(job)
+	return &result, nil
+}
+
+func (u *importUsecase) FailInterruptedJobs(ctx context.Context) {
+	// Products are created in a single transaction at the end of a job, so an interrupted job imported nothing
+	failed, err := u.importRepo.FailUnfinishedJobs(ctx, "the import was interrupted by a server restart and nothing was imported, upload the file again")
+	if err != nil {
+		logger.Errorf("Failed to fail interrupted import jobs: %v", err)
+		return
+	}
+	if failed > 0 {
+		logger.Infof("Failed %d import jobs interrupted by a restart", failed)
+	}
+}
+
+// runJob processes an import and records the outcome on the job
+func (u *importUsecase) runJob(ctx context.Context, job *entity.ImportJob, rows [][]string) {
+	u.workers <- struct{}{}
+	defer func() { <-u.workers }()
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Import job %d panicked: %v", job.ID, r)
+			u.finishJob(ctx, job, fmt.Errorf("unexpected error"))
+		}
+	}()
+
+	job.Status = entity.ImportJobRunning
+	if err := u.importRepo.UpdateJob(ctx, job); err != nil {
+		logger.Errorf("Failed to update import job %d: %v", job.ID, err)
+	}
+
+	u.finishJob(ctx, job, u.processImport(ctx, job, rows))
+}
+
+func (u *importUsecase) finishJob(ctx context.Context, job *entity.ImportJob, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = entity.ImportJobCompleted
+	if err != nil {
+		logger.Errorf("Import job %d failed: %v", job.ID, err)
+		job.Status = entity.ImportJobFailed
+		job.Message = err.Error()
+	}
+	if err := u.importRepo.UpdateJob(ctx, job); err != nil {
+		logger.Errorf("Failed to update import job %d: %v", job.ID, err)
+	}
+}
+
+// importGroup collects the rows of one product
+type importGroup struct {
+	ref      string
+	firstRow int
+	fields   map[string]string
+	fieldRow map[string]int
+	images   []string
+	variants []importVariantRow
+}
+
+type importVariantRow struct {
+	row    int
+	values map[string]string
+}
+
+// importReport accumulates row errors up to maxImportErrors
+type importReport struct {
+	errors    []entity.ImportRowError
+	truncated bool
+}
+
+func (r *importReport) add(row int, column, format string, args ...interface{}) {
+	if len(r.errors) >= maxImportErrors {
+		r.truncated = true
+		return
+	}
+	r.errors = append(r.errors, entity.ImportRowError{Row: row, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// processImport validates every row and, when all are valid and this is not a dry run,
+// creates the products. Row errors are reported on the job, the returned error is fatal.
+func (u *importUsecase) processImport(ctx context.Context, job *entity.ImportJob, rows [][]string) error {
+	report := &importReport{}
+	defer func() {
+		job.Errors = report.errors
+		if report.truncated {
+			job.Message = fmt.Sprintf("only the first %d errors are shown", maxImportErrors)
+		}
+	}()
+
+	columns, ok := parseImportHeader(rows[0], report)
+	if !ok {
+		return nil
+	}
+
+	groups := groupImportRows(rows, columns, report)
+
+	lookup, err := u.loadImportLookup(ctx)
+	if err != nil {
+		return err
+	}
+
+	products := make([]*entity.Product, 0, len(groups))
+	skuRows := make(map[string]int)
+	now := time.Now()
+	for _, group := range groups {
+		product := u.buildImportProduct(ctx, group, lookup, skuRows, report)
+		if product == nil {
+			continue
+		}
+		product.SellerID = job.SellerID
+		product.ApprovalStatus = "pending"
+		product.IsActive = true
+		product.CreatedAt = now
+		product.Revisions = []entity.ProductRevision{*newProductRevision(product, job.SellerID, 1)}
+		products = append(products, product)
+	}
+	if lookup.err != nil {
+		return lookup.err
+	}
+
+	if err := u.checkImportSKUs(skuRows, report); err != nil {
+		return err
+	}
+
+	job.Products, job.Variants, job.Images = 0, 0, 0
+	for _, p := range products {
+		job.Products++
+		job.Variants += len(p.Variants)
+		job.Images += len(p.Images)
+	}
+
+	if len(report.errors) > 0 || report.truncated || job.DryRun {
+		return nil
+	}
+	return u.importRepo.ImportProducts(ctx, products)
+}
+
+// parseImportHeader maps column names to indexes
+func parseImportHeader(header []string, report *importReport) (map[string]int, bool) {
+	known := make(map[string]bool, len(importColumns))
+	for _, c := range importColumns {
+		known[c] = true
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, raw := range header {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		if code := strings.TrimPrefix(name, importAttributePrefix); code != name {
+			if code == "" {
+				report.add(1, raw, "attribute column needs a code, e.g. attr:color")
+				continue
+			}
+		} else if !known[name] {
+			report.add(1, raw, "unknown column")
+			continue
+		}
+		if _, dup := columns[name]; dup {
+			report.add(1, raw, "duplicate column")
+			continue
+		}
+		columns[name] = i
+	}
+
+	if _, ok := columns[importColName]; !ok {
+		report.add(1, importColName, "column is required")
+	}
+	if _, ok := columns[importColBasePrice]; !ok {
+		report.add(1, importColBasePrice, "column is required")
+	}
+	return columns, len(report.errors) == 0
+}
+
+// groupImportRows gathers rows by product, keeping file order
+func groupImportRows(rows [][]string, columns map[string]int, report *importReport) []*importGroup {
+	productColumns := []string{importColName, importColCategory, importColBrand, importColDescription, importColBasePrice}
+
+	var groups []*importGroup
+	byRef := make(map[string]*importGroup)
+	for i := 1; i < len(rows); i++ {
+		rowNum := i + 1
+		values := make(map[string]string, len(columns))
+		blank := true
+		for name, index := range columns {
+			if index < len(rows[i]) {
+				if v := strings.TrimSpace(rows[i][index]); v != "" {
+					values[name] = v
+					blank = false
+				}
+			}
+		}
+		if blank {
+			continue
+		}
+
+		ref := values[importColProductRef]
+		if ref == "" {
+			ref = values[importColName]
+		}
+		if ref == "" {
+			report.add(rowNum, importColProductRef, "product_ref or name is required")
+			continue
+		}
+
+		group, ok := byRef[ref]
+		if !ok {
+			group = &importGroup{ref: ref, firstRow: rowNum, fields: map[string]string{}, fieldRow: map[string]int{}}
+			byRef[ref] = group
+			groups = append(groups, group)
+		}
+
+		for _, col := range productColumns {
+			v, ok := values[col]
+			if !ok {
+				continue
+			}
+			if current, set := group.fields[col]; set && current != v {
+				report.add(rowNum, col, "conflicts with row %d of product %s", group.fieldRow[col], ref)
+				continue
+			}
+			group.fields[col] = v
+			group.fieldRow[col] = rowNum
+		}
+
+		if urls, ok := values[importColImageURLs]; ok {
+			for _, raw := range strings.Split(urls, importImageSeparator) {
+				if raw = strings.TrimSpace(raw); raw == "" {
+					continue
+				}
+				if !isImportableURL(raw) {
+					report.add(rowNum, importColImageURLs, "%s is not an http(s) URL", raw)
+					continue
+				}
+				if !containsString(group.images, raw) {
+					group.images = append(group.images, raw)
+				}
+			}
+		}
+
+		if isImportVariantRow(values) {
+			group.variants = append(group.variants, importVariantRow{row: rowNum, values: values})
+		}
+	}
+	return groups
+}
+
+func isImportVariantRow(values map[string]string) bool {
+	for name := range values {
+		switch name {
+		case importColSKU, importColSwitch, importColPrice, importColStock,
+			importColLayout, importColConnectionType, importColHotswap, importColLedType:
+			return true
+		}
+		if strings.HasPrefix(name, importAttributePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// importLookup resolves catalog references by name or slug, case-insensitively
+type importLookup struct {
+	categories  map[string]*entity.Category
+	brands      map[string]*entity.Brand
+	switches    map[string]*entity.Switch
+	definitions map[int][]entity.AttributeDefinition
+	err         error
+}
+
+func (u *importUsecase) loadImportLookup(ctx context.Context) (*importLookup, error) {
+	categories, err := u.catalogRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	brands, err := u.catalogRepo.GetAllBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switches, err := u.catalogRepo.GetAllSwitches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &importLookup{
+		categories:  make(map[string]*entity.Category, len(categories)*2),
+		brands:      make(map[string]*entity.Brand, len(brands)),
+		switches:    make(map[string]*entity.Switch, len(switches)*2),
+		definitions: make(map[int][]entity.AttributeDefinition),
+	}
+	for i := range categories {
+		lookup.categories[strings.ToLower(categories[i].Slug)] = &categories[i]
+		if _, ok := lookup.categories[strings.ToLower(categories[i].Name)]; !ok {
+			lookup.categories[strings.ToLower(categories[i].Name)] = &categories[i]
+		}
+	}
+	for i := range brands {
+		lookup.brands[strings.ToLower(brands[i].Name)] = &brands[i]
+	}
+	for i := range switches {
+		lookup.switches[strings.ToLower(switches[i].Name)] = &switches[i]
+		lookup.switches[strconv.Itoa(switches[i].ID)] = &switches[i]
+	}
+	return lookup, nil
+}
+
+// definitionsFor returns the effective attribute definitions of a category, 0 for none
+func (u *importUsecase) definitionsFor(ctx context.Context, lookup *importLookup, categoryID int) []entity.AttributeDefinition {
+	if definitions, ok := lookup.definitions[categoryID]; ok {
+		return definitions
+	}
+
+	var id *int
+	if categoryID != 0 {
+		id = &categoryID
+	}
+	definitions, err := u.attributeRepo.GetEffectiveDefinitions(ctx, id)
+	if err != nil {
+		lookup.err = err
+		return nil
+	}
+	lookup.definitions[categoryID] = definitions
+	return definitions
+}
+
+// buildImportProduct validates a product group, returning nil when it has errors
+func (u *importUsecase) buildImportProduct(
+	ctx context.Context,
+	group *importGroup,
+	lookup *importLookup,
+	skuRows map[string]int,
+	report *importReport,
+) *entity.Product {
+	errorsBefore := len(report.errors)
+	rowOf := func(col string) int {
+		if row, ok := group.fieldRow[col]; ok {
+			return row
+		}
+		return group.firstRow
+	}
+
+	product := &entity.Product{
+		Name:        group.fields[importColName],
+		Description: group.fields[importColDescription],
+	}
+	if product.Name == "" {
+		report.add(group.firstRow, importColName, "product %s has no name", group.ref)
+	}
+
+	if raw, ok := group.fields[importColBasePrice]; !ok {
+		report.add(group.firstRow, importColBasePrice, "product %s has no base price", group.ref)
+	} else if price, err := strconv.ParseFloat(raw, 64); err != nil || price <= 0 {
+		report.add(rowOf(importColBasePrice), importColBasePrice, "must be a positive number")
+	} else {
+		product.BasePrice = price
+	}
+
+	categoryID := 0
+	if raw, ok := group.fields[importColCategory]; ok {
+		if category, found := lookup.categories[strings.ToLower(raw)]; found {
+			categoryID = category.ID
+			product.CategoryID = &category.ID
+		} else {
+			report.add(rowOf(importColCategory), importColCategory, "category %s not found", raw)
+		}
+	}
+	if raw, ok := group.fields[importColBrand]; ok {
+		if brand, found := lookup.brands[strings.ToLower(raw)]; found {
+			product.BrandID = &brand.ID
+		} else {
+			report.add(rowOf(importColBrand), importColBrand, "brand %s not found", raw)
+		}
+	}
+
+	for i, imageURL := range group.images {
+		product.Images = append(product.Images, entity.ProductImage{
+			ImageURL:     imageURL,
+			IsPrimary:    i == 0,
+			DisplayOrder: i,
+			CreatedAt:    time.Now(),
+		})
+	}
+
+	definitions := u.definitionsFor(ctx, lookup, categoryID)
+	for _, row := range group.variants {
+		if variant := buildImportVariant(row, definitions, lookup, skuRows, report); variant != nil {
+			product.Variants = append(product.Variants, *variant)
+		}
+	}
+
+	if len(report.errors) > errorsBefore {
+		return nil
+	}
+	return product
+}
+
+func buildImportVariant(
+	row importVariantRow,
+	definitions []entity.AttributeDefinition,
+	lookup *importLookup,
+	skuRows map[string]int,
+	report *importReport,
+) *entity.ProductVariant {
+	errorsBefore := len(report.errors)
+	values := row.values
+	variant := &entity.ProductVariant{SKU: values[importColSKU]}
+
+	switch {
+	case variant.SKU == "":
+		report.add(row.row, importColSKU, "variant rows need a SKU")
+	case len(variant.SKU) > maxSKULength:
+		report.add(row.row, importColSKU, "longer than %d characters", maxSKULength)
+	default:
+		if first, dup := skuRows[variant.SKU]; dup {
+			report.add(row.row, importColSKU, "SKU %s is also used on row %d", variant.SKU, first)
+		} else {
+			skuRows[variant.SKU] = row.row
+		}
+	}
+
+	if price, err := strconv.ParseFloat(values[importColPrice], 64); err != nil || price <= 0 {
+		report.add(row.row, importColPrice, "must be a positive number")
+	} else {
+		variant.Price = price
+	}
+
+	if raw, ok := values[importColStock]; ok {
+		if stock, err := strconv.Atoi(raw); err != nil || stock < 0 {
+			report.add(row.row, importColStock, "must be a whole number of at least 0")
+		} else {
+			variant.Stock = stock
+		}
+	}
+
+	if raw, ok := values[importColSwitch]; ok {
+		if sw, found := lookup.switches[strings.ToLower(raw)]; found {
+			variant.SwitchID = &sw.ID
+		} else {
+			report.add(row.row, importColSwitch, "switch %s not found", raw)
+		}
+	}
+
+	attributeValues := make(map[string]string)
+	for _, code := range []string{importColLayout, importColConnectionType, importColHotswap, importColLedType} {
+		if v, ok := values[code]; ok {
+			attributeValues[code] = v
+		}
+	}
+	for name, v := range values {
+		if code := strings.TrimPrefix(name, importAttributePrefix); code != name {
+			attributeValues[code] = v
+		}
+	}
+	attributes, err := buildVariantAttributesWithLegacy(definitions, attributeValues, variant)
+	if err != nil {
+		report.add(row.row, "", "%s", strings.TrimPrefix(err.Error(), ErrInvalidAttribute.Error()+": "))
+	}
+	for i := range attributes {
+		attributes[i].Definition = nil
+	}
+	variant.Attributes = attributes
+
+	if len(report.errors) > errorsBefore {
+		return nil
+	}
+	return variant
+}
+
+// checkImportSKUs reports SKUs already used by existing variants
+func (u *importUsecase) checkImportSKUs(skuRows map[string]int, report *importReport) error {
+	skus := make([]string, 0, len(skuRows))
+	for sku := range skuRows {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+
+	for start := 0; start < len(skus); start += skuLookupBatchSize {
+		end := start + skuLookupBatchSize
+		if end > len(skus) {
+			end = len(skus)
+		}
+		taken, err := u.productRepo.GetVariantsBySKUs(skus[start:end])
+		if err != nil {
+			return err
+		}
+		for _, variant := range taken {
+			report.add(skuRows[variant.SKU], importColSKU, "SKU %s already exists", variant.SKU)
+		}
+	}
+	return nil
+}
+
+func (u *importUsecase) ExportProducts(ctx context.Context, sellerID int, format string) ([]byte, error) {
+	products, err := u.importRepo.GetSellerCatalog(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attribute columns for every code in use, legacy codes already have their own column
+	codeSet := make(map[string]bool)
+	for _, p := range products {
+		for _, v := range p.Variants {
+			for code := range currentAttributeValues(&v) {
+				codeSet[code] = true
+			}
+		}
+	}
+	for _, code := range []string{importColLayout, importColConnectionType, importColHotswap, importColLedType} {
+		delete(codeSet, code)
+	}
+	codes := make([]string, 0, len(codeSet))
+	for code := range codeSet {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	header := append([]string{}, importColumns...)
+	for _, code := range codes {
+		header = append(header, importAttributePrefix+code)
+	}
+	rows := [][]string{header}
+
+	for _, p := range products {
+		product := map[string]string{
+			importColProductRef:  strconv.Itoa(p.ID),
+			importColName:        p.Name,
+			importColDescription: p.Description,
+			importColBasePrice:   formatImportNumber(p.BasePrice),
+		}
+		if p.Category != nil {
+			product[importColCategory] = p.Category.Slug
+		}
+		if p.Brand != nil {
+			product[importColBrand] = p.Brand.Name
+		}
+		urls := make([]string, 0, len(p.Images))
+		for _, img := range p.Images {
+			urls = append(urls, img.ImageURL)
+		}
+		product[importColImageURLs] = strings.Join(urls, importImageSeparator)
+
+		if len(p.Variants) == 0 {
+			rows = append(rows, exportRow(header, product))
+			continue
+		}
+		for i, v := range p.Variants {
+			values := map[string]string{
+				importColProductRef:     strconv.Itoa(p.ID),
+				importColSKU:            v.SKU,
+				importColPrice:          formatImportNumber(v.Price),
+				importColStock:          strconv.Itoa(v.Stock),
+				importColLayout:         v.Layout,
+				importColConnectionType: v.ConnectionType,
+				importColHotswap:        strconv.FormatBool(v.Hotswap),
+				importColLedType:        v.LedType,
+			}
+			if i == 0 {
+				for k, val := range product {
+					values[k] = val
+				}
+			}
+			if v.Switch != nil {
+				values[importColSwitch] = v.Switch.Name
+			}
+			for code, value := range currentAttributeValues(&v) {
+				if codeSet[code] {
+					values[importAttributePrefix+code] = value
+				}
+			}
+			rows = append(rows, exportRow(header, values))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := sheet.Write(&buf, format, rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func exportRow(header []string, values map[string]string) []string {
+	row := make([]string, len(header))
+	for i, name := range header {
+		row[i] = values[name]
+	}
+	return row
+}
+
+func formatImportNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func countDataRows(rows [][]string) int {
+	count := 0
+	for _, row := range rows[1:] {
+		for _, v := range row {
+			if strings.TrimSpace(v) != "" {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+func isImportableURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func mapImportJobToResponse(job *entity.ImportJob) response.ImportJobResponse {
+	rowErrors := make([]response.ImportRowError, 0, len(job.Errors))
+	for _, e := range job.Errors {
+		rowErrors = append(rowErrors, response.ImportRowError{Row: e.Row, Column: e.Column, Message: e.Message})
+	}
+	return response.ImportJobResponse{
+		ID:         job.ID,
+		FileName:   job.FileName,
+		Format:     job.Format,
+		DryRun:     job.DryRun,
+		Status:     job.Status,
+		TotalRows:  job.TotalRows,
+		Products:   job.Products,
+		Variants:   job.Variants,
+		Images:     job.Images,
+		Errors:     rowErrors,
+		Message:    job.Message,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
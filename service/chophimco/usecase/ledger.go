@@ -0,0 +1,1004 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/utils/mathutil"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrOrderNotCompleted      = errors.New("order is not completed")
+	ErrInvalidRefund          = errors.New("invalid refund")
+	ErrInvalidCommissionRule  = errors.New("a commission rule needs either a seller or a category")
+	ErrCommissionRuleNotFound = errors.New("commission rule not found")
+	ErrNothingToPay           = errors.New("no seller is due a payout")
+	ErrPayoutBatchNotFound    = errors.New("payout batch not found")
+	ErrPayoutNotFound         = errors.New("payout not found")
+	ErrInvalidPayoutStatus    = errors.New("payout is not in a status allowing this")
+	ErrInvalidPayoutPeriod    = errors.New("the payout period cannot end in the future")
+)
+
+// defaultReportPeriod is the period of earnings and reconciliation reports without dates
+const defaultReportPeriod = 30 * 24 * time.Hour
+
+type ILedgerUsecase interface {
+	// RecordOrderSale records the sale of a completed order, once
+	RecordOrderSale(ctx context.Context, orderID int) error
+	RefundOrder(ctx context.Context, req request.RefundOrder) (*response.OrderRefundResponse, error)
+
+	// Admin - commission rates
+	GetCommissionRules(ctx context.Context) (*response.CommissionRulesResponse, error)
+	SetCommissionRule(ctx context.Context, req request.SetCommissionRule) (*response.CommissionRuleResponse, error)
+	DeleteCommissionRule(ctx context.Context, id int) error
+
+	// Seller earnings
+	GetSellerEarnings(ctx context.Context, sellerID int, req request.GetSellerEarnings) (*response.SellerEarningsResponse, error)
+	GetSellerLedger(ctx context.Context, sellerID int, req request.GetSellerLedger) (*response.LedgerLineListResponse, error)
+
+	// Admin - payouts
+	CreatePayoutBatch(ctx context.Context, req request.CreatePayoutBatch) (*response.PayoutBatchResponse, error)
+	GetPayoutBatches(ctx context.Context, req request.GetPayoutBatches) (*response.PayoutBatchListResponse, error)
+	GetPayoutBatch(ctx context.Context, id int) (*response.PayoutBatchResponse, error)
+	ProcessPayoutBatch(ctx context.Context, id int) error
+	CancelPayoutBatch(ctx context.Context, id int) error
+	SettlePayout(ctx context.Context, req request.SettlePayout) error
+	// RunPayoutSchedule creates a payout batch every payout interval until ctx is done
+	RunPayoutSchedule(ctx context.Context)
+
+	// Admin - reconciliation
+	GetReconciliationReport(ctx context.Context, req request.ReconciliationReport) (*response.ReconciliationReportResponse, error)
+}
+
+type ledgerUsecase struct {
+	ledgerRepo          repository.ILedgerRepo
+	sellerRepo          repository.ISellerRepository
+	catalogRepo         repository.ICatalogRepo
+	notificationUsecase INotificationUsecase
+}
+
+func NewLedgerUsecase(
+	ledgerRepo repository.ILedgerRepo,
+	sellerRepo repository.ISellerRepository,
+	catalogRepo repository.ICatalogRepo,
+	notificationUsecase INotificationUsecase,
+) ILedgerUsecase {
+	return &ledgerUsecase{
+		ledgerRepo:          ledgerRepo,
+		sellerRepo:          sellerRepo,
+		catalogRepo:         catalogRepo,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+// saleItem is an order item priced for settlement
+type saleItem struct {
+	item       *entity.OrderItem
+	sellerID   int
+	gross      float64 // at the regular price
+	charged    float64 // at the price the buyer paid, before the voucher
+	voucher    float64 // the item's share of the order voucher
+	commission float64
+}
+
+// RecordOrderSale books, per seller, the gross sale at the regular price against platform
+// cash, the seller-funded markdown, the platform-funded voucher share and the commission.
+// What remains on the seller payable account is the net amount owed to the seller.
+func (u *ledgerUsecase) RecordOrderSale(ctx context.Context, orderID int) error {
+	order, err := u.getOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != "completed" && order.Status != "refunded" {
+		return ErrOrderNotCompleted
+	}
+
+	rates, err := u.newCommissionRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	items := make([]saleItem, 0, len(order.OrderItems))
+	var charged float64
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		if item.ProductVariant == nil || item.ProductVariant.Product == nil {
+			return fmt.Errorf("order %d item %d has no product to settle", order.ID, item.ID)
+		}
+		product := item.ProductVariant.Product
+		listPrice := math.Max(item.ListPrice, item.Price)
+
+		sale := saleItem{
+			item:     item,
+			sellerID: product.SellerID,
+			gross:    roundMoney(listPrice * float64(item.Quantity)),
+			charged:  roundMoney(item.Price * float64(item.Quantity)),
+		}
+		sale.commission = roundMoney(sale.charged * rates.rate(product.SellerID, product.CategoryID) / 100)
+		charged += sale.charged
+		items = append(items, sale)
+	}
+
+	// The voucher is platform-funded, shared between the items by what they cost
+	allocateVoucher(items, math.Min(order.DiscountAmount, charged))
+
+	entries := make([]entity.LedgerEntry, 0)
+	bySeller := make(map[int]int)
+	unitsBySeller := make(map[int]int)
+	for _, sale := range items {
+		i, ok := bySeller[sale.sellerID]
+		if !ok {
+			sellerID, orderID := sale.sellerID, order.ID
+			entries = append(entries, entity.LedgerEntry{
+				Type:         entity.LedgerEntrySale,
+				ReferenceKey: entity.SaleReferenceKey(order.ID, sale.sellerID),
+				SellerID:     &sellerID,
+				OrderID:      &orderID,
+				Description:  fmt.Sprintf("Order #%d", order.ID),
+				CreatedAt:    time.Now(),
+			})
+			i = len(entries) - 1
+			bySeller[sale.sellerID] = i
+		}
+		entries[i].Lines = append(entries[i].Lines, saleLines(sale)...)
+		unitsBySeller[sale.sellerID] += sale.item.Quantity
+	}
+
+	recorded, err := u.ledgerRepo.RecordSale(ctx, order.ID, entries, unitsBySeller)
+	if err != nil {
+		return err
+	}
+	if recorded {
+		logger.EnhanceWith(ctx).Infow("Recorded order sale", "order_id", order.ID, "sellers", len(entries))
+	}
+	return nil
+}
+
+func saleLines(sale saleItem) []entity.LedgerLine {
+	sellerID, itemID := sale.sellerID, sale.item.ID
+	line := func(account, component string, debit, credit float64) entity.LedgerLine {
+		return entity.LedgerLine{
+			Account:     account,
+			SellerID:    &sellerID,
+			Component:   component,
+			OrderItemID: &itemID,
+			Debit:       debit,
+			Credit:      credit,
+			CreatedAt:   time.Now(),
+		}
+	}
+
+	lines := []entity.LedgerLine{
+		line(entity.AccountPlatformCash, entity.ComponentGross, sale.gross, 0),
+		line(entity.AccountSellerPayable, entity.ComponentGross, 0, sale.gross),
+	}
+	if discount := roundMoney(sale.gross - sale.charged); discount > 0 {
+		lines = append(lines,
+			line(entity.AccountSellerPayable, entity.ComponentSellerDiscount, discount, 0),
+			line(entity.AccountPlatformCash, entity.ComponentSellerDiscount, 0, discount))
+	}
+	if sale.voucher > 0 {
+		lines = append(lines,
+			line(entity.AccountPromotionExpense, entity.ComponentPlatformDiscount, sale.voucher, 0),
+			line(entity.AccountPlatformCash, entity.ComponentPlatformDiscount, 0, sale.voucher))
+	}
+	if sale.commission > 0 {
+		lines = append(lines,
+			line(entity.AccountSellerPayable, entity.ComponentCommission, sale.commission, 0),
+			line(entity.AccountCommissionRevenue, entity.ComponentCommission, 0, sale.commission))
+	}
+	return lines
+}
+
+// allocateVoucher shares the voucher amount between the items by what they cost, the last
+// item taking the rounding difference
+func allocateVoucher(items []saleItem, voucher float64) {
+	if voucher <= 0 {
+		return
+	}
+	var charged float64
+	for _, sale := range items {
+		charged += sale.charged
+	}
+	if charged <= 0 {
+		return
+	}
+
+	remaining := roundMoney(voucher)
+	for i := range items {
+		share := roundMoney(voucher * items[i].charged / charged)
+		if i == len(items)-1 || share > remaining {
+			share = remaining
+		}
+		items[i].voucher = share
+		remaining = roundMoney(remaining - share)
+	}
+}
+
+func (u *ledgerUsecase) RefundOrder(ctx context.Context, req request.RefundOrder) (*response.OrderRefundResponse, error) {
+	order, err := u.getOrder(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != "completed" {
+		return nil, fmt.Errorf("%w: only completed orders can be refunded", ErrInvalidRefund)
+	}
+
+	// A sale that failed to record when the order completed is recorded now
+	if err := u.RecordOrderSale(ctx, order.ID); err != nil {
+		return nil, err
+	}
+	saleLines, err := u.ledgerRepo.GetSaleLines(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// What the sale recorded for each item, to reverse in proportion
+	type itemTotals struct{ voucher, commission float64 }
+	recorded := make(map[int]*itemTotals)
+	for _, line := range saleLines {
+		if line.OrderItemID == nil {
+			continue
+		}
+		totals, ok := recorded[*line.OrderItemID]
+		if !ok {
+			totals = &itemTotals{}
+			recorded[*line.OrderItemID] = totals
+		}
+		switch {
+		case line.Account == entity.AccountPromotionExpense:
+			totals.voucher += line.Debit
+		case line.Account == entity.AccountCommissionRevenue:
+			totals.commission += line.Credit
+		}
+	}
+
+	quantities := make(map[int]int)
+	orderItems := make(map[int]*entity.OrderItem, len(order.OrderItems))
+	for i := range order.OrderItems {
+		orderItems[order.OrderItems[i].ID] = &order.OrderItems[i]
+	}
+	ids := make([]int, 0, len(req.Items))
+	for _, it := range req.Items {
+		if _, ok := orderItems[it.OrderItemID]; !ok {
+			return nil, fmt.Errorf("%w: item %d is not part of order %d", ErrInvalidRefund, it.OrderItemID, order.ID)
+		}
+		if _, ok := quantities[it.OrderItemID]; !ok {
+			ids = append(ids, it.OrderItemID)
+		}
+		quantities[it.OrderItemID] += it.Quantity
+	}
+
+	now := time.Now()
+	refund := &entity.OrderRefund{
+		OrderID:   order.ID,
+		Reason:    req.Reason,
+		CreatedBy: req.CreatedBy,
+		CreatedAt: now,
+	}
+	entries := make(map[int]*entity.LedgerEntry)
+	unitsBySeller := make(map[int]int)
+	for _, id := range ids {
+		item, quantity := orderItems[id], quantities[id]
+		if item.RefundedQuantity+quantity > item.Quantity {
+			return nil, fmt.Errorf("%w: only %d of item %d are left to refund",
+				ErrInvalidRefund, item.Quantity-item.RefundedQuantity, item.ID)
+		}
+		sellerID := item.ProductVariant.Product.SellerID
+		totals := recorded[item.ID]
+		if totals == nil {
+			totals = &itemTotals{}
+		}
+
+		// Reverse the share of the refunded units, so refunding every unit reverses it all
+		share := func(total float64) float64 {
+			before := roundMoney(total * float64(item.RefundedQuantity) / float64(item.Quantity))
+			after := roundMoney(total * float64(item.RefundedQuantity+quantity) / float64(item.Quantity))
+			return roundMoney(after - before)
+		}
+		charged := share(item.Price * float64(item.Quantity))
+		voucher := share(totals.voucher)
+		commission := share(totals.commission)
+		amount := roundMoney(charged - voucher)
+
+		refund.Items = append(refund.Items, entity.OrderRefundItem{
+			OrderItemID: item.ID,
+			Quantity:    quantity,
+			Amount:      amount,
+		})
+		refund.Amount = roundMoney(refund.Amount + amount)
+
+		entry, ok := entries[sellerID]
+		if !ok {
+			seller, orderID := sellerID, order.ID
+			entry = &entity.LedgerEntry{
+				Type:        entity.LedgerEntryRefund,
+				SellerID:    &seller,
+				OrderID:     &orderID,
+				Description: fmt.Sprintf("Refund on order #%d: %s", order.ID, req.Reason),
+				CreatedAt:   now,
+			}
+			entries[sellerID] = entry
+		}
+		entry.Lines = append(entry.Lines, refundLines(sellerID, item.ID, charged, voucher, commission)...)
+		unitsBySeller[sellerID] += quantity
+	}
+
+	if err := u.ledgerRepo.RecordRefund(ctx, refund, entries, unitsBySeller); err != nil {
+		if errors.Is(err, repository.ErrRefundExceedsQuantity) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRefund, err.Error())
+		}
+		return nil, err
+	}
+
+	resp := &response.OrderRefundResponse{
+		ID:        refund.ID,
+		OrderID:   refund.OrderID,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+		Items:     make([]response.OrderRefundItemResponse, 0, len(refund.Items)),
+		CreatedAt: refund.CreatedAt,
+	}
+	for _, item := range refund.Items {
+		resp.Items = append(resp.Items, response.OrderRefundItemResponse{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		})
+	}
+	return resp, nil
+}
+
+// refundLines takes the refunded sale back from the seller, returns the commission on it to
+// the seller, and pays the buyer what they paid, the voucher share going back to the platform
+func refundLines(sellerID, itemID int, charged, voucher, commission float64) []entity.LedgerLine {
+	line := func(account, component string, debit, credit float64) entity.LedgerLine {
+		return entity.LedgerLine{
+			Account:     account,
+			SellerID:    &sellerID,
+			Component:   component,
+			OrderItemID: &itemID,
+			Debit:       debit,
+			Credit:      credit,
+			CreatedAt:   time.Now(),
+		}
+	}
+
+	lines := []entity.LedgerLine{
+		line(entity.AccountSellerPayable, entity.ComponentRefund, charged, 0),
+		line(entity.AccountPlatformCash, entity.ComponentRefund, 0, roundMoney(charged-voucher)),
+	}
+	if voucher > 0 {
+		lines = append(lines, line(entity.AccountPromotionExpense, entity.ComponentPlatformDiscount, 0, voucher))
+	}
+	if commission > 0 {
+		lines = append(lines,
+			line(entity.AccountCommissionRevenue, entity.ComponentCommissionReversal, commission, 0),
+			line(entity.AccountSellerPayable, entity.ComponentCommissionReversal, 0, commission))
+	}
+	return lines
+}
+
+// Admin - commission rates
+func (u *ledgerUsecase) GetCommissionRules(ctx context.Context) (*response.CommissionRulesResponse, error) {
+	rules, err := u.ledgerRepo.GetCommissionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.CommissionRulesResponse{
+		DefaultRate: config.MarketplaceConfig().CommissionRate,
+		Rules:       make([]response.CommissionRuleResponse, 0, len(rules)),
+	}
+	for i := range rules {
+		resp.Rules = append(resp.Rules, mapCommissionRuleToResponse(&rules[i]))
+	}
+	return resp, nil
+}
+
+// SetCommissionRule sets the rate applied to orders completed from now on, recorded sales keep theirs
+func (u *ledgerUsecase) SetCommissionRule(ctx context.Context, req request.SetCommissionRule) (*response.CommissionRuleResponse, error) {
+	if (req.SellerID == nil) == (req.CategoryID == nil) {
+		return nil, ErrInvalidCommissionRule
+	}
+	if req.SellerID != nil {
+		if _, err := u.sellerRepo.GetSellerProfileByUserID(ctx, *req.SellerID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSellerProfileNotFound
+			}
+			return nil, err
+		}
+	} else {
+		if _, err := u.catalogRepo.GetCategoryByID(ctx, *req.CategoryID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, err
+		}
+	}
+
+	rule := &entity.CommissionRule{
+		SellerID:   req.SellerID,
+		CategoryID: req.CategoryID,
+		Rate:       mathutil.RoundToFloat(req.Rate, 2),
+		UpdatedBy:  req.UpdatedBy,
+		UpdatedAt:  time.Now(),
+	}
+	if err := u.ledgerRepo.SaveCommissionRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	saved, err := u.ledgerRepo.GetCommissionRuleByID(ctx, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapCommissionRuleToResponse(saved)
+	return &resp, nil
+}
+
+func (u *ledgerUsecase) DeleteCommissionRule(ctx context.Context, id int) error {
+	err := u.ledgerRepo.DeleteCommissionRule(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommissionRuleNotFound
+	}
+	return err
+}
+
+// commissionRates resolves the commission rate of a sale
+type commissionRates struct {
+	bySeller   map[int]float64
+	byCategory map[int]float64
+	parents    map[int]*int
+	fallback   float64
+}
+
+func (u *ledgerUsecase) newCommissionRates(ctx context.Context) (*commissionRates, error) {
+	rules, err := u.ledgerRepo.GetCommissionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := u.catalogRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := &commissionRates{
+		bySeller:   make(map[int]float64),
+		byCategory: make(map[int]float64),
+		parents:    make(map[int]*int, len(categories)),
+		fallback:   config.MarketplaceConfig().CommissionRate,
+	}
+	for _, rule := range rules {
+		switch {
+		case rule.SellerID != nil:
+			rates.bySeller[*rule.SellerID] = rule.Rate
+		case rule.CategoryID != nil:
+			rates.byCategory[*rule.CategoryID] = rule.Rate
+		}
+	}
+	for _, category := range categories {
+		rates.parents[category.ID] = category.ParentID
+	}
+	return rates, nil
+}
+
+// rate is the seller's rate, else the rate of the category or its nearest parent having one,
+// else the default rate
+func (r *commissionRates) rate(sellerID int, categoryID *int) float64 {
+	if rate, ok := r.bySeller[sellerID]; ok {
+		return rate
+	}
+	seen := make(map[int]bool)
+	for id := categoryID; id != nil && !seen[*id]; id = r.parents[*id] {
+		if rate, ok := r.byCategory[*id]; ok {
+			return rate
+		}
+		seen[*id] = true
+	}
+	return r.fallback
+}
+
+// Seller earnings
+func (u *ledgerUsecase) GetSellerEarnings(
+	ctx context.Context,
+	sellerID int,
+	req request.GetSellerEarnings,
+) (*response.SellerEarningsResponse, error) {
+	from, to := reportPeriod(req.From, req.To)
+	totals, err := u.ledgerRepo.GetSellerComponentTotals(ctx, sellerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.SellerEarningsResponse{From: from, To: to}
+	for _, total := range totals {
+		switch total.Component {
+		case entity.ComponentGross:
+			resp.GrossSales += total.Credit - total.Debit
+		case entity.ComponentSellerDiscount:
+			resp.SellerDiscounts += total.Debit - total.Credit
+		case entity.ComponentCommission, entity.ComponentCommissionReversal:
+			resp.Commission += total.Debit - total.Credit
+		case entity.ComponentRefund:
+			resp.Refunds += total.Debit - total.Credit
+		}
+	}
+	resp.GrossSales = roundMoney(resp.GrossSales)
+	resp.SellerDiscounts = roundMoney(resp.SellerDiscounts)
+	resp.Commission = roundMoney(resp.Commission)
+	resp.Refunds = roundMoney(resp.Refunds)
+	resp.NetEarnings = roundMoney(resp.GrossSales - resp.SellerDiscounts - resp.Commission - resp.Refunds)
+
+	now := time.Now()
+	if resp.Balance, err = u.ledgerRepo.GetSellerBalance(ctx, sellerID, entity.AccountSellerPayable, now); err != nil {
+		return nil, err
+	}
+	hold := now.Add(-config.MarketplaceConfig().PayoutHoldPeriod)
+	if resp.Available, err = u.ledgerRepo.GetSellerBalance(ctx, sellerID, entity.AccountSellerPayable, hold); err != nil {
+		return nil, err
+	}
+	if resp.InTransit, err = u.ledgerRepo.GetSellerBalance(ctx, sellerID, entity.AccountPayoutsInTransit, now); err != nil {
+		return nil, err
+	}
+	if resp.PaidOut, err = u.ledgerRepo.GetSellerPaidOut(ctx, sellerID, from, to); err != nil {
+		return nil, err
+	}
+	resp.Balance = roundMoney(resp.Balance)
+	resp.Available = roundMoney(math.Max(0, math.Min(resp.Available, resp.Balance)))
+	resp.InTransit = roundMoney(resp.InTransit)
+	resp.PaidOut = roundMoney(resp.PaidOut)
+
+	payouts, err := u.ledgerRepo.GetPayoutsBySeller(ctx, sellerID, 10)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecentPayouts = make([]response.PayoutResponse, 0, len(payouts))
+	for i := range payouts {
+		resp.RecentPayouts = append(resp.RecentPayouts, mapPayoutToResponse(&payouts[i]))
+	}
+	return resp, nil
+}
+
+func (u *ledgerUsecase) GetSellerLedger(
+	ctx context.Context,
+	sellerID int,
+	req request.GetSellerLedger,
+) (*response.LedgerLineListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	rows, total, err := u.ledgerRepo.GetSellerLines(ctx, sellerID, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.LedgerLineListResponse{
+		Lines:      make([]response.LedgerLineResponse, 0, len(rows)),
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+	}
+	for _, row := range rows {
+		resp.Lines = append(resp.Lines, response.LedgerLineResponse{
+			ID:          row.ID,
+			EntryType:   row.EntryType,
+			Component:   row.Component,
+			OrderID:     row.OrderID,
+			OrderItemID: row.OrderItemID,
+			PayoutID:    row.PayoutID,
+			Description: row.Description,
+			Amount:      roundMoney(row.Credit - row.Debit),
+			CreatedAt:   row.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// Admin - payouts
+func (u *ledgerUsecase) CreatePayoutBatch(ctx context.Context, req request.CreatePayoutBatch) (*response.PayoutBatchResponse, error) {
+	now := time.Now()
+	periodEnd := now.Add(-config.MarketplaceConfig().PayoutHoldPeriod)
+	if req.PeriodEnd != nil {
+		if req.PeriodEnd.After(now) {
+			return nil, ErrInvalidPayoutPeriod
+		}
+		periodEnd = *req.PeriodEnd
+	}
+
+	batch := &entity.PayoutBatch{
+		PeriodEnd: periodEnd,
+		Status:    entity.PayoutBatchPending,
+		Note:      req.Note,
+		CreatedAt: now,
+	}
+	if req.CreatedBy != 0 {
+		batch.CreatedBy = &req.CreatedBy
+	}
+
+	err := u.ledgerRepo.CreatePayoutBatch(ctx, batch, config.MarketplaceConfig().PayoutMinAmount, func(payout *entity.Payout) entity.LedgerEntry {
+		return payoutEntry(entity.LedgerEntryPayout, payout, entity.AccountSellerPayable, entity.AccountPayoutsInTransit,
+			fmt.Sprintf("Payout #%d in batch #%d", payout.ID, payout.BatchID))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNothingToPay
+		}
+		return nil, err
+	}
+
+	return u.GetPayoutBatch(ctx, batch.ID)
+}
+
+// payoutEntry moves the payout amount from the debit account to the credit account
+func payoutEntry(entryType string, payout *entity.Payout, debit, credit, description string) entity.LedgerEntry {
+	sellerID, payoutID := payout.SellerID, payout.ID
+	now := time.Now()
+	return entity.LedgerEntry{
+		Type:         entryType,
+		ReferenceKey: entity.PayoutReferenceKey(entryType, payout.ID),
+		SellerID:     &sellerID,
+		PayoutID:     &payoutID,
+		Description:  description,
+		CreatedAt:    now,
+		Lines: []entity.LedgerLine{
+			{Account: debit, SellerID: &sellerID, Component: entity.ComponentPayout, Debit: payout.Amount, CreatedAt: now},
+			{Account: credit, SellerID: &sellerID, Component: entity.ComponentPayout, Credit: payout.Amount, CreatedAt: now},
+		},
+	}
+}
+
+func (u *ledgerUsecase) GetPayoutBatches(ctx context.Context, req request.GetPayoutBatches) (*response.PayoutBatchListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	batches, total, err := u.ledgerRepo.GetPayoutBatches(ctx, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.PayoutBatchListResponse{
+		Batches:    make([]response.PayoutBatchResponse, 0, len(batches)),
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+	}
+	for i := range batches {
+		resp.Batches = append(resp.Batches, mapPayoutBatchToResponse(&batches[i]))
+	}
+	return resp, nil
+}
+
+func (u *ledgerUsecase) GetPayoutBatch(ctx context.Context, id int) (*response.PayoutBatchResponse, error) {
+	batch, err := u.ledgerRepo.GetPayoutBatchByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPayoutBatchNotFound
+		}
+		return nil, err
+	}
+
+	resp := mapPayoutBatchToResponse(batch)
+	resp.PayoutCount = len(batch.Payouts)
+	resp.Payouts = make([]response.PayoutResponse, 0, len(batch.Payouts))
+	for i := range batch.Payouts {
+		resp.Payouts = append(resp.Payouts, mapPayoutToResponse(&batch.Payouts[i]))
+	}
+	return &resp, nil
+}
+
+// ProcessPayoutBatch marks a batch as sent to the bank, its payouts are then settled one by one
+func (u *ledgerUsecase) ProcessPayoutBatch(ctx context.Context, id int) error {
+	err := u.ledgerRepo.ChangeBatchStatus(ctx, id, entity.PayoutBatchPending, entity.PayoutBatchProcessing)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if _, err := u.ledgerRepo.GetPayoutBatchByID(ctx, id); errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPayoutBatchNotFound
+		}
+		return ErrInvalidPayoutStatus
+	}
+	return err
+}
+
+// CancelPayoutBatch cancels a batch not sent to the bank yet, the amounts go back to the sellers' balances
+func (u *ledgerUsecase) CancelPayoutBatch(ctx context.Context, id int) error {
+	batch, err := u.ledgerRepo.GetPayoutBatchByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPayoutBatchNotFound
+		}
+		return err
+	}
+	if batch.Status != entity.PayoutBatchPending {
+		return ErrInvalidPayoutStatus
+	}
+
+	entries := make([]entity.LedgerEntry, 0, len(batch.Payouts))
+	for i := range batch.Payouts {
+		payout := &batch.Payouts[i]
+		if payout.Status != entity.PayoutPending {
+			continue
+		}
+		entries = append(entries, payoutEntry(entity.LedgerEntryPayoutReversed, payout,
+			entity.AccountPayoutsInTransit, entity.AccountSellerPayable,
+			fmt.Sprintf("Payout #%d cancelled with batch #%d", payout.ID, batch.ID)))
+	}
+
+	err = u.ledgerRepo.CancelPayoutBatch(ctx, id, entries)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidPayoutStatus
+	}
+	return err
+}
+
+// SettlePayout records the bank's answer for a payout. A failed payout returns to the seller's
+// balance and is paid in the next batch.
+func (u *ledgerUsecase) SettlePayout(ctx context.Context, req request.SettlePayout) error {
+	if req.Status == entity.PayoutPaid && req.TransferReference == "" {
+		return fmt.Errorf("%w: a transfer reference is required", ErrInvalidPayoutStatus)
+	}
+	if req.Status == entity.PayoutFailed && req.FailureReason == "" {
+		return fmt.Errorf("%w: a failure reason is required", ErrInvalidPayoutStatus)
+	}
+
+	payout, err := u.ledgerRepo.GetPayoutByID(ctx, req.PayoutID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPayoutNotFound
+		}
+		return err
+	}
+	batch, err := u.ledgerRepo.GetPayoutBatchByID(ctx, payout.BatchID)
+	if err != nil {
+		return err
+	}
+	if batch.Status != entity.PayoutBatchProcessing || payout.Status != entity.PayoutPending {
+		return ErrInvalidPayoutStatus
+	}
+
+	now := time.Now()
+	payout.Status = req.Status
+	payout.TransferReference = req.TransferReference
+	payout.FailureReason = req.FailureReason
+	payout.SettledAt = &now
+
+	var entry entity.LedgerEntry
+	if req.Status == entity.PayoutPaid {
+		entry = payoutEntry(entity.LedgerEntryPayoutPaid, payout, entity.AccountPayoutsInTransit, entity.AccountPlatformCash,
+			fmt.Sprintf("Payout #%d paid, reference %s", payout.ID, req.TransferReference))
+	} else {
+		entry = payoutEntry(entity.LedgerEntryPayoutReversed, payout, entity.AccountPayoutsInTransit, entity.AccountSellerPayable,
+			fmt.Sprintf("Payout #%d failed: %s", payout.ID, req.FailureReason))
+	}
+
+	if err := u.ledgerRepo.SettlePayout(ctx, payout, &entry); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidPayoutStatus
+		}
+		return err
+	}
+
+	if req.Status == entity.PayoutPaid {
+		u.notifyPayout(ctx, payout, entity.NotificationPayoutPaid, "Payout sent",
+			fmt.Sprintf("A payout of %.2f was sent to your bank account, reference %s.", payout.Amount, req.TransferReference))
+	} else {
+		u.notifyPayout(ctx, payout, entity.NotificationPayoutFailed, "Payout failed",
+			fmt.Sprintf("A payout of %.2f could not be sent: %s. It will be retried with the next payout.", payout.Amount, req.FailureReason))
+	}
+	return nil
+}
+
+func (u *ledgerUsecase) RunPayoutSchedule(ctx context.Context) {
+	interval := config.MarketplaceConfig().PayoutInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			batch, err := u.CreatePayoutBatch(ctx, request.CreatePayoutBatch{Note: "Scheduled payout"})
+			switch {
+			case errors.Is(err, ErrNothingToPay):
+				logger.Info("Scheduled payout: no seller is due a payout")
+			case err != nil:
+				logger.Errorf("Scheduled payout failed: %v", err)
+			default:
+				logger.Infof("Scheduled payout batch %d created for %d sellers", batch.ID, batch.PayoutCount)
+			}
+		}
+	}
+}
+
+func (u *ledgerUsecase) notifyPayout(ctx context.Context, payout *entity.Payout, kind, title, message string) {
+	payoutID := payout.ID
+	err := u.notificationUsecase.Notify(ctx, &entity.Notification{
+		UserID:        payout.SellerID,
+		Type:          kind,
+		Title:         title,
+		Message:       message,
+		ReferenceType: "payout",
+		ReferenceID:   &payoutID,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to notify seller", "payout_id", payout.ID, "error", err)
+	}
+}
+
+// Admin - reconciliation
+func (u *ledgerUsecase) GetReconciliationReport(
+	ctx context.Context,
+	req request.ReconciliationReport,
+) (*response.ReconciliationReportResponse, error) {
+	from, to := reportPeriod(req.From, req.To)
+
+	accounts, err := u.ledgerRepo.GetAccountTotals(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	unbalanced, err := u.ledgerRepo.GetUnbalancedEntries(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := u.ledgerRepo.GetOrderSettlements(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := u.ledgerRepo.GetPayoutTotals(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.ReconciliationReportResponse{
+		From:              from,
+		To:                to,
+		Accounts:          make([]response.LedgerAccountResponse, 0, len(accounts)),
+		UnbalancedEntries: unbalanced,
+		OrdersChecked:     len(orders),
+		UnsettledOrders:   []response.OrderSettlementResponse{},
+		MismatchedOrders:  []response.OrderSettlementResponse{},
+		Payouts:           make([]response.PayoutStatusTotalResponse, 0, len(payouts)),
+	}
+	if resp.UnbalancedEntries == nil {
+		resp.UnbalancedEntries = []int{}
+	}
+	for _, account := range accounts {
+		resp.Accounts = append(resp.Accounts, response.LedgerAccountResponse{
+			Account: account.Account,
+			Debit:   roundMoney(account.Debit),
+			Credit:  roundMoney(account.Credit),
+			Balance: roundMoney(account.Debit - account.Credit),
+		})
+		resp.TotalDebit += account.Debit
+		resp.TotalCredit += account.Credit
+	}
+	resp.TotalDebit = roundMoney(resp.TotalDebit)
+	resp.TotalCredit = roundMoney(resp.TotalCredit)
+
+	for _, order := range orders {
+		settlement := response.OrderSettlementResponse{
+			OrderID:    order.OrderID,
+			Status:     order.Status,
+			Collected:  roundMoney(order.TotalAmount - order.RefundedAmount),
+			LedgerCash: roundMoney(order.LedgerCash),
+		}
+		settlement.Difference = roundMoney(settlement.LedgerCash - settlement.Collected)
+		switch {
+		case !order.Settled:
+			resp.UnsettledOrders = append(resp.UnsettledOrders, settlement)
+		case settlement.Difference != 0:
+			resp.MismatchedOrders = append(resp.MismatchedOrders, settlement)
+		}
+	}
+
+	for _, total := range payouts {
+		resp.Payouts = append(resp.Payouts, response.PayoutStatusTotalResponse{
+			Status: total.Status,
+			Count:  total.Count,
+			Amount: roundMoney(total.Amount),
+		})
+	}
+
+	resp.Balanced = resp.TotalDebit == resp.TotalCredit &&
+		len(resp.UnbalancedEntries) == 0 &&
+		len(resp.UnsettledOrders) == 0 &&
+		len(resp.MismatchedOrders) == 0
+	return resp, nil
+}
+
+func (u *ledgerUsecase) getOrder(ctx context.Context, orderID int) (*entity.Order, error) {
+	order, err := u.ledgerRepo.GetOrderForSettlement(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// reportPeriod turns optional inclusive dates into a [from, to) range, the last 30 days by default
+func reportPeriod(fromDate, toDate *time.Time) (time.Time, time.Time) {
+	to := time.Now()
+	if toDate != nil {
+		to = toDate.AddDate(0, 0, 1)
+	}
+	from := to.Add(-defaultReportPeriod)
+	if fromDate != nil {
+		from = *fromDate
+	}
+	return from, to
+}
+
+func roundMoney(amount float64) float64 {
+	return mathutil.RoundToFloat(amount, 2)
+}
+
+func mapCommissionRuleToResponse(rule *entity.CommissionRule) response.CommissionRuleResponse {
+	resp := response.CommissionRuleResponse{
+		ID:         rule.ID,
+		SellerID:   rule.SellerID,
+		CategoryID: rule.CategoryID,
+		Rate:       rule.Rate,
+		UpdatedAt:  rule.UpdatedAt,
+	}
+	if rule.Seller != nil {
+		resp.SellerName = rule.Seller.FullName
+	}
+	if rule.Category != nil {
+		resp.CategoryName = rule.Category.Name
+	}
+	return resp
+}
+
+func mapPayoutBatchToResponse(batch *entity.PayoutBatch) response.PayoutBatchResponse {
+	return response.PayoutBatchResponse{
+		ID:          batch.ID,
+		PeriodEnd:   batch.PeriodEnd,
+		Status:      batch.Status,
+		TotalAmount: batch.TotalAmount,
+		PayoutCount: len(batch.Payouts),
+		CreatedBy:   batch.CreatedBy,
+		Note:        batch.Note,
+		CreatedAt:   batch.CreatedAt,
+		CompletedAt: batch.CompletedAt,
+	}
+}
+
+func mapPayoutToResponse(payout *entity.Payout) response.PayoutResponse {
+	resp := response.PayoutResponse{
+		ID:                payout.ID,
+		BatchID:           payout.BatchID,
+		SellerID:          payout.SellerID,
+		Amount:            payout.Amount,
+		Status:            payout.Status,
+		BankName:          payout.BankName,
+		BankAccountName:   payout.BankAccountName,
+		BankAccountNumber: payout.BankAccountNumber,
+		TransferReference: payout.TransferReference,
+		FailureReason:     payout.FailureReason,
+		CreatedAt:         payout.CreatedAt,
+		SettledAt:         payout.SettledAt,
+	}
+	if payout.Seller != nil {
+		resp.SellerName = payout.Seller.FullName
+	}
+	return resp
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"sort"
+
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+)
+
+func mapProductsToResponse(products []entity.Product) []response.ProductResponse {
+	result := make([]response.ProductResponse, 0, len(products))
+	for _, p := range products {
+		result = append(result, mapProductToResponse(&p))
+	}
+	return result
+}
+
+func mapProductToResponse(product *entity.Product) response.ProductResponse {
+	resp := response.ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		BasePrice:   product.BasePrice,
+		IsActive:    product.IsActive,
+		CreatedAt:   product.CreatedAt,
+
+		AverageRating: product.AverageRating,
+		ReviewCount:   product.ReviewCount,
+		RatingHistogram: []response.RatingCountResponse{
+			{Rating: 5, Count: int64(product.Rating5Count)},
+			{Rating: 4, Count: int64(product.Rating4Count)},
+			{Rating: 3, Count: int64(product.Rating3Count)},
+			{Rating: 2, Count: int64(product.Rating2Count)},
+			{Rating: 1, Count: int64(product.Rating1Count)},
+		},
+	}
+
+	if product.Category != nil {
+		categoryName := product.Category.Name
+		resp.Category = &categoryName
+	}
+
+	if product.Brand != nil {
+		brandName := product.Brand.Name
+		resp.Brand = &brandName
+	}
+
+	if len(product.Variants) > 0 {
+		variants := make([]response.ProductVariantResponse, 0, len(product.Variants))
+		for _, v := range product.Variants {
+			variants = append(variants, mapVariantToResponse(&v))
+		}
+		resp.Variants = variants
+	}
+
+	return resp
+}
+
+func mapVariantToResponse(v *entity.ProductVariant) response.ProductVariantResponse {
+	resp := response.ProductVariantResponse{
+		ID:             v.ID,
+		ProductID:      v.ProductID,
+		Layout:         v.Layout,
+		ConnectionType: v.ConnectionType,
+		Hotswap:        v.Hotswap,
+		LedType:        v.LedType,
+		Price:          v.Price,
+		Stock:          v.Stock,
+		SKU:            v.SKU,
+		Attributes:     mapVariantAttributesToResponse(v.Attributes),
+	}
+	if v.Switch != nil {
+		switchName := v.Switch.Name
+		resp.Switch = &switchName
+	}
+	return resp
+}
+
+// mapVariantAttributesToResponse orders attribute values like their definitions
+func mapVariantAttributesToResponse(attributes []entity.ProductVariantAttribute) []response.VariantAttributeResponse {
+	withDefinition := make([]entity.ProductVariantAttribute, 0, len(attributes))
+	for _, attr := range attributes {
+		if attr.Definition != nil {
+			withDefinition = append(withDefinition, attr)
+		}
+	}
+	sort.Slice(withDefinition, func(i, j int) bool {
+		a, b := withDefinition[i].Definition, withDefinition[j].Definition
+		if a.DisplayOrder != b.DisplayOrder {
+			return a.DisplayOrder < b.DisplayOrder
+		}
+		return a.Code < b.Code
+	})
+
+	result := make([]response.VariantAttributeResponse, 0, len(withDefinition))
+	for _, attr := range withDefinition {
+		result = append(result, response.VariantAttributeResponse{
+			Code:  attr.Definition.Code,
+			Name:  attr.Definition.Name,
+			Type:  attr.Definition.Type,
+			Value: attr.Value,
+			Unit:  attr.Definition.Unit,
+		})
+	}
+	return result
+}
+
+func mapProductRevisionToResponse(r *entity.ProductRevision) response.ProductRevisionResponse {
+	return response.ProductRevisionResponse{
+		ID:             r.ID,
+		ProductID:      r.ProductID,
+		RevisionNumber: r.RevisionNumber,
+		Status:         r.Status,
+		SubmittedBy:    r.SubmittedBy,
+		Name:           r.Name,
+		CategoryID:     r.CategoryID,
+		Category:       revisionCategoryName(r),
+		BrandID:        r.BrandID,
+		Brand:          revisionBrandName(r),
+		Description:    r.Description,
+		BasePrice:      r.BasePrice,
+		ReviewerID:     r.ReviewerID,
+		ReviewNote:     r.ReviewNote,
+		CreatedAt:      r.CreatedAt,
+		ReviewedAt:     r.ReviewedAt,
+	}
+}
+
+func revisionCategoryName(r *entity.ProductRevision) *string {
+	if r.Category == nil {
+		return nil
+	}
+	name := r.Category.Name
+	return &name
+}
+
+func revisionBrandName(r *entity.ProductRevision) *string {
+	if r.Brand == nil {
+		return nil
+	}
+	name := r.Brand.Name
+	return &name
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/leehai1107/chophimco-server/pkg/imaging"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/storage"
+	"github.com/leehai1107/chophimco-server/pkg/xhttp"
+)
+
+// Rendition names, every stored image also has an "original"
+const (
+	ImageSizeOriginal  = "original"
+	ImageSizeThumbnail = "thumbnail"
+	ImageSizeSmall     = "small"
+	ImageSizeMedium    = "medium"
+	ImageSizeLarge     = "large"
+)
+
+var productImageSizes = []imaging.Size{
+	{Name: ImageSizeThumbnail, MaxSize: 150},
+	{Name: ImageSizeSmall, MaxSize: 320},
+	{Name: ImageSizeMedium, MaxSize: 640},
+	{Name: ImageSizeLarge, MaxSize: 1280},
+}
+
+var reviewImageSizes = []imaging.Size{
+	{Name: ImageSizeThumbnail, MaxSize: 150},
+	{Name: ImageSizeMedium, MaxSize: 640},
+}
+
+var logoSizes = []imaging.Size{
+	{Name: ImageSizeThumbnail, MaxSize: 96},
+	{Name: ImageSizeMedium, MaxSize: 256},
+}
+
+var (
+	ErrInvalidUpload = errors.New("invalid image upload")
+	ErrImageNotFound = errors.New("image not found")
+)
+
+// storedImage is an uploaded image saved with all its renditions below a common key prefix
+type storedImage struct {
+	key         string
+	contentType string
+	width       int
+	height      int
+	size        int64
+	urls        map[string]string
+}
+
+// storeImage validates and resizes an upload, then stores the original and every size
+// under dir/<random id>/. Nothing is left behind when a write fails.
+func storeImage(
+	ctx context.Context,
+	backend storage.Backend,
+	maxBytes int64,
+	dir string,
+	data []byte,
+	sizes []imaging.Size,
+) (*storedImage, error) {
+	original, renditions, err := imaging.Process(data, maxBytes, sizes)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedType) || errors.Is(err, imaging.ErrTooLarge) ||
+			errors.Is(err, imaging.ErrInvalidImage) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+		}
+		return nil, err
+	}
+
+	id, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &storedImage{
+		key:         path.Join(dir, id),
+		contentType: original.ContentType,
+		width:       original.Width,
+		height:      original.Height,
+		size:        int64(len(original.Data)),
+		urls:        make(map[string]string, len(renditions)+1),
+	}
+
+	var written []string
+	for _, r := range append([]imaging.Rendition{original}, renditions...) {
+		key := stored.key + "/" + r.Name + r.Extension
+		if err := backend.Put(ctx, key, bytes.NewReader(r.Data), int64(len(r.Data)), r.ContentType); err != nil {
+			if cleanupErr := backend.Delete(ctx, written...); cleanupErr != nil {
+				logger.Errorf("Failed to clean up partial upload %s: %v", stored.key, cleanupErr)
+			}
+			return nil, err
+		}
+		written = append(written, key)
+		stored.urls[r.Name] = backend.URL(key)
+	}
+	return stored, nil
+}
+
+// removeStoredImage deletes everything storeImage wrote for a key prefix
+func removeStoredImage(ctx context.Context, backend storage.Backend, key, contentType string, sizes []imaging.Size) error {
+	if key == "" {
+		return nil
+	}
+	ext := ".jpg"
+	if contentType == xhttp.MIMEPNG {
+		ext = ".png"
+	}
+
+	keys := []string{key + "/" + ImageSizeOriginal + ext}
+	for _, size := range sizes {
+		keys = append(keys, key+"/"+size.Name+ext)
+	}
+	return backend.Delete(ctx, keys...)
+}
+
+// randomKey returns an unguessable storage key segment
+func randomKey() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,335 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVoteOwnReview   = errors.New("you cannot vote on your own review")
+	ErrReportOwnReview = errors.New("you cannot report your own review")
+	ErrAlreadyReported = errors.New("you have already reported this review")
+)
+
+// linkPattern matches URLs and bare domain names
+var linkPattern = regexp.MustCompile(
+	`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|info|biz|io|co|me|vn|xyz|ru|cn|shop|top|site|online)\b`)
+
+type IModerationUsecase interface {
+	VoteHelpful(ctx context.Context, userID int, reviewID int) (*response.HelpfulVoteResponse, error)
+	RemoveHelpfulVote(ctx context.Context, userID int, reviewID int) (*response.HelpfulVoteResponse, error)
+	ReportReview(ctx context.Context, userID int, req request.ReportReview) error
+
+	// Admin
+	GetQueue(ctx context.Context, req request.GetModerationQueue) (*response.ModerationQueueResponse, error)
+	GetReports(ctx context.Context, req request.GetReviewReports) ([]response.ReviewReportResponse, error)
+	ModerateReview(ctx context.Context, adminID int, req request.ModerateReview) error
+}
+
+type moderationUsecase struct {
+	moderationRepo      repository.IModerationRepo
+	notificationUsecase INotificationUsecase
+}
+
+func NewModerationUsecase(
+	moderationRepo repository.IModerationRepo,
+	notificationUsecase INotificationUsecase,
+) IModerationUsecase {
+	return &moderationUsecase{
+		moderationRepo:      moderationRepo,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+// screenReview runs the automatic checks on the text of a new or edited review. It returns the
+// status the review starts in, and why it was held.
+func screenReview(text string) (string, string) {
+	cfg := config.ModerationConfig()
+
+	var reasons []string
+	if cfg.HoldLinks && linkPattern.MatchString(text) {
+		reasons = append(reasons, "contains a link")
+	}
+	if word := findBannedWord(text, cfg.BannedWords); word != "" {
+		reasons = append(reasons, fmt.Sprintf("contains banned word %q", word))
+	}
+
+	if len(reasons) == 0 {
+		return entity.ReviewStatusPublished, ""
+	}
+	return entity.ReviewStatusPending, strings.Join(reasons, "; ")
+}
+
+// findBannedWord returns the first banned word or phrase found as whole words in the text
+func findBannedWord(text string, banned []string) string {
+	words := " " + strings.Join(splitWords(text), " ") + " "
+	for _, entry := range banned {
+		term := strings.Join(splitWords(entry), " ")
+		if term != "" && strings.Contains(words, " "+term+" ") {
+			return strings.TrimSpace(entry)
+		}
+	}
+	return ""
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func (u *moderationUsecase) VoteHelpful(ctx context.Context, userID int, reviewID int) (*response.HelpfulVoteResponse, error) {
+	if _, err := u.getVotableReview(ctx, userID, reviewID); err != nil {
+		return nil, err
+	}
+
+	_, err := u.moderationRepo.AddHelpfulVote(ctx, &entity.ReviewVote{
+		ReviewID:  reviewID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.helpfulVoteResponse(ctx, reviewID, true)
+}
+
+func (u *moderationUsecase) RemoveHelpfulVote(
+	ctx context.Context,
+	userID int,
+	reviewID int,
+) (*response.HelpfulVoteResponse, error) {
+	if _, err := u.getVotableReview(ctx, userID, reviewID); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.moderationRepo.RemoveHelpfulVote(ctx, reviewID, userID); err != nil {
+		return nil, err
+	}
+	return u.helpfulVoteResponse(ctx, reviewID, false)
+}
+
+func (u *moderationUsecase) getVotableReview(ctx context.Context, userID int, reviewID int) (*repository.ReviewTarget, error) {
+	target, err := u.getPublishedTarget(ctx, entity.ReviewTargetProduct, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if target.AuthorID == userID {
+		return nil, ErrVoteOwnReview
+	}
+	return target, nil
+}
+
+func (u *moderationUsecase) helpfulVoteResponse(ctx context.Context, reviewID int, voted bool) (*response.HelpfulVoteResponse, error) {
+	count, err := u.moderationRepo.GetHelpfulCount(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	return &response.HelpfulVoteResponse{
+		ReviewID:     reviewID,
+		Voted:        voted,
+		HelpfulCount: count,
+	}, nil
+}
+
+func (u *moderationUsecase) ReportReview(ctx context.Context, userID int, req request.ReportReview) error {
+	target, err := u.getPublishedTarget(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		return err
+	}
+	if target.AuthorID == userID {
+		return ErrReportOwnReview
+	}
+
+	created, err := u.moderationRepo.CreateReport(ctx, &entity.ReviewReport{
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		ReporterID: userID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     entity.ReportStatusOpen,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrAlreadyReported
+	}
+
+	// Enough reports hide the review until a moderator looks at it
+	threshold := config.ModerationConfig().ReportThreshold
+	if threshold <= 0 {
+		return nil
+	}
+	open, err := u.moderationRepo.CountOpenReports(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		return err
+	}
+	if open < int64(threshold) {
+		return nil
+	}
+	return u.moderationRepo.SetReviewModeration(ctx, req.TargetType, req.TargetID, entity.ReviewModeration{
+		Status:     entity.ReviewStatusPending,
+		FlagReason: fmt.Sprintf("reported by %d users", open),
+	})
+}
+
+// getPublishedTarget loads a review that is visible to users, held and removed ones count as missing
+func (u *moderationUsecase) getPublishedTarget(ctx context.Context, targetType string, id int) (*repository.ReviewTarget, error) {
+	target, err := u.getTarget(ctx, targetType, id)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != entity.ReviewStatusPublished {
+		return nil, ErrReviewNotFound
+	}
+	return target, nil
+}
+
+func (u *moderationUsecase) getTarget(ctx context.Context, targetType string, id int) (*repository.ReviewTarget, error) {
+	target, err := u.moderationRepo.GetReviewTarget(ctx, targetType, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReviewNotFound
+	}
+	return target, err
+}
+
+func (u *moderationUsecase) GetQueue(
+	ctx context.Context,
+	req request.GetModerationQueue,
+) (*response.ModerationQueueResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	rows, total, err := u.moderationRepo.GetQueue(ctx, req.TargetType, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.ModerationQueueResponse{
+		Items:      make([]response.ModerationQueueItem, 0, len(rows)),
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+	}
+	for _, row := range rows {
+		resp.Items = append(resp.Items, response.ModerationQueueItem{
+			TargetType:  req.TargetType,
+			TargetID:    row.ID,
+			AuthorID:    row.AuthorID,
+			AuthorName:  row.AuthorName,
+			SubjectID:   row.SubjectID,
+			SubjectName: row.SubjectName,
+			Rating:      row.Rating,
+			Comment:     row.Comment,
+			Status:      row.Status,
+			FlagReason:  row.FlagReason,
+			OpenReports: row.OpenReports,
+			CreatedAt:   row.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (u *moderationUsecase) GetReports(
+	ctx context.Context,
+	req request.GetReviewReports,
+) ([]response.ReviewReportResponse, error) {
+	if _, err := u.getTarget(ctx, req.TargetType, req.TargetID); err != nil {
+		return nil, err
+	}
+
+	reports, err := u.moderationRepo.GetReports(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.ReviewReportResponse, 0, len(reports))
+	for _, report := range reports {
+		resp := response.ReviewReportResponse{
+			ID:         report.ID,
+			ReporterID: report.ReporterID,
+			Reason:     report.Reason,
+			Details:    report.Details,
+			Status:     report.Status,
+			CreatedAt:  report.CreatedAt,
+			ResolvedAt: report.ResolvedAt,
+		}
+		if report.Reporter != nil {
+			resp.ReporterName = report.Reporter.FullName
+		}
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+func (u *moderationUsecase) ModerateReview(ctx context.Context, adminID int, req request.ModerateReview) error {
+	target, err := u.getTarget(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		return err
+	}
+
+	status, reportStatus := entity.ReviewStatusPublished, entity.ReportStatusDismissed
+	if req.Action == request.ModerationReject {
+		status, reportStatus = entity.ReviewStatusRejected, entity.ReportStatusUpheld
+	}
+
+	now := time.Now()
+	err = u.moderationRepo.SetReviewModeration(ctx, req.TargetType, req.TargetID, entity.ReviewModeration{
+		Status:         status,
+		FlagReason:     target.FlagReason,
+		ModeratedBy:    &adminID,
+		ModeratedAt:    &now,
+		ModerationNote: req.Note,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := u.moderationRepo.ResolveReports(ctx, req.TargetType, req.TargetID, reportStatus, adminID); err != nil {
+		return err
+	}
+
+	if status == entity.ReviewStatusRejected && target.Status != entity.ReviewStatusRejected {
+		u.notifyAuthor(ctx, req.TargetType, target, req.Note)
+	}
+	return nil
+}
+
+// notifyAuthor does not fail the decision, the review is already removed
+func (u *moderationUsecase) notifyAuthor(ctx context.Context, targetType string, target *repository.ReviewTarget, note string) {
+	message := "A moderator removed your review because it breaks the review guidelines."
+	if note != "" {
+		message = fmt.Sprintf("A moderator removed your review: %s", note)
+	}
+
+	reviewID := target.ID
+	err := u.notificationUsecase.Notify(ctx, &entity.Notification{
+		UserID:        target.AuthorID,
+		Type:          entity.NotificationReviewRemoved,
+		Title:         "Your review was removed",
+		Message:       message,
+		ReferenceType: targetType,
+		ReferenceID:   &reviewID,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to notify review author", "target_type", targetType,
+			"target_id", target.ID, "error", err)
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/websocket"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type INotificationUsecase interface {
+	// Notify stores a notification and pushes it to the user's open websockets
+	Notify(ctx context.Context, notification *entity.Notification) error
+	// NotifyAll sends a copy of the notification to each user
+	NotifyAll(ctx context.Context, userIDs []int, notification entity.Notification) error
+	GetNotifications(ctx context.Context, userID int, req request.GetNotifications) (*response.NotificationListResponse, error)
+	MarkRead(ctx context.Context, userID int, id int) error
+	MarkAllRead(ctx context.Context, userID int) error
+}
+
+type notificationUsecase struct {
+	notificationRepo repository.INotificationRepo
+}
+
+func NewNotificationUsecase(notificationRepo repository.INotificationRepo) INotificationUsecase {
+	return &notificationUsecase{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// NotificationRoom is the websocket room receiving the notifications of a user
+func NotificationRoom(userID int) string {
+	return fmt.Sprintf("user_%d", userID)
+}
+
+func (u *notificationUsecase) Notify(ctx context.Context, notification *entity.Notification) error {
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+	if err := u.notificationRepo.CreateNotification(ctx, notification); err != nil {
+		return err
+	}
+
+	publishNotification(ctx, notification)
+	return nil
+}
+
+func (u *notificationUsecase) NotifyAll(ctx context.Context, userIDs []int, notification entity.Notification) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	notifications := make([]entity.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		n := notification
+		n.UserID = userID
+		notifications = append(notifications, n)
+	}
+	if err := u.notificationRepo.CreateNotifications(ctx, notifications); err != nil {
+		return err
+	}
+
+	for i := range notifications {
+		publishNotification(ctx, &notifications[i])
+	}
+	return nil
+}
+
+// publishNotification pushes a stored notification to the user's open websockets
+func publishNotification(ctx context.Context, notification *entity.Notification) {
+	content, err := json.Marshal(mapNotificationToResponse(notification))
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to encode notification", "error", err)
+		return
+	}
+	websocket.Publish(websocket.Message{
+		Type:      websocket.MessageTypeNotification.Value(),
+		Sender:    "server",
+		Recipient: NotificationRoom(notification.UserID),
+		Content:   string(content),
+		ID:        strconv.Itoa(notification.ID),
+	})
+}
+
+func (u *notificationUsecase) GetNotifications(
+	ctx context.Context,
+	userID int,
+	req request.GetNotifications,
+) (*response.NotificationListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	notifications, total, err := u.notificationRepo.GetNotifications(
+		ctx, userID, req.UnreadOnly, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := u.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.NotificationListResponse{
+		Notifications: make([]response.NotificationResponse, 0, len(notifications)),
+		Unread:        unread,
+		Pagination:    response.NewPagination(req.Page, req.PageSize, total),
+	}
+	for i := range notifications {
+		resp.Notifications = append(resp.Notifications, mapNotificationToResponse(&notifications[i]))
+	}
+	return resp, nil
+}
+
+func (u *notificationUsecase) MarkRead(ctx context.Context, userID int, id int) error {
+	err := u.notificationRepo.MarkRead(ctx, userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotificationNotFound
+	}
+	return err
+}
+
+func (u *notificationUsecase) MarkAllRead(ctx context.Context, userID int) error {
+	return u.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+func mapNotificationToResponse(n *entity.Notification) response.NotificationResponse {
+	return response.NotificationResponse{
+		ID:            n.ID,
+		Type:          n.Type,
+		Title:         n.Title,
+		Message:       n.Message,
+		ReferenceType: n.ReferenceType,
+		ReferenceID:   n.ReferenceID,
+		IsRead:        n.IsRead,
+		CreatedAt:     n.CreatedAt,
+		ReadAt:        n.ReadAt,
+	}
+}
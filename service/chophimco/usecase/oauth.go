@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/oauth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOAuthProviderNotFound  = errors.New("login provider not found")
+	ErrOAuthInvalidState      = errors.New("the login expired or was started elsewhere, please try again")
+	ErrOAuthDenied            = errors.New("the login was cancelled at the provider")
+	ErrOAuthFailed            = errors.New("the provider could not confirm the login, please try again")
+	ErrOAuthEmailNotVerified  = errors.New("the provider did not share a verified email address")
+	ErrOAuthAccountUnverified = errors.New("an account with this email exists but its email is not verified, " +
+		"log in with its password or reset the password to link it")
+)
+
+type IOAuthUsecase interface {
+	// GetProviders lists the enabled login providers
+	GetProviders(ctx context.Context) []string
+	// StartLogin records a login attempt and returns the provider URL to send the browser to
+	StartLogin(ctx context.Context, provider string) (*response.OAuthStartResponse, error)
+	// CompleteLogin handles the provider's redirect back. The provider account logs into the
+	// user it is linked to, is linked to the user with the same verified email, or else signs
+	// up a new user without a password. Users with two-factor authentication get a challenge.
+	CompleteLogin(ctx context.Context, req request.OAuthCallback) (*response.LoginResponse, error)
+}
+
+type oauthUsecase struct {
+	oauthRepo        repository.IOAuthRepo
+	userRepo         repository.IUserRepo
+	providers        oauth.Providers
+	twoFactorUsecase ITwoFactorUsecase
+}
+
+func NewOAuthUsecase(
+	oauthRepo repository.IOAuthRepo,
+	userRepo repository.IUserRepo,
+	providers oauth.Providers,
+	twoFactorUsecase ITwoFactorUsecase,
+) IOAuthUsecase {
+	return &oauthUsecase{
+		oauthRepo:        oauthRepo,
+		userRepo:         userRepo,
+		providers:        providers,
+		twoFactorUsecase: twoFactorUsecase,
+	}
+}
+
+func (u *oauthUsecase) GetProviders(ctx context.Context) []string {
+	return u.providers.Names()
+}
+
+func (u *oauthUsecase) StartLogin(ctx context.Context, providerName string) (*response.OAuthStartResponse, error) {
+	provider, err := u.providers.Get(providerName)
+	if err != nil {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	var values [3]string // state, PKCE verifier, nonce
+	for i := range values {
+		if values[i], err = oauth.RandomToken(); err != nil {
+			return nil, err
+		}
+	}
+	state, verifier, nonce := values[0], values[1], values[2]
+
+	now := time.Now()
+	err = u.oauthRepo.CreateState(ctx, &entity.OAuthState{
+		ID:           state,
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(config.OAuthConfig().StateTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oauth.CodeChallenge(verifier))
+	if err != nil {
+		return nil, err
+	}
+	return &response.OAuthStartResponse{URL: authURL, State: state}, nil
+}
+
+func (u *oauthUsecase) CompleteLogin(ctx context.Context, req request.OAuthCallback) (*response.LoginResponse, error) {
+	provider, err := u.providers.Get(req.Provider)
+	if err != nil {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	// The state must come back to the browser that started the login, or an attacker could
+	// log a victim into the attacker's account
+	if req.State == "" || subtle.ConstantTimeCompare([]byte(req.State), []byte(req.BrowserState)) != 1 {
+		return nil, ErrOAuthInvalidState
+	}
+	state, err := u.oauthRepo.TakeState(ctx, req.State)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOAuthInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state.Provider != req.Provider || time.Now().After(state.ExpiresAt) {
+		return nil, ErrOAuthInvalidState
+	}
+
+	if req.Error != "" || req.Code == "" {
+		return nil, ErrOAuthDenied
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		logger.EnhanceWith(ctx).Warnw("Social login exchange failed", "provider", req.Provider, "error", err)
+		return nil, ErrOAuthFailed
+	}
+
+	user, err := u.resolveUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider vouches for the password, not for our second factor
+	return u.twoFactorUsecase.BeginLogin(ctx, user, req.UserAgent, req.IPAddress)
+}
+
+// resolveUser finds or creates the user a provider account logs in as
+func (u *oauthUsecase) resolveUser(ctx context.Context, identity *oauth.Identity) (*entity.User, error) {
+	email := strings.TrimSpace(identity.Email)
+
+	linked, err := u.oauthRepo.GetIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil && linked.User != nil {
+		if err := u.oauthRepo.TouchIdentity(ctx, linked.ID, email); err != nil {
+			return nil, err
+		}
+		return linked.User, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Linking trusts the provider's word that the address is theirs
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrOAuthEmailNotVerified
+	}
+
+	now := time.Now()
+	record := &entity.UserIdentity{
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       email,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	}
+
+	user, err := u.userRepo.GetUserByEmail(email)
+	if err == nil {
+		// Whoever registered an unverified account may not own the address, linking would
+		// hand them the provider account's sessions
+		if user.EmailVerifiedAt == nil {
+			return nil, ErrOAuthAccountUnverified
+		}
+		record.UserID = user.ID
+		// A concurrent login may have linked it first, which is just as good
+		if _, err := u.oauthRepo.LinkIdentity(ctx, record); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// First login with this provider account, sign up without a password. One can be set
+	// through the password reset email.
+	user = &entity.User{
+		RoleID:          2, // customer
+		FullName:        identity.Name,
+		Email:           email,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+	}
+	if err := u.oauthRepo.CreateUserWithIdentity(ctx, user, record); err != nil {
+		return nil, err
+	}
+	return u.userRepo.GetUserByID(user.ID)
+}
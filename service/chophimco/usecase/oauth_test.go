@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/oauth"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+)
+
+// stubProvider stands in for a provider whose ID tokens are already verified, see
+// pkg/oauth for the tests against a fake OpenID Connect provider
+type stubProvider struct {
+	name     string
+	identity oauth.Identity
+	// challenges by state, as the provider would remember them
+	challenges map[string]string
+	nonces     map[string]string
+}
+
+func newStubProvider(identity oauth.Identity) *stubProvider {
+	identity.Provider = oauth.ProviderGoogle
+	return &stubProvider{
+		name:       oauth.ProviderGoogle,
+		identity:   identity,
+		challenges: map[string]string{},
+		nonces:     map[string]string{},
+	}
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	p.challenges[state] = codeChallenge
+	p.nonces[state] = nonce
+	return "https://accounts.example.com/authorize?state=" + state, nil
+}
+
+// Exchange accepts the code "code-<state>" with the verifier and nonce of that state
+func (p *stubProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*oauth.Identity, error) {
+	for state, challenge := range p.challenges {
+		if code != "code-"+state {
+			continue
+		}
+		if oauth.CodeChallenge(codeVerifier) != challenge || nonce != p.nonces[state] {
+			return nil, errors.New("invalid_grant")
+		}
+		identity := p.identity
+		return &identity, nil
+	}
+	return nil, errors.New("invalid_grant")
+}
+
+type oauthFixture struct {
+	store    *memoryStore
+	provider *stubProvider
+	oauth    *oauthUsecase
+}
+
+func newOAuthFixture(t *testing.T, identity oauth.Identity) *oauthFixture {
+	t.Helper()
+	jwtService, err := auth.NewJWTService()
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+	store := newMemoryStore()
+	provider := newStubProvider(identity)
+	return &oauthFixture{
+		store:    store,
+		provider: provider,
+		oauth: NewOAuthUsecase(
+			memoryOAuthRepo{store},
+			memoryUserRepo{store},
+			oauth.Providers{provider.Name(): provider},
+			NewTwoFactorUsecase(
+				memoryTwoFactorRepo{store},
+				memoryUserRepo{store},
+				jwtService,
+				NewSessionUsecase(memorySessionRepo{store}, jwtService),
+			),
+		).(*oauthUsecase),
+	}
+}
+
+// start begins a login and returns the callback the provider would send the browser back with
+func (f *oauthFixture) start(t *testing.T) request.OAuthCallback {
+	t.Helper()
+	started, err := f.oauth.StartLogin(context.Background(), oauth.ProviderGoogle)
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	return request.OAuthCallback{
+		Code:         "code-" + started.State,
+		State:        started.State,
+		Provider:     oauth.ProviderGoogle,
+		BrowserState: started.State,
+	}
+}
+
+func verifiedGoogleIdentity() oauth.Identity {
+	return oauth.Identity{Subject: "google-user-1", Email: "buyer@example.com", EmailVerified: true, Name: "Buyer"}
+}
+
+func TestOAuthCompleteLogin(t *testing.T) {
+	f := newOAuthFixture(t, verifiedGoogleIdentity())
+	ctx := context.Background()
+	callback := f.start(t)
+
+	login, err := f.oauth.CompleteLogin(ctx, callback)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if login.User.Email != "buyer@example.com" || login.User.HasPassword {
+		t.Fatalf("logged in as %+v, want a new passwordless user", login.User)
+	}
+	if login.Token == "" || login.RefreshToken == "" {
+		t.Fatal("no session started")
+	}
+
+	// A state completes a single login
+	if _, err := f.oauth.CompleteLogin(ctx, callback); !errors.Is(err, ErrOAuthInvalidState) {
+		t.Fatalf("replayed callback = %v, want ErrOAuthInvalidState", err)
+	}
+}
+
+func TestOAuthCompleteLoginRejectsBadState(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]func(f *oauthFixture, callback *request.OAuthCallback){
+		"started in another browser": func(f *oauthFixture, c *request.OAuthCallback) { c.BrowserState = "attacker-state" },
+		"no state cookie":            func(f *oauthFixture, c *request.OAuthCallback) { c.BrowserState = "" },
+		"no state":                   func(f *oauthFixture, c *request.OAuthCallback) { c.State, c.BrowserState = "", "" },
+		"unknown state": func(f *oauthFixture, c *request.OAuthCallback) {
+			c.State, c.BrowserState = "forged-state", "forged-state"
+		},
+		"expired": func(f *oauthFixture, c *request.OAuthCallback) {
+			f.store.mu.Lock()
+			f.store.oauthStates[c.State].ExpiresAt = time.Now().Add(-time.Second)
+			f.store.mu.Unlock()
+		},
+		"other provider": func(f *oauthFixture, c *request.OAuthCallback) {
+			f.store.mu.Lock()
+			f.store.oauthStates[c.State].Provider = oauth.ProviderFacebook
+			f.store.mu.Unlock()
+		},
+	}
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := newOAuthFixture(t, verifiedGoogleIdentity())
+			callback := f.start(t)
+			tamper(f, &callback)
+
+			if _, err := f.oauth.CompleteLogin(ctx, callback); !errors.Is(err, ErrOAuthInvalidState) {
+				t.Fatalf("CompleteLogin = %v, want ErrOAuthInvalidState", err)
+			}
+			if len(f.store.identities) != 0 {
+				t.Fatal("an identity was linked")
+			}
+		})
+	}
+}
+
+func TestOAuthCompleteLoginFailedExchange(t *testing.T) {
+	f := newOAuthFixture(t, verifiedGoogleIdentity())
+	callback := f.start(t)
+	// The PKCE verifier stored with the state no longer matches the challenge sent to the provider
+	f.store.mu.Lock()
+	f.store.oauthStates[callback.State].CodeVerifier = "another-verifier"
+	f.store.mu.Unlock()
+
+	if _, err := f.oauth.CompleteLogin(context.Background(), callback); !errors.Is(err, ErrOAuthFailed) {
+		t.Fatalf("CompleteLogin = %v, want ErrOAuthFailed", err)
+	}
+}
+
+func TestOAuthCompleteLoginDenied(t *testing.T) {
+	f := newOAuthFixture(t, verifiedGoogleIdentity())
+	callback := f.start(t)
+	callback.Code, callback.Error = "", "access_denied"
+
+	if _, err := f.oauth.CompleteLogin(context.Background(), callback); !errors.Is(err, ErrOAuthDenied) {
+		t.Fatalf("CompleteLogin = %v, want ErrOAuthDenied", err)
+	}
+}
+
+func TestOAuthResolveUser(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	t.Run("signs up a new user", func(t *testing.T) {
+		f := newOAuthFixture(t, verifiedGoogleIdentity())
+		identity := verifiedGoogleIdentity()
+		identity.Provider = oauth.ProviderGoogle
+
+		user, err := f.oauth.resolveUser(ctx, &identity)
+		if err != nil {
+			t.Fatalf("resolveUser: %v", err)
+		}
+		if user.PasswordHash != "" || user.EmailVerifiedAt == nil || user.Role == nil || user.Role.Name != "customer" {
+			t.Fatalf("new user = %+v, want a verified customer without a password", user)
+		}
+
+		// The provider account keeps logging into the same user, even with a changed email
+		identity.Email = "renamed@example.com"
+		again, err := f.oauth.resolveUser(ctx, &identity)
+		if err != nil || again.ID != user.ID {
+			t.Fatalf("second resolveUser = %v, %v, want user %d", again, err, user.ID)
+		}
+	})
+
+	t.Run("links a verified account by email", func(t *testing.T) {
+		f := newOAuthFixture(t, verifiedGoogleIdentity())
+		userID := f.store.addUser(entity.User{Email: "buyer@example.com", PasswordHash: "hash", EmailVerifiedAt: &verifiedAt})
+		identity := verifiedGoogleIdentity()
+		identity.Provider = oauth.ProviderGoogle
+
+		user, err := f.oauth.resolveUser(ctx, &identity)
+		if err != nil {
+			t.Fatalf("resolveUser: %v", err)
+		}
+		if user.ID != userID {
+			t.Fatalf("logged in as user %d, want %d", user.ID, userID)
+		}
+		if got := f.store.identitiesOf(userID); fmt.Sprint(got) != "[google:google-user-1]" {
+			t.Fatalf("linked identities = %v", got)
+		}
+	})
+
+	t.Run("refuses to link an unverified account", func(t *testing.T) {
+		f := newOAuthFixture(t, verifiedGoogleIdentity())
+		userID := f.store.addUser(entity.User{Email: "buyer@example.com", PasswordHash: "hash"})
+		identity := verifiedGoogleIdentity()
+		identity.Provider = oauth.ProviderGoogle
+
+		if _, err := f.oauth.resolveUser(ctx, &identity); !errors.Is(err, ErrOAuthAccountUnverified) {
+			t.Fatalf("resolveUser = %v, want ErrOAuthAccountUnverified", err)
+		}
+		if got := f.store.identitiesOf(userID); len(got) != 0 {
+			t.Fatalf("linked identities = %v, want none", got)
+		}
+		if len(f.store.users) != 1 {
+			t.Fatal("a second user was created for the email")
+		}
+	})
+
+	t.Run("refuses an unverified provider email", func(t *testing.T) {
+		f := newOAuthFixture(t, verifiedGoogleIdentity())
+		f.store.addUser(entity.User{Email: "buyer@example.com", PasswordHash: "hash", EmailVerifiedAt: &verifiedAt})
+		identity := verifiedGoogleIdentity()
+		identity.Provider = oauth.ProviderGoogle
+		identity.EmailVerified = false
+
+		if _, err := f.oauth.resolveUser(ctx, &identity); !errors.Is(err, ErrOAuthEmailNotVerified) {
+			t.Fatalf("resolveUser = %v, want ErrOAuthEmailNotVerified", err)
+		}
+		if len(f.store.identities) != 0 {
+			t.Fatal("an identity was linked")
+		}
+	})
+}
+
+func TestOAuthLoginAsksForTheSecondFactor(t *testing.T) {
+	f := newOAuthFixture(t, verifiedGoogleIdentity())
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	userID := f.store.addUser(entity.User{Email: "buyer@example.com", PasswordHash: "hash", EmailVerifiedAt: &verifiedAt})
+	f.store.twoFactors[userID] = &entity.UserTwoFactor{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", EnabledAt: &verifiedAt}
+
+	// Linking by email, then logging in through the linked identity
+	for i := 0; i < 2; i++ {
+		login, err := f.oauth.CompleteLogin(ctx, f.start(t))
+		if err != nil {
+			t.Fatalf("CompleteLogin %d: %v", i+1, err)
+		}
+		if login.Challenge == nil || login.Token != "" {
+			t.Fatalf("login %d started a session without the second factor", i+1)
+		}
+	}
+	if got := f.store.identitiesOf(userID); len(got) != 1 {
+		t.Fatalf("linked identities = %v, want one", got)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
 )
 
+var ErrOrderSettled = errors.New("the order is settled and its status can no longer change")
+
 type IOrderUsecase interface {
 	CreateOrder(ctx context.Context, userID int, req request.CreateOrder) (*response.OrderResponse, error)
 	GetOrderByID(ctx context.Context, orderID int) (*response.OrderResponse, error)
@@ -25,6 +27,9 @@ type orderUsecase struct {
 	voucherRepo repository.IVoucherRepo
 	productRepo repository.IProductRepo
 	paymentRepo repository.IPaymentRepo
+
+	ledgerUsecase  ILedgerUsecase
+	accountUsecase IAccountUsecase
 }
 
 func NewOrderUsecase(
@@ -33,19 +38,27 @@ func NewOrderUsecase(
 	voucherRepo repository.IVoucherRepo,
 	productRepo repository.IProductRepo,
 	paymentRepo repository.IPaymentRepo,
+	ledgerUsecase ILedgerUsecase,
+	accountUsecase IAccountUsecase,
 ) IOrderUsecase {
 	return &orderUsecase{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		voucherRepo: voucherRepo,
-		productRepo: productRepo,
-		paymentRepo: paymentRepo,
+		orderRepo:      orderRepo,
+		cartRepo:       cartRepo,
+		voucherRepo:    voucherRepo,
+		productRepo:    productRepo,
+		paymentRepo:    paymentRepo,
+		ledgerUsecase:  ledgerUsecase,
+		accountUsecase: accountUsecase,
 	}
 }
 
 func (u *orderUsecase) CreateOrder(ctx context.Context, userID int, req request.CreateOrder) (*response.OrderResponse, error) {
 	log := logger.EnhanceWith(ctx)
 
+	if err := u.accountUsecase.CheckCanPlaceOrder(ctx, userID); err != nil {
+		return nil, err
+	}
+
 	// Get user's cart
 	cart, err := u.cartRepo.GetCartByUserID(userID)
 	if err != nil {
@@ -128,6 +141,7 @@ func (u *orderUsecase) CreateOrder(ctx context.Context, userID int, req request.
 				OrderID:          order.ID,
 				ProductVariantID: item.ProductVariantID,
 				Price:            item.ProductVariant.Price,
+				ListPrice:        item.ProductVariant.Price,
 				Quantity:         item.Quantity,
 			})
 
@@ -180,7 +194,32 @@ func (u *orderUsecase) GetUserOrders(ctx context.Context, userID int) ([]respons
 }
 
 func (u *orderUsecase) UpdateOrderStatus(ctx context.Context, req request.UpdateOrderStatus) error {
-	return u.orderRepo.UpdateOrderStatus(req.OrderID, req.Status)
+	order, err := u.orderRepo.GetOrderByID(req.OrderID)
+	if err != nil {
+		return ErrOrderNotFound
+	}
+	// A completed order is in the sellers' earnings, it is only reversed by refunds
+	if (order.Status == "completed" || order.Status == "refunded") && req.Status != order.Status {
+		return ErrOrderSettled
+	}
+
+	if req.Status == "cancelled" {
+		// Flash sale units go back to the sale and the buyer's limit
+		_, err := u.orderRepo.CancelOrder(req.OrderID)
+		return err
+	}
+
+	if err := u.orderRepo.UpdateOrderStatus(req.OrderID, req.Status); err != nil {
+		return err
+	}
+
+	if req.Status == "completed" {
+		// Reconciliation lists the order as unsettled if this fails, a refund records it again
+		if err := u.ledgerUsecase.RecordOrderSale(ctx, req.OrderID); err != nil {
+			logger.EnhanceWith(ctx).Errorw("Failed to record order sale", "order_id", req.OrderID, "error", err)
+		}
+	}
+	return nil
 }
 
 func (u *orderUsecase) mapOrderToResponse(order *entity.Order) *response.OrderResponse {
@@ -192,6 +231,7 @@ func (u *orderUsecase) mapOrderToResponse(order *entity.Order) *response.OrderRe
 		Status:          order.Status,
 		ShippingAddress: order.ShippingAddress,
 		CreatedAt:       order.CreatedAt,
+		CompletedAt:     order.CompletedAt,
 	}
 
 	if order.Voucher != nil {
@@ -3,7 +3,10 @@ package usecase
 import (
 	"context"
 	"errors"
+	"html"
+	"strings"
 
+	"github.com/leehai1107/chophimco-server/pkg/cache"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
@@ -11,11 +14,15 @@ import (
 	"gorm.io/gorm"
 )
 
+const defaultPageSize = 20
+
 type IProductUsecase interface {
 	GetAllProducts(ctx context.Context) ([]response.ProductResponse, error)
 	GetProductByID(ctx context.Context, id int) (*response.ProductResponse, error)
 	GetProductsByCategory(ctx context.Context, categoryID int) ([]response.ProductResponse, error)
 	GetProductsByBrand(ctx context.Context, brandID int) ([]response.ProductResponse, error)
+	ListProducts(ctx context.Context, req request.ListProducts) (*response.ProductListResponse, error)
+	SearchProducts(ctx context.Context, req request.SearchProducts) (*response.ProductSearchResponse, error)
 	CreateProduct(ctx context.Context, req request.CreateProduct) error
 	UpdateProduct(ctx context.Context, req request.UpdateProduct) error
 	DeleteProduct(ctx context.Context, id int) error
@@ -24,11 +31,21 @@ type IProductUsecase interface {
 }
 
 type productUsecase struct {
-	repo repository.IProductRepo
+	repo          repository.IProductRepo
+	attributeRepo repository.IAttributeRepo
+	suggestIndex  *cache.SuggestIndex
 }
 
-func NewProductUsecase(repo repository.IProductRepo) IProductUsecase {
-	return &productUsecase{repo: repo}
+func NewProductUsecase(
+	repo repository.IProductRepo,
+	attributeRepo repository.IAttributeRepo,
+	suggestIndex *cache.SuggestIndex,
+) IProductUsecase {
+	return &productUsecase{
+		repo:          repo,
+		attributeRepo: attributeRepo,
+		suggestIndex:  suggestIndex,
+	}
 }
 
 func (u *productUsecase) GetAllProducts(ctx context.Context) ([]response.ProductResponse, error) {
@@ -36,15 +53,15 @@ func (u *productUsecase) GetAllProducts(ctx context.Context) ([]response.Product
 	if err != nil {
 		return nil, err
 	}
-	return u.mapProductsToResponse(products), nil
+	return mapProductsToResponse(products), nil
 }
 
 func (u *productUsecase) GetProductByID(ctx context.Context, id int) (*response.ProductResponse, error) {
-	product, err := u.repo.GetProductByID(id)
+	product, err := u.repo.GetApprovedProductByID(id)
 	if err != nil {
 		return nil, err
 	}
-	resp := u.mapProductToResponse(product)
+	resp := mapProductToResponse(product)
 	return &resp, nil
 }
 
@@ -53,7 +70,7 @@ func (u *productUsecase) GetProductsByCategory(ctx context.Context, categoryID i
 	if err != nil {
 		return nil, err
 	}
-	return u.mapProductsToResponse(products), nil
+	return mapProductsToResponse(products), nil
 }
 
 func (u *productUsecase) GetProductsByBrand(ctx context.Context, brandID int) ([]response.ProductResponse, error) {
@@ -61,7 +78,100 @@ func (u *productUsecase) GetProductsByBrand(ctx context.Context, brandID int) ([
 	if err != nil {
 		return nil, err
 	}
-	return u.mapProductsToResponse(products), nil
+	return mapProductsToResponse(products), nil
+}
+
+func (u *productUsecase) ListProducts(ctx context.Context, req request.ListProducts) (*response.ProductListResponse, error) {
+	// Pending and rejected seller submissions stay out of the shop
+	req.OnlyApproved = true
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	products, total, err := u.repo.ListProducts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := u.getFacets(ctx, request.SearchProducts{ListProducts: req})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.ProductListResponse{
+		Items:      mapProductsToResponse(products),
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+		Facets:     facets,
+	}, nil
+}
+
+func (u *productUsecase) SearchProducts(ctx context.Context, req request.SearchProducts) (*response.ProductSearchResponse, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	req.OnlyApproved = true
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	hits, total, err := u.repo.SearchProducts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ProductID)
+	}
+
+	products, err := u.repo.GetProductsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	productByID := make(map[int]*entity.Product, len(products))
+	for i := range products {
+		productByID[products[i].ID] = &products[i]
+	}
+
+	// Keep the ranking order of the search hits
+	items := make([]response.ProductSearchItem, 0, len(hits))
+	for _, hit := range hits {
+		product, ok := productByID[hit.ProductID]
+		if !ok {
+			continue
+		}
+		items = append(items, response.ProductSearchItem{
+			ProductResponse: mapProductToResponse(product),
+			Rank:            hit.Rank,
+			Highlight: response.SearchHighlight{
+				Name:        renderHighlight(hit.NameHighlight),
+				Description: renderHighlight(hit.DescriptionHighlight),
+			},
+		})
+	}
+
+	facets, err := u.getFacets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.ProductSearchResponse{
+		Items:      items,
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+		Facets:     facets,
+	}, nil
+}
+
+// highlightMarkup turns the repository's match delimiters into <mark> tags once the text is escaped
+var highlightMarkup = strings.NewReplacer(repository.HighlightStart, "<mark>", repository.HighlightStop, "</mark>")
+
+// renderHighlight escapes a search snippet and marks its matches, the result is safe HTML
+func renderHighlight(snippet string) string {
+	return highlightMarkup.Replace(html.EscapeString(snippet))
 }
 
 func (u *productUsecase) CreateProduct(ctx context.Context, req request.CreateProduct) error {
@@ -101,23 +211,40 @@ func (u *productUsecase) UpdateProduct(ctx context.Context, req request.UpdatePr
 		product.IsActive = *req.IsActive
 	}
 
-	return u.repo.UpdateProduct(product)
+	if err := u.repo.UpdateProduct(product); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+	return nil
 }
 
 func (u *productUsecase) DeleteProduct(ctx context.Context, id int) error {
-	return u.repo.DeleteProduct(id)
+	if err := u.repo.DeleteProduct(id); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+	return nil
 }
 
 func (u *productUsecase) CreateProductVariant(ctx context.Context, req request.CreateProductVariant) error {
 	// Check if SKU exists
 	_, err := u.repo.GetVariantsBySKU(req.SKU)
 	if err == nil {
-		return errors.New("SKU already exists")
+		return ErrSKUTaken
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 
+	product, err := u.repo.GetProductByID(req.ProductID)
+	if err != nil {
+		return err
+	}
+	definitions, err := u.attributeRepo.GetEffectiveDefinitions(ctx, product.CategoryID)
+	if err != nil {
+		return err
+	}
+
 	variant := &entity.ProductVariant{
 		ProductID:      req.ProductID,
 		SwitchID:       req.SwitchID,
@@ -129,6 +256,15 @@ func (u *productUsecase) CreateProductVariant(ctx context.Context, req request.C
 		Stock:          req.Stock,
 		SKU:            req.SKU,
 	}
+	attributes, err := buildVariantAttributes(definitions, req.Attributes, variant)
+	if err != nil {
+		return err
+	}
+	for i := range attributes {
+		attributes[i].Definition = nil
+	}
+	variant.Attributes = attributes
+
 	return u.repo.CreateVariant(variant)
 }
 
@@ -160,59 +296,133 @@ func (u *productUsecase) UpdateProductVariant(ctx context.Context, req request.U
 		variant.Stock = *req.Stock
 	}
 
+	if variant.Product == nil {
+		return errors.New("product not found")
+	}
+	definitions, err := u.attributeRepo.GetEffectiveDefinitions(ctx, variant.Product.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	// Stored values still defined for the category first, then legacy fields and
+	// attributes given in this request
+	defined := make(map[string]bool, len(definitions))
+	for _, d := range definitions {
+		defined[d.Code] = true
+	}
+	values := make(map[string]string)
+	for code, value := range currentAttributeValues(variant) {
+		if defined[code] {
+			values[code] = value
+		}
+	}
+	for code, value := range legacyAttributeValues(variant) {
+		if !defined[code] {
+			continue
+		}
+		if _, stored := values[code]; !stored || requestSetsLegacyAttribute(req, code) {
+			values[code] = value
+		}
+	}
+	for code, value := range req.Attributes {
+		values[code] = value
+	}
+
+	attributes, err := buildVariantAttributes(definitions, values, variant)
+	if err != nil {
+		return err
+	}
+
+	variant.Product = nil
+	variant.Switch = nil
+	variant.Attributes = attributes
 	return u.repo.UpdateVariant(variant)
 }
 
-func (u *productUsecase) mapProductsToResponse(products []entity.Product) []response.ProductResponse {
-	result := make([]response.ProductResponse, 0, len(products))
-	for _, p := range products {
-		result = append(result, u.mapProductToResponse(&p))
-	}
-	return result
-}
-
-func (u *productUsecase) mapProductToResponse(product *entity.Product) response.ProductResponse {
-	resp := response.ProductResponse{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		BasePrice:   product.BasePrice,
-		IsActive:    product.IsActive,
-		CreatedAt:   product.CreatedAt,
-	}
-
-	if product.Category != nil {
-		categoryName := product.Category.Name
-		resp.Category = &categoryName
-	}
-
-	if product.Brand != nil {
-		brandName := product.Brand.Name
-		resp.Brand = &brandName
-	}
-
-	if len(product.Variants) > 0 {
-		variants := make([]response.ProductVariantResponse, 0, len(product.Variants))
-		for _, v := range product.Variants {
-			variantResp := response.ProductVariantResponse{
-				ID:             v.ID,
-				ProductID:      v.ProductID,
-				Layout:         v.Layout,
-				ConnectionType: v.ConnectionType,
-				Hotswap:        v.Hotswap,
-				LedType:        v.LedType,
-				Price:          v.Price,
-				Stock:          v.Stock,
-				SKU:            v.SKU,
-			}
-			if v.Switch != nil {
-				switchName := v.Switch.Name
-				variantResp.Switch = &switchName
-			}
-			variants = append(variants, variantResp)
+// requestSetsLegacyAttribute reports whether the update carries a legacy column for code
+func requestSetsLegacyAttribute(req request.UpdateProductVariant, code string) bool {
+	switch code {
+	case AttributeCodeLayout:
+		return req.Layout != ""
+	case AttributeCodeConnectionType:
+		return req.ConnectionType != ""
+	case AttributeCodeHotswap:
+		return req.Hotswap != nil
+	case AttributeCodeLedType:
+		return req.LedType != ""
+	}
+	return false
+}
+
+// getFacets counts the fixed facets plus the filterable attributes defined for the
+// selected category, or the global attributes when no category is selected
+func (u *productUsecase) getFacets(ctx context.Context, req request.SearchProducts) (*response.ProductFacets, error) {
+	facets, err := u.repo.GetProductFacets(req)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := u.attributeRepo.GetEffectiveDefinitions(ctx, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	filterable := make([]entity.AttributeDefinition, 0, len(definitions))
+	ids := make([]int, 0, len(definitions))
+	for _, d := range definitions {
+		if d.IsFilterable {
+			filterable = append(filterable, d)
+			ids = append(ids, d.ID)
+		}
+	}
+
+	counts, err := u.repo.GetAttributeFacets(req, ids)
+	if err != nil {
+		return nil, err
+	}
+	countsByDefinition := make(map[int][]response.FacetCount)
+	for _, c := range counts {
+		countsByDefinition[c.DefinitionID] = append(countsByDefinition[c.DefinitionID],
+			response.FacetCount{Value: c.Value, Count: c.Count})
+	}
+
+	resp := mapFacetsToResponse(facets)
+	resp.Attributes = make([]response.AttributeFacet, 0, len(filterable))
+	for _, d := range filterable {
+		values := countsByDefinition[d.ID]
+		if values == nil {
+			values = []response.FacetCount{}
+		}
+		resp.Attributes = append(resp.Attributes, response.AttributeFacet{
+			Code:   d.Code,
+			Name:   d.Name,
+			Type:   d.Type,
+			Unit:   d.Unit,
+			Values: values,
+		})
+	}
+	return resp, nil
+}
+
+func mapFacetsToResponse(facets *repository.ProductFacets) *response.ProductFacets {
+	mapCounts := func(counts []repository.FacetCount) []response.FacetCount {
+		result := make([]response.FacetCount, 0, len(counts))
+		for _, c := range counts {
+			result = append(result, response.FacetCount{Value: c.Value, Count: c.Count})
 		}
-		resp.Variants = variants
+		return result
 	}
 
-	return resp
+	histogram := make([]response.PriceBucket, 0, len(facets.PriceHistogram))
+	for _, b := range facets.PriceHistogram {
+		histogram = append(histogram, response.PriceBucket{Min: b.Min, Max: b.Max, Count: b.Count})
+	}
+
+	return &response.ProductFacets{
+		Layout:         mapCounts(facets.Layout),
+		SwitchType:     mapCounts(facets.SwitchType),
+		ConnectionType: mapCounts(facets.ConnectionType),
+		Hotswap:        mapCounts(facets.Hotswap),
+		LedType:        mapCounts(facets.LedType),
+		PriceHistogram: histogram,
+	}
 }
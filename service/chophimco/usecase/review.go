@@ -3,8 +3,12 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/storage"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
@@ -12,66 +16,444 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("you have already reviewed this product")
+	ErrNotVerifiedBuyer    = errors.New("only buyers with a completed order of this product can review it")
+	ErrReviewEditExpired   = errors.New("the review can no longer be edited")
+	ErrReviewPhotoLimit    = fmt.Errorf("a review can have at most %d photos", maxReviewPhotos)
+	ErrReviewPhotoNotFound = errors.New("review photo not found")
+	ErrReviewReplyNotFound = errors.New("review reply not found")
+	ErrReviewRemoved       = errors.New("the review was removed by a moderator")
+)
+
+const maxReviewPhotos = 5
+
 type IReviewUsecase interface {
-	GetProductReviews(ctx context.Context, productID int) ([]response.ReviewResponse, error)
-	CreateReview(ctx context.Context, userID int, req request.CreateReview) error
+	ListReviews(ctx context.Context, req request.ListReviews) (*response.ReviewListResponse, error)
+	GetReviewHistory(ctx context.Context, reviewID int) (*response.ReviewHistoryResponse, error)
+
+	// Reviewer
+	CreateReview(ctx context.Context, userID int, req request.CreateReview) (*response.ReviewResponse, error)
+	UpdateReview(ctx context.Context, userID int, req request.UpdateReview) (*response.ReviewResponse, error)
+	DeleteReview(ctx context.Context, userID int, reviewID int) error
+	UploadReviewPhoto(ctx context.Context, userID int, req request.UploadReviewPhoto) (*response.ReviewPhotoResponse, error)
+	DeleteReviewPhoto(ctx context.Context, userID int, photoID int) error
+
+	// Seller of the reviewed product
+	ReplyToReview(ctx context.Context, sellerID int, req request.ReplyToReview) (*response.ReviewResponse, error)
+	DeleteReviewReply(ctx context.Context, sellerID int, reviewID int) error
 }
 
 type reviewUsecase struct {
-	reviewRepo repository.IReviewRepo
+	reviewRepo          repository.IReviewRepo
+	storage             storage.Backend
+	notificationUsecase INotificationUsecase
 }
 
-func NewReviewUsecase(reviewRepo repository.IReviewRepo) IReviewUsecase {
-	return &reviewUsecase{reviewRepo: reviewRepo}
+func NewReviewUsecase(
+	reviewRepo repository.IReviewRepo,
+	storage storage.Backend,
+	notificationUsecase INotificationUsecase,
+) IReviewUsecase {
+	return &reviewUsecase{
+		reviewRepo:          reviewRepo,
+		storage:             storage,
+		notificationUsecase: notificationUsecase,
+	}
 }
 
-func (u *reviewUsecase) GetProductReviews(ctx context.Context, productID int) ([]response.ReviewResponse, error) {
-	reviews, err := u.reviewRepo.GetReviewsByProductID(productID)
+func (u *reviewUsecase) ListReviews(ctx context.Context, req request.ListReviews) (*response.ReviewListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	reviews, total, err := u.reviewRepo.ListReviews(ctx, req, (req.Page-1)*req.PageSize, req.PageSize)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]response.ReviewResponse, 0, len(reviews))
-	for _, review := range reviews {
-		resp := response.ReviewResponse{
-			ID:        review.ID,
-			ProductID: review.ProductID,
-			Rating:    review.Rating,
-			Comment:   review.Comment,
-			CreatedAt: review.CreatedAt,
-		}
+	resp := &response.ReviewListResponse{
+		Reviews:    make([]response.ReviewResponse, 0, len(reviews)),
+		Pagination: response.NewPagination(req.Page, req.PageSize, total),
+	}
+	for i := range reviews {
+		resp.Reviews = append(resp.Reviews, mapReviewToResponse(&reviews[i]))
+	}
 
-		if review.User != nil {
-			resp.UserName = review.User.FullName
-		}
+	return resp, nil
+}
 
-		if review.Product != nil {
-			resp.ProductName = review.Product.Name
-		}
+func (u *reviewUsecase) GetReviewHistory(ctx context.Context, reviewID int) (*response.ReviewHistoryResponse, error) {
+	review, err := u.getPublishedReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
 
-		result = append(result, resp)
+	edits, err := u.reviewRepo.GetReviewEdits(ctx, reviewID)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	resp := &response.ReviewHistoryResponse{
+		Review: mapReviewToResponse(review),
+		Edits:  make([]response.ReviewEditResponse, 0, len(edits)),
+	}
+	for _, edit := range edits {
+		resp.Edits = append(resp.Edits, response.ReviewEditResponse{
+			Rating:   edit.Rating,
+			Comment:  edit.Comment,
+			EditedAt: edit.EditedAt,
+		})
+	}
+	return resp, nil
 }
 
-func (u *reviewUsecase) CreateReview(ctx context.Context, userID int, req request.CreateReview) error {
+func (u *reviewUsecase) CreateReview(ctx context.Context, userID int, req request.CreateReview) (*response.ReviewResponse, error) {
 	// Check if user already reviewed this product
-	_, err := u.reviewRepo.GetReviewByUserAndProduct(userID, req.ProductID)
+	_, err := u.reviewRepo.GetReviewByUserAndProduct(ctx, userID, req.ProductID)
 	if err == nil {
-		return errors.New("you have already reviewed this product")
+		return nil, ErrReviewExists
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return err
+		return nil, err
+	}
+
+	// Only buyers who received the product can review it
+	orderID, err := u.reviewRepo.GetCompletedOrderWithProduct(ctx, userID, req.ProductID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotVerifiedBuyer
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	review := &entity.Review{
-		UserID:    userID,
-		ProductID: req.ProductID,
-		Rating:    req.Rating,
+		UserID:             userID,
+		ProductID:          req.ProductID,
+		OrderID:            &orderID,
+		Rating:             req.Rating,
+		Comment:            req.Comment,
+		IsVerifiedPurchase: true,
+		CreatedAt:          time.Now(),
+	}
+	review.Status, review.FlagReason = screenReview(req.Comment)
+	created, err := u.reviewRepo.CreateReview(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+	// A concurrent post got there first
+	if !created {
+		return nil, ErrReviewExists
+	}
+
+	resp := mapReviewToResponse(review)
+	return &resp, nil
+}
+
+func (u *reviewUsecase) UpdateReview(ctx context.Context, userID int, req request.UpdateReview) (*response.ReviewResponse, error) {
+	review, err := u.getOwnReview(ctx, userID, req.ReviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status == entity.ReviewStatusRejected {
+		return nil, ErrReviewRemoved
+	}
+	if !canEditReview(review) {
+		return nil, ErrReviewEditExpired
+	}
+
+	if review.Rating != req.Rating || review.Comment != req.Comment {
+		now := time.Now()
+		previous := &entity.ReviewEdit{
+			ReviewID: review.ID,
+			Rating:   review.Rating,
+			Comment:  review.Comment,
+			EditedAt: now,
+		}
+		review.Rating = req.Rating
+		review.Comment = req.Comment
+		review.EditedAt = &now
+
+		// A held review stays held, edits cannot clear a moderator's pending decision
+		status, flagReason := screenReview(req.Comment)
+		if review.Status != entity.ReviewStatusPending || status == entity.ReviewStatusPending {
+			review.Status, review.FlagReason = status, flagReason
+		}
+
+		if err := u.reviewRepo.UpdateReview(ctx, review, previous); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := mapReviewToResponse(review)
+	return &resp, nil
+}
+
+func (u *reviewUsecase) DeleteReview(ctx context.Context, userID int, reviewID int) error {
+	review, err := u.getOwnReview(ctx, userID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	// Photos, edits and the reply go with the review
+	if err := u.reviewRepo.DeleteReview(ctx, review.ID); err != nil {
+		return err
+	}
+	for _, image := range review.Images {
+		u.removeImage(ctx, image.StorageKey, image.ContentType)
+	}
+	return nil
+}
+
+func (u *reviewUsecase) UploadReviewPhoto(
+	ctx context.Context,
+	userID int,
+	req request.UploadReviewPhoto,
+) (*response.ReviewPhotoResponse, error) {
+	review, err := u.getOwnReview(ctx, userID, req.ReviewID)
+	if err != nil {
+		return nil, err
+	}
+	if !canEditReview(review) {
+		return nil, ErrReviewEditExpired
+	}
+	if len(req.ImageData) == 0 {
+		return nil, fmt.Errorf("%w: an image file is required", ErrInvalidUpload)
+	}
+
+	count, err := u.reviewRepo.CountReviewImages(ctx, review.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxReviewPhotos {
+		return nil, ErrReviewPhotoLimit
+	}
+
+	stored, err := storeImage(ctx, u.storage, config.StorageConfig().MaxUploadSize,
+		fmt.Sprintf("reviews/%d", review.ID), req.ImageData, reviewImageSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &entity.ReviewImage{
+		ReviewID:     review.ID,
+		ImageURL:     stored.urls[ImageSizeOriginal],
+		ThumbnailURL: stored.urls[ImageSizeThumbnail],
+		MediumURL:    stored.urls[ImageSizeMedium],
+		StorageKey:   stored.key,
+		ContentType:  stored.contentType,
+		Width:        stored.width,
+		Height:       stored.height,
+		SizeBytes:    stored.size,
+		CreatedAt:    time.Now(),
+	}
+	if err := u.reviewRepo.CreateReviewImage(ctx, image); err != nil {
+		u.removeImage(ctx, stored.key, stored.contentType)
+		return nil, err
+	}
+
+	resp := mapReviewPhoto(image)
+	return &resp, nil
+}
+
+func (u *reviewUsecase) DeleteReviewPhoto(ctx context.Context, userID int, photoID int) error {
+	image, err := u.reviewRepo.GetReviewImageByID(ctx, photoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrReviewPhotoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := u.getOwnReview(ctx, userID, image.ReviewID); err != nil {
+		if errors.Is(err, ErrReviewNotFound) {
+			return ErrReviewPhotoNotFound
+		}
+		return err
+	}
+
+	if err := u.reviewRepo.DeleteReviewImage(ctx, image.ID); err != nil {
+		return err
+	}
+	u.removeImage(ctx, image.StorageKey, image.ContentType)
+	return nil
+}
+
+func (u *reviewUsecase) ReplyToReview(ctx context.Context, sellerID int, req request.ReplyToReview) (*response.ReviewResponse, error) {
+	review, err := u.getSellerReview(ctx, sellerID, req.ReviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != entity.ReviewStatusPublished {
+		return nil, ErrReviewNotFound
+	}
+
+	now := time.Now()
+	reply := &entity.ReviewReply{
+		ReviewID:  review.ID,
+		SellerID:  sellerID,
 		Comment:   req.Comment,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if review.Reply != nil {
+		reply.CreatedAt = review.Reply.CreatedAt
+	}
+	if err := u.reviewRepo.SaveReviewReply(ctx, reply); err != nil {
+		return nil, err
 	}
 
-	return u.reviewRepo.CreateReview(review)
+	// Only the first reply is news to the reviewer, edits are not
+	if review.Reply == nil {
+		u.notifyReviewer(ctx, review)
+	}
+
+	review.Reply = reply
+	resp := mapReviewToResponse(review)
+	return &resp, nil
+}
+
+func (u *reviewUsecase) DeleteReviewReply(ctx context.Context, sellerID int, reviewID int) error {
+	review, err := u.getSellerReview(ctx, sellerID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := u.reviewRepo.DeleteReviewReply(ctx, review.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrReviewReplyNotFound
+	}
+	return nil
+}
+
+func (u *reviewUsecase) getReview(ctx context.Context, reviewID int) (*entity.Review, error) {
+	review, err := u.reviewRepo.GetReviewByID(ctx, reviewID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+// getPublishedReview loads a review visible to everyone, held and removed reviews are reported as missing
+func (u *reviewUsecase) getPublishedReview(ctx context.Context, reviewID int) (*entity.Review, error) {
+	review, err := u.getReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != entity.ReviewStatusPublished {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// getOwnReview loads a review written by the user, other users' reviews are reported as missing
+func (u *reviewUsecase) getOwnReview(ctx context.Context, userID int, reviewID int) (*entity.Review, error) {
+	review, err := u.getReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// getSellerReview loads a review of one of the seller's products
+func (u *reviewUsecase) getSellerReview(ctx context.Context, sellerID int, reviewID int) (*entity.Review, error) {
+	review, err := u.getReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Product == nil || review.Product.SellerID != sellerID {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// canEditReview reports whether the review is still inside the edit window
+func canEditReview(review *entity.Review) bool {
+	return time.Since(review.CreatedAt) <= config.MarketplaceConfig().ReviewEditWindow
+}
+
+// notifyReviewer does not fail the reply, it is already public on the review
+func (u *reviewUsecase) notifyReviewer(ctx context.Context, review *entity.Review) {
+	productName := "your purchase"
+	if review.Product != nil {
+		productName = fmt.Sprintf("\"%s\"", review.Product.Name)
+	}
+
+	reviewID := review.ID
+	err := u.notificationUsecase.Notify(ctx, &entity.Notification{
+		UserID:        review.UserID,
+		Type:          entity.NotificationReviewReplied,
+		Title:         "The seller replied to your review",
+		Message:       fmt.Sprintf("The seller answered your review of %s.", productName),
+		ReferenceType: "review",
+		ReferenceID:   &reviewID,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to notify reviewer", "review_id", review.ID, "error", err)
+	}
+}
+
+// removeImage deletes stored files on a best effort basis, a leftover file only costs space
+func (u *reviewUsecase) removeImage(ctx context.Context, key, contentType string) {
+	if err := removeStoredImage(ctx, u.storage, key, contentType, reviewImageSizes); err != nil {
+		logger.Errorf("Failed to remove stored image %s: %v", key, err)
+	}
+}
+
+func mapReviewToResponse(review *entity.Review) response.ReviewResponse {
+	resp := response.ReviewResponse{
+		ID:                 review.ID,
+		ProductID:          review.ProductID,
+		Rating:             review.Rating,
+		Comment:            review.Comment,
+		IsVerifiedPurchase: review.IsVerifiedPurchase,
+		HelpfulCount:       review.HelpfulCount,
+		Status:             review.Status,
+		Photos:             make([]response.ReviewPhotoResponse, 0, len(review.Images)),
+		CreatedAt:          review.CreatedAt,
+		EditedAt:           review.EditedAt,
+	}
+
+	if review.User != nil {
+		resp.UserName = review.User.FullName
+	}
+
+	if review.Product != nil {
+		resp.ProductName = review.Product.Name
+	}
+
+	for i := range review.Images {
+		resp.Photos = append(resp.Photos, mapReviewPhoto(&review.Images[i]))
+	}
+
+	if review.Reply != nil {
+		resp.Reply = &response.ReviewReplyResponse{
+			Comment:   review.Reply.Comment,
+			CreatedAt: review.Reply.CreatedAt,
+			UpdatedAt: review.Reply.UpdatedAt,
+		}
+	}
+
+	return resp
+}
+
+func mapReviewPhoto(image *entity.ReviewImage) response.ReviewPhotoResponse {
+	return response.ReviewPhotoResponse{
+		ID:           image.ID,
+		ImageURL:     image.ImageURL,
+		ThumbnailURL: image.ThumbnailURL,
+		MediumURL:    image.MediumURL,
+		Width:        image.Width,
+		Height:       image.Height,
+	}
 }
@@ -3,10 +3,19 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/leehai1107/chophimco-server/pkg/cache"
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/imaging"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/storage"
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
 	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
 	"gorm.io/gorm"
 )
@@ -17,6 +26,7 @@ type ISellerUsecase interface {
 	GetSellerProfileByUserID(ctx context.Context, userID int) (*entity.SellerProfile, error)
 	GetSellerByID(ctx context.Context, sellerID int) (*entity.SellerProfile, error)
 	UpdateSellerProfile(ctx context.Context, req request.UpdateSellerProfile) (*entity.SellerProfile, error)
+	UploadSellerLogo(ctx context.Context, userID int, data []byte) (*entity.SellerProfile, error)
 
 	// Seller product management
 	CreateProduct(ctx context.Context, req request.CreateProduct) (*entity.Product, error)
@@ -31,28 +41,61 @@ type ISellerUsecase interface {
 
 	// Admin - seller verification
 	GetPendingSellers(ctx context.Context) ([]entity.SellerProfile, error)
-	VerifySeller(ctx context.Context, req request.VerifySeller) error
-	RejectSeller(ctx context.Context, req request.RejectSeller) error
 
 	// Admin - product approval
 	GetPendingProducts(ctx context.Context) ([]entity.Product, error)
 	ApproveProduct(ctx context.Context, req request.ApproveProduct) error
 	RejectProduct(ctx context.Context, req request.RejectProduct) error
 
+	// Product revisions, sellerID 0 skips the ownership check for admins
+	GetProductRevisions(ctx context.Context, sellerID int, productID int) ([]response.ProductRevisionResponse, error)
+	GetProductRevisionDiff(ctx context.Context, productID int, req request.ProductRevisionDiff) (*response.ProductRevisionDiff, error)
+
 	// Seller reviews
-	GetSellerReviews(ctx context.Context, sellerID int) ([]entity.SellerReview, error)
-	CreateSellerReview(ctx context.Context, req request.CreateSellerReview) (*entity.SellerReview, error)
+	GetSellerReviews(ctx context.Context, sellerID int) ([]response.SellerReviewResponse, error)
+	CreateSellerReview(ctx context.Context, req request.CreateSellerReview) (*response.SellerReviewResponse, error)
 }
 
+var (
+	ErrNoPendingRevision = errors.New("product has no changes waiting for review")
+	ErrRevisionNotFound  = errors.New("product revision not found")
+	ErrInvalidShopSlug   = errors.New("invalid shop slug")
+	ErrShopSlugTaken     = errors.New("shop slug is already taken")
+
+	ErrSellerReviewExists  = errors.New("you have already reviewed this seller for this order")
+	ErrSellerReviewExpired = errors.New("the review window for this order has closed")
+)
+
+// maxDerivedShopSlugLength leaves room in the slug column for the user ID suffix
+const maxDerivedShopSlugLength = 100
+
 type sellerUsecase struct {
-	sellerRepo repository.ISellerRepository
-	userRepo   repository.IUserRepo
+	sellerRepo          repository.ISellerRepository
+	userRepo            repository.IUserRepo
+	suggestIndex        *cache.SuggestIndex
+	storage             storage.Backend
+	revisionRepo        repository.IProductRevisionRepo
+	notificationUsecase INotificationUsecase
+	followUsecase       IFollowUsecase
 }
 
-func NewSellerUsecase(sellerRepo repository.ISellerRepository, userRepo repository.IUserRepo) ISellerUsecase {
+func NewSellerUsecase(
+	sellerRepo repository.ISellerRepository,
+	userRepo repository.IUserRepo,
+	suggestIndex *cache.SuggestIndex,
+	storage storage.Backend,
+	revisionRepo repository.IProductRevisionRepo,
+	notificationUsecase INotificationUsecase,
+	followUsecase IFollowUsecase,
+) ISellerUsecase {
 	return &sellerUsecase{
-		sellerRepo: sellerRepo,
-		userRepo:   userRepo,
+		sellerRepo:          sellerRepo,
+		userRepo:            userRepo,
+		suggestIndex:        suggestIndex,
+		storage:             storage,
+		revisionRepo:        revisionRepo,
+		notificationUsecase: notificationUsecase,
+		followUsecase:       followUsecase,
 	}
 }
 
@@ -78,14 +121,20 @@ func (u *sellerUsecase) CreateSellerProfile(ctx context.Context, req request.Cre
 		return nil, err
 	}
 
+	slug, err := u.deriveShopSlug(ctx, req.ShopName, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
 	profile := &entity.SellerProfile{
 		UserID:             req.UserID,
 		ShopName:           req.ShopName,
+		Slug:               slug,
 		ShopDescription:    req.ShopDescription,
 		BusinessAddress:    req.BusinessAddress,
 		BusinessPhone:      req.BusinessPhone,
 		LogoURL:            req.LogoURL,
-		VerificationStatus: "pending",
+		VerificationStatus: entity.SellerStatusDraft,
 		AverageRating:      0.0,
 		TotalSales:         0,
 		CreatedAt:          time.Now(),
@@ -117,6 +166,20 @@ func (u *sellerUsecase) UpdateSellerProfile(ctx context.Context, req request.Upd
 	if req.ShopName != "" {
 		profile.ShopName = req.ShopName
 	}
+	if req.Slug != "" {
+		slug := strtool.Slugify(req.Slug)
+		if slug == "" {
+			return nil, ErrInvalidShopSlug
+		}
+		taken, err := u.sellerRepo.SellerSlugExists(ctx, slug, profile.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrShopSlugTaken
+		}
+		profile.Slug = slug
+	}
 	if req.ShopDescription != "" {
 		profile.ShopDescription = req.ShopDescription
 	}
@@ -126,28 +189,90 @@ func (u *sellerUsecase) UpdateSellerProfile(ctx context.Context, req request.Upd
 	if req.BusinessPhone != "" {
 		profile.BusinessPhone = req.BusinessPhone
 	}
-	if req.LogoURL != "" {
+	var oldLogoKey, oldLogoType string
+	if req.LogoURL != "" && req.LogoURL != profile.LogoURL {
+		// An external logo replaces any uploaded one
+		oldLogoKey, oldLogoType = profile.LogoStorageKey, profile.LogoContentType
 		profile.LogoURL = req.LogoURL
+		profile.LogoThumbnailURL = ""
+		profile.LogoStorageKey = ""
+		profile.LogoContentType = ""
 	}
 
 	err = u.sellerRepo.UpdateSellerProfile(ctx, profile)
 	if err != nil {
 		return nil, err
 	}
+	u.removeImage(ctx, oldLogoKey, oldLogoType, logoSizes)
+
+	return profile, nil
+}
+
+// deriveShopSlug makes a slug from the shop name, suffixed with the user ID when it is taken
+func (u *sellerUsecase) deriveShopSlug(ctx context.Context, shopName string, userID int) (string, error) {
+	slug := strtool.Slugify(shopName)
+	if len(slug) > maxDerivedShopSlugLength {
+		slug = strings.TrimRight(slug[:maxDerivedShopSlugLength], "-")
+	}
+	if slug == "" {
+		slug = "shop"
+	}
+
+	taken, err := u.sellerRepo.SellerSlugExists(ctx, slug, 0)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		slug = fmt.Sprintf("%s-%d", slug, userID)
+	}
+	return slug, nil
+}
+
+func (u *sellerUsecase) UploadSellerLogo(ctx context.Context, userID int, data []byte) (*entity.SellerProfile, error) {
+	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("seller profile not found")
+	}
+
+	stored, err := storeImage(ctx, u.storage, config.StorageConfig().MaxUploadSize,
+		fmt.Sprintf("sellers/%d/logo", profile.ID), data, logoSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	oldKey, oldType := profile.LogoStorageKey, profile.LogoContentType
+	profile.LogoURL = stored.urls[ImageSizeMedium]
+	profile.LogoThumbnailURL = stored.urls[ImageSizeThumbnail]
+	profile.LogoStorageKey = stored.key
+	profile.LogoContentType = stored.contentType
+
+	if err := u.sellerRepo.UpdateSellerProfile(ctx, profile); err != nil {
+		u.removeImage(ctx, stored.key, stored.contentType, logoSizes)
+		return nil, err
+	}
+	u.removeImage(ctx, oldKey, oldType, logoSizes)
 
 	return profile, nil
 }
 
+// removeImage deletes stored files on a best effort basis, a leftover file only costs space
+func (u *sellerUsecase) removeImage(ctx context.Context, key, contentType string, sizes []imaging.Size) {
+	if err := removeStoredImage(ctx, u.storage, key, contentType, sizes); err != nil {
+		logger.Errorf("Failed to remove stored image %s: %v", key, err)
+	}
+}
+
 // Seller Product Management
 func (u *sellerUsecase) CreateProduct(ctx context.Context, req request.CreateProduct) (*entity.Product, error) {
 	// Verify seller exists and is verified
 	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, req.SellerID)
 	if err != nil {
-		return nil, errors.New("seller profile not found")
+		return nil, ErrSellerProfileNotFound
 	}
 
-	if profile.VerificationStatus != "verified" {
-		return nil, errors.New("seller is not verified")
+	// Only sellers who completed onboarding can list products
+	if profile.VerificationStatus != entity.SellerStatusVerified {
+		return nil, ErrSellerNotVerified
 	}
 
 	product := &entity.Product{
@@ -161,6 +286,8 @@ func (u *sellerUsecase) CreateProduct(ctx context.Context, req request.CreatePro
 		IsActive:       true,
 		CreatedAt:      time.Now(),
 	}
+	// The first submission is revision 1, created together with the product
+	product.Revisions = []entity.ProductRevision{*newProductRevision(product, req.SellerID, 1)}
 
 	err = u.sellerRepo.CreateProduct(ctx, product)
 	if err != nil {
@@ -181,33 +308,83 @@ func (u *sellerUsecase) UpdateProduct(ctx context.Context, sellerID int, req req
 		return nil, errors.New("product not found or access denied")
 	}
 
-	// Update fields
+	// Edits build on the change still waiting for review, if any
+	live := newProductRevision(product, sellerID, 0)
+	base := live
+	pending, err := u.revisionRepo.GetPendingRevision(ctx, product.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if pending != nil {
+		base = pending
+	}
+
+	proposed := *base
+	proposed.ID = 0
+	proposed.SubmittedBy = sellerID
+	proposed.Status = entity.RevisionPending
+	proposed.ReviewerID, proposed.ReviewNote, proposed.ReviewedAt = nil, "", nil
+	proposed.Category, proposed.Brand = nil, nil
+	proposed.CreatedAt = time.Now()
 	if req.Name != "" {
-		product.Name = req.Name
-		// Reset approval status if major fields change
-		product.ApprovalStatus = "pending"
+		proposed.Name = req.Name
 	}
 	if req.CategoryID != nil {
-		product.CategoryID = req.CategoryID
+		proposed.CategoryID = req.CategoryID
 	}
 	if req.BrandID != nil {
-		product.BrandID = req.BrandID
+		proposed.BrandID = req.BrandID
 	}
 	if req.Description != "" {
-		product.Description = req.Description
+		proposed.Description = req.Description
 	}
 	if req.BasePrice > 0 {
-		product.BasePrice = req.BasePrice
+		proposed.BasePrice = req.BasePrice
 	}
-	if req.IsActive != nil {
+
+	// Visibility is not moderated
+	fields := map[string]interface{}{}
+	if req.IsActive != nil && *req.IsActive != product.IsActive {
 		product.IsActive = *req.IsActive
+		fields["is_active"] = product.IsActive
 	}
 
-	err = u.sellerRepo.UpdateProduct(ctx, product)
+	var revision *entity.ProductRevision
+	switch {
+	case sameRevisionContent(&proposed, base):
+		// Nothing to review, a pending change stays pending
+		if len(fields) == 0 {
+			return product, nil
+		}
+		err = u.sellerRepo.UpdateProductFields(ctx, product.ID, fields)
+	case product.ApprovedAt != nil && sameRevisionContent(&proposed, live):
+		// Reverted to the live version, which withdraws the pending change
+		err = u.revisionRepo.SubmitChanges(ctx, product.ID, nil, fields)
+	case product.ApprovedAt != nil:
+		// The approved version stays live until the change is reviewed
+		revision = &proposed
+		err = u.revisionRepo.SubmitChanges(ctx, product.ID, revision, fields)
+	default:
+		// Never approved, nothing is live so the product itself holds the latest submission
+		revision = &proposed
+		for field, value := range revisionProductFields(revision) {
+			fields[field] = value
+		}
+		fields["approval_status"] = "pending"
+		fields["rejection_reason"] = ""
+		applyRevisionToProduct(product, revision)
+		product.ApprovalStatus = "pending"
+		product.RejectionReason = ""
+		err = u.revisionRepo.SubmitChanges(ctx, product.ID, revision, fields)
+	}
 	if err != nil {
 		return nil, err
 	}
+	u.suggestIndex.Refresh()
 
+	if revision != nil {
+		product.Revisions = []entity.ProductRevision{*revision}
+	}
 	return product, nil
 }
 
@@ -218,7 +395,11 @@ func (u *sellerUsecase) DeleteProduct(ctx context.Context, sellerID int, product
 		return errors.New("product not found or access denied")
 	}
 
-	return u.sellerRepo.DeleteProduct(ctx, productID)
+	if err := u.sellerRepo.DeleteProduct(ctx, productID); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+	return nil
 }
 
 // Product Image Management
@@ -229,14 +410,6 @@ func (u *sellerUsecase) UploadProductImage(ctx context.Context, sellerID int, re
 		return nil, errors.New("product not found or access denied")
 	}
 
-	// If this is primary, unset other primary images
-	if req.IsPrimary {
-		err = u.sellerRepo.UnsetPrimaryImages(ctx, req.ProductID)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	image := &entity.ProductImage{
 		ProductID:    req.ProductID,
 		ImageURL:     req.ImageURL,
@@ -246,8 +419,38 @@ func (u *sellerUsecase) UploadProductImage(ctx context.Context, sellerID int, re
 		CreatedAt:    time.Now(),
 	}
 
+	if len(req.ImageData) > 0 {
+		stored, err := storeImage(ctx, u.storage, config.StorageConfig().MaxUploadSize,
+			fmt.Sprintf("products/%d", req.ProductID), req.ImageData, productImageSizes)
+		if err != nil {
+			return nil, err
+		}
+		image.ImageURL = stored.urls[ImageSizeOriginal]
+		image.ThumbnailURL = stored.urls[ImageSizeThumbnail]
+		image.SmallURL = stored.urls[ImageSizeSmall]
+		image.MediumURL = stored.urls[ImageSizeMedium]
+		image.LargeURL = stored.urls[ImageSizeLarge]
+		image.StorageKey = stored.key
+		image.ContentType = stored.contentType
+		image.Width = stored.width
+		image.Height = stored.height
+		image.SizeBytes = stored.size
+	} else if req.ImageURL == "" {
+		return nil, fmt.Errorf("%w: an image file or image_url is required", ErrInvalidUpload)
+	}
+
+	// If this is primary, unset other primary images
+	if req.IsPrimary {
+		err = u.sellerRepo.UnsetPrimaryImages(ctx, req.ProductID)
+		if err != nil {
+			u.removeImage(ctx, image.StorageKey, image.ContentType, productImageSizes)
+			return nil, err
+		}
+	}
+
 	err = u.sellerRepo.CreateProductImage(ctx, image)
 	if err != nil {
+		u.removeImage(ctx, image.StorageKey, image.ContentType, productImageSizes)
 		return nil, err
 	}
 
@@ -255,10 +458,19 @@ func (u *sellerUsecase) UploadProductImage(ctx context.Context, sellerID int, re
 }
 
 func (u *sellerUsecase) DeleteProductImage(ctx context.Context, sellerID int, imageID int) error {
-	// First get the image to verify product ownership
-	// Note: We need to fetch the image first to check product ownership
-	// This is a simplified version - you may want to add a method to get image with product
-	return u.sellerRepo.DeleteProductImage(ctx, imageID)
+	image, err := u.sellerRepo.GetImageByIDAndSeller(ctx, imageID, sellerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrImageNotFound
+		}
+		return err
+	}
+
+	if err := u.sellerRepo.DeleteProductImage(ctx, imageID); err != nil {
+		return err
+	}
+	u.removeImage(ctx, image.StorageKey, image.ContentType, productImageSizes)
+	return nil
 }
 
 func (u *sellerUsecase) SetPrimaryImage(ctx context.Context, sellerID int, req request.SetPrimaryImage) error {
@@ -289,79 +501,369 @@ func (u *sellerUsecase) GetPendingSellers(ctx context.Context) ([]entity.SellerP
 	return u.sellerRepo.GetPendingSellers(ctx)
 }
 
-func (u *sellerUsecase) VerifySeller(ctx context.Context, req request.VerifySeller) error {
-	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, req.UserID)
+// Admin - Product Approval
+func (u *sellerUsecase) GetPendingProducts(ctx context.Context) ([]entity.Product, error) {
+	return u.sellerRepo.GetPendingProducts(ctx)
+}
+
+func (u *sellerUsecase) ApproveProduct(ctx context.Context, req request.ApproveProduct) error {
+	product, revision, err := u.getRevisionToReview(ctx, req.ProductID)
 	if err != nil {
-		return errors.New("seller profile not found")
+		return err
 	}
+	firstApproval := product.ApprovedAt == nil
 
-	profile.VerificationStatus = "verified"
 	now := time.Now()
-	profile.VerifiedAt = &now
+	revision.Status = entity.RevisionApproved
+	revision.ReviewerID = &req.ReviewerID
+	revision.ReviewNote = req.Note
+	revision.ReviewedAt = &now
 
-	err = u.sellerRepo.UpdateSellerProfile(ctx, profile)
-	if err != nil {
+	fields := revisionProductFields(revision)
+	fields["approval_status"] = "approved"
+	fields["rejection_reason"] = ""
+	fields["approved_at"] = now
+
+	if err := u.reviewRevision(ctx, revision, fields); err != nil {
 		return err
 	}
+	u.suggestIndex.Refresh()
 
-	// Update user is_seller_verified flag
-	user, err := u.userRepo.GetUserByID(req.UserID)
+	message := fmt.Sprintf("Your changes to \"%s\" are now live.", revision.Name)
+	if firstApproval {
+		message = fmt.Sprintf("Your product \"%s\" was approved and is now live.", revision.Name)
+	}
+	if req.Note != "" {
+		message += " Note: " + req.Note
+	}
+	u.notifySeller(ctx, product, entity.NotificationProductApproved, "Product approved", message)
+
+	if firstApproval && product.IsActive {
+		launched := *product
+		applyRevisionToProduct(&launched, revision)
+		u.followUsecase.NotifyProductLaunch(ctx, &launched)
+	}
+	return nil
+}
+
+func (u *sellerUsecase) RejectProduct(ctx context.Context, req request.RejectProduct) error {
+	product, revision, err := u.getRevisionToReview(ctx, req.ProductID)
 	if err != nil {
 		return err
 	}
-	user.IsSellerVerified = true
-	return u.userRepo.UpdateUser(user)
+
+	now := time.Now()
+	revision.Status = entity.RevisionRejected
+	revision.ReviewerID = &req.ReviewerID
+	revision.ReviewNote = req.Reason
+	revision.ReviewedAt = &now
+
+	// An approved product keeps its live version, only the change is rejected
+	var fields map[string]interface{}
+	message := fmt.Sprintf("Your changes to \"%s\" were rejected: %s", product.Name, req.Reason)
+	if product.ApprovedAt == nil {
+		fields = map[string]interface{}{
+			"approval_status":  "rejected",
+			"rejection_reason": req.Reason,
+		}
+		message = fmt.Sprintf("Your product \"%s\" was rejected: %s", revision.Name, req.Reason)
+	}
+
+	if err := u.reviewRevision(ctx, revision, fields); err != nil {
+		return err
+	}
+	u.suggestIndex.Refresh()
+
+	u.notifySeller(ctx, product, entity.NotificationProductRejected, "Product rejected", message)
+	return nil
 }
 
-func (u *sellerUsecase) RejectSeller(ctx context.Context, req request.RejectSeller) error {
-	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, req.UserID)
+// getRevisionToReview returns the pending revision of a product. Products submitted before
+// revisions were tracked get one created from their current content.
+func (u *sellerUsecase) getRevisionToReview(ctx context.Context, productID int) (*entity.Product, *entity.ProductRevision, error) {
+	product, err := u.revisionRepo.GetProduct(ctx, productID)
 	if err != nil {
-		return errors.New("seller profile not found")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrProductNotFound
+		}
+		return nil, nil, err
+	}
+
+	revision, err := u.revisionRepo.GetPendingRevision(ctx, productID)
+	if err == nil {
+		return product, revision, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	if product.ApprovalStatus != "pending" {
+		return nil, nil, ErrNoPendingRevision
 	}
 
-	profile.VerificationStatus = "rejected"
-	return u.sellerRepo.UpdateSellerProfile(ctx, profile)
+	revision = newProductRevision(product, product.SellerID, 0)
+	if err := u.revisionRepo.SubmitChanges(ctx, productID, revision, nil); err != nil {
+		return nil, nil, err
+	}
+	return product, revision, nil
 }
 
-// Admin - Product Approval
-func (u *sellerUsecase) GetPendingProducts(ctx context.Context) ([]entity.Product, error) {
-	return u.sellerRepo.GetPendingProducts(ctx)
+func (u *sellerUsecase) reviewRevision(ctx context.Context, revision *entity.ProductRevision, fields map[string]interface{}) error {
+	err := u.revisionRepo.ReviewRevision(ctx, revision, fields)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Reviewed by someone else, or superseded by a newer edit meanwhile
+		return ErrNoPendingRevision
+	}
+	return err
 }
 
-func (u *sellerUsecase) ApproveProduct(ctx context.Context, req request.ApproveProduct) error {
-	var product entity.Product
-	product.ID = req.ProductID
-	product.ApprovalStatus = "approved"
-	now := time.Now()
-	product.ApprovedAt = &now
+// notifySeller does not fail the review, the revision history still shows the outcome
+func (u *sellerUsecase) notifySeller(ctx context.Context, product *entity.Product, kind, title, message string) {
+	productID := product.ID
+	err := u.notificationUsecase.Notify(ctx, &entity.Notification{
+		UserID:        product.SellerID,
+		Type:          kind,
+		Title:         title,
+		Message:       message,
+		ReferenceType: "product",
+		ReferenceID:   &productID,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to notify seller", "product_id", product.ID, "error", err)
+	}
+}
+
+// Product Revisions
+func (u *sellerUsecase) GetProductRevisions(ctx context.Context, sellerID int, productID int) ([]response.ProductRevisionResponse, error) {
+	if sellerID != 0 {
+		if _, err := u.sellerRepo.GetProductByIDAndSeller(ctx, productID, sellerID); err != nil {
+			return nil, ErrProductNotFound
+		}
+	} else if _, err := u.revisionRepo.GetProduct(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
 
-	return u.sellerRepo.UpdateProduct(ctx, &product)
+	revisions, err := u.revisionRepo.GetRevisionsByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]response.ProductRevisionResponse, 0, len(revisions))
+	for i := range revisions {
+		resp = append(resp, mapProductRevisionToResponse(&revisions[i]))
+	}
+	return resp, nil
 }
 
-func (u *sellerUsecase) RejectProduct(ctx context.Context, req request.RejectProduct) error {
-	var product entity.Product
-	product.ID = req.ProductID
-	product.ApprovalStatus = "rejected"
-	product.RejectionReason = req.Reason
+func (u *sellerUsecase) GetProductRevisionDiff(
+	ctx context.Context,
+	productID int,
+	req request.ProductRevisionDiff,
+) (*response.ProductRevisionDiff, error) {
+	product, err := u.revisionRepo.GetProduct(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	to, err := u.getDiffTarget(ctx, productID, req.To)
+	if err != nil {
+		return nil, err
+	}
 
-	return u.sellerRepo.UpdateProduct(ctx, &product)
+	diff := &response.ProductRevisionDiff{
+		ProductID: productID,
+		Base:      "none",
+		To:        mapProductRevisionToResponse(to),
+	}
+
+	var from *entity.ProductRevision
+	if req.From != nil {
+		if from, err = u.revisionRepo.GetRevisionByNumber(ctx, productID, *req.From); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrRevisionNotFound
+			}
+			return nil, err
+		}
+	} else {
+		from, err = u.revisionRepo.GetLastApprovedRevision(ctx, productID, to.RevisionNumber)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	switch {
+	case from != nil:
+		diff.Base = "revision"
+	case product.ApprovedAt != nil && to.Status != entity.RevisionApproved:
+		// Approved before revisions were tracked, compare with what is live
+		from = newProductRevision(product, product.SellerID, 0)
+		from.Category, from.Brand = product.Category, product.Brand
+		diff.Base = "live"
+	default:
+		from = &entity.ProductRevision{}
+	}
+	if diff.Base != "none" {
+		fromResp := mapProductRevisionToResponse(from)
+		if diff.Base == "live" {
+			fromResp.Status = product.ApprovalStatus
+		}
+		diff.From = &fromResp
+	}
+
+	diff.Changes = diffRevisions(from, to)
+	return diff, nil
+}
+
+// getDiffTarget returns the requested revision, or else the pending or latest one
+func (u *sellerUsecase) getDiffTarget(ctx context.Context, productID int, number *int) (*entity.ProductRevision, error) {
+	if number != nil {
+		revision, err := u.revisionRepo.GetRevisionByNumber(ctx, productID, *number)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return revision, err
+	}
+
+	revision, err := u.revisionRepo.GetPendingRevision(ctx, productID)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return revision, err
+	}
+	revisions, err := u.revisionRepo.GetRevisionsByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, ErrRevisionNotFound
+	}
+	return &revisions[0], nil
+}
+
+// newProductRevision snapshots the moderated fields of a product
+func newProductRevision(product *entity.Product, submittedBy int, number int) *entity.ProductRevision {
+	return &entity.ProductRevision{
+		ProductID:      product.ID,
+		RevisionNumber: number,
+		SubmittedBy:    submittedBy,
+		Name:           product.Name,
+		CategoryID:     product.CategoryID,
+		BrandID:        product.BrandID,
+		Description:    product.Description,
+		BasePrice:      product.BasePrice,
+		Status:         entity.RevisionPending,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func revisionProductFields(revision *entity.ProductRevision) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        revision.Name,
+		"category_id": revision.CategoryID,
+		"brand_id":    revision.BrandID,
+		"description": revision.Description,
+		"base_price":  revision.BasePrice,
+	}
+}
+
+func applyRevisionToProduct(product *entity.Product, revision *entity.ProductRevision) {
+	product.Name = revision.Name
+	product.CategoryID = revision.CategoryID
+	product.BrandID = revision.BrandID
+	product.Description = revision.Description
+	product.BasePrice = revision.BasePrice
+}
+
+func sameRevisionContent(a, b *entity.ProductRevision) bool {
+	return a.Name == b.Name &&
+		equalIntPtr(a.CategoryID, b.CategoryID) &&
+		equalIntPtr(a.BrandID, b.BrandID) &&
+		a.Description == b.Description &&
+		a.BasePrice == b.BasePrice
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func diffRevisions(from, to *entity.ProductRevision) []response.RevisionFieldChange {
+	changes := []response.RevisionFieldChange{}
+	if from.Name != to.Name {
+		changes = append(changes, response.RevisionFieldChange{Field: "name", Old: from.Name, New: to.Name})
+	}
+	if !equalIntPtr(from.CategoryID, to.CategoryID) {
+		changes = append(changes, response.RevisionFieldChange{
+			Field: "category",
+			Old:   revisionCategoryName(from),
+			New:   revisionCategoryName(to),
+		})
+	}
+	if !equalIntPtr(from.BrandID, to.BrandID) {
+		changes = append(changes, response.RevisionFieldChange{
+			Field: "brand",
+			Old:   revisionBrandName(from),
+			New:   revisionBrandName(to),
+		})
+	}
+	if from.Description != to.Description {
+		changes = append(changes, response.RevisionFieldChange{Field: "description", Old: from.Description, New: to.Description})
+	}
+	if from.BasePrice != to.BasePrice {
+		changes = append(changes, response.RevisionFieldChange{Field: "base_price", Old: from.BasePrice, New: to.BasePrice})
+	}
+	return changes
 }
 
 // Seller Reviews
-func (u *sellerUsecase) GetSellerReviews(ctx context.Context, sellerID int) ([]entity.SellerReview, error) {
-	return u.sellerRepo.GetSellerReviews(ctx, sellerID)
+func (u *sellerUsecase) GetSellerReviews(ctx context.Context, sellerID int) ([]response.SellerReviewResponse, error) {
+	reviews, err := u.sellerRepo.GetSellerReviews(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.SellerReviewResponse, 0, len(reviews))
+	for i := range reviews {
+		result = append(result, mapSellerReviewToResponse(&reviews[i]))
+	}
+	return result, nil
 }
 
-func (u *sellerUsecase) CreateSellerReview(ctx context.Context, req request.CreateSellerReview) (*entity.SellerReview, error) {
-	// Verify buyer and seller exist
-	_, err := u.userRepo.GetUserByID(req.BuyerID)
+// CreateSellerReview accepts one review per buyer, seller and order, for a completed order
+// of the buyer's that contains the seller's products and completed within the review window
+func (u *sellerUsecase) CreateSellerReview(
+	ctx context.Context,
+	req request.CreateSellerReview,
+) (*response.SellerReviewResponse, error) {
+	order, err := u.sellerRepo.GetBuyerOrderWithSeller(ctx, req.BuyerID, req.SellerID, req.OrderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
 	if err != nil {
-		return nil, errors.New("buyer not found")
+		return nil, err
+	}
+	if order.Status != "completed" {
+		return nil, ErrOrderNotCompleted
+	}
+
+	completedAt := order.CreatedAt
+	if order.CompletedAt != nil {
+		completedAt = *order.CompletedAt
+	}
+	if time.Since(completedAt) > config.MarketplaceConfig().SellerReviewWindow {
+		return nil, ErrSellerReviewExpired
 	}
 
-	_, err = u.userRepo.GetUserByID(req.SellerID)
+	exists, err := u.sellerRepo.HasSellerReview(ctx, req.BuyerID, req.SellerID, req.OrderID)
 	if err != nil {
-		return nil, errors.New("seller not found")
+		return nil, err
+	}
+	if exists {
+		return nil, ErrSellerReviewExists
 	}
 
 	review := &entity.SellerReview{
@@ -372,17 +874,33 @@ func (u *sellerUsecase) CreateSellerReview(ctx context.Context, req request.Crea
 		Comment:   req.Comment,
 		CreatedAt: time.Now(),
 	}
+	review.Status, review.FlagReason = screenReview(req.Comment)
 
-	err = u.sellerRepo.CreateSellerReview(ctx, review)
+	// A concurrent review of the same order loses on the unique constraint
+	created, err := u.sellerRepo.CreateSellerReview(ctx, review)
 	if err != nil {
 		return nil, err
 	}
-
-	// Update seller's average rating
-	avgRating, err := u.sellerRepo.GetSellerAverageRating(ctx, req.SellerID)
-	if err == nil {
-		u.sellerRepo.UpdateSellerRating(ctx, req.SellerID, avgRating)
+	if !created {
+		return nil, ErrSellerReviewExists
 	}
 
-	return review, nil
+	resp := mapSellerReviewToResponse(review)
+	return &resp, nil
+}
+
+func mapSellerReviewToResponse(review *entity.SellerReview) response.SellerReviewResponse {
+	resp := response.SellerReviewResponse{
+		ID:        review.ID,
+		SellerID:  review.SellerID,
+		OrderID:   review.OrderID,
+		Rating:    review.Rating,
+		Comment:   review.Comment,
+		Status:    review.Status,
+		CreatedAt: review.CreatedAt,
+	}
+	if review.Buyer != nil {
+		resp.BuyerName = review.Buyer.FullName
+	}
+	return resp
 }
@@ -0,0 +1,650 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/storage"
+	"github.com/leehai1107/chophimco-server/pkg/xhttp"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSellerProfileNotFound = errors.New("seller profile not found")
+	ErrKYCLocked             = errors.New("verification details cannot be changed while under review or after verification")
+	ErrKYCIncomplete         = errors.New("verification details are incomplete")
+	ErrInvalidSellerStatus   = errors.New("seller is not waiting for review")
+	ErrDocumentNotFound      = errors.New("document not found")
+	ErrInvalidDocument       = errors.New("invalid document")
+)
+
+// documentTypes maps the accepted MIME types of verification documents to file extensions
+var documentTypes = map[string]string{
+	xhttp.MIMEJPEG: ".jpg",
+	xhttp.MIMEPNG:  ".png",
+	xhttp.MIMEWEBP: ".webp",
+	xhttp.MIMEPDF:  ".pdf",
+}
+
+// editableSellerStatuses are the statuses in which a seller may change their details
+var editableSellerStatuses = map[string]bool{
+	entity.SellerStatusDraft:         true,
+	entity.SellerStatusInfoRequested: true,
+	entity.SellerStatusRejected:      true,
+}
+
+type ISellerKYCUsecase interface {
+	// Seller onboarding, by the seller's user ID
+	GetKYC(ctx context.Context, userID int) (*response.SellerKYCResponse, error)
+	UpdateBusinessDetails(ctx context.Context, userID int, req request.UpdateSellerBusiness) (*response.SellerKYCResponse, error)
+	UpdateBankDetails(ctx context.Context, userID int, req request.UpdateSellerBank) (*response.SellerKYCResponse, error)
+	UploadDocument(ctx context.Context, userID int, docType, fileName string, data []byte) (*response.SellerDocumentResponse, error)
+	DeleteDocument(ctx context.Context, userID int, documentID int) error
+	Submit(ctx context.Context, userID int) (*response.SellerKYCResponse, error)
+	// OpenDocument returns a document and its content, userID 0 skips the ownership check for admins
+	OpenDocument(ctx context.Context, userID int, documentID int) (*entity.SellerDocument, io.ReadCloser, error)
+
+	// Admin review
+	ReviewDocument(ctx context.Context, req request.ReviewSellerDocument) error
+	RequestInfo(ctx context.Context, req request.RequestSellerInfo) error
+	VerifySeller(ctx context.Context, req request.VerifySeller) error
+	RejectSeller(ctx context.Context, req request.RejectSeller) error
+}
+
+type sellerKYCUsecase struct {
+	sellerRepo          repository.ISellerRepository
+	kycRepo             repository.ISellerKYCRepo
+	storage             storage.Backend
+	notificationUsecase INotificationUsecase
+}
+
+func NewSellerKYCUsecase(
+	sellerRepo repository.ISellerRepository,
+	kycRepo repository.ISellerKYCRepo,
+	storage storage.Backend,
+	notificationUsecase INotificationUsecase,
+) ISellerKYCUsecase {
+	return &sellerKYCUsecase{
+		sellerRepo:          sellerRepo,
+		kycRepo:             kycRepo,
+		storage:             storage,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+func (u *sellerKYCUsecase) GetKYC(ctx context.Context, userID int) (*response.SellerKYCResponse, error) {
+	profile, err := u.getProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildKYCResponse(ctx, profile)
+}
+
+func (u *sellerKYCUsecase) UpdateBusinessDetails(
+	ctx context.Context,
+	userID int,
+	req request.UpdateSellerBusiness,
+) (*response.SellerKYCResponse, error) {
+	profile, kyc, err := u.getEditableKYC(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	kyc.BusinessType = req.BusinessType
+	kyc.LegalName = strings.TrimSpace(req.LegalName)
+	kyc.RegistrationNumber = strings.TrimSpace(req.RegistrationNumber)
+	kyc.RegisteredAddress = strings.TrimSpace(req.RegisteredAddress)
+	kyc.TaxID = strings.TrimSpace(req.TaxID)
+	kyc.UpdatedAt = time.Now()
+	if err := u.kycRepo.SaveKYC(ctx, kyc); err != nil {
+		return nil, err
+	}
+	return u.buildKYCResponse(ctx, profile)
+}
+
+func (u *sellerKYCUsecase) UpdateBankDetails(
+	ctx context.Context,
+	userID int,
+	req request.UpdateSellerBank,
+) (*response.SellerKYCResponse, error) {
+	profile, kyc, err := u.getEditableKYC(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	kyc.BankName = strings.TrimSpace(req.BankName)
+	kyc.BankBranch = strings.TrimSpace(req.BankBranch)
+	kyc.BankAccountName = strings.TrimSpace(req.BankAccountName)
+	kyc.BankAccountNumber = strings.ReplaceAll(strings.TrimSpace(req.BankAccountNumber), " ", "")
+	kyc.UpdatedAt = time.Now()
+	if err := u.kycRepo.SaveKYC(ctx, kyc); err != nil {
+		return nil, err
+	}
+	return u.buildKYCResponse(ctx, profile)
+}
+
+func (u *sellerKYCUsecase) UploadDocument(
+	ctx context.Context,
+	userID int,
+	docType, fileName string,
+	data []byte,
+) (*response.SellerDocumentResponse, error) {
+	profile, err := u.getProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !editableSellerStatuses[profile.VerificationStatus] {
+		return nil, ErrKYCLocked
+	}
+
+	if maxSize := config.StorageConfig().MaxDocumentSize; int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidDocument, maxSize)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := documentTypes[contentType]
+	if !ok || len(data) == 0 {
+		return nil, fmt.Errorf("%w: use a JPEG, PNG, WebP or PDF file", ErrInvalidDocument)
+	}
+
+	id, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%skyc/%d/%s%s", storage.PrivatePrefix, profile.ID, id, ext)
+	if err := u.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+
+	doc := &entity.SellerDocument{
+		SellerProfileID: profile.ID,
+		Type:            docType,
+		FileName:        documentFileName(fileName, ext),
+		StorageKey:      key,
+		ContentType:     contentType,
+		SizeBytes:       int64(len(data)),
+		Status:          entity.DocumentStatusPending,
+		CreatedAt:       time.Now(),
+	}
+	if err := u.kycRepo.AddDocument(ctx, doc); err != nil {
+		u.removeDocumentFile(ctx, key)
+		return nil, err
+	}
+
+	resp := mapSellerDocumentToResponse(doc)
+	return &resp, nil
+}
+
+func (u *sellerKYCUsecase) DeleteDocument(ctx context.Context, userID int, documentID int) error {
+	profile, err := u.getProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !editableSellerStatuses[profile.VerificationStatus] {
+		return ErrKYCLocked
+	}
+
+	doc, err := u.kycRepo.GetDocumentByID(ctx, documentID)
+	if err != nil || doc.SellerProfileID != profile.ID {
+		return ErrDocumentNotFound
+	}
+	// Reviewed documents stay as a record of the review, upload a replacement instead
+	if doc.Status != entity.DocumentStatusPending {
+		return ErrKYCLocked
+	}
+
+	if err := u.kycRepo.DeleteDocument(ctx, documentID); err != nil {
+		return err
+	}
+	u.removeDocumentFile(ctx, doc.StorageKey)
+	return nil
+}
+
+func (u *sellerKYCUsecase) Submit(ctx context.Context, userID int) (*response.SellerKYCResponse, error) {
+	profile, err := u.getProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !editableSellerStatuses[profile.VerificationStatus] {
+		return nil, ErrKYCLocked
+	}
+
+	kyc, docs, err := u.loadKYC(ctx, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	if missing := kycMissing(kyc, docs); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing %s", ErrKYCIncomplete, strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	event := &entity.SellerVerificationEvent{
+		SellerProfileID: profile.ID,
+		FromStatus:      profile.VerificationStatus,
+		ToStatus:        entity.SellerStatusPending,
+		ActorID:         userID,
+		CreatedAt:       now,
+	}
+	if err := u.changeStatus(ctx, profile, event, nil); err != nil {
+		return nil, err
+	}
+
+	kyc.SubmittedAt = &now
+	kyc.UpdatedAt = now
+	if err := u.kycRepo.SaveKYC(ctx, kyc); err != nil {
+		return nil, err
+	}
+	return u.buildKYCResponse(ctx, profile)
+}
+
+func (u *sellerKYCUsecase) OpenDocument(ctx context.Context, userID int, documentID int) (*entity.SellerDocument, io.ReadCloser, error) {
+	doc, err := u.kycRepo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrDocumentNotFound
+		}
+		return nil, nil, err
+	}
+	if userID != 0 {
+		profile, err := u.getProfile(ctx, userID)
+		if err != nil || profile.ID != doc.SellerProfileID {
+			return nil, nil, ErrDocumentNotFound
+		}
+	}
+
+	body, err := u.storage.Get(ctx, doc.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrDocumentNotFound
+		}
+		return nil, nil, err
+	}
+	return doc, body, nil
+}
+
+// Admin review
+func (u *sellerKYCUsecase) ReviewDocument(ctx context.Context, req request.ReviewSellerDocument) error {
+	if req.Status == entity.DocumentStatusRejected && strings.TrimSpace(req.Note) == "" {
+		return fmt.Errorf("%w: a note is required when rejecting a document", ErrInvalidDocument)
+	}
+
+	doc, err := u.kycRepo.GetDocumentByID(ctx, req.DocumentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDocumentNotFound
+		}
+		return err
+	}
+	profile, err := u.sellerRepo.GetSellerProfileByID(ctx, doc.SellerProfileID)
+	if err != nil {
+		return err
+	}
+	if profile.VerificationStatus != entity.SellerStatusPending &&
+		profile.VerificationStatus != entity.SellerStatusInfoRequested {
+		return ErrInvalidSellerStatus
+	}
+
+	now := time.Now()
+	from := doc.Status
+	doc.Status = req.Status
+	doc.ReviewerID = &req.ReviewerID
+	doc.ReviewNote = req.Note
+	doc.ReviewedAt = &now
+	docID := doc.ID
+	event := &entity.SellerVerificationEvent{
+		SellerProfileID: profile.ID,
+		DocumentID:      &docID,
+		FromStatus:      from,
+		ToStatus:        doc.Status,
+		ActorID:         req.ReviewerID,
+		Reason:          req.Note,
+		CreatedAt:       now,
+	}
+	if err := u.kycRepo.ReviewDocument(ctx, doc, event); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: the document was already reviewed or replaced", ErrInvalidDocument)
+		}
+		return err
+	}
+
+	message := fmt.Sprintf("Your %s document was %s.", documentLabel(doc.Type), doc.Status)
+	if req.Note != "" {
+		message += " " + req.Note
+	}
+	u.notify(ctx, profile, entity.NotificationDocumentReviewed, "Verification document reviewed", message)
+	return nil
+}
+
+func (u *sellerKYCUsecase) RequestInfo(ctx context.Context, req request.RequestSellerInfo) error {
+	profile, err := u.getProfile(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if profile.VerificationStatus != entity.SellerStatusPending {
+		return ErrInvalidSellerStatus
+	}
+
+	event := &entity.SellerVerificationEvent{
+		SellerProfileID: profile.ID,
+		FromStatus:      profile.VerificationStatus,
+		ToStatus:        entity.SellerStatusInfoRequested,
+		ActorID:         req.ReviewerID,
+		Reason:          req.Reason,
+		CreatedAt:       time.Now(),
+	}
+	if err := u.changeStatus(ctx, profile, event, nil); err != nil {
+		return err
+	}
+
+	u.notify(ctx, profile, entity.NotificationSellerInfoRequested, "More information needed",
+		"Please update your verification details and submit them again: "+req.Reason)
+	return nil
+}
+
+func (u *sellerKYCUsecase) VerifySeller(ctx context.Context, req request.VerifySeller) error {
+	profile, err := u.getProfile(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if profile.VerificationStatus != entity.SellerStatusPending {
+		return ErrInvalidSellerStatus
+	}
+
+	// Every document has to be reviewed and approved first
+	kyc, docs, err := u.loadKYC(ctx, profile.ID)
+	if err != nil {
+		return err
+	}
+	missing := kycMissing(kyc, docs)
+	for _, doc := range currentDocuments(docs) {
+		if doc.Status != entity.DocumentStatusApproved {
+			missing = append(missing, "approved "+doc.Type)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrKYCIncomplete, strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	event := &entity.SellerVerificationEvent{
+		SellerProfileID: profile.ID,
+		FromStatus:      profile.VerificationStatus,
+		ToStatus:        entity.SellerStatusVerified,
+		ActorID:         req.ReviewerID,
+		Reason:          req.Note,
+		CreatedAt:       now,
+	}
+	if err := u.changeStatus(ctx, profile, event, map[string]interface{}{"verified_at": now}); err != nil {
+		return err
+	}
+
+	u.notify(ctx, profile, entity.NotificationSellerVerified, "Seller account verified",
+		"Your shop is verified, you can now list products.")
+	return nil
+}
+
+func (u *sellerKYCUsecase) RejectSeller(ctx context.Context, req request.RejectSeller) error {
+	profile, err := u.getProfile(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if profile.VerificationStatus != entity.SellerStatusPending &&
+		profile.VerificationStatus != entity.SellerStatusInfoRequested {
+		return ErrInvalidSellerStatus
+	}
+
+	event := &entity.SellerVerificationEvent{
+		SellerProfileID: profile.ID,
+		FromStatus:      profile.VerificationStatus,
+		ToStatus:        entity.SellerStatusRejected,
+		ActorID:         req.ReviewerID,
+		Reason:          req.Reason,
+		CreatedAt:       time.Now(),
+	}
+	if err := u.changeStatus(ctx, profile, event, nil); err != nil {
+		return err
+	}
+
+	u.notify(ctx, profile, entity.NotificationSellerRejected, "Seller verification rejected",
+		"Your seller verification was rejected: "+req.Reason)
+	return nil
+}
+
+func (u *sellerKYCUsecase) getProfile(ctx context.Context, userID int) (*entity.SellerProfile, error) {
+	profile, err := u.sellerRepo.GetSellerProfileByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSellerProfileNotFound
+		}
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (u *sellerKYCUsecase) getEditableKYC(ctx context.Context, userID int) (*entity.SellerProfile, *entity.SellerKYC, error) {
+	profile, err := u.getProfile(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !editableSellerStatuses[profile.VerificationStatus] {
+		return nil, nil, ErrKYCLocked
+	}
+
+	kyc, err := u.kycRepo.GetKYC(ctx, profile.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return profile, &entity.SellerKYC{SellerProfileID: profile.ID}, nil
+	}
+	return profile, kyc, err
+}
+
+// loadKYC returns the seller's details, empty when none were entered, and all documents
+func (u *sellerKYCUsecase) loadKYC(ctx context.Context, profileID int) (*entity.SellerKYC, []entity.SellerDocument, error) {
+	kyc, err := u.kycRepo.GetKYC(ctx, profileID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		kyc, err = &entity.SellerKYC{SellerProfileID: profileID}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	docs, err := u.kycRepo.GetDocuments(ctx, profileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kyc, docs, nil
+}
+
+func (u *sellerKYCUsecase) changeStatus(
+	ctx context.Context,
+	profile *entity.SellerProfile,
+	event *entity.SellerVerificationEvent,
+	fields map[string]interface{},
+) error {
+	if err := u.kycRepo.ChangeStatus(ctx, profile, event, fields); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Changed by someone else meanwhile
+			return ErrInvalidSellerStatus
+		}
+		return err
+	}
+	profile.VerificationStatus = event.ToStatus
+	if verifiedAt, ok := fields["verified_at"].(time.Time); ok {
+		profile.VerifiedAt = &verifiedAt
+	}
+	return nil
+}
+
+func (u *sellerKYCUsecase) buildKYCResponse(ctx context.Context, profile *entity.SellerProfile) (*response.SellerKYCResponse, error) {
+	kyc, docs, err := u.loadKYC(ctx, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := u.kycRepo.GetEvents(ctx, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := kycMissing(kyc, docs)
+	resp := &response.SellerKYCResponse{
+		UserID:          profile.UserID,
+		SellerProfileID: profile.ID,
+		ShopName:        profile.ShopName,
+		Status:          profile.VerificationStatus,
+		Business: response.SellerBusinessDetails{
+			BusinessType:       kyc.BusinessType,
+			LegalName:          kyc.LegalName,
+			RegistrationNumber: kyc.RegistrationNumber,
+			RegisteredAddress:  kyc.RegisteredAddress,
+			TaxID:              kyc.TaxID,
+		},
+		Bank: response.SellerBankDetails{
+			BankName:          kyc.BankName,
+			BankBranch:        kyc.BankBranch,
+			BankAccountName:   kyc.BankAccountName,
+			BankAccountNumber: kyc.BankAccountNumber,
+		},
+		Documents:   make([]response.SellerDocumentResponse, 0, len(docs)),
+		Missing:     missing,
+		CanSubmit:   len(missing) == 0 && editableSellerStatuses[profile.VerificationStatus],
+		SubmittedAt: kyc.SubmittedAt,
+		VerifiedAt:  profile.VerifiedAt,
+		History:     make([]response.SellerVerificationEventResponse, 0, len(events)),
+	}
+	for i := range docs {
+		resp.Documents = append(resp.Documents, mapSellerDocumentToResponse(&docs[i]))
+	}
+	for _, event := range events {
+		resp.History = append(resp.History, response.SellerVerificationEventResponse{
+			ID:         event.ID,
+			DocumentID: event.DocumentID,
+			FromStatus: event.FromStatus,
+			ToStatus:   event.ToStatus,
+			ActorID:    event.ActorID,
+			Reason:     event.Reason,
+			CreatedAt:  event.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// notify does not fail the review, the history still records it
+func (u *sellerKYCUsecase) notify(ctx context.Context, profile *entity.SellerProfile, kind, title, message string) {
+	profileID := profile.ID
+	err := u.notificationUsecase.Notify(ctx, &entity.Notification{
+		UserID:        profile.UserID,
+		Type:          kind,
+		Title:         title,
+		Message:       message,
+		ReferenceType: "seller_profile",
+		ReferenceID:   &profileID,
+	})
+	if err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to notify seller", "seller_profile_id", profile.ID, "error", err)
+	}
+}
+
+func (u *sellerKYCUsecase) removeDocumentFile(ctx context.Context, key string) {
+	if err := u.storage.Delete(ctx, key); err != nil {
+		logger.Errorf("Failed to remove document %s: %v", key, err)
+	}
+}
+
+// requiredDocuments lists the document types a seller has to provide
+func requiredDocuments(businessType string) []string {
+	docs := []string{entity.DocumentIdentityFront, entity.DocumentIdentityBack}
+	if businessType == entity.BusinessTypeCompany {
+		docs = append([]string{entity.DocumentBusinessRegistration}, docs...)
+	}
+	return docs
+}
+
+// currentDocuments returns the latest upload of every document type
+func currentDocuments(docs []entity.SellerDocument) map[string]entity.SellerDocument {
+	current := make(map[string]entity.SellerDocument)
+	for _, doc := range docs {
+		if doc.Status != entity.DocumentStatusSuperseded {
+			current[doc.Type] = doc
+		}
+	}
+	return current
+}
+
+// kycMissing lists what is still needed before the details can be submitted
+func kycMissing(kyc *entity.SellerKYC, docs []entity.SellerDocument) []string {
+	missing := []string{}
+	fields := map[string]string{
+		"business_type":       kyc.BusinessType,
+		"legal_name":          kyc.LegalName,
+		"registered_address":  kyc.RegisteredAddress,
+		"tax_id":              kyc.TaxID,
+		"bank_name":           kyc.BankName,
+		"bank_account_name":   kyc.BankAccountName,
+		"bank_account_number": kyc.BankAccountNumber,
+	}
+	if kyc.BusinessType == entity.BusinessTypeCompany {
+		fields["registration_number"] = kyc.RegistrationNumber
+	}
+	for _, field := range []string{
+		"business_type", "legal_name", "registration_number", "registered_address", "tax_id",
+		"bank_name", "bank_account_name", "bank_account_number",
+	} {
+		if value, ok := fields[field]; ok && value == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	// Required documents have to be uploaded, and no document may stay rejected
+	current := currentDocuments(docs)
+	required := requiredDocuments(kyc.BusinessType)
+	for _, docType := range []string{
+		entity.DocumentBusinessRegistration, entity.DocumentTaxCertificate,
+		entity.DocumentIdentityFront, entity.DocumentIdentityBack, entity.DocumentBankStatement,
+	} {
+		doc, ok := current[docType]
+		if (!ok && containsString(required, docType)) || (ok && doc.Status == entity.DocumentStatusRejected) {
+			missing = append(missing, "document:"+docType)
+		}
+	}
+	return missing
+}
+
+// documentFileName keeps the uploaded name for display, without any path
+func documentFileName(name, ext string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "document" + ext
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+func documentLabel(docType string) string {
+	return strings.ReplaceAll(docType, "_", " ")
+}
+
+func mapSellerDocumentToResponse(doc *entity.SellerDocument) response.SellerDocumentResponse {
+	return response.SellerDocumentResponse{
+		ID:          doc.ID,
+		Type:        doc.Type,
+		FileName:    doc.FileName,
+		ContentType: doc.ContentType,
+		SizeBytes:   doc.SizeBytes,
+		Status:      doc.Status,
+		ReviewNote:  doc.ReviewNote,
+		CreatedAt:   doc.CreatedAt,
+		ReviewedAt:  doc.ReviewedAt,
+	}
+}
@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/logger"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/tools/random"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, the session has been logged out")
+)
+
+type ISessionUsecase interface {
+	// CreateSession starts a login session for the user and issues its first tokens. twoFactor
+	// tells whether the login passed two-factor authentication.
+	CreateSession(ctx context.Context, user *entity.User, userAgent, ipAddress string, twoFactor bool) (*response.TokenResponse, error)
+	// Refresh exchanges a refresh token for a new access token and refresh token. Presenting
+	// a refresh token a second time logs its session out.
+	Refresh(ctx context.Context, refreshToken string) (*response.TokenResponse, error)
+	// Logout ends the session of the access token, or else the one the refresh token belongs to
+	Logout(ctx context.Context, sessionID string, refreshToken string) error
+	LogoutAll(ctx context.Context, userID int) error
+	// MarkTwoFactor upgrades a session that just proved the second factor, refreshing it
+	// issues access tokens that pass two-factor checks
+	MarkTwoFactor(ctx context.Context, sessionID string) error
+
+	// IsRevoked implements auth.RevocationList for the auth middleware
+	IsRevoked(ctx context.Context, claims *auth.JWTClaims) (bool, error)
+}
+
+type sessionUsecase struct {
+	sessionRepo repository.ISessionRepo
+	jwtService  auth.IJWTService
+}
+
+func NewSessionUsecase(sessionRepo repository.ISessionRepo, jwtService auth.IJWTService) ISessionUsecase {
+	return &sessionUsecase{
+		sessionRepo: sessionRepo,
+		jwtService:  jwtService,
+	}
+}
+
+func (u *sessionUsecase) CreateSession(
+	ctx context.Context,
+	user *entity.User,
+	userAgent, ipAddress string,
+	twoFactor bool,
+) (*response.TokenResponse, error) {
+	sessionID, err := random.UUIdV4()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, token, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &entity.AuthSession{
+		ID:         sessionID,
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		TwoFactor:  twoFactor,
+	}
+	if err := u.sessionRepo.CreateSession(ctx, session, token); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(user, session, refreshToken, token.ExpiresAt)
+}
+
+func (u *sessionUsecase) Refresh(ctx context.Context, refreshToken string) (*response.TokenResponse, error) {
+	current, err := u.sessionRepo.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := current.Session
+	if session == nil || session.RevokedAt != nil || session.User == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.UsedAt != nil {
+		return nil, u.revokeReusedSession(ctx, session)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	nextToken, next, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := u.sessionRepo.RotateRefreshToken(ctx, current, next)
+	if err != nil {
+		return nil, err
+	}
+	// Someone exchanged the same token between the lookup and the rotation
+	if !rotated {
+		return nil, u.revokeReusedSession(ctx, session)
+	}
+
+	return u.issueTokens(session.User, session, nextToken, next.ExpiresAt)
+}
+
+// revokeReusedSession logs out a session whose refresh token was replayed. Either the client
+// or an attacker holds a stolen token, and there is no telling which.
+func (u *sessionUsecase) revokeReusedSession(ctx context.Context, session *entity.AuthSession) error {
+	logger.EnhanceWith(ctx).Warnw("Refresh token reuse detected, revoking session",
+		"session_id", session.ID, "user_id", session.UserID)
+	if err := u.sessionRepo.RevokeSession(ctx, session.ID, entity.SessionRevokedReuse); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (u *sessionUsecase) Logout(ctx context.Context, sessionID string, refreshToken string) error {
+	if sessionID == "" && refreshToken != "" {
+		token, err := u.sessionRepo.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		sessionID = token.SessionID
+	}
+	if sessionID == "" {
+		return nil
+	}
+	return u.sessionRepo.RevokeSession(ctx, sessionID, entity.SessionRevokedLogout)
+}
+
+func (u *sessionUsecase) LogoutAll(ctx context.Context, userID int) error {
+	_, err := u.sessionRepo.RevokeUserSessions(ctx, userID, entity.SessionRevokedLogoutAll)
+	return err
+}
+
+func (u *sessionUsecase) MarkTwoFactor(ctx context.Context, sessionID string) error {
+	return u.sessionRepo.MarkTwoFactor(ctx, sessionID)
+}
+
+func (u *sessionUsecase) IsRevoked(ctx context.Context, claims *auth.JWTClaims) (bool, error) {
+	active, err := u.sessionRepo.IsSessionActive(ctx, claims.SessionID)
+	return !active, err
+}
+
+func (u *sessionUsecase) issueTokens(
+	user *entity.User,
+	session *entity.AuthSession,
+	refreshToken string,
+	refreshExpiresAt time.Time,
+) (*response.TokenResponse, error) {
+	roleName := ""
+	if user.Role != nil {
+		roleName = user.Role.Name
+	}
+
+	accessToken, expiresAt, err := u.jwtService.GenerateToken(user.ID, user.Email, roleName, session.ID, session.TwoFactor)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	return &response.TokenResponse{
+		Token:            accessToken,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// newRefreshToken returns an unguessable refresh token along with the record storing its hash
+func newRefreshToken() (string, *entity.RefreshToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+	return refreshToken, &entity.RefreshToken{
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(config.ServerConfig().RefreshTokenTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,420 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
+	"github.com/leehai1107/chophimco-server/pkg/utils/mathutil"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrShopNotFound              = errors.New("shop not found")
+	ErrCollectionNotFound        = errors.New("collection not found")
+	ErrCollectionLimit           = errors.New("shop has too many collections")
+	ErrInvalidCollectionProducts = errors.New("collection products must be the seller's own, without duplicates")
+)
+
+const (
+	maxShopCollections = 10
+	// collectionPreviewSize is how many products of each collection the storefront shows
+	collectionPreviewSize = 8
+)
+
+type IStorefrontUsecase interface {
+	// Public storefront of verified shops
+	// viewerID is the signed-in user, 0 for guests
+	GetStorefront(ctx context.Context, slug string, viewerID int) (*response.StorefrontResponse, error)
+	GetStorefrontByID(ctx context.Context, sellerProfileID int, viewerID int) (*response.StorefrontResponse, error)
+	ListShopProducts(ctx context.Context, slug string, req request.ListProducts) (*response.ProductListResponse, error)
+	SearchShopProducts(ctx context.Context, slug string, req request.SearchProducts) (*response.ProductSearchResponse, error)
+
+	// Featured collections, by the seller's user ID
+	GetCollections(ctx context.Context, sellerID int) ([]response.ShopCollectionResponse, error)
+	CreateCollection(ctx context.Context, sellerID int, req request.CreateShopCollection) (*response.ShopCollectionResponse, error)
+	UpdateCollection(ctx context.Context, sellerID int, req request.UpdateShopCollection) (*response.ShopCollectionResponse, error)
+	DeleteCollection(ctx context.Context, sellerID int, collectionID int) error
+	SetCollectionProducts(ctx context.Context, sellerID int, req request.SetShopCollectionProducts) (*response.ShopCollectionResponse, error)
+}
+
+type storefrontUsecase struct {
+	storefrontRepo repository.IStorefrontRepo
+	sellerRepo     repository.ISellerRepository
+	followRepo     repository.IFollowRepo
+	productUsecase IProductUsecase
+}
+
+func NewStorefrontUsecase(
+	storefrontRepo repository.IStorefrontRepo,
+	sellerRepo repository.ISellerRepository,
+	followRepo repository.IFollowRepo,
+	productUsecase IProductUsecase,
+) IStorefrontUsecase {
+	return &storefrontUsecase{
+		storefrontRepo: storefrontRepo,
+		sellerRepo:     sellerRepo,
+		followRepo:     followRepo,
+		productUsecase: productUsecase,
+	}
+}
+
+func (u *storefrontUsecase) GetStorefront(ctx context.Context, slug string, viewerID int) (*response.StorefrontResponse, error) {
+	profile, err := findVerifiedShop(ctx, u.sellerRepo, slug)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildStorefront(ctx, profile, viewerID)
+}
+
+func (u *storefrontUsecase) GetStorefrontByID(ctx context.Context, sellerProfileID int, viewerID int) (*response.StorefrontResponse, error) {
+	profile, err := u.sellerRepo.GetSellerProfileByID(ctx, sellerProfileID)
+	if err != nil {
+		return nil, shopLookupError(err)
+	}
+	if profile.VerificationStatus != entity.SellerStatusVerified {
+		return nil, ErrShopNotFound
+	}
+	return u.buildStorefront(ctx, profile, viewerID)
+}
+
+func (u *storefrontUsecase) ListShopProducts(
+	ctx context.Context,
+	slug string,
+	req request.ListProducts,
+) (*response.ProductListResponse, error) {
+	if err := u.scopeToShop(ctx, slug, &req); err != nil {
+		return nil, err
+	}
+	return u.productUsecase.ListProducts(ctx, req)
+}
+
+func (u *storefrontUsecase) SearchShopProducts(
+	ctx context.Context,
+	slug string,
+	req request.SearchProducts,
+) (*response.ProductSearchResponse, error) {
+	if err := u.scopeToShop(ctx, slug, &req.ListProducts); err != nil {
+		return nil, err
+	}
+	return u.productUsecase.SearchProducts(ctx, req)
+}
+
+// scopeToShop narrows the filter down to the shop's approved products, and to one of its active collections when asked
+func (u *storefrontUsecase) scopeToShop(ctx context.Context, slug string, req *request.ListProducts) error {
+	profile, err := findVerifiedShop(ctx, u.sellerRepo, slug)
+	if err != nil {
+		return err
+	}
+
+	if req.CollectionID != nil {
+		collection, err := u.storefrontRepo.GetCollectionByID(ctx, *req.CollectionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCollectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if collection.SellerID != profile.UserID || !collection.IsActive {
+			return ErrCollectionNotFound
+		}
+	}
+
+	req.SellerID = &profile.UserID
+	return nil
+}
+
+// findVerifiedShop finds a verified shop by slug
+func findVerifiedShop(ctx context.Context, sellerRepo repository.ISellerRepository, slug string) (*entity.SellerProfile, error) {
+	profile, err := sellerRepo.GetSellerProfileBySlug(ctx, strtool.Slugify(slug))
+	if err != nil {
+		return nil, shopLookupError(err)
+	}
+	if profile.VerificationStatus != entity.SellerStatusVerified {
+		return nil, ErrShopNotFound
+	}
+	return profile, nil
+}
+
+func shopLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrShopNotFound
+	}
+	return err
+}
+
+func (u *storefrontUsecase) buildStorefront(
+	ctx context.Context,
+	profile *entity.SellerProfile,
+	viewerID int,
+) (*response.StorefrontResponse, error) {
+	productCount, err := u.storefrontRepo.CountShopProducts(ctx, profile.UserID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := u.storefrontRepo.GetShopRatingDistribution(ctx, profile.UserID)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := u.storefrontRepo.GetCollections(ctx, profile.UserID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response.StorefrontResponse{
+		ID:               profile.ID,
+		SellerID:         profile.UserID,
+		Slug:             profile.Slug,
+		ShopName:         profile.ShopName,
+		ShopDescription:  profile.ShopDescription,
+		LogoURL:          profile.LogoURL,
+		LogoThumbnailURL: profile.LogoThumbnailURL,
+		FollowerCount:    profile.FollowerCount,
+		TotalSales:       profile.TotalSales,
+		ProductCount:     productCount,
+		Rating:           mapRatingSummary(counts),
+		CreatedAt:        profile.CreatedAt,
+		VerifiedAt:       profile.VerifiedAt,
+	}
+
+	if viewerID != 0 {
+		resp.IsFollowing, err = u.followRepo.IsFollowing(ctx, viewerID, profile.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Collections, err = u.previewCollections(ctx, profile.UserID, collections)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// previewCollections maps the collections with their first visible products, leaving out empty ones
+func (u *storefrontUsecase) previewCollections(
+	ctx context.Context,
+	sellerID int,
+	collections []entity.ShopCollection,
+) ([]response.ShopCollectionResponse, error) {
+	var ids []int
+	for _, c := range collections {
+		for _, item := range c.Items {
+			ids = append(ids, item.ProductID)
+		}
+	}
+
+	productByID := make(map[int]*entity.Product)
+	if len(ids) > 0 {
+		products, err := u.storefrontRepo.GetShopProductsByIDs(ctx, sellerID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range products {
+			productByID[products[i].ID] = &products[i]
+		}
+	}
+
+	result := make([]response.ShopCollectionResponse, 0, len(collections))
+	for _, c := range collections {
+		resp := mapShopCollection(&c)
+		resp.ProductIDs = nil
+		resp.ProductCount = 0
+		resp.Products = make([]response.ProductResponse, 0, collectionPreviewSize)
+		for _, item := range c.Items {
+			product, ok := productByID[item.ProductID]
+			if !ok {
+				continue
+			}
+			resp.ProductCount++
+			if len(resp.Products) < collectionPreviewSize {
+				resp.Products = append(resp.Products, mapProductToResponse(product))
+			}
+		}
+		if resp.ProductCount > 0 {
+			result = append(result, resp)
+		}
+	}
+	return result, nil
+}
+
+func (u *storefrontUsecase) GetCollections(ctx context.Context, sellerID int) ([]response.ShopCollectionResponse, error) {
+	collections, err := u.storefrontRepo.GetCollections(ctx, sellerID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.ShopCollectionResponse, 0, len(collections))
+	for _, c := range collections {
+		result = append(result, mapShopCollection(&c))
+	}
+	return result, nil
+}
+
+func (u *storefrontUsecase) CreateCollection(
+	ctx context.Context,
+	sellerID int,
+	req request.CreateShopCollection,
+) (*response.ShopCollectionResponse, error) {
+	count, err := u.storefrontRepo.CountCollections(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxShopCollections {
+		return nil, fmt.Errorf("%w: at most %d", ErrCollectionLimit, maxShopCollections)
+	}
+
+	now := time.Now()
+	collection := &entity.ShopCollection{
+		SellerID:    sellerID,
+		Name:        req.Name,
+		Description: req.Description,
+		Position:    req.Position,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := u.storefrontRepo.CreateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	resp := mapShopCollection(collection)
+	return &resp, nil
+}
+
+func (u *storefrontUsecase) UpdateCollection(
+	ctx context.Context,
+	sellerID int,
+	req request.UpdateShopCollection,
+) (*response.ShopCollectionResponse, error) {
+	collection, err := u.getOwnCollection(ctx, sellerID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		collection.Name = req.Name
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+	if req.Position != nil {
+		collection.Position = *req.Position
+	}
+	if req.IsActive != nil {
+		collection.IsActive = *req.IsActive
+	}
+	collection.UpdatedAt = time.Now()
+
+	if err := u.storefrontRepo.UpdateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	resp := mapShopCollection(collection)
+	return &resp, nil
+}
+
+func (u *storefrontUsecase) DeleteCollection(ctx context.Context, sellerID int, collectionID int) error {
+	if _, err := u.getOwnCollection(ctx, sellerID, collectionID); err != nil {
+		return err
+	}
+	return u.storefrontRepo.DeleteCollection(ctx, collectionID)
+}
+
+func (u *storefrontUsecase) SetCollectionProducts(
+	ctx context.Context,
+	sellerID int,
+	req request.SetShopCollectionProducts,
+) (*response.ShopCollectionResponse, error) {
+	if _, err := u.getOwnCollection(ctx, sellerID, req.CollectionID); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		if seen[id] {
+			return nil, ErrInvalidCollectionProducts
+		}
+		seen[id] = true
+	}
+	if len(req.ProductIDs) > 0 {
+		owned, err := u.storefrontRepo.CountSellerProducts(ctx, sellerID, req.ProductIDs)
+		if err != nil {
+			return nil, err
+		}
+		if owned != int64(len(req.ProductIDs)) {
+			return nil, ErrInvalidCollectionProducts
+		}
+	}
+
+	if err := u.storefrontRepo.SetCollectionProducts(ctx, req.CollectionID, req.ProductIDs); err != nil {
+		return nil, err
+	}
+
+	collection, err := u.storefrontRepo.GetCollectionByID(ctx, req.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapShopCollection(collection)
+	return &resp, nil
+}
+
+func (u *storefrontUsecase) getOwnCollection(ctx context.Context, sellerID int, collectionID int) (*entity.ShopCollection, error) {
+	collection, err := u.storefrontRepo.GetCollectionByID(ctx, collectionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if collection.SellerID != sellerID {
+		return nil, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+// mapShopCollection maps the seller's view of a collection, with every product ID in display order
+func mapShopCollection(c *entity.ShopCollection) response.ShopCollectionResponse {
+	resp := response.ShopCollectionResponse{
+		ID:           c.ID,
+		Name:         c.Name,
+		Description:  c.Description,
+		Position:     c.Position,
+		IsActive:     c.IsActive,
+		ProductCount: len(c.Items),
+		ProductIDs:   make([]int, 0, len(c.Items)),
+		UpdatedAt:    c.UpdatedAt,
+	}
+	for _, item := range c.Items {
+		resp.ProductIDs = append(resp.ProductIDs, item.ProductID)
+	}
+	return resp
+}
+
+// mapRatingSummary totals the reviews per rating, listing every star from 5 to 1
+func mapRatingSummary(counts []repository.RatingCountRow) response.ShopRatingSummary {
+	byRating := make(map[int]int64, len(counts))
+	for _, count := range counts {
+		byRating[count.Rating] = count.Count
+	}
+
+	summary := response.ShopRatingSummary{
+		Distribution: make([]response.RatingCountResponse, 0, 5),
+	}
+	var sum int64
+	for rating := 5; rating >= 1; rating-- {
+		summary.Distribution = append(summary.Distribution, response.RatingCountResponse{Rating: rating, Count: byRating[rating]})
+		summary.Reviews += byRating[rating]
+		sum += int64(rating) * byRating[rating]
+	}
+	if summary.Reviews > 0 {
+		summary.AverageRating = mathutil.RoundToFloat(float64(sum)/float64(summary.Reviews), 2)
+	}
+	return summary
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/cache"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+)
+
+const (
+	SuggestionKindProduct  = "product"
+	SuggestionKindBrand    = "brand"
+	SuggestionKindSwitch   = "switch"
+	SuggestionKindCategory = "category"
+	SuggestionKindQuery    = "query"
+
+	defaultSuggestLimit = 8
+
+	// Popular queries are only suggested once they have been searched this many times
+	minPopularQueryCount = 3
+	maxPopularQueries    = 500
+	// SuggestIndexMaxAge bounds how stale popular query boosts can get
+	SuggestIndexMaxAge = 10 * time.Minute
+)
+
+// Base weights per kind, popular queries add a logarithmic boost on top
+var suggestionKindWeight = map[string]float64{
+	SuggestionKindCategory: 5,
+	SuggestionKindBrand:    4,
+	SuggestionKindSwitch:   3,
+	SuggestionKindProduct:  2,
+	SuggestionKindQuery:    1,
+}
+
+type ISuggestUsecase interface {
+	Suggest(ctx context.Context, req request.SuggestProducts) []response.SuggestionResponse
+	LogQuery(ctx context.Context, query string) error
+}
+
+type suggestUsecase struct {
+	suggestRepo repository.ISuggestRepo
+	index       *cache.SuggestIndex
+}
+
+// NewSuggestUsecase wires the index loader and starts the initial build
+func NewSuggestUsecase(suggestRepo repository.ISuggestRepo, index *cache.SuggestIndex) ISuggestUsecase {
+	u := &suggestUsecase{
+		suggestRepo: suggestRepo,
+		index:       index,
+	}
+	index.SetLoader(u.loadSuggestions)
+	index.Refresh()
+	return u
+}
+
+func (u *suggestUsecase) Suggest(ctx context.Context, req request.SuggestProducts) []response.SuggestionResponse {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+
+	suggestions := u.index.Search(req.Query, limit)
+	result := make([]response.SuggestionResponse, 0, len(suggestions))
+	for _, s := range suggestions {
+		result = append(result, response.SuggestionResponse{Text: s.Text, Kind: s.Kind})
+	}
+	return result
+}
+
+func (u *suggestUsecase) LogQuery(ctx context.Context, query string) error {
+	query = cache.NormalizeSuggestKey(query)
+	if query == "" {
+		return nil
+	}
+	return u.suggestRepo.LogSearchQuery(ctx, query, time.Now())
+}
+
+func (u *suggestUsecase) loadSuggestions() ([]cache.Suggestion, error) {
+	ctx := context.Background()
+	var suggestions []cache.Suggestion
+	byKey := make(map[string]int)
+
+	sources := []struct {
+		kind string
+		load func(context.Context) ([]string, error)
+	}{
+		{SuggestionKindProduct, u.suggestRepo.GetSuggestableProductNames},
+		{SuggestionKindBrand, u.suggestRepo.GetBrandNames},
+		{SuggestionKindSwitch, u.suggestRepo.GetSwitchNames},
+		{SuggestionKindCategory, u.suggestRepo.GetCategoryNames},
+	}
+	for _, source := range sources {
+		names, err := source.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			byKey[cache.NormalizeSuggestKey(name)] = len(suggestions)
+			suggestions = append(suggestions, cache.Suggestion{
+				Text:   name,
+				Kind:   source.kind,
+				Weight: suggestionKindWeight[source.kind],
+			})
+		}
+	}
+
+	queries, err := u.suggestRepo.GetPopularQueries(ctx, minPopularQueryCount, maxPopularQueries)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range queries {
+		boost := math.Log2(float64(q.Count))
+		// A popular query naming a known term boosts that term instead of duplicating it
+		if i, ok := byKey[q.Query]; ok {
+			suggestions[i].Weight += boost
+			continue
+		}
+		suggestions = append(suggestions, cache.Suggestion{
+			Text:   q.Query,
+			Kind:   SuggestionKindQuery,
+			Weight: suggestionKindWeight[SuggestionKindQuery] + boost,
+		})
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,392 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/totp"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// Wrong codes a login challenge takes before the password has to be entered again
+	twoFactorMaxAttempts = 5
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp       = errors.New("start the two-factor setup first")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for your account")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidLoginChallenge   = errors.New("the login expired, please log in again")
+	ErrTwoFactorLocked         = errors.New("too many wrong two-factor codes, try again later")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type ITwoFactorUsecase interface {
+	GetStatus(ctx context.Context, userID int) (*response.TwoFactorStatusResponse, error)
+	// Setup creates a pending authenticator secret for the user to scan
+	Setup(ctx context.Context, userID int) (*response.TwoFactorSetupResponse, error)
+	// Enable turns on the pending authenticator once a code from it checks out. The session
+	// it is called from counts as having passed two-factor authentication.
+	Enable(ctx context.Context, userID int, sessionID string, req request.TwoFactorCode) (*response.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID int, req request.TwoFactorCode) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, req request.TwoFactorCode) (*response.RecoveryCodesResponse, error)
+
+	// BeginLogin starts a session for a user whose password or provider login checked out,
+	// or returns a challenge when the user has two-factor authentication
+	BeginLogin(ctx context.Context, user *entity.User, userAgent, ipAddress string) (*response.LoginResponse, error)
+	// CompleteLogin starts the session of a challenge once its code checks out
+	CompleteLogin(ctx context.Context, req request.TwoFactorLogin) (*response.LoginResponse, error)
+}
+
+type twoFactorUsecase struct {
+	twoFactorRepo  repository.ITwoFactorRepo
+	userRepo       repository.IUserRepo
+	jwtService     auth.IJWTService
+	sessionUsecase ISessionUsecase
+}
+
+func NewTwoFactorUsecase(
+	twoFactorRepo repository.ITwoFactorRepo,
+	userRepo repository.IUserRepo,
+	jwtService auth.IJWTService,
+	sessionUsecase ISessionUsecase,
+) ITwoFactorUsecase {
+	return &twoFactorUsecase{
+		twoFactorRepo:  twoFactorRepo,
+		userRepo:       userRepo,
+		jwtService:     jwtService,
+		sessionUsecase: sessionUsecase,
+	}
+}
+
+func (u *twoFactorUsecase) GetStatus(ctx context.Context, userID int) (*response.TwoFactorStatusResponse, error) {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &response.TwoFactorStatusResponse{
+		Enabled:  twoFactorEnabled(user),
+		Required: user.Role != nil && auth.RequiresTwoFactor(user.Role.Name),
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = u.twoFactorRepo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (u *twoFactorUsecase) Setup(ctx context.Context, userID int) (*response.TwoFactorSetupResponse, error) {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactorEnabled(user) {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = u.twoFactorRepo.SaveSetup(ctx, &entity.UserTwoFactor{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(config.TwoFactorConfig().Issuer, user.Email, secret),
+	}, nil
+}
+
+func (u *twoFactorUsecase) Enable(
+	ctx context.Context,
+	userID int,
+	sessionID string,
+	req request.TwoFactorCode,
+) (*response.RecoveryCodesResponse, error) {
+	twoFactor, err := u.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := u.twoFactorRepo.Enable(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	// The user just proved the second factor, refreshing now yields access tokens that pass it
+	if sessionID != "" {
+		if err := u.sessionUsecase.MarkTwoFactor(ctx, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	return &response.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (u *twoFactorUsecase) Disable(ctx context.Context, userID int, req request.TwoFactorCode) error {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactorEnabled(user) {
+		return ErrTwoFactorNotEnabled
+	}
+	if user.Role != nil && auth.RequiresTwoFactor(user.Role.Name) {
+		return ErrTwoFactorRequired
+	}
+
+	if err := u.checkCode(ctx, user.TwoFactor, req.Code, true); err != nil {
+		return err
+	}
+	return u.twoFactorRepo.Disable(ctx, userID)
+}
+
+func (u *twoFactorUsecase) RegenerateRecoveryCodes(
+	ctx context.Context,
+	userID int,
+	req request.TwoFactorCode,
+) (*response.RecoveryCodesResponse, error) {
+	twoFactor, err := u.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && twoFactor.EnabledAt == nil) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the authenticator itself vouches for new recovery codes
+	if err := u.checkCode(ctx, twoFactor, req.Code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &response.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (u *twoFactorUsecase) BeginLogin(
+	ctx context.Context,
+	user *entity.User,
+	userAgent, ipAddress string,
+) (*response.LoginResponse, error) {
+	if !twoFactorEnabled(user) {
+		tokens, err := u.sessionUsecase.CreateSession(ctx, user, userAgent, ipAddress, false)
+		if err != nil {
+			return nil, err
+		}
+		return &response.LoginResponse{TokenResponse: *tokens, User: mapUserToResponse(user)}, nil
+	}
+	// A new challenge would come with fresh guesses at the code
+	if twoFactorLocked(user.TwoFactor, time.Now()) {
+		return nil, ErrTwoFactorLocked
+	}
+
+	signed, claims, err := u.jwtService.GenerateActionToken(
+		user.ID, entity.ActionLoginTwoFactor, config.TwoFactorConfig().ChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	err = u.twoFactorRepo.CreateChallenge(ctx, &entity.ActionToken{
+		ID:        claims.ID,
+		UserID:    user.ID,
+		Purpose:   entity.ActionLoginTwoFactor,
+		ExpiresAt: claims.ExpiresAt.Time,
+		CreatedAt: claims.IssuedAt.Time,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.LoginResponse{
+		User: mapUserToResponse(user),
+		Challenge: &response.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    signed,
+			ExpiresAt:         claims.ExpiresAt.Time,
+		},
+	}, nil
+}
+
+func (u *twoFactorUsecase) CompleteLogin(ctx context.Context, req request.TwoFactorLogin) (*response.LoginResponse, error) {
+	claims, err := u.jwtService.ValidateActionToken(req.ChallengeToken, entity.ActionLoginTwoFactor)
+	if err != nil {
+		return nil, ErrInvalidLoginChallenge
+	}
+	challenge, err := u.twoFactorRepo.GetActiveChallenge(ctx, claims.ID, claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidLoginChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// Two-factor authentication was turned off since the password was entered
+	if !twoFactorEnabled(user) {
+		return nil, ErrInvalidLoginChallenge
+	}
+
+	if err := u.checkCode(ctx, user.TwoFactor, req.Code, true); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if err := u.twoFactorRepo.RecordChallengeFailure(ctx, challenge.ID, twoFactorMaxAttempts); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	used, err := u.twoFactorRepo.UseChallenge(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidLoginChallenge
+	}
+
+	tokens, err := u.sessionUsecase.CreateSession(ctx, user, req.UserAgent, req.IPAddress, true)
+	if err != nil {
+		return nil, err
+	}
+	return &response.LoginResponse{TokenResponse: *tokens, User: mapUserToResponse(user)}, nil
+}
+
+// checkCode accepts an authenticator code, each one once, or an unused recovery code when
+// allowRecovery is set. Every code tried counts towards locking the user out, whatever the
+// login challenge or request it came with, until one is accepted.
+func (u *twoFactorUsecase) checkCode(
+	ctx context.Context,
+	twoFactor *entity.UserTwoFactor,
+	code string,
+	allowRecovery bool,
+) error {
+	cfg := config.TwoFactorConfig()
+	allowed, err := u.twoFactorRepo.TakeAttempt(ctx, twoFactor.UserID, cfg.MaxAttempts, cfg.Lockout)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrTwoFactorLocked
+	}
+
+	if err := u.matchCode(ctx, twoFactor, code, allowRecovery); err != nil {
+		return err
+	}
+	return u.twoFactorRepo.ResetAttempts(ctx, twoFactor.UserID)
+}
+
+func (u *twoFactorUsecase) matchCode(
+	ctx context.Context,
+	twoFactor *entity.UserTwoFactor,
+	code string,
+	allowRecovery bool,
+) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(twoFactor.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		used, err := u.twoFactorRepo.UseStep(ctx, twoFactor.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+	if !allowRecovery {
+		return ErrInvalidTwoFactorCode
+	}
+
+	used, err := u.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func twoFactorEnabled(user *entity.User) bool {
+	return user.TwoFactor != nil && user.TwoFactor.EnabledAt != nil
+}
+
+func twoFactorLocked(twoFactor *entity.UserTwoFactor, now time.Time) bool {
+	return twoFactor != nil && twoFactor.LockedUntil != nil && twoFactor.LockedUntil.After(now)
+}
+
+// newRecoveryCodes returns codes formatted like "abcde-fghij" along with their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	raw := make([]byte, 7) // 50 random bits per code
+	for i := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, which users retype differently
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
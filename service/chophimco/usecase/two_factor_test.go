@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leehai1107/chophimco-server/pkg/config"
+	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
+	"github.com/leehai1107/chophimco-server/pkg/totp"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+)
+
+type twoFactorFixture struct {
+	store     *memoryStore
+	twoFactor ITwoFactorUsecase
+	userID    int
+	secret    string
+}
+
+// newTwoFactorFixture has a user whose authenticator is enabled
+func newTwoFactorFixture(t *testing.T) *twoFactorFixture {
+	t.Helper()
+	jwtService, err := auth.NewJWTService()
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMemoryStore()
+	userID := store.addUser(entity.User{Email: "buyer@example.com", FullName: "Buyer", PasswordHash: "hash"})
+	enabledAt := time.Now()
+	store.twoFactors[userID] = &entity.UserTwoFactor{UserID: userID, Secret: secret, EnabledAt: &enabledAt}
+
+	sessions := NewSessionUsecase(memorySessionRepo{store}, jwtService)
+	return &twoFactorFixture{
+		store:     store,
+		twoFactor: NewTwoFactorUsecase(memoryTwoFactorRepo{store}, memoryUserRepo{store}, jwtService, sessions),
+		userID:    userID,
+		secret:    secret,
+	}
+}
+
+// beginLogin is what a correct password leads to
+func (f *twoFactorFixture) beginLogin(t *testing.T) (string, error) {
+	t.Helper()
+	user, err := memoryUserRepo{f.store}.GetUserByID(f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := f.twoFactor.BeginLogin(context.Background(), user, "test", "127.0.0.1")
+	if err != nil {
+		return "", err
+	}
+	if login.Challenge == nil {
+		t.Fatal("logged in without a two-factor challenge")
+	}
+	return login.Challenge.ChallengeToken, nil
+}
+
+func (f *twoFactorFixture) completeLogin(challengeToken, code string) error {
+	_, err := f.twoFactor.CompleteLogin(context.Background(), request.TwoFactorLogin{
+		ChallengeToken: challengeToken,
+		Code:           code,
+	})
+	return err
+}
+
+func (f *twoFactorFixture) code(t *testing.T) string {
+	t.Helper()
+	code, err := totp.Code(f.secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// wrongCode returns a code the authenticator doesn't show around now
+func (f *twoFactorFixture) wrongCode(t *testing.T) string {
+	t.Helper()
+	for i := 0; ; i++ {
+		code := fmt.Sprintf("%06d", i)
+		if _, ok := totp.Validate(f.secret, code, time.Now()); !ok {
+			return code
+		}
+	}
+}
+
+func (f *twoFactorFixture) stored(t *testing.T) entity.UserTwoFactor {
+	t.Helper()
+	twoFactor, err := memoryTwoFactorRepo{f.store}.GetTwoFactor(context.Background(), f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *twoFactor
+}
+
+// guess enters wrong codes against a challenge, each of which must be refused as wrong
+func (f *twoFactorFixture) guess(t *testing.T, challengeToken string, times int) {
+	t.Helper()
+	for i := 0; i < times; i++ {
+		if err := f.completeLogin(challengeToken, f.wrongCode(t)); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("wrong code %d = %v, want ErrInvalidTwoFactorCode", i+1, err)
+		}
+	}
+}
+
+func TestTwoFactorLockoutAcrossLogins(t *testing.T) {
+	cfg := config.TwoFactorConfig()
+	if cfg.MaxAttempts != 2*twoFactorMaxAttempts {
+		t.Fatalf("the test expects TWO_FACTOR_MAX_ATTEMPTS to be %d, not %d", 2*twoFactorMaxAttempts, cfg.MaxAttempts)
+	}
+	f := newTwoFactorFixture(t)
+
+	// A challenge takes a few wrong codes, a new login gives a new challenge
+	first, err := f.beginLogin(t)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	f.guess(t, first, twoFactorMaxAttempts)
+	if err := f.completeLogin(first, f.code(t)); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Fatalf("used up challenge = %v, want ErrInvalidLoginChallenge", err)
+	}
+
+	second, err := f.beginLogin(t)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	third, err := f.beginLogin(t)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	// ... but the wrong codes of every challenge add up
+	f.guess(t, second, twoFactorMaxAttempts)
+
+	if _, err := f.beginLogin(t); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("BeginLogin while locked = %v, want ErrTwoFactorLocked", err)
+	}
+	if err := f.completeLogin(third, f.code(t)); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("right code of an earlier challenge while locked = %v, want ErrTwoFactorLocked", err)
+	}
+	if lockedUntil := f.stored(t).LockedUntil; lockedUntil == nil || lockedUntil.Before(time.Now().Add(cfg.Lockout-time.Minute)) {
+		t.Fatalf("locked until %v, want about %v from now", lockedUntil, cfg.Lockout)
+	}
+
+	// The lockout runs out
+	f.store.mu.Lock()
+	expired := time.Now().Add(-time.Second)
+	f.store.twoFactors[f.userID].LockedUntil = &expired
+	f.store.mu.Unlock()
+
+	if err := f.completeLogin(third, f.code(t)); err != nil {
+		t.Fatalf("CompleteLogin after the lockout: %v", err)
+	}
+}
+
+func TestTwoFactorAcceptedCodeResetsAttempts(t *testing.T) {
+	f := newTwoFactorFixture(t)
+
+	challenge, err := f.beginLogin(t)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	f.guess(t, challenge, twoFactorMaxAttempts-1)
+	if got := f.stored(t).FailedAttempts; got != twoFactorMaxAttempts-1 {
+		t.Fatalf("failed attempts = %d, want %d", got, twoFactorMaxAttempts-1)
+	}
+	if err := f.completeLogin(challenge, f.code(t)); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if got := f.stored(t); got.FailedAttempts != 0 || got.LockedUntil != nil {
+		t.Fatalf("after an accepted code: %d failed attempts, locked until %v", got.FailedAttempts, got.LockedUntil)
+	}
+}
+
+func TestTwoFactorLockoutCoversAccountChecks(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	ctx := context.Background()
+
+	for i := 0; i < config.TwoFactorConfig().MaxAttempts; i++ {
+		_, err := f.twoFactor.RegenerateRecoveryCodes(ctx, f.userID, request.TwoFactorCode{Code: f.wrongCode(t)})
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("wrong code %d = %v, want ErrInvalidTwoFactorCode", i+1, err)
+		}
+	}
+
+	if err := f.twoFactor.Disable(ctx, f.userID, request.TwoFactorCode{Code: f.code(t)}); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("Disable while locked = %v, want ErrTwoFactorLocked", err)
+	}
+	if _, err := f.beginLogin(t); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("BeginLogin while locked = %v, want ErrTwoFactorLocked", err)
+	}
+}
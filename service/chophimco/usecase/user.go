@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/leehai1107/chophimco-server/pkg/logger"
-	"github.com/leehai1107/chophimco-server/pkg/middleware/auth"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
 	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
@@ -22,12 +21,17 @@ type IUserUsecase interface {
 }
 
 type userUsecase struct {
-	repo       repository.IUserRepo
-	jwtService auth.IJWTService
+	repo             repository.IUserRepo
+	twoFactorUsecase ITwoFactorUsecase
+	accountUsecase   IAccountUsecase
 }
 
-func NewUserUsecase(repo repository.IUserRepo, jwtService auth.IJWTService) IUserUsecase {
-	return &userUsecase{repo: repo, jwtService: jwtService}
+func NewUserUsecase(
+	repo repository.IUserRepo,
+	twoFactorUsecase ITwoFactorUsecase,
+	accountUsecase IAccountUsecase,
+) IUserUsecase {
+	return &userUsecase{repo: repo, twoFactorUsecase: twoFactorUsecase, accountUsecase: accountUsecase}
 }
 
 func (u *userUsecase) Login(ctx context.Context, req request.Login) (*response.LoginResponse, error) {
@@ -45,29 +49,8 @@ func (u *userUsecase) Login(ctx context.Context, req request.Login) (*response.L
 		return nil, errors.New("invalid credentials")
 	}
 
-	// Get role name
-	roleName := ""
-	if user.Role != nil {
-		roleName = user.Role.Name
-	}
-
-	// Generate JWT token
-	token, err := u.jwtService.GenerateToken(user.ID, user.Email, roleName)
-	if err != nil {
-		return nil, errors.New("failed to generate token")
-	}
-
-	return &response.LoginResponse{
-		Token: token,
-		User: response.UserResponse{
-			ID:        user.ID,
-			FullName:  user.FullName,
-			Email:     user.Email,
-			Phone:     user.Phone,
-			Role:      roleName,
-			CreatedAt: user.CreatedAt,
-		},
-	}, nil
+	// Start a session with an access token and a refresh token, unless a second factor is due
+	return u.twoFactorUsecase.BeginLogin(ctx, user, req.UserAgent, req.IPAddress)
 }
 
 func (u *userUsecase) Register(ctx context.Context, req request.Register) error {
@@ -98,7 +81,15 @@ func (u *userUsecase) Register(ctx context.Context, req request.Register) error
 		CreatedAt:    time.Now(),
 	}
 
-	return u.repo.CreateUser(user)
+	if err := u.repo.CreateUser(user); err != nil {
+		return err
+	}
+
+	// The account is usable without a verified email, a lost email can be resent
+	if err := u.accountUsecase.SendVerificationEmail(ctx, user.ID); err != nil {
+		logger.EnhanceWith(ctx).Errorw("Failed to send verification email", "user_id", user.ID, "error", err)
+	}
+	return nil
 }
 
 func (u *userUsecase) GetUserProfile(ctx context.Context, userID int) (*response.UserResponse, error) {
@@ -107,17 +98,25 @@ func (u *userUsecase) GetUserProfile(ctx context.Context, userID int) (*response
 		return nil, err
 	}
 
+	userResponse := mapUserToResponse(user)
+	return &userResponse, nil
+}
+
+func mapUserToResponse(user *entity.User) response.UserResponse {
 	roleName := ""
 	if user.Role != nil {
 		roleName = user.Role.Name
 	}
 
-	return &response.UserResponse{
-		ID:        user.ID,
-		FullName:  user.FullName,
-		Email:     user.Email,
-		Phone:     user.Phone,
-		Role:      roleName,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return response.UserResponse{
+		ID:            user.ID,
+		FullName:      user.FullName,
+		Email:         user.Email,
+		Phone:         user.Phone,
+		Role:          roleName,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		HasPassword:   user.PasswordHash != "",
+		TwoFactor:     user.TwoFactor != nil && user.TwoFactor.EnabledAt != nil,
+		CreatedAt:     user.CreatedAt,
+	}
 }
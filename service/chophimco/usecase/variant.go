@@ -0,0 +1,531 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leehai1107/chophimco-server/pkg/tools/strtool"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/entity"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/request"
+	"github.com/leehai1107/chophimco-server/service/chophimco/model/response"
+	"github.com/leehai1107/chophimco-server/service/chophimco/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// VariantAxisSwitch is the axis code selecting the switch of a variant, by switch ID
+	VariantAxisSwitch = "switch"
+
+	maxVariantMatrixCells = 200
+	maxSKULength          = 100
+
+	VariantStatusCreated = "created"
+	VariantStatusUpdated = "updated"
+)
+
+var (
+	ErrProductNotFound      = errors.New("product not found or access denied")
+	ErrInvalidVariantMatrix = errors.New("invalid variant matrix")
+	ErrSKUTaken             = errors.New("SKU already exists")
+)
+
+var skuPlaceholder = regexp.MustCompile(`\{([a-z0-9_]+)\}`)
+
+type IVariantUsecase interface {
+	// GenerateVariantMatrix creates or updates one variant per combination of axis values.
+	// A sellerID other than 0 restricts it to that seller's products.
+	GenerateVariantMatrix(ctx context.Context, sellerID int, req request.GenerateVariantMatrix) (*response.VariantMatrixResponse, error)
+}
+
+type variantUsecase struct {
+	productRepo   repository.IProductRepo
+	attributeRepo repository.IAttributeRepo
+	catalogRepo   repository.ICatalogRepo
+}
+
+func NewVariantUsecase(
+	productRepo repository.IProductRepo,
+	attributeRepo repository.IAttributeRepo,
+	catalogRepo repository.ICatalogRepo,
+) IVariantUsecase {
+	return &variantUsecase{
+		productRepo:   productRepo,
+		attributeRepo: attributeRepo,
+		catalogRepo:   catalogRepo,
+	}
+}
+
+// matrixAxis holds the canonical values of an axis and their SKU tokens
+type matrixAxis struct {
+	code     string
+	values   []string
+	tokens   []string
+	switches []*entity.Switch // switch axis only, parallel to values
+}
+
+type matrixCell struct {
+	options  map[string]string
+	tokens   map[string]string
+	switchID *int
+	sw       *entity.Switch
+	price    float64
+	stock    *int
+	sku      string
+	variant  *entity.ProductVariant
+	existing bool
+}
+
+func (u *variantUsecase) GenerateVariantMatrix(
+	ctx context.Context,
+	sellerID int,
+	req request.GenerateVariantMatrix,
+) (*response.VariantMatrixResponse, error) {
+	product, err := u.productRepo.GetProductByID(req.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if sellerID != 0 && product.SellerID != sellerID {
+		return nil, ErrProductNotFound
+	}
+
+	definitions, err := u.attributeRepo.GetEffectiveDefinitions(ctx, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*entity.AttributeDefinition, len(definitions))
+	for i := range definitions {
+		byCode[definitions[i].Code] = &definitions[i]
+	}
+
+	axes, err := u.resolveAxes(ctx, req, byCode)
+	if err != nil {
+		return nil, err
+	}
+	cells := expandMatrix(axes)
+
+	if err := applyCellOverrides(req, axes, byCode, cells); err != nil {
+		return nil, err
+	}
+
+	// Match cells to existing variants by their option values
+	existing := make(map[string]*entity.ProductVariant, len(product.Variants))
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		key := matrixKey(axes, variantAxisValues(variant, axes, byCode))
+		if _, ok := existing[key]; !ok {
+			existing[key] = variant
+		}
+	}
+	matched := make(map[int]bool, len(existing))
+
+	pattern := strings.TrimSpace(req.SKUPattern)
+	if pattern == "" {
+		pattern = defaultSKUPattern(axes)
+	}
+
+	for n, cell := range cells {
+		variant := &entity.ProductVariant{ProductID: product.ID}
+		if current, ok := existing[matrixKey(axes, cell.options)]; ok {
+			variant = current
+			cell.existing = true
+			matched[current.ID] = true
+		}
+		cell.variant = variant
+
+		if cell.switchID != nil {
+			variant.SwitchID = cell.switchID
+			variant.Switch = cell.sw
+		}
+		if err := u.buildCellAttributes(cell, req.Attributes, definitions, byCode); err != nil {
+			return nil, err
+		}
+
+		variant.Price = cell.price
+		switch {
+		case cell.stock != nil:
+			variant.Stock = *cell.stock
+		case !cell.existing:
+			variant.Stock = req.DefaultStock
+		}
+
+		switch {
+		case cell.sku != "":
+			variant.SKU = cell.sku
+		case !cell.existing || variant.SKU == "":
+			sku, err := renderSKU(pattern, product.ID, n+1, cell.tokens)
+			if err != nil {
+				return nil, err
+			}
+			variant.SKU = sku
+		}
+	}
+
+	if err := u.checkMatrixSKUs(cells); err != nil {
+		return nil, err
+	}
+
+	var staleIDs []int
+	if req.RemoveMissing {
+		for _, variant := range product.Variants {
+			if !matched[variant.ID] {
+				staleIDs = append(staleIDs, variant.ID)
+			}
+		}
+	}
+
+	var create, update []*entity.ProductVariant
+	for _, cell := range cells {
+		if cell.existing {
+			update = append(update, cell.variant)
+		} else {
+			create = append(create, cell.variant)
+		}
+	}
+
+	result := &response.VariantMatrixResponse{
+		Created: len(create),
+		Updated: len(update),
+		DryRun:  req.DryRun,
+	}
+	if req.DryRun {
+		result.Removed = len(staleIDs)
+	} else {
+		result.Removed, result.Retired, err = u.productRepo.ApplyVariantMatrix(create, update, staleIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Variants = make([]response.VariantMatrixCell, 0, len(cells))
+	for _, cell := range cells {
+		status := VariantStatusCreated
+		if cell.existing {
+			status = VariantStatusUpdated
+		}
+		result.Variants = append(result.Variants, response.VariantMatrixCell{
+			ProductVariantResponse: mapVariantToResponse(cell.variant),
+			Options:                cell.options,
+			Status:                 status,
+		})
+	}
+	return result, nil
+}
+
+// resolveAxes validates the axes and turns their values into canonical form
+func (u *variantUsecase) resolveAxes(
+	ctx context.Context,
+	req request.GenerateVariantMatrix,
+	byCode map[string]*entity.AttributeDefinition,
+) ([]matrixAxis, error) {
+	axes := make([]matrixAxis, 0, len(req.Axes))
+	seenCodes := make(map[string]bool, len(req.Axes))
+	cellCount := 1
+
+	for _, reqAxis := range req.Axes {
+		code := strings.TrimSpace(reqAxis.Code)
+		if seenCodes[code] {
+			return nil, fmt.Errorf("%w: axis %s is given twice", ErrInvalidVariantMatrix, code)
+		}
+		seenCodes[code] = true
+		if _, shared := req.Attributes[code]; shared {
+			return nil, fmt.Errorf("%w: %s cannot be both an axis and a shared attribute", ErrInvalidVariantMatrix, code)
+		}
+
+		axis := matrixAxis{code: code}
+		seenValues := make(map[string]bool, len(reqAxis.Values))
+		for _, raw := range reqAxis.Values {
+			value, err := resolveAxisValue(code, raw, byCode)
+			if err != nil {
+				return nil, err
+			}
+			if seenValues[value] {
+				return nil, fmt.Errorf("%w: %s is listed twice on axis %s", ErrInvalidVariantMatrix, value, code)
+			}
+			seenValues[value] = true
+
+			token := value
+			if code == VariantAxisSwitch {
+				id, _ := strconv.Atoi(value)
+				sw, err := u.catalogRepo.GetSwitchByID(ctx, id)
+				if err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return nil, fmt.Errorf("%w: switch %d not found", ErrInvalidVariantMatrix, id)
+					}
+					return nil, err
+				}
+				axis.switches = append(axis.switches, sw)
+				token = sw.Name
+			}
+			axis.values = append(axis.values, value)
+			axis.tokens = append(axis.tokens, skuToken(token))
+		}
+
+		cellCount *= len(axis.values)
+		if cellCount > maxVariantMatrixCells {
+			return nil, fmt.Errorf("%w: more than %d variants", ErrInvalidVariantMatrix, maxVariantMatrixCells)
+		}
+		axes = append(axes, axis)
+	}
+	return axes, nil
+}
+
+// resolveAxisValue returns the canonical form of a value on the given axis
+func resolveAxisValue(code, raw string, byCode map[string]*entity.AttributeDefinition) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: empty value on axis %s", ErrInvalidVariantMatrix, code)
+	}
+
+	if code == VariantAxisSwitch {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			return "", fmt.Errorf("%w: switch values must be switch IDs", ErrInvalidVariantMatrix)
+		}
+		return strconv.Itoa(id), nil
+	}
+	if definition, ok := byCode[code]; ok {
+		return normalizeAttributeValue(definition, raw)
+	}
+	return normalizeLegacyValue(code, raw)
+}
+
+// expandMatrix builds one cell per combination of axis values, the last axis varying fastest
+func expandMatrix(axes []matrixAxis) []*matrixCell {
+	cells := []*matrixCell{{options: map[string]string{}, tokens: map[string]string{}}}
+	for _, axis := range axes {
+		next := make([]*matrixCell, 0, len(cells)*len(axis.values))
+		for _, cell := range cells {
+			for i, value := range axis.values {
+				expanded := &matrixCell{
+					options:  make(map[string]string, len(cell.options)+1),
+					tokens:   make(map[string]string, len(cell.tokens)+1),
+					switchID: cell.switchID,
+					sw:       cell.sw,
+				}
+				for k, v := range cell.options {
+					expanded.options[k] = v
+				}
+				for k, v := range cell.tokens {
+					expanded.tokens[k] = v
+				}
+				expanded.options[axis.code] = value
+				expanded.tokens[axis.code] = axis.tokens[i]
+				if axis.code == VariantAxisSwitch {
+					id := axis.switches[i].ID
+					expanded.switchID = &id
+					expanded.sw = axis.switches[i]
+				}
+				next = append(next, expanded)
+			}
+		}
+		cells = next
+	}
+	return cells
+}
+
+// applyCellOverrides sets price, stock and SKU on the cells matching each override, later overrides win
+func applyCellOverrides(
+	req request.GenerateVariantMatrix,
+	axes []matrixAxis,
+	byCode map[string]*entity.AttributeDefinition,
+	cells []*matrixCell,
+) error {
+	isAxis := make(map[string]bool, len(axes))
+	for _, axis := range axes {
+		isAxis[axis.code] = true
+	}
+
+	for _, cell := range cells {
+		cell.price = req.DefaultPrice
+	}
+
+	for _, override := range req.Overrides {
+		options := make(map[string]string, len(override.Options))
+		for code, raw := range override.Options {
+			if !isAxis[code] {
+				return fmt.Errorf("%w: override option %s is not an axis", ErrInvalidVariantMatrix, code)
+			}
+			value, err := resolveAxisValue(code, raw, byCode)
+			if err != nil {
+				return err
+			}
+			options[code] = value
+		}
+
+		applied := false
+		for _, cell := range cells {
+			if !cellMatches(cell, options) {
+				continue
+			}
+			applied = true
+			if override.Price != nil {
+				cell.price = *override.Price
+			}
+			if override.Stock != nil {
+				stock := *override.Stock
+				cell.stock = &stock
+			}
+			if sku := strings.TrimSpace(override.SKU); sku != "" {
+				cell.sku = sku
+			}
+		}
+		if !applied {
+			return fmt.Errorf("%w: an override matches no variant", ErrInvalidVariantMatrix)
+		}
+	}
+	return nil
+}
+
+func cellMatches(cell *matrixCell, options map[string]string) bool {
+	for code, value := range options {
+		if cell.options[code] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// buildCellAttributes validates the attribute values of a cell and stores them on its variant
+func (u *variantUsecase) buildCellAttributes(
+	cell *matrixCell,
+	shared map[string]string,
+	definitions []entity.AttributeDefinition,
+	byCode map[string]*entity.AttributeDefinition,
+) error {
+	values := make(map[string]string)
+	if cell.existing {
+		for code, value := range currentAttributeValues(cell.variant) {
+			if _, ok := byCode[code]; ok {
+				values[code] = value
+			}
+		}
+	}
+	for code, value := range shared {
+		values[code] = value
+	}
+	for code, value := range cell.options {
+		if code != VariantAxisSwitch {
+			values[code] = value
+		}
+	}
+
+	attributes, err := buildVariantAttributesWithLegacy(definitions, values, cell.variant)
+	if err != nil {
+		return err
+	}
+	cell.variant.Attributes = attributes
+	return nil
+}
+
+// checkMatrixSKUs rejects SKUs repeated within the matrix or used by another variant
+func (u *variantUsecase) checkMatrixSKUs(cells []*matrixCell) error {
+	owner := make(map[string]*matrixCell, len(cells))
+	skus := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		sku := cell.variant.SKU
+		if _, dup := owner[sku]; dup {
+			return fmt.Errorf("%w: SKU %s is generated more than once, include every axis or {n} in the pattern",
+				ErrInvalidVariantMatrix, sku)
+		}
+		owner[sku] = cell
+		skus = append(skus, sku)
+	}
+
+	taken, err := u.productRepo.GetVariantsBySKUs(skus)
+	if err != nil {
+		return err
+	}
+	for _, variant := range taken {
+		cell := owner[variant.SKU]
+		if cell == nil || !cell.existing || cell.variant.ID != variant.ID {
+			return fmt.Errorf("%w: %s", ErrSKUTaken, variant.SKU)
+		}
+	}
+	return nil
+}
+
+// variantAxisValues returns the values an existing variant has on each axis
+func variantAxisValues(
+	variant *entity.ProductVariant,
+	axes []matrixAxis,
+	byCode map[string]*entity.AttributeDefinition,
+) map[string]string {
+	stored := currentAttributeValues(variant)
+	legacy := legacyAttributeValues(variant)
+
+	values := make(map[string]string, len(axes))
+	for _, axis := range axes {
+		switch {
+		case axis.code == VariantAxisSwitch:
+			if variant.SwitchID != nil {
+				values[axis.code] = strconv.Itoa(*variant.SwitchID)
+			}
+		case byCode[axis.code] != nil:
+			values[axis.code] = stored[axis.code]
+		default:
+			values[axis.code] = legacy[axis.code]
+		}
+	}
+	return values
+}
+
+// matrixKey identifies a combination of axis values, ignoring case
+func matrixKey(axes []matrixAxis, values map[string]string) string {
+	parts := make([]string, 0, len(axes))
+	for _, axis := range axes {
+		parts = append(parts, strings.ToLower(values[axis.code]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func defaultSKUPattern(axes []matrixAxis) string {
+	parts := []string{"P{product}"}
+	for _, axis := range axes {
+		parts = append(parts, "{"+axis.code+"}")
+	}
+	return strings.Join(parts, "-")
+}
+
+func renderSKU(pattern string, productID, n int, tokens map[string]string) (string, error) {
+	var unknown []string
+	sku := skuPlaceholder.ReplaceAllStringFunc(pattern, func(match string) string {
+		name := match[1 : len(match)-1]
+		switch name {
+		case "product":
+			return strconv.Itoa(productID)
+		case "n":
+			return strconv.Itoa(n)
+		}
+		if token, ok := tokens[name]; ok {
+			return token
+		}
+		unknown = append(unknown, name)
+		return match
+	})
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("%w: unknown SKU placeholder %s", ErrInvalidVariantMatrix, strings.Join(unknown, ", "))
+	}
+	if len(sku) > maxSKULength {
+		return "", fmt.Errorf("%w: SKU %s is longer than %d characters", ErrInvalidVariantMatrix, sku, maxSKULength)
+	}
+	return sku, nil
+}
+
+// skuToken turns a value into an SKU segment, e.g. "Gateron Red" becomes "GATERON-RED"
+func skuToken(value string) string {
+	token := strings.ToUpper(strtool.Slugify(value))
+	if token == "" {
+		return "X"
+	}
+	return token
+}